@@ -0,0 +1,75 @@
+package main
+
+import (
+	"context"
+	"log/slog"
+
+	"github.com/spf13/cobra"
+
+	"go-mysql/internal/config"
+	"go-mysql/internal/logging"
+)
+
+// newRootCommand returns the command line of the binary. Without a subcommand it
+// serves, as it did before it had any.
+func newRootCommand() *cobra.Command {
+	var configFile string
+	root := &cobra.Command{
+		Use:   "app",
+		Short: "Users API backed by MySQL and Redis",
+		// Errors are logged by main, like every other failure
+		SilenceErrors: true,
+		SilenceUsage:  true,
+		PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+			return setup(cmd, configFile)
+		},
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return serve(cmd.Context(), false)
+		},
+	}
+	root.PersistentFlags().StringVar(&configFile, "config", "", "config file to read settings from, like CONFIG_FILE")
+	root.AddCommand(
+		&cobra.Command{
+			Use:   "serve",
+			Short: "Serve the API, the default",
+			Args:  cobra.NoArgs,
+			RunE: func(cmd *cobra.Command, args []string) error {
+				return serve(cmd.Context(), false)
+			},
+		},
+		&cobra.Command{
+			Use:   "worker",
+			Short: "Consume provisioning requests from RabbitMQ instead of serving",
+			Args:  cobra.NoArgs,
+			RunE: func(cmd *cobra.Command, args []string) error {
+				return serve(cmd.Context(), true)
+			},
+		},
+		newMigrateCommand(),
+		newSeedCommand(),
+		newConfigCommand(),
+	)
+	return root
+}
+
+// setup does what every command needs first: it points the config at configFile, if
+// given, and sets up logging. Everything logs through the logger carried by the
+// command's context; the default logger also picks up output from the standard log
+// package.
+func setup(cmd *cobra.Command, configFile string) error {
+	if configFile != "" {
+		err := config.UseFile(configFile)
+		if err != nil {
+			return err
+		}
+	}
+	logger := logging.New(config.LoadLog())
+	slog.SetDefault(logger)
+	ctx := cmd.Context()
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	cmd.SetContext(logging.WithLogger(ctx, logger))
+	return nil
+}
@@ -0,0 +1,93 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"go-mysql/internal/config"
+)
+
+// newConfigCommand returns the command showing the configuration.
+func newConfigCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "config",
+		Short: "Show the configuration",
+	}
+	var sources bool
+	printCmd := &cobra.Command{
+		Use:   "print",
+		Short: "Print the effective settings, with secrets masked",
+		Long: "Print the effective settings as KEY=VALUE lines, in the format of the config file,\n" +
+			"whether they come from the environment, the config file or their defaults.\n" +
+			"Invalid settings fail the command, as they would fail the server.",
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			err := config.Validate(configLoads...)
+			if err != nil {
+				return fmt.Errorf("invalid configuration: %w", err)
+			}
+			out := cmd.OutOrStdout()
+			for _, s := range config.Settings() {
+				value := s.Value
+				if strings.ContainsAny(value, " #\"'") {
+					value = fmt.Sprintf("%q", value)
+				}
+				if sources {
+					fmt.Fprintf(out, "%s=%s # %s\n", s.Key, value, s.Source)
+					continue
+				}
+				fmt.Fprintf(out, "%s=%s\n", s.Key, value)
+			}
+			return nil
+		},
+	}
+	printCmd.Flags().BoolVar(&sources, "sources", false, "follow each setting with where it comes from")
+	cmd.AddCommand(printCmd)
+	return cmd
+}
+
+// configLoads are the Load functions of the settings the server reads on start.
+var configLoads = []func(){
+	load(config.LoadLog),
+	load(config.LoadAccessLog),
+	load(config.LoadErrorReporting),
+	load(config.LoadTracing),
+	load(config.LoadDatabase),
+	load(config.LoadRedis),
+	load(config.LoadCache),
+	func() { config.LoadBreaker("MYSQL") },
+	func() { config.LoadBreaker("REDIS") },
+	load(config.LoadWorkerPool),
+	load(config.LoadJobs),
+	load(config.LoadMail),
+	load(config.LoadFlags),
+	load(config.LoadReadModel),
+	load(config.LoadUsernames),
+	load(config.LoadEmailChanges),
+	load(config.LoadStorage),
+	load(config.LoadAvatars),
+	load(config.LoadTenancy),
+	load(config.LoadFeed),
+	load(config.LoadPresence),
+	load(config.LoadStats),
+	load(config.LoadRabbitMQ),
+	load(config.LoadRateLimit),
+	load(config.LoadConcurrency),
+	load(config.LoadDev),
+	load(config.LoadOutbox),
+	load(config.LoadScheduler),
+	load(config.LoadNATS),
+	load(config.LoadCompression),
+	load(config.LoadI18n),
+	load(config.LoadTimeout),
+	load(config.LoadServer),
+	load(config.LoadAdmin),
+	load(config.LoadShutdown),
+}
+
+// load returns a Load function without its result, for configLoads.
+func load[T any](fn func() T) func() {
+	return func() { fn() }
+}
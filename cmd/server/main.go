@@ -0,0 +1,605 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/XSAM/otelsql"
+	"github.com/go-redis/redis/extra/redisotel/v8"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+
+	"go-mysql/internal/activity"
+	"go-mysql/internal/cache"
+	"go-mysql/internal/config"
+	"go-mysql/internal/events"
+	"go-mysql/internal/handlers"
+	"go-mysql/internal/i18n"
+	"go-mysql/internal/jobs"
+	"go-mysql/internal/logging"
+	"go-mysql/internal/mailer"
+	"go-mysql/internal/models"
+	"go-mysql/internal/natsapi"
+	"go-mysql/internal/outbox"
+	"go-mysql/internal/readmodel"
+	"go-mysql/internal/repository"
+	"go-mysql/internal/requestid"
+	"go-mysql/internal/server"
+	"go-mysql/internal/service"
+	"go-mysql/internal/storage"
+	"go-mysql/internal/webhooks"
+	"go-mysql/internal/worker"
+	"go-mysql/pkg/breaker"
+	"go-mysql/pkg/flags"
+	"go-mysql/pkg/lifecycle"
+	"go-mysql/pkg/middleware"
+	"go-mysql/pkg/retry"
+	"go-mysql/pkg/scheduler"
+	"go-mysql/pkg/workerpool"
+)
+
+func main() {
+	err := newRootCommand().Execute()
+	if err != nil {
+		fatal("Command failed", "error", err)
+	}
+}
+
+// serve runs the HTTP servers and the background workers until a signal or a failed
+// component, or with workerMode, consumes provisioning requests from RabbitMQ instead
+// of serving HTTP.
+func serve(ctx context.Context, workerMode bool) error {
+	logger := logging.From(ctx)
+	err := config.Validate(configLoads...)
+	if err != nil {
+		return fmt.Errorf("invalid configuration: %w", err)
+	}
+
+	reporter, err := server.NewErrorReporter(config.LoadErrorReporting(), logger)
+	if err != nil {
+		fatal("Failed to set up error reporting", "error", err)
+	}
+	defer server.FlushErrorReports(reporter)
+
+	// Tracing has to be set up before the instrumented clients below are created
+	if tracing := config.LoadTracing(); tracing.Enabled {
+		shutdownTracing, err := server.SetupTracing(ctx, tracing)
+		if err != nil {
+			fatal("Failed to set up tracing", "error", err)
+		}
+		defer func() {
+			err := shutdownTracing(context.Background())
+			if err != nil {
+				logger.Error("Failed to flush traces", "error", err)
+			}
+		}()
+	}
+
+	db := openMySQL(ctx)
+	defer db.Close()
+
+	// Circuit breakers make calls to MySQL or Redis fail fast while it's down, instead
+	// of each waiting for it to time out
+	var breakers []*breaker.Breaker
+	mysqlBreaker := newBreaker("mysql", repository.IsMySQLFailure, repository.IsMySQLExcluded, logger)
+	if mysqlBreaker != nil {
+		breakers = append(breakers, mysqlBreaker)
+	}
+	repo := repository.New(db, mysqlBreaker)
+
+	// Initialize Redis connection
+	redisCfg := config.LoadRedis()
+	rdb := cache.NewRedisClient(redisCfg)
+	rdb.AddHook(redisotel.NewTracingHook())
+	defer rdb.Close()
+
+	redisBreaker := newBreaker("redis", cache.IsRedisFailure, nil, logger)
+	if redisBreaker != nil {
+		rdb.AddHook(cache.BreakerHook{Breaker: redisBreaker})
+		breakers = append(breakers, redisBreaker)
+	}
+
+	// Redis connection. Redis is optional at runtime: without it the cache is bypassed
+	// until the connection can be re-established.
+	cacheState := cache.NewState(config.LoadCache())
+	cacheState.RegisterMetrics()
+	_, err = rdb.Ping(ctx).Result()
+	if err != nil {
+		logger.Warn("Redis unavailable, starting without the cache", "error", err)
+	} else {
+		cacheState.MarkRedisAvailable()
+		logger.Info("Connected to Redis")
+
+		err = cache.PreloadScripts(ctx, rdb)
+		if err != nil {
+			logger.Warn("Failed to load Lua scripts", "error", err)
+		}
+	}
+
+	// Servers and background workers run as components: if one fails, the service
+	// shuts down. Background workers stop when the servers have, finishing the work
+	// they've started until drainCtx is done; what's left then is requeued or persisted.
+	components := lifecycle.New(logger)
+	backgroundCtx, stopBackground := context.WithCancel(ctx)
+	drainCtx, abandonBackground := context.WithCancel(context.WithoutCancel(ctx))
+
+	// Worker pool for the asynchronous work done on behalf of requests. It drains its
+	// queue on shutdown until drainCtx is done, then hands what's left to fallbacks.
+	poolCfg := config.LoadWorkerPool()
+	pool := workerpool.New(poolCfg.Workers, poolCfg.QueueSize, logger.With("component", "worker_pool"))
+	server.RegisterWorkerPoolMetrics("background", pool)
+	components.Go("worker_pool", func() error {
+		<-backgroundCtx.Done()
+		pool.Shutdown(drainCtx)
+		return nil
+	})
+
+	jobsCfg := config.LoadJobs()
+	jobQueue := jobs.NewQueue(jobsCfg, rdb, logger.With("component", "jobs"))
+
+	mailCfg := config.LoadMail()
+	transport, err := mailer.NewTransport(ctx, mailCfg, logger.With("component", "mailer"))
+	if err != nil {
+		fatal("Failed to set up the mail transport", "transport", mailCfg.Transport, "error", err)
+	}
+	mail := mailer.New(mailCfg, transport, pool)
+
+	userCache := cache.New(cacheState, rdb, repo, pool)
+
+	// Feature flags: configured defaults, overridden at runtime through the admin API
+	flagsCfg := config.LoadFlags()
+	featureFlags := flags.New(rdb, flagsCfg.RedisKey, flagsCfg.Defaults, logger.With("component", "flags"))
+	components.Go("flags", func() error {
+		featureFlags.Run(backgroundCtx, flagsCfg.RefreshInterval)
+		return nil
+	})
+
+	// User events: the cache, the audit log and notifications react to changes
+	bus := events.NewBus()
+	userCache.Subscribe(bus)
+	events.SubscribeAuditLog(bus)
+	jobs.SubscribeNotifications(bus, jobQueue)
+	hooks := webhooks.New(repo, jobQueue, pool)
+	hooks.Subscribe(bus)
+
+	// The read model serves user reads once built; until then they go through the cache.
+	// The service is given a nil interface, not a nil *readmodel.Model, when it's disabled
+	readModelCfg := config.LoadReadModel()
+	var readModel *readmodel.Model
+	var reads service.ReadModel
+	if readModelCfg.Enabled {
+		readModel = readmodel.New(rdb, readModelCfg.KeyPrefix)
+		readModel.Subscribe(bus)
+		reads = readModel
+	}
+	userService := service.NewUserService(repo, userCache, reads, bus)
+
+	usernamesCfg := config.LoadUsernames()
+	userService.RedirectFormerUsernames(usernamesCfg.RedirectGrace)
+	reserved := service.NewReservedUsernames(repo, userCache, usernamesCfg.Reserved, usernamesCfg.ReservedPatterns, usernamesCfg.ReservedCacheTTL)
+	userService.ReserveUsernames(reserved)
+	userService.ConfirmEmailChanges(mail, mailCfg.BaseURL+"/confirm-email", config.LoadEmailChanges().TTL)
+
+	// Avatar uploads, if object storage is configured
+	storageCfg := config.LoadStorage()
+	avatarStorage, err := storage.New(ctx, storageCfg)
+	if err != nil {
+		fatal("Failed to set up object storage", "backend", storageCfg.Backend, "error", err)
+	}
+	if avatarStorage != nil {
+		avatarsCfg := config.LoadAvatars()
+		userService.UseAvatarStorage(avatarStorage, service.AvatarOptions{
+			MaxUploadSize: avatarsCfg.MaxUploadSize,
+			MaxDimension:  avatarsCfg.MaxDimension,
+			URLTTL:        avatarsCfg.URLTTL,
+		})
+	}
+	registration := service.NewRegistration(userService, repo, mail)
+	tenancy := config.LoadTenancy()
+	tenants := service.NewTenants(repo, bus, tenancy.CacheTTL)
+	groups := service.NewGroups(repo, bus)
+	follows := service.NewFollows(repo, userCache, bus)
+	feed := activity.New(rdb, cacheState, config.LoadFeed())
+	feed.Subscribe(bus, userService)
+	adminService := service.NewAdmin(repo, mail, bus, mailCfg.BaseURL+"/password-reset")
+	presence := config.LoadPresence()
+	adminService.TrackLastSeen(userCache, presence.SeenInterval, presence.LoginIdle)
+	statsCfg := config.LoadStats()
+	stats := service.NewStats(repo, userCache, statsCfg.TTL, statsCfg.SignupsRetention)
+	stats.Subscribe(bus)
+	// The API's routes are served from a mux of their own, leaving out what packages
+	// register on http.DefaultServeMux, such as net/http/pprof
+	mux := http.NewServeMux()
+	app := handlers.New(handlers.Deps{
+		Users:        userService,
+		Registration: registration,
+		Admin:        adminService,
+		Groups:       groups,
+		Follows:      follows,
+		Feed:         feed,
+		Stats:        stats,
+		Reserved:     reserved,
+		Redis:        rdb,
+		Cache:        cacheState,
+		Pool:         pool,
+		Webhooks:     hooks,
+		Mux:          mux,
+	})
+	components.Go("cache_keyspace_watcher", func() error {
+		userCache.WatchKeyspace(backgroundCtx)
+		return nil
+	})
+
+	// Bring the schema up to date and make sure it matches what this binary expects
+	readOnly, err := repo.CheckSchema(ctx)
+	if err != nil {
+		fatal("Failed to check schema", "error", err)
+	}
+
+	// The worker consumes provisioning requests from RabbitMQ instead of serving HTTP
+	if workerMode {
+		if readOnly {
+			fatal("The worker can't run against a mismatched schema")
+		}
+		consumer := worker.NewConsumer(config.LoadRabbitMQ(), worker.NewProvisioner(userService))
+		components.Go("worker", func() error {
+			consumer.Run(logging.WithLogger(backgroundCtx, logger.With("component", "worker")))
+			return nil
+		})
+		err := server.ShutdownOnSignal(ctx, config.LoadShutdown(), components, nil, stopBackground, abandonBackground)
+		if err != nil {
+			return fmt.Errorf("worker stopped after a failure: %w", err)
+		}
+		logger.Info("Worker stopped")
+		return nil
+	}
+
+	// Every request passes through the server chain. API routes add the per-client
+	// middleware on top of it; the operational endpoints are left alone
+	rateLimit := server.NewRateLimit(server.NewRateLimiter(config.LoadRateLimit(), rdb, cacheState), cacheState, logger)
+	var readOnlyGuard middleware.Middleware
+	if readOnly {
+		readOnlyGuard = server.ReadOnly
+	}
+	// Flags are rolled out per request
+	flagsMiddleware := featureFlags.Middleware(func(r *http.Request) string {
+		return requestid.From(r.Context())
+	})
+	api := middleware.NewGroup(mux, middleware.New(readOnlyGuard, rateLimit.Middleware, flagsMiddleware))
+	ops := middleware.NewGroup(mux, nil)
+
+	// Each API group gets its own concurrency limit, so a spike on one doesn't starve the other
+	concurrency := config.LoadConcurrency()
+	// User data is kept per tenant, so those routes resolve the request's tenant, once
+	// they're let in: resolving it may query MySQL, which the limit is there to protect.
+	// Visitors are counted per tenant too, so only once it's known
+	users := api.With(server.ConcurrencyLimit("users", concurrency.UsersLimit, concurrency.QueueWait), server.Tenant(tenancy, tenants), app.VisitorMiddleware)
+	redisRoutes := api.With(server.ConcurrencyLimit("redis", concurrency.RedisLimit, concurrency.QueueWait), app.VisitorMiddleware)
+
+	// Create routes
+	app.RegisterUserRoutes(users)
+	// Routes authenticated by API key, issued to users with one of roles, who are then
+	// counted as active
+	authenticated := func(roles ...string) *middleware.Group {
+		return users.With(server.RequireRole(adminService, roles...), app.ActiveUserMiddleware)
+	}
+	// The admin API is for the administrators of each tenant, unlike the operational
+	// endpoints of the admin listener
+	app.RegisterAdminUserRoutes(authenticated(models.RoleAdmin))
+	app.RegisterWebhookRoutes(authenticated(models.RoleAdmin))
+	// Following and groups are managed as the user the API key was issued to
+	app.RegisterFollowRoutes(authenticated(models.Roles...))
+	app.RegisterGroupRoutes(authenticated(models.Roles...))
+	app.RegisterAccountRoutes(authenticated(models.Roles...))
+	app.RegisterExportRoutes(authenticated(models.Roles...))
+	if devCfg := config.LoadDev(); devCfg.Enabled {
+		logger.Warn("Dev mode is on; its endpoints must not be exposed in production")
+		app.RegisterDevRoutes(users, devCfg)
+	}
+
+	// Probes for orchestrators and load balancers. Metrics and the other operational
+	// endpoints are served by the admin listener
+	readyz := server.NewReadyz(repo, cacheState, readOnly, breakers...)
+	ops.HandleFunc("GET /livez", server.Livez)
+	ops.Handle("GET /readyz", readyz)
+	server.RegisterDBMetrics(db)
+
+	// Routes backed by Redis alone. The statistics are kept per tenant, so they're served
+	// with the user routes. The data structure demos are optional
+	app.RegisterStatsRoutes(users)
+	app.RegisterSessionRoutes(redisRoutes)
+	// Their keys can only be listed on the admin listener
+	var demoKeys http.Handler
+	if redisCfg.Demos {
+		app.RegisterRedisDemos(redisRoutes)
+		demoKeys = http.HandlerFunc(app.ListDemoKeys)
+	}
+
+	// Background jobs write to the database, so they only run against a matching schema
+	// Archiving moves users out without events, so everything holding users is refreshed
+	onArchived := func(ctx context.Context) {
+		userCache.InvalidateUsers(ctx)
+		userCache.DropUsernameIndex(ctx)
+		if readModel != nil {
+			err := readModel.RebuildAll(ctx, repo)
+			if err != nil {
+				logging.From(ctx).Error("Failed to rebuild the read model", "error", err)
+			}
+		}
+	}
+	if !readOnly {
+		archiverCtx := logging.WithLogger(backgroundCtx, logger.With("component", "archiver"))
+		components.Go("archiver", func() error {
+			repo.RunArchiver(archiverCtx, func() {
+				onArchived(archiverCtx)
+			})
+			return nil
+		})
+	}
+	outboxCfg := config.LoadOutbox()
+	if !readOnly && outboxCfg.Enabled {
+		relay := outbox.NewRelay(outboxCfg, repo, outbox.NewPublisher(outboxCfg, rdb))
+		components.Go("outbox_relay", func() error {
+			relay.Run(logging.WithLogger(backgroundCtx, logger.With("component", "outbox_relay")), drainCtx)
+			return nil
+		})
+	}
+	if !readOnly && jobsCfg.Workers > 0 {
+		jobs.Register(jobQueue, repo, mail, onArchived)
+		hooks.RegisterJobs(jobQueue)
+		components.Go("jobs", func() error {
+			jobQueue.Run(logging.WithLogger(backgroundCtx, logger.With("component", "jobs")), drainCtx)
+			return nil
+		})
+	}
+
+	reload := func(ctx context.Context) error {
+		return reloadConfig(ctx, rateLimit, rdb, cacheState)
+	}
+	go reloadOnSIGHUP(backgroundCtx, reload)
+	go userCache.ProbeRedis(backgroundCtx, config.EnvDuration("REDIS_PROBE_INTERVAL", 5*time.Second))
+	if interval := config.EnvDuration("CACHE_STATS_INTERVAL", 5*time.Minute); interval > 0 {
+		go cache.LogStats(backgroundCtx, interval)
+	}
+	if readModel != nil {
+		components.Go("read_model_build", func() error {
+			readModelCtx := logging.WithLogger(backgroundCtx, logger.With("component", "read_model"))
+			err := readModel.EnsureBuilt(readModelCtx, repo)
+			if err != nil {
+				logging.From(readModelCtx).Error("Failed to build the read model", "error", err)
+			}
+			return nil
+		})
+	}
+	if cacheState.Config().Enabled && cacheState.Config().WarmOnStart {
+		userCache.Warm(ctx)
+	}
+
+	// Recurring jobs
+	sched := scheduler.New(logger.With("component", "scheduler"))
+	schedCfg := config.LoadScheduler()
+	if schedCfg.CacheWarm.Enabled && cacheState.Config().Enabled {
+		addScheduledJob(sched, "cache_warm", schedCfg.CacheWarm, func(ctx context.Context) error {
+			userCache.Warm(ctx)
+			return nil
+		})
+	}
+	if schedCfg.SessionCleanup.Enabled {
+		addScheduledJob(sched, "session_cleanup", schedCfg.SessionCleanup, app.CleanupSessions)
+	}
+	if schedCfg.Archive.Enabled && !readOnly {
+		addScheduledJob(sched, "archive", schedCfg.Archive, func(ctx context.Context) error {
+			_, err := jobQueue.Enqueue(ctx, jobs.TypeArchiveUsers, nil)
+			return err
+		})
+	}
+	if schedCfg.OutboxPurge.Enabled && !readOnly {
+		addScheduledJob(sched, "outbox_purge", schedCfg.OutboxPurge, func(ctx context.Context) error {
+			purged, err := repo.PurgeOutbox(ctx, time.Now().Add(-outboxCfg.Retention))
+			if purged > 0 {
+				logging.From(ctx).Info("Purged published outbox events", "count", purged)
+			}
+			return err
+		})
+	}
+	if schedCfg.UserStats.Enabled {
+		addScheduledJob(sched, "user_stats", schedCfg.UserStats, stats.Refresh)
+	}
+	components.Go("scheduler", func() error {
+		sched.Run(logging.WithLogger(backgroundCtx, logger.With("component", "scheduler")), drainCtx)
+		return nil
+	})
+	if natsCfg := config.LoadNATS(); natsCfg.Enabled {
+		natsServer := natsapi.NewServer(natsCfg, userService, tenants, logger.With("component", "nats"))
+		components.Go("nats", func() error {
+			return natsServer.Run(backgroundCtx)
+		})
+	}
+	// The stream worker consumes the demo stream, so like the demos it's opt-in
+	if config.EnvBool("STREAM_WORKER_ENABLED", false) {
+		components.Go("stream_worker", func() error {
+			handlers.NewStreamWorker(rdb).Run(logging.WithLogger(backgroundCtx, logger.With("component", "stream_worker")))
+			return nil
+		})
+	}
+
+	var compress, localize, accessLog, slowRequests middleware.Middleware
+	if cfg := config.LoadCompression(); cfg.Enabled {
+		compress = server.Compress(cfg)
+	}
+	if cfg := config.LoadI18n(); cfg.Enabled {
+		bundle, err := i18n.New(cfg.Fallback)
+		if err != nil {
+			fatal("Failed to load translations", "error", err)
+		}
+		localize = server.Localize(bundle)
+	}
+	if cfg := config.LoadAccessLog(); cfg.Enabled {
+		accessLog = server.AccessLog(cfg)
+	}
+	if threshold := config.EnvDuration("SLOW_REQUEST_THRESHOLD", time.Second); threshold > 0 {
+		slowRequests = server.SlowRequests(threshold, mux)
+	}
+	serverChain := middleware.New(
+		server.Tracing(mux),
+		server.RequestID,
+		server.Logger(logger, mux),
+		server.ErrorReporting(reporter),
+		server.Metrics(mux),
+		accessLog,
+		slowRequests,
+		compress,
+		localize,
+		server.Recovery(reporter),
+		server.Timeout(config.LoadTimeout(), mux),
+	)
+	handler := serverChain.Then(mux)
+
+	serverCfg := config.LoadServer()
+	httpServer := &http.Server{
+		Addr:              serverCfg.Addr,
+		Handler:           handler,
+		ReadHeaderTimeout: serverCfg.ReadHeaderTimeout,
+		ReadTimeout:       serverCfg.ReadTimeout,
+		WriteTimeout:      serverCfg.WriteTimeout,
+		IdleTimeout:       serverCfg.IdleTimeout,
+		MaxHeaderBytes:    serverCfg.MaxHeaderBytes,
+		ErrorLog:          slog.NewLogLogger(logger.Handler(), slog.LevelWarn),
+	}
+	err = server.ConfigureHTTP2(httpServer, serverCfg)
+	if err != nil {
+		fatal("Failed to configure HTTP/2", "error", err)
+	}
+	// Open /subscribe streams never finish on their own, so end them on shutdown
+	httpServer.RegisterOnShutdown(app.CloseSubscribers)
+
+	var adminServer *http.Server
+	if admin := config.LoadAdmin(); admin.Addr != "" {
+		// No write timeout: CPU profiles and traces take as long as the caller asks
+		adminServer = &http.Server{
+			Addr:              admin.Addr,
+			Handler:           server.NewAdminHandler(admin, readyz, reload, userCache, jobQueue, sched, featureFlags, tenants, adminService, demoKeys),
+			ReadHeaderTimeout: serverCfg.ReadHeaderTimeout,
+			IdleTimeout:       serverCfg.IdleTimeout,
+			MaxHeaderBytes:    serverCfg.MaxHeaderBytes,
+			ErrorLog:          httpServer.ErrorLog,
+		}
+		components.Go("admin_server", func() error {
+			logger.Info("Admin server started", "addr", adminServer.Addr)
+			err := adminServer.ListenAndServe()
+			if err == http.ErrServerClosed {
+				return nil
+			}
+			return err
+		})
+	}
+
+	servers := []*http.Server{httpServer}
+	if adminServer != nil {
+		servers = append(servers, adminServer)
+	}
+	// Start server
+	listeners, err := server.Listen(serverCfg)
+	if err != nil {
+		fatal("Failed to listen", "error", err)
+	}
+	for _, ln := range listeners {
+		logger.Info("Server started", "network", ln.Addr().Network(), "addr", ln.Addr().String(),
+			"tls", serverCfg.TLSCertFile != "", "h2c", serverCfg.H2C)
+		components.Go("http_server", func() error {
+			err := server.Serve(httpServer, ln, serverCfg)
+			if err == http.ErrServerClosed {
+				return nil
+			}
+			return err
+		})
+	}
+
+	// Runs until a signal or a failed component, then waits for the others to stop.
+	// The deferred calls close Redis and MySQL and flush traces and error reports
+	err = server.ShutdownOnSignal(ctx, config.LoadShutdown(), components, servers, stopBackground, abandonBackground)
+	if err != nil {
+		return fmt.Errorf("server stopped after a failure: %w", err)
+	}
+	logger.Info("Server stopped")
+	return nil
+}
+
+// openMySQL opens the configured MySQL database, waiting a little for MySQL to come
+// up when started alongside it, and creates the database if it doesn't exist. Failures
+// exit.
+func openMySQL(ctx context.Context) *sql.DB {
+	logger := logging.From(ctx)
+	cfg := config.LoadDatabase()
+	db, err := otelsql.Open(repository.Driver, cfg.DSN(), otelsql.WithAttributes(semconv.DBSystemMySQL))
+	if err != nil {
+		fatal("Failed to open MySQL connection", "error", err)
+	}
+
+	err = retry.Do(ctx, retry.Policy{
+		MaxAttempts:  10,
+		InitialDelay: 500 * time.Millisecond,
+		MaxDelay:     5 * time.Second,
+		OnRetry: func(attempt int, err error, delay time.Duration) {
+			logger.Warn("MySQL unavailable, retrying", "attempt", attempt, "retry_in", delay, "error", err)
+		},
+	}, db.PingContext)
+	if err != nil {
+		fatal("Failed to connect to MySQL", "error", err)
+	}
+	logger.Info("Connected to MySQL database")
+
+	// Create the database if it doesn't exist
+	_, err = db.ExecContext(ctx, "CREATE DATABASE IF NOT EXISTS "+cfg.Name)
+	if err != nil {
+		fatal("Failed to create database", "error", err)
+	}
+
+	// Switch to the newly created database
+	_, err = db.ExecContext(ctx, "USE "+cfg.Name)
+	if err != nil {
+		fatal("Failed to switch database", "error", err)
+	}
+	return db
+}
+
+// addScheduledJob adds fn to sched under name, exiting if cfg's schedule is invalid.
+func addScheduledJob(sched *scheduler.Scheduler, name string, cfg config.ScheduledJob, fn scheduler.Func) {
+	err := sched.Add(name, cfg.Schedule, fn)
+	if err != nil {
+		fatal("Invalid schedule", "job", name, "error", err)
+	}
+}
+
+// newBreaker returns the circuit breaker around dependency configured by the
+// environment, with its metrics registered, or nil if it's disabled.
+func newBreaker(dependency string, isFailure, isExcluded func(error) bool, logger *slog.Logger) *breaker.Breaker {
+	cfg := config.LoadBreaker(strings.ToUpper(dependency))
+	if !cfg.Enabled {
+		return nil
+	}
+	b := breaker.New(breaker.Settings{
+		Name:             dependency,
+		Failures:         cfg.Failures,
+		OpenTimeout:      cfg.OpenTimeout,
+		HalfOpenRequests: cfg.HalfOpenRequests,
+		IsFailure:        isFailure,
+		IsExcluded:       isExcluded,
+		OnStateChange: func(name string, from, to breaker.State) {
+			logger.Warn("Circuit breaker changed state", "breaker", name, "from", from.String(), "to", to.String())
+		},
+	})
+	server.RegisterBreakerMetrics(b)
+	return b
+}
+
+// fatal logs msg at error level and exits, like log.Fatal.
+func fatal(msg string, args ...any) {
+	slog.Error(msg, args...)
+	os.Exit(1)
+}
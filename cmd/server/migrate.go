@@ -0,0 +1,68 @@
+package main
+
+import (
+	"github.com/spf13/cobra"
+
+	"go-mysql/internal/logging"
+	"go-mysql/internal/repository"
+)
+
+// newMigrateCommand returns the command applying and reverting schema migrations,
+// which the server otherwise applies on start unless AUTO_MIGRATE=false.
+func newMigrateCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "migrate",
+		Short: "Apply or revert schema migrations",
+	}
+
+	up := &cobra.Command{
+		Use:   "up",
+		Short: "Apply every pending migration",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			ctx := cmd.Context()
+			db := openMySQL(ctx)
+			defer db.Close()
+
+			version, err := repository.New(db, nil).MigrateUp(ctx)
+			if err != nil {
+				return err
+			}
+			logging.From(ctx).Info("Schema is up to date", "version", version)
+			return nil
+		},
+	}
+
+	var steps, to int
+	down := &cobra.Command{
+		Use:   "down",
+		Short: "Revert the latest migrations, dropping what they added",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			ctx := cmd.Context()
+			db := openMySQL(ctx)
+			defer db.Close()
+			repo := repository.New(db, nil)
+
+			if !cmd.Flags().Changed("to") {
+				version, err := repo.SchemaVersion(ctx)
+				if err != nil {
+					return err
+				}
+				to = max(version-steps, 0)
+			}
+			err := repo.MigrateDown(ctx, to)
+			if err != nil {
+				return err
+			}
+			logging.From(ctx).Info("Schema reverted", "version", to)
+			return nil
+		},
+	}
+	down.Flags().IntVar(&steps, "steps", 1, "number of migrations to revert")
+	down.Flags().IntVar(&to, "to", 0, "schema version to revert to, instead of --steps")
+	down.MarkFlagsMutuallyExclusive("steps", "to")
+
+	cmd.AddCommand(up, down)
+	return cmd
+}
@@ -0,0 +1,66 @@
+package main
+
+import (
+	"context"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/go-redis/redis/v8"
+
+	"go-mysql/internal/cache"
+	"go-mysql/internal/config"
+	"go-mysql/internal/logging"
+	"go-mysql/internal/server"
+)
+
+// reloadConfig re-reads the config file and applies the settings that can change
+// without a restart: the log level, the rate limits, and the cache TTLs and rebuild
+// settings. Everything else, such as addresses and the cache backend, keeps its value
+// until the next restart. If any reloaded setting is invalid, nothing changes.
+func reloadConfig(ctx context.Context, limiter *server.RateLimit, rdb redis.UniversalClient, state *cache.State) error {
+	var logCfg config.Log
+	var rateLimitCfg config.RateLimit
+	var loadedCache config.Cache
+	err := config.Reload(func() {
+		logCfg = config.LoadLog()
+		rateLimitCfg = config.LoadRateLimit()
+		loadedCache = config.LoadCache()
+	})
+	if err != nil {
+		return err
+	}
+
+	logging.Level.Set(logCfg.Level)
+	limiter.Set(server.NewRateLimiter(rateLimitCfg, rdb, state))
+
+	cacheCfg := *state.Config()
+	cacheCfg.ListTTL = loadedCache.ListTTL
+	cacheCfg.UserTTL = loadedCache.UserTTL
+	cacheCfg.RebuildLockTTL = loadedCache.RebuildLockTTL
+	cacheCfg.RebuildWait = loadedCache.RebuildWait
+	cacheCfg.EarlyRefreshBeta = loadedCache.EarlyRefreshBeta
+	state.SetConfig(cacheCfg)
+
+	logging.From(ctx).Info("Configuration reloaded", "log_level", logCfg.Level.String(), "rate_limit", rateLimitCfg.Algorithm,
+		"cache_list_ttl", cacheCfg.ListTTL, "cache_user_ttl", cacheCfg.UserTTL)
+	return nil
+}
+
+// reloadOnSIGHUP calls reload on every SIGHUP until ctx is done.
+func reloadOnSIGHUP(ctx context.Context, reload func(context.Context) error) {
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, syscall.SIGHUP)
+	defer signal.Stop(signals)
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-signals:
+		}
+		err := reload(ctx)
+		if err != nil {
+			logging.From(ctx).Error("Failed to reload configuration", "error", err)
+		}
+	}
+}
@@ -0,0 +1,78 @@
+package main
+
+import (
+	"errors"
+
+	"github.com/spf13/cobra"
+
+	"go-mysql/internal/cache"
+	"go-mysql/internal/config"
+	"go-mysql/internal/events"
+	"go-mysql/internal/logging"
+	"go-mysql/internal/readmodel"
+	"go-mysql/internal/repository"
+	"go-mysql/internal/service"
+	"go-mysql/internal/tenant"
+	"go-mysql/pkg/workerpool"
+)
+
+// newSeedCommand returns the command creating users with made-up profiles, like
+// POST /dev/users/generate but without dev mode or its limit. Seeded users are cached,
+// counted as signups and added to the read model; no emails or webhooks are sent.
+func newSeedCommand() *cobra.Command {
+	var count, batch, tenantID int
+	cmd := &cobra.Command{
+		Use:   "seed",
+		Short: "Create users with made-up profiles",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			ctx := tenant.WithID(cmd.Context(), tenantID)
+			logger := logging.From(ctx)
+			db := openMySQL(ctx)
+			defer db.Close()
+			repo := repository.New(db, nil)
+			readOnly, err := repo.CheckSchema(ctx)
+			if err != nil {
+				return err
+			}
+			if readOnly {
+				return errors.New("can't seed a mismatched schema")
+			}
+
+			rdb := cache.NewRedisClient(config.LoadRedis())
+			defer rdb.Close()
+			cacheState := cache.NewState(config.LoadCache())
+			_, err = rdb.Ping(ctx).Result()
+			if err != nil {
+				logger.Warn("Redis unavailable, seeding without the cache", "error", err)
+			} else {
+				cacheState.MarkRedisAvailable()
+			}
+			pool := workerpool.New(1, 100, logger.With("component", "worker_pool"))
+			defer pool.Shutdown(ctx)
+			userCache := cache.New(cacheState, rdb, repo, pool)
+
+			bus := events.NewBus()
+			userCache.Subscribe(bus)
+			statsCfg := config.LoadStats()
+			service.NewStats(repo, userCache, statsCfg.TTL, statsCfg.SignupsRetention).Subscribe(bus)
+			var reads service.ReadModel
+			if readModelCfg := config.LoadReadModel(); readModelCfg.Enabled {
+				readModel := readmodel.New(rdb, readModelCfg.KeyPrefix)
+				readModel.Subscribe(bus)
+				reads = readModel
+			}
+			userService := service.NewUserService(repo, userCache, reads, bus)
+
+			if batch < 1 {
+				batch = config.LoadDev().GenerateBatch
+			}
+			_, err = userService.GenerateUsers(ctx, count, batch)
+			return err
+		},
+	}
+	cmd.Flags().IntVar(&count, "count", 100, "number of users to create")
+	cmd.Flags().IntVar(&batch, "batch", 0, "users created per insert, DEV_GENERATE_BATCH by default")
+	cmd.Flags().IntVar(&tenantID, "tenant", tenant.DefaultID, "id of the tenant to create the users for")
+	return cmd
+}
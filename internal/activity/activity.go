@@ -0,0 +1,174 @@
+// Package activity keeps the activity feed of each user: what it did that others can
+// see, such as updating its profile, joining a group or following someone. Activities
+// are recorded from the events bus into a Redis stream per user, capped at the latest
+// entries, and read newest first with the stream ids as cursors.
+//
+// Feeds aren't a record: they're lost with Redis, and activities recorded while it's
+// unreachable are dropped.
+package activity
+
+import (
+	"context"
+	"errors"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+
+	"go-mysql/internal/cache"
+	"go-mysql/internal/config"
+	"go-mysql/internal/events"
+	"go-mysql/internal/logging"
+	"go-mysql/internal/models"
+	"go-mysql/internal/tenant"
+)
+
+// ErrInvalidCursor is returned for a cursor that isn't an activity id.
+var ErrInvalidCursor = errors.New("Invalid cursor")
+
+// cursorPattern is what stream ids, hence activity ids, look like.
+var cursorPattern = regexp.MustCompile(`^\d+-\d+$`)
+
+// Stream entry fields other than the details of the activity.
+const (
+	typeField      = "type"
+	createdAtField = "created_at"
+)
+
+// Users looks users up, implemented by service.UserService.
+type Users interface {
+	Get(ctx context.Context, id int) (models.User, error)
+}
+
+// Feed records and lists the activities of users.
+type Feed struct {
+	rdb        redis.UniversalClient
+	cache      *cache.State
+	maxEntries int64
+}
+
+// New returns the feeds kept in rdb under the key prefix of the cache, each capped as
+// cfg says.
+func New(rdb redis.UniversalClient, state *cache.State, cfg config.Feed) *Feed {
+	return &Feed{rdb: rdb, cache: state, maxEntries: int64(cfg.MaxEntries)}
+}
+
+// key is the stream holding the feed of the user with the given id.
+func (f *Feed) key(ctx context.Context, userID int) string {
+	return f.cache.Config().KeyPrefix + tenant.Key(ctx, "feed:"+strconv.Itoa(userID))
+}
+
+// Subscribe records the activities published on bus in the feeds of the users doing
+// them, looking up the users they involve in users. Feeds are deleted with their users.
+func (f *Feed) Subscribe(bus *events.Bus, users Users) {
+	events.Subscribe(bus, func(ctx context.Context, e events.UserUpdated) {
+		var fields []string
+		for _, field := range e.Fields {
+			if field == "avatar_key" || slices.Contains(models.ProfileFieldNames, field) {
+				fields = append(fields, field)
+			}
+		}
+		// Only profile changes show up: a nil Fields is a whole user written at once
+		if len(fields) > 0 {
+			f.Record(ctx, e.User.ID, models.ActivityProfileUpdated, map[string]string{"fields": strings.Join(fields, ",")})
+		}
+	})
+	events.Subscribe(bus, func(ctx context.Context, e events.GroupJoined) {
+		f.Record(ctx, e.UserID, models.ActivityGroupJoined, map[string]string{
+			"group_id":   strconv.Itoa(e.Group.ID),
+			"group_name": e.Group.Name,
+			"role":       e.Role,
+		})
+	})
+	events.Subscribe(bus, func(ctx context.Context, e events.UserFollowed) {
+		followee, err := users.Get(ctx, e.FolloweeID)
+		if err != nil {
+			logging.From(ctx).Warn("Failed to look up followed user for the activity feed", "user_id", e.FolloweeID, "error", err)
+			return
+		}
+		f.Record(ctx, e.FollowerID, models.ActivityFollowed, map[string]string{
+			"user_id":  strconv.Itoa(followee.ID),
+			"username": followee.Username,
+		})
+	})
+	events.Subscribe(bus, func(ctx context.Context, e events.UserDeleted) {
+		err := f.rdb.Del(context.WithoutCancel(ctx), f.key(ctx, e.ID)).Err()
+		if err != nil {
+			logging.From(ctx).Warn("Failed to delete activity feed", "user_id", e.ID, "error", err)
+		}
+	})
+}
+
+// Record adds an activity of the given type to the feed of the user with the given id,
+// dropping the oldest ones past the cap. Failures are logged, not returned: the change
+// the activity is about already happened.
+func (f *Feed) Record(ctx context.Context, userID int, activityType string, details map[string]string) {
+	values := make(map[string]any, len(details)+2)
+	for k, v := range details {
+		values[k] = v
+	}
+	values[typeField] = activityType
+	values[createdAtField] = time.Now().UTC().Format(time.RFC3339Nano)
+
+	err := f.rdb.XAdd(context.WithoutCancel(ctx), &redis.XAddArgs{
+		Stream: f.key(ctx, userID),
+		// Trimming approximately lets Redis drop whole nodes of the stream at once
+		MaxLen: f.maxEntries,
+		Approx: true,
+		Values: values,
+	}).Err()
+	if err != nil {
+		logging.From(ctx).Warn("Failed to record activity", "user_id", userID, "type", activityType, "error", err)
+	}
+}
+
+// List returns up to limit activities of the user with the given id, newest first,
+// starting after the activity cursor if it's set, and the cursor of the next page,
+// empty on the last one.
+func (f *Feed) List(ctx context.Context, userID int, cursor string, limit int) ([]models.Activity, string, error) {
+	start := "+"
+	if cursor != "" {
+		if !cursorPattern.MatchString(cursor) {
+			return nil, "", ErrInvalidCursor
+		}
+		start = "(" + cursor
+	}
+	// One more than asked for tells whether there's a next page
+	entries, err := f.rdb.XRevRangeN(ctx, f.key(ctx, userID), start, "-", int64(limit)+1).Result()
+	if err != nil {
+		return nil, "", err
+	}
+
+	next := ""
+	if len(entries) > limit {
+		entries = entries[:limit]
+		next = entries[limit-1].ID
+	}
+	activities := make([]models.Activity, 0, len(entries))
+	for _, entry := range entries {
+		activities = append(activities, toActivity(entry))
+	}
+	return activities, next, nil
+}
+
+func toActivity(entry redis.XMessage) models.Activity {
+	a := models.Activity{ID: entry.ID}
+	for k, v := range entry.Values {
+		s, _ := v.(string)
+		switch k {
+		case typeField:
+			a.Type = s
+		case createdAtField:
+			a.CreatedAt, _ = time.Parse(time.RFC3339Nano, s)
+		default:
+			if a.Details == nil {
+				a.Details = make(map[string]string, len(entry.Values))
+			}
+			a.Details[k] = s
+		}
+	}
+	return a
+}
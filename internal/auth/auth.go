@@ -0,0 +1,22 @@
+// Package auth carries the principal a request was authenticated as in its context,
+// for the handlers behind server.RequireRole.
+package auth
+
+import (
+	"context"
+
+	"go-mysql/internal/models"
+)
+
+type contextKey struct{}
+
+// WithPrincipal returns a copy of ctx authenticated as p.
+func WithPrincipal(ctx context.Context, p models.Principal) context.Context {
+	return context.WithValue(ctx, contextKey{}, p)
+}
+
+// Principal returns the principal ctx was authenticated as, or ok false.
+func Principal(ctx context.Context) (p models.Principal, ok bool) {
+	p, ok = ctx.Value(contextKey{}).(models.Principal)
+	return p, ok
+}
@@ -0,0 +1,65 @@
+package cache
+
+import (
+	"context"
+	"errors"
+
+	"github.com/go-redis/redis/v8"
+
+	"go-mysql/pkg/breaker"
+)
+
+// IsRedisFailure reports whether err means Redis couldn't be reached or didn't answer,
+// as opposed to answering with an error or a missing key.
+func IsRedisFailure(err error) bool {
+	var replyErr redis.Error
+	return !errors.As(err, &replyErr) && err != redis.Nil && !errors.Is(err, context.Canceled)
+}
+
+// BreakerHook puts a circuit breaker around the commands of the client it's added
+// to: while the breaker is open they fail right away with breaker.ErrOpen instead of
+// waiting on an unreachable Redis. A pipeline counts as a single call.
+type BreakerHook struct {
+	Breaker *breaker.Breaker
+}
+
+type breakerDoneKey struct{}
+
+func (h BreakerHook) BeforeProcess(ctx context.Context, cmd redis.Cmder) (context.Context, error) {
+	return h.before(ctx)
+}
+
+func (h BreakerHook) AfterProcess(ctx context.Context, cmd redis.Cmder) error {
+	h.after(ctx, cmd.Err())
+	return nil
+}
+
+func (h BreakerHook) BeforeProcessPipeline(ctx context.Context, cmds []redis.Cmder) (context.Context, error) {
+	return h.before(ctx)
+}
+
+func (h BreakerHook) AfterProcessPipeline(ctx context.Context, cmds []redis.Cmder) error {
+	var err error
+	for _, cmd := range cmds {
+		if cmd.Err() != nil && IsRedisFailure(cmd.Err()) {
+			err = cmd.Err()
+			break
+		}
+	}
+	h.after(ctx, err)
+	return nil
+}
+
+func (h BreakerHook) before(ctx context.Context) (context.Context, error) {
+	done, err := h.Breaker.Allow()
+	if err != nil {
+		return ctx, err
+	}
+	return context.WithValue(ctx, breakerDoneKey{}, done), nil
+}
+
+func (h BreakerHook) after(ctx context.Context, err error) {
+	if done, ok := ctx.Value(breakerDoneKey{}).(func(error)); ok {
+		done(err)
+	}
+}
@@ -0,0 +1,353 @@
+// Package cache is the Redis layer of the service: the cache in front of MySQL's
+// users, with its backends and layouts, the username index, and the Redis client,
+// Lua scripts and health tracking they rely on.
+package cache
+
+import (
+	"context"
+	"errors"
+	"math"
+	"math/rand"
+	"strconv"
+	"sync/atomic"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+	"golang.org/x/sync/singleflight"
+
+	"go-mysql/internal/config"
+	"go-mysql/internal/logging"
+	"go-mysql/internal/models"
+	"go-mysql/internal/repository"
+	"go-mysql/internal/tenant"
+	"go-mysql/pkg/workerpool"
+)
+
+// Cache is a byte-oriented key-value store with per-entry expiry. The user cache below is
+// written against it so handlers never talk to a particular backend directly.
+type Cache interface {
+	// Get returns errCacheMiss if key isn't cached.
+	Get(ctx context.Context, key string) ([]byte, error)
+	// GetMulti returns one value per key, nil where the key isn't cached.
+	GetMulti(ctx context.Context, keys ...string) ([][]byte, error)
+	// Set stores value under key. A ttl of 0 means the entry doesn't expire.
+	Set(ctx context.Context, key string, value []byte, ttl time.Duration) error
+	Delete(ctx context.Context, keys ...string) error
+	// Invalidate deletes every key starting with prefix.
+	Invalidate(ctx context.Context, prefix string) error
+}
+
+var errCacheMiss = errors.New("cache miss")
+
+// newCache returns the backend selected by state's configuration, instrumented with
+// the cache metrics. rdb is only used by the Redis-backed backends.
+func newCache(state *State, rdb redis.UniversalClient) Cache {
+	cfg := state.Config()
+	switch {
+	case !cfg.Enabled:
+		return instrumentedCache{noopCache{}, state}
+	case cfg.Backend == "memory":
+		return instrumentedCache{newMemoryCache(cfg.MemoryMaxEntries), state}
+	case cfg.Backend == "tiered":
+		return instrumentedCache{newTieredCache(&redisCache{client: rdb, prefix: cfg.KeyPrefix}, cfg.MemoryMaxEntries, cfg.LocalTTL), state}
+	default:
+		return instrumentedCache{&redisCache{client: rdb, prefix: cfg.KeyPrefix}, state}
+	}
+}
+
+// noopCache never stores anything, so every read falls through to MySQL.
+type noopCache struct{}
+
+func (noopCache) Get(context.Context, string) ([]byte, error) { return nil, errCacheMiss }
+
+func (noopCache) GetMulti(_ context.Context, keys ...string) ([][]byte, error) {
+	return make([][]byte, len(keys)), nil
+}
+
+func (noopCache) Set(context.Context, string, []byte, time.Duration) error { return nil }
+func (noopCache) Delete(context.Context, ...string) error                  { return nil }
+func (noopCache) Invalidate(context.Context, string) error                 { return nil }
+
+// userCacheLayout is how users are represented in the cache. jsonLayout stores each
+// user as a JSON blob through a Cache backend; hashLayout stores Redis hashes.
+type userCacheLayout interface {
+	// getAll returns every user ordered by id, and when the listing expires.
+	// ok is false unless the complete set is cached.
+	getAll(ctx context.Context) (users []models.User, expiresAt time.Time, ok bool)
+	// setAll replaces the cached user set.
+	setAll(ctx context.Context, users []models.User) error
+	get(ctx context.Context, id int) (models.User, bool)
+	// getFields returns only the named fields (see userFieldNames) of a user.
+	getFields(ctx context.Context, id int, fields []string) (map[string]string, bool)
+	// set stores a user. isNew says the user was just created and isn't in the listing yet.
+	set(ctx context.Context, user models.User, isNew bool) error
+	// setField updates one field of a cached user, and does nothing if it isn't cached.
+	setField(ctx context.Context, id int, field, value string) error
+	remove(ctx context.Context, id int) error
+	removeAll(ctx context.Context) error
+}
+
+// newUserCacheLayout returns the layout selected by state's configuration on top of
+// cache, the configured backend. The hash layout talks to Redis directly and requires
+// the redis backend.
+func newUserCacheLayout(state *State, cache Cache, rdb redis.UniversalClient) userCacheLayout {
+	cfg := state.Config()
+	if cfg.Enabled && cfg.Layout == "hash" {
+		return &hashLayout{client: rdb, prefix: cfg.KeyPrefix, state: state}
+	}
+	return jsonLayout{cache: cache, state: state}
+}
+
+// State is the cache configuration and whether Redis is reachable, shared by the user
+// cache and everything else keeping data in Redis.
+type State struct {
+	// config holds the cache configuration. Its TTLs and rebuild settings can be
+	// changed at runtime with SetConfig, so it's always read through Config.
+	config atomic.Pointer[config.Cache]
+	// redisUp tracks whether Redis is reachable, see RedisAvailable.
+	redisUp atomic.Bool
+}
+
+// NewState returns the state of a cache configured by cfg, with Redis taken to be
+// unreachable until MarkRedisAvailable says otherwise.
+func NewState(cfg config.Cache) *State {
+	s := &State{}
+	s.SetConfig(cfg)
+	return s
+}
+
+// Config returns the current cache configuration.
+func (s *State) Config() *config.Cache {
+	return s.config.Load()
+}
+
+// SetConfig replaces the cache configuration. Only the TTLs and rebuild settings take
+// effect; the backend and layout are fixed by New.
+func (s *State) SetConfig(cfg config.Cache) {
+	s.config.Store(&cfg)
+}
+
+// UserCache is the cache in front of MySQL's users, together with the username index.
+// Reads fall through to the repository on a miss, and the write methods keep the cache
+// in step with changes made to the database.
+type UserCache struct {
+	state   *State
+	rdb     redis.UniversalClient
+	repo    *repository.Repository
+	backend Cache
+	layout  userCacheLayout
+	// pool runs early refreshes of the listing.
+	pool *workerpool.Pool
+
+	// group collapses concurrent cache rebuilds into a single MySQL query.
+	group singleflight.Group
+
+	// rebuildTime is how long the last rebuild took, in nanoseconds.
+	rebuildTime atomic.Int64
+}
+
+// New creates the cache selected by state's configuration on top of rdb, loading misses
+// from repo and refreshing in the background on pool.
+func New(state *State, rdb redis.UniversalClient, repo *repository.Repository, pool *workerpool.Pool) *UserCache {
+	backend := newCache(state, rdb)
+	return &UserCache{
+		state:   state,
+		rdb:     rdb,
+		repo:    repo,
+		backend: backend,
+		layout:  newUserCacheLayout(state, backend, rdb),
+		pool:    pool,
+	}
+}
+
+// State returns the configuration and Redis health the cache was created with.
+func (c *UserCache) State() *State {
+	return c.state
+}
+
+// WatchKeyspace keeps the local tier of the tiered backend coherent until ctx is done.
+// It returns straight away for the other backends.
+func (c *UserCache) WatchKeyspace(ctx context.Context) {
+	if tiered, ok := c.backend.(instrumentedCache).Cache.(*tieredCache); ok {
+		tiered.watch(ctx)
+	}
+}
+
+// userKey is the key of a user of the tenant ctx belongs to. Every key of the user
+// cache is built with tenant.Key, so tenants never see each other's users.
+func userKey(ctx context.Context, id int) string {
+	return tenant.Key(ctx, "user:"+strconv.Itoa(id))
+}
+
+// Users returns every user from the cache, ordered by id.
+// ok is false if the cache doesn't hold the complete set.
+func (c *UserCache) Users(ctx context.Context) (users []models.User, ok bool) {
+	if !c.state.Usable() {
+		return nil, false
+	}
+	users, expiresAt, ok := c.layout.getAll(ctx)
+	if ok && c.shouldRefreshEarly(expiresAt) {
+		err := c.pool.Submit(ctx, "refresh users cache", func(ctx context.Context) error {
+			_, err := c.LoadUsers(ctx)
+			return err
+		})
+		if err != nil {
+			logging.From(ctx).Warn("Skipped early refresh of users cache", "error", err)
+		}
+	}
+	return users, ok
+}
+
+// LoadUsers queries MySQL and repopulates the cache. Concurrent callers in this process
+// share one query, and a lock in Redis makes sure only one instance rebuilds at a time.
+// Only MySQL errors are returned; failing to cache the result is logged.
+func (c *UserCache) LoadUsers(ctx context.Context) ([]models.User, error) {
+	v, err, _ := c.group.Do(tenant.Key(ctx, "users"), func() (any, error) {
+		// Callers sharing this rebuild shouldn't fail because the first one went away
+		ctx := context.WithoutCancel(ctx)
+		if !c.state.Usable() {
+			return c.repo.Users(ctx)
+		}
+
+		lock, err := c.acquireRebuildLock(ctx)
+		if err == errLockNotAcquired {
+			// Another instance is rebuilding; use its result, or go to MySQL
+			// without touching the cache if it takes too long
+			users, ok := c.waitForUsers(ctx, c.state.Config().RebuildWait)
+			if ok {
+				return users, nil
+			}
+			return c.repo.Users(ctx)
+		}
+		if err != nil {
+			c.state.reportError(ctx, err)
+			logging.From(ctx).Warn("Failed to acquire cache rebuild lock", "error", err)
+		}
+		if lock != nil {
+			defer lock.Release(ctx)
+		}
+
+		start := time.Now()
+		users, err := c.repo.Users(ctx)
+		if err != nil {
+			recordRebuild(time.Since(start), err)
+			return nil, err
+		}
+		err = c.layout.setAll(ctx, users)
+		recordRebuild(time.Since(start), err)
+		if err != nil {
+			logging.From(ctx).Warn("Failed to update cache", "error", err)
+			return users, nil
+		}
+		c.rebuildTime.Store(int64(time.Since(start)))
+		c.indexUsernames(ctx, users)
+		return users, nil
+	})
+	users, _ := v.([]models.User)
+	return users, err
+}
+
+// acquireRebuildLock takes the listing rebuild lock. It returns a nil lock when the cache
+// isn't shared between instances and no lock is needed.
+func (c *UserCache) acquireRebuildLock(ctx context.Context) (*redisLock, error) {
+	if !c.state.Config().Enabled || c.state.Config().Backend == "memory" {
+		return nil, nil
+	}
+	return acquireLock(ctx, c.rdb, c.state.Config().KeyPrefix+tenant.Key(ctx, "lock:users:rebuild"), c.state.Config().RebuildLockTTL)
+}
+
+// waitForUsers polls the cache until the listing shows up or timeout passes.
+func (c *UserCache) waitForUsers(ctx context.Context, timeout time.Duration) ([]models.User, bool) {
+	deadline := time.Now().Add(timeout)
+	for time.Now().Before(deadline) {
+		time.Sleep(50 * time.Millisecond)
+		users, _, ok := c.layout.getAll(ctx)
+		if ok {
+			return users, true
+		}
+	}
+	return nil, false
+}
+
+// shouldRefreshEarly implements probabilistic early expiration ("XFetch"): the closer the
+// listing is to expiring and the longer a rebuild takes, the more likely a request is to
+// trigger a background refresh, so the key is usually rebuilt before every request misses.
+func (c *UserCache) shouldRefreshEarly(expiresAt time.Time) bool {
+	if c.state.Config().EarlyRefreshBeta <= 0 {
+		return false
+	}
+	ttl := time.Until(expiresAt)
+	if ttl <= 0 {
+		return false
+	}
+	delta := float64(c.rebuildTime.Load())
+	return delta*c.state.Config().EarlyRefreshBeta*-math.Log(rand.Float64()) >= float64(ttl)
+}
+
+// User returns a single user from the cache.
+func (c *UserCache) User(ctx context.Context, id int) (models.User, bool) {
+	if !c.state.Usable() {
+		return models.User{}, false
+	}
+	return c.layout.get(ctx, id)
+}
+
+// UserFields returns some fields of a single user from the cache.
+func (c *UserCache) UserFields(ctx context.Context, id int, fields []string) (map[string]string, bool) {
+	if !c.state.Usable() {
+		return nil, false
+	}
+	return c.layout.getFields(ctx, id, fields)
+}
+
+// The methods below keep the cache in step with writes. Failures are logged, not
+// returned, since the database already holds the change. They finish even if ctx is
+// cancelled, so a client going away can't leave a stale entry behind. While Redis is
+// unreachable they do nothing; ProbeRedis drops the whole cache before caching resumes.
+
+// SetUser stores or refreshes a single existing user.
+func (c *UserCache) SetUser(ctx context.Context, user models.User) {
+	if !c.state.Usable() {
+		return
+	}
+	logCacheError(ctx, c.layout.set(context.WithoutCancel(ctx), user, false))
+}
+
+// AddUser caches a user that was just created.
+func (c *UserCache) AddUser(ctx context.Context, user models.User) {
+	if !c.state.Usable() {
+		return
+	}
+	logCacheError(ctx, c.layout.set(context.WithoutCancel(ctx), user, true))
+}
+
+// SetUserField updates one field of a cached user.
+func (c *UserCache) SetUserField(ctx context.Context, id int, field, value string) {
+	if !c.state.Usable() {
+		return
+	}
+	logCacheError(ctx, c.layout.setField(context.WithoutCancel(ctx), id, field, value))
+}
+
+// RemoveUser removes a single user from the cache.
+func (c *UserCache) RemoveUser(ctx context.Context, id int) {
+	if !c.state.Usable() {
+		return
+	}
+	logCacheError(ctx, c.layout.remove(context.WithoutCancel(ctx), id))
+}
+
+// InvalidateUsers drops every cached user of every tenant, along with the username
+// index, e.g. after bulk changes.
+func (c *UserCache) InvalidateUsers(ctx context.Context) {
+	if !c.state.Usable() {
+		return
+	}
+	logCacheError(ctx, c.layout.removeAll(context.WithoutCancel(ctx)))
+}
+
+func logCacheError(ctx context.Context, err error) {
+	if err != nil {
+		logging.From(ctx).Warn("Failed to update cache", "error", err)
+	}
+}
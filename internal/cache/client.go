@@ -0,0 +1,33 @@
+package cache
+
+import (
+	"github.com/go-redis/redis/v8"
+
+	"go-mysql/internal/config"
+)
+
+// NewRedisClient builds a single-node, Sentinel failover or cluster client depending on cfg.Mode.
+// All three satisfy redis.UniversalClient, so the rest of the code doesn't care which is in use.
+func NewRedisClient(cfg config.Redis) redis.UniversalClient {
+	switch cfg.Mode {
+	case "sentinel":
+		return redis.NewFailoverClient(&redis.FailoverOptions{
+			MasterName:       cfg.MasterName,
+			SentinelAddrs:    cfg.Addrs,
+			SentinelPassword: cfg.SentinelPassword,
+			Password:         cfg.Password,
+			DB:               cfg.DB,
+		})
+	case "cluster":
+		return redis.NewClusterClient(&redis.ClusterOptions{
+			Addrs:    cfg.Addrs,
+			Password: cfg.Password,
+		})
+	default:
+		return redis.NewClient(&redis.Options{
+			Addr:     cfg.Addrs[0],
+			Password: cfg.Password,
+			DB:       cfg.DB,
+		})
+	}
+}
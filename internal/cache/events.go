@@ -0,0 +1,40 @@
+package cache
+
+import (
+	"context"
+
+	"go-mysql/internal/events"
+	"go-mysql/internal/models"
+)
+
+// Subscribe keeps the cache and the username index in step with the user changes
+// published on bus.
+func (c *UserCache) Subscribe(bus *events.Bus) {
+	events.Subscribe(bus, func(ctx context.Context, e events.UserCreated) {
+		c.AddUser(ctx, e.User)
+		c.IndexUsername(ctx, e.User.Username, e.User.ID)
+	})
+	events.Subscribe(bus, func(ctx context.Context, e events.UserUpdated) {
+		if e.PreviousUsername != "" {
+			c.UnindexUsername(ctx, e.PreviousUsername)
+			c.IndexUsername(ctx, e.User.Username, e.User.ID)
+		}
+		if e.Fields == nil {
+			c.SetUser(ctx, e.User)
+			c.IndexUsername(ctx, e.User.Username, e.User.ID)
+			return
+		}
+		values := models.UserFieldMap(e.User)
+		for _, field := range e.Fields {
+			c.SetUserField(ctx, e.User.ID, field, values[field])
+		}
+	})
+	events.Subscribe(bus, func(ctx context.Context, e events.UserDeleted) {
+		c.RemoveUser(ctx, e.ID)
+		c.UnindexUsername(ctx, e.Username)
+		c.forgetFollowCounts(ctx, e.ID)
+	})
+	events.Subscribe(bus, func(ctx context.Context, e events.UserMerged) {
+		c.forgetFollowCounts(ctx, append([]int{e.Merge.UserID, e.Merge.IntoID}, e.Related...)...)
+	})
+}
@@ -0,0 +1,105 @@
+package cache
+
+import (
+	"context"
+	"strconv"
+
+	"github.com/go-redis/redis/v8"
+
+	"go-mysql/internal/logging"
+	"go-mysql/internal/models"
+)
+
+// followersKey and followingKey are the Redis counters of a user's followers and of the
+// users it follows. They're filled from MySQL when missing, then moved with INCR and
+// DECR on every follow and unfollow, and expire with the user entries; follows deleted
+// along with a user aren't counted down, so the counters of its followers only catch up
+// then.
+func (c *UserCache) followersKey(ctx context.Context, id int) string {
+	return c.state.Config().KeyPrefix + userKey(ctx, id) + ":followers"
+}
+
+func (c *UserCache) followingKey(ctx context.Context, id int) string {
+	return c.state.Config().KeyPrefix + userKey(ctx, id) + ":following"
+}
+
+// redisValuesUsable reports whether the values kept in Redis whatever the cache
+// backend, such as the follow counters, should be used right now.
+func (c *UserCache) redisValuesUsable() bool {
+	return c.state.Config().Enabled && c.state.RedisAvailable()
+}
+
+// FollowCounts returns the cached follow counts of the user with the given id. ok is
+// false unless both are cached.
+func (c *UserCache) FollowCounts(ctx context.Context, id int) (counts models.FollowCounts, ok bool) {
+	if !c.redisValuesUsable() {
+		return models.FollowCounts{}, false
+	}
+	values, err := c.rdb.MGet(ctx, c.followersKey(ctx, id), c.followingKey(ctx, id)).Result()
+	if err != nil {
+		c.state.reportError(ctx, err)
+		return models.FollowCounts{}, false
+	}
+	followers, ok1 := values[0].(string)
+	following, ok2 := values[1].(string)
+	if !ok1 || !ok2 {
+		cacheMisses.Add(1)
+		return models.FollowCounts{}, false
+	}
+	counts.Followers, _ = strconv.Atoi(followers)
+	counts.Following, _ = strconv.Atoi(following)
+	cacheHits.Add(1)
+	return counts, true
+}
+
+// SetFollowCounts caches the follow counts of the user with the given id, as counted
+// in MySQL.
+func (c *UserCache) SetFollowCounts(ctx context.Context, id int, counts models.FollowCounts) {
+	if !c.redisValuesUsable() {
+		return
+	}
+	_, err := c.rdb.TxPipelined(context.WithoutCancel(ctx), func(pipe redis.Pipeliner) error {
+		pipe.Set(ctx, c.followersKey(ctx, id), counts.Followers, c.state.Config().UserTTL)
+		pipe.Set(ctx, c.followingKey(ctx, id), counts.Following, c.state.Config().UserTTL)
+		return nil
+	})
+	if err != nil {
+		c.state.reportError(ctx, err)
+		logging.From(ctx).Warn("Failed to cache follow counts", "user_id", id, "error", err)
+	}
+}
+
+// CountFollow moves the cached counters after followerID started following followeeID,
+// or stopped if followed is false. Counters that aren't cached are left alone.
+func (c *UserCache) CountFollow(ctx context.Context, followerID, followeeID int, followed bool) {
+	if !c.redisValuesUsable() {
+		return
+	}
+	incr := "0"
+	if followed {
+		incr = "1"
+	}
+	keys := []string{c.followersKey(ctx, followeeID), c.followingKey(ctx, followerID)}
+	err := Script("incr_if_exists").Run(context.WithoutCancel(ctx), c.rdb, keys, incr).Err()
+	if err != nil {
+		c.state.reportError(ctx, err)
+		// A counter that missed the change would stay wrong until it expires
+		c.forgetFollowCounts(ctx, followerID, followeeID)
+		logging.From(ctx).Warn("Failed to update follow counts", "follower_id", followerID, "followee_id", followeeID, "error", err)
+	}
+}
+
+// forgetFollowCounts drops the cached counters of the users with the given ids.
+func (c *UserCache) forgetFollowCounts(ctx context.Context, ids ...int) {
+	if !c.redisValuesUsable() {
+		return
+	}
+	keys := make([]string, 0, 2*len(ids))
+	for _, id := range ids {
+		keys = append(keys, c.followersKey(ctx, id), c.followingKey(ctx, id))
+	}
+	err := c.rdb.Del(context.WithoutCancel(ctx), keys...).Err()
+	if err != nil {
+		c.state.reportError(ctx, err)
+	}
+}
@@ -0,0 +1,81 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+
+	"go-mysql/internal/logging"
+)
+
+// RedisAvailable reports whether Redis is reachable. While it isn't, the user cache is
+// bypassed and requests are served from MySQL alone; ProbeRedis flips it back once
+// Redis answers.
+func (s *State) RedisAvailable() bool {
+	return s.redisUp.Load()
+}
+
+// MarkRedisAvailable records that Redis answered, e.g. at startup.
+func (s *State) MarkRedisAvailable() {
+	s.redisUp.Store(true)
+}
+
+// Usable reports whether the user cache should be used right now. The memory
+// backend doesn't depend on Redis, the others only work while Redis is reachable.
+func (s *State) Usable() bool {
+	return s.Config().Backend == "memory" || s.RedisAvailable()
+}
+
+// reportError counts a failed cache operation and, if Redis couldn't be reached
+// at all (as opposed to rejecting a command), stops using it until ProbeRedis succeeds.
+func (s *State) reportError(ctx context.Context, err error) {
+	cacheErrors.Add(1)
+
+	var replyErr redis.Error
+	if errors.As(err, &replyErr) || err == redis.Nil || errors.Is(err, context.Canceled) {
+		return
+	}
+	if s.redisUp.CompareAndSwap(true, false) {
+		logging.From(ctx).Warn("Redis unavailable, bypassing the cache", "error", err)
+	}
+}
+
+// ProbeRedis pings Redis every interval while it's marked unavailable, until ctx is done.
+// Writes made while Redis was unreachable never reached the cache, so everything cached
+// in Redis is dropped before it's marked available; until then nothing else can cache
+// a user that the drop would miss. If the drop fails, the next tick tries again.
+func (c *UserCache) ProbeRedis(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+		if c.state.RedisAvailable() {
+			continue
+		}
+
+		err := c.rdb.Ping(ctx).Err()
+		if err != nil {
+			continue
+		}
+		if c.state.Config().Enabled {
+			if c.state.Config().Backend != "memory" {
+				err = c.layout.removeAll(ctx)
+			}
+			if err == nil {
+				err = c.dropUsernameIndex(ctx)
+			}
+			if err != nil {
+				logging.From(ctx).Warn("Failed to drop the cache once Redis was reachable again", "error", err)
+				continue
+			}
+		}
+		c.state.MarkRedisAvailable()
+		logging.From(ctx).Info("Redis is reachable again, resuming caching")
+	}
+}
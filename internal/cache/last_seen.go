@@ -0,0 +1,22 @@
+package cache
+
+import (
+	"context"
+	"time"
+)
+
+// ThrottleSeen reports whether the user with the given id wasn't reported seen in the
+// last interval, and then remembers it is, so last_seen_at is written at most once per
+// interval by all instances together. While Redis is unreachable it reports false:
+// last_seen_at falling behind beats a write per request.
+func (c *UserCache) ThrottleSeen(ctx context.Context, id int, interval time.Duration) bool {
+	if !c.state.RedisAvailable() {
+		return false
+	}
+	ok, err := c.rdb.SetNX(context.WithoutCancel(ctx), c.state.Config().KeyPrefix+userKey(ctx, id)+":seen", 1, interval).Result()
+	if err != nil {
+		c.state.reportError(ctx, err)
+		return false
+	}
+	return ok
+}
@@ -0,0 +1,167 @@
+package cache
+
+import (
+	"context"
+	"strconv"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+
+	"go-mysql/internal/models"
+	"go-mysql/internal/tenant"
+)
+
+// hashLayout caches each user as a Redis hash under user:{id}, so single fields can be
+// read and updated without touching the rest of the entry. usersIndexKey is a sorted set
+// of user ids (scored by id) used for listing, and usersLoadedKey marks the index as
+// complete; it expires after ListTTL. All three are kept per tenant.
+//
+// hashLayout bypasses the Cache interface, so it counts its own cache metrics.
+type hashLayout struct {
+	client redis.UniversalClient
+	prefix string
+	state  *State
+}
+
+const (
+	usersIndexKey  = "users:index"
+	usersLoadedKey = "users:loaded"
+)
+
+// key returns the key called name of the tenant ctx belongs to.
+func (l *hashLayout) key(ctx context.Context, name string) string {
+	return l.prefix + tenant.Key(ctx, name)
+}
+
+func (l *hashLayout) getAll(ctx context.Context) (users []models.User, expiresAt time.Time, ok bool) {
+	ttl, err := l.client.PTTL(ctx, l.key(ctx, usersLoadedKey)).Result()
+	if err != nil {
+		l.state.reportError(ctx, err)
+		return nil, expiresAt, false
+	}
+	if ttl < 0 {
+		cacheMisses.Add(1)
+		return nil, expiresAt, false
+	}
+	expiresAt = time.Now().Add(ttl)
+
+	ids, err := l.client.ZRange(ctx, l.key(ctx, usersIndexKey), 0, -1).Result()
+	if err != nil {
+		l.state.reportError(ctx, err)
+		return nil, expiresAt, false
+	}
+
+	pipe := l.client.Pipeline()
+	cmds := make([]*redis.StringStringMapCmd, len(ids))
+	for i, id := range ids {
+		cmds[i] = pipe.HGetAll(ctx, l.key(ctx, "user:"+id))
+	}
+	_, err = pipe.Exec(ctx)
+	if err != nil {
+		l.state.reportError(ctx, err)
+		return nil, expiresAt, false
+	}
+
+	for _, cmd := range cmds {
+		user, err := models.UserFromFieldMap(cmd.Val())
+		if err != nil {
+			// An entry expired or was evicted
+			cacheMisses.Add(1)
+			return nil, expiresAt, false
+		}
+		users = append(users, user)
+	}
+	cacheHits.Add(1)
+	return users, expiresAt, true
+}
+
+func (l *hashLayout) setAll(ctx context.Context, users []models.User) error {
+	pipe := l.client.TxPipeline()
+	pipe.Del(ctx, l.key(ctx, usersIndexKey))
+	for _, user := range users {
+		l.queueSet(ctx, pipe, user)
+	}
+	pipe.Expire(ctx, l.key(ctx, usersIndexKey), l.state.Config().UserTTL)
+	pipe.Set(ctx, l.key(ctx, usersLoadedKey), 1, l.state.Config().ListTTL)
+	_, err := pipe.Exec(ctx)
+	l.state.countResult(ctx, cacheSets, err)
+	return err
+}
+
+func (l *hashLayout) get(ctx context.Context, id int) (models.User, bool) {
+	fields, err := l.client.HGetAll(ctx, l.prefix+userKey(ctx, id)).Result()
+	if err != nil {
+		l.state.reportError(ctx, err)
+		return models.User{}, false
+	}
+	user, err := models.UserFromFieldMap(fields)
+	if err != nil {
+		cacheMisses.Add(1)
+		return models.User{}, false
+	}
+	cacheHits.Add(1)
+	return user, true
+}
+
+func (l *hashLayout) getFields(ctx context.Context, id int, fields []string) (map[string]string, bool) {
+	vals, err := l.client.HMGet(ctx, l.prefix+userKey(ctx, id), fields...).Result()
+	if err != nil {
+		l.state.reportError(ctx, err)
+		return nil, false
+	}
+
+	result := make(map[string]string, len(fields))
+	for i, val := range vals {
+		s, ok := val.(string)
+		if !ok {
+			cacheMisses.Add(1)
+			return nil, false
+		}
+		result[fields[i]] = s
+	}
+	cacheHits.Add(1)
+	return result, true
+}
+
+// set adds new users to the index, so creating a user keeps the listing valid. The
+// index gets an expiry as well, since the ZADD creates it if it had expired.
+func (l *hashLayout) set(ctx context.Context, user models.User, isNew bool) error {
+	pipe := l.client.TxPipeline()
+	l.queueSet(ctx, pipe, user)
+	pipe.Expire(ctx, l.key(ctx, usersIndexKey), l.state.Config().UserTTL)
+	_, err := pipe.Exec(ctx)
+	l.state.countResult(ctx, cacheSets, err)
+	return err
+}
+
+// queueSet queues the commands storing user. The user is added to the index even if it
+// is already there, which keeps set idempotent.
+func (l *hashLayout) queueSet(ctx context.Context, pipe redis.Pipeliner, user models.User) {
+	key := l.prefix + userKey(ctx, user.ID)
+	pipe.Del(ctx, key)
+	pipe.HSet(ctx, key, models.UserFieldMap(user))
+	pipe.Expire(ctx, key, l.state.Config().UserTTL)
+	pipe.ZAdd(ctx, l.key(ctx, usersIndexKey), &redis.Z{Score: float64(user.ID), Member: user.ID})
+}
+
+func (l *hashLayout) setField(ctx context.Context, id int, field, value string) error {
+	err := Script("hset_if_exists").Run(ctx, l.client, []string{l.prefix + userKey(ctx, id)}, field, value).Err()
+	l.state.countResult(ctx, cacheSets, err)
+	return err
+}
+
+func (l *hashLayout) remove(ctx context.Context, id int) error {
+	pipe := l.client.TxPipeline()
+	pipe.Del(ctx, l.prefix+userKey(ctx, id))
+	pipe.ZRem(ctx, l.key(ctx, usersIndexKey), strconv.Itoa(id))
+	_, err := pipe.Exec(ctx)
+	l.state.countResult(ctx, cacheDeletes, err)
+	return err
+}
+
+// removeAll drops the users of every tenant.
+func (l *hashLayout) removeAll(ctx context.Context) error {
+	err := (&redisCache{client: l.client, prefix: l.prefix}).Invalidate(ctx, tenant.KeyPrefix)
+	l.state.countResult(ctx, cacheInvalidations, err)
+	return err
+}
@@ -0,0 +1,145 @@
+package cache
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"go-mysql/internal/models"
+	"go-mysql/internal/tenant"
+)
+
+// jsonLayout caches each user as JSON under user:{id}. usersListingKey holds the ordered
+// ids of every user; listings are served from it as long as every referenced entry is
+// present. Both are kept per tenant.
+type jsonLayout struct {
+	cache Cache
+	state *State
+}
+
+const usersListingKey = "users:listing"
+
+type usersListing struct {
+	IDs       []int     `json:"ids"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+func (l jsonLayout) getAll(ctx context.Context) (users []models.User, expiresAt time.Time, ok bool) {
+	data, err := l.cache.Get(ctx, tenant.Key(ctx, usersListingKey))
+	if err != nil {
+		return nil, expiresAt, false
+	}
+	var listing usersListing
+	err = json.Unmarshal(data, &listing)
+	if err != nil {
+		return nil, expiresAt, false
+	}
+	if len(listing.IDs) == 0 {
+		return nil, listing.ExpiresAt, true
+	}
+
+	keys := make([]string, len(listing.IDs))
+	for i, id := range listing.IDs {
+		keys[i] = userKey(ctx, id)
+	}
+	vals, err := l.cache.GetMulti(ctx, keys...)
+	if err != nil {
+		return nil, expiresAt, false
+	}
+
+	for _, val := range vals {
+		if val == nil {
+			// An entry expired or was evicted
+			return nil, expiresAt, false
+		}
+		var user models.User
+		err := json.Unmarshal(val, &user)
+		if err != nil {
+			return nil, expiresAt, false
+		}
+		users = append(users, user)
+	}
+	return users, listing.ExpiresAt, true
+}
+
+func (l jsonLayout) setAll(ctx context.Context, users []models.User) error {
+	listing := usersListing{
+		IDs:       make([]int, len(users)),
+		ExpiresAt: time.Now().Add(l.state.Config().ListTTL),
+	}
+	for i, user := range users {
+		data, err := json.Marshal(user)
+		if err != nil {
+			return err
+		}
+		err = l.cache.Set(ctx, userKey(ctx, user.ID), data, l.state.Config().UserTTL)
+		if err != nil {
+			return err
+		}
+		listing.IDs[i] = user.ID
+	}
+
+	data, err := json.Marshal(listing)
+	if err != nil {
+		return err
+	}
+	return l.cache.Set(ctx, tenant.Key(ctx, usersListingKey), data, l.state.Config().ListTTL)
+}
+
+func (l jsonLayout) get(ctx context.Context, id int) (models.User, bool) {
+	var user models.User
+	data, err := l.cache.Get(ctx, userKey(ctx, id))
+	if err != nil {
+		return user, false
+	}
+	err = json.Unmarshal(data, &user)
+	return user, err == nil
+}
+
+// getFields has to fetch the whole entry; only hashLayout can read single fields.
+func (l jsonLayout) getFields(ctx context.Context, id int, fields []string) (map[string]string, bool) {
+	user, ok := l.get(ctx, id)
+	if !ok {
+		return nil, false
+	}
+	return models.SelectUserFields(user, fields), true
+}
+
+// set drops the listing when a user is created, since it no longer covers every user.
+// Adding the user to it instead would be a read-modify-write, which users created at
+// the same time could undo for each other on backends without transactions.
+func (l jsonLayout) set(ctx context.Context, user models.User, isNew bool) error {
+	data, err := json.Marshal(user)
+	if err != nil {
+		return err
+	}
+	err = l.cache.Set(ctx, userKey(ctx, user.ID), data, l.state.Config().UserTTL)
+	if err != nil || !isNew {
+		return err
+	}
+	return l.cache.Delete(ctx, tenant.Key(ctx, usersListingKey))
+}
+
+// setField rewrites the whole entry.
+func (l jsonLayout) setField(ctx context.Context, id int, field, value string) error {
+	user, ok := l.get(ctx, id)
+	if !ok {
+		return nil
+	}
+	fields := models.UserFieldMap(user)
+	fields[field] = value
+	user, err := models.UserFromFieldMap(fields)
+	if err != nil {
+		return err
+	}
+	return l.set(ctx, user, false)
+}
+
+func (l jsonLayout) remove(ctx context.Context, id int) error {
+	return l.cache.Delete(ctx, userKey(ctx, id), tenant.Key(ctx, usersListingKey))
+}
+
+// removeAll drops the users of every tenant.
+func (l jsonLayout) removeAll(ctx context.Context) error {
+	return l.cache.Invalidate(ctx, tenant.KeyPrefix)
+}
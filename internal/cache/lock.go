@@ -0,0 +1,46 @@
+package cache
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+)
+
+var errLockNotAcquired = errors.New("lock is held by someone else")
+
+// redisLock is a single-instance Redis lock (SET NX PX with a random token). It expires
+// on its own after its TTL, so a crashed holder can't block everyone else forever.
+type redisLock struct {
+	client redis.UniversalClient
+	key    string
+	token  string
+}
+
+// acquireLock tries once to take the lock and returns errLockNotAcquired if it's held.
+func acquireLock(ctx context.Context, client redis.UniversalClient, key string, ttl time.Duration) (*redisLock, error) {
+	buf := make([]byte, 16)
+	_, err := rand.Read(buf)
+	if err != nil {
+		return nil, err
+	}
+	token := hex.EncodeToString(buf)
+
+	ok, err := client.SetNX(ctx, key, token, ttl).Result()
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, errLockNotAcquired
+	}
+	return &redisLock{client: client, key: key, token: token}, nil
+}
+
+// Release gives the lock up if we still hold it. Comparing the token first means a
+// holder whose lock expired can't release a lock since acquired by someone else.
+func (l *redisLock) Release(ctx context.Context) error {
+	return Script("compare_and_delete").Run(ctx, l.client, []string{l.key}, l.token).Err()
+}
@@ -0,0 +1,192 @@
+package cache
+
+import (
+	"context"
+	"expvar"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+
+	"go-mysql/internal/logging"
+)
+
+// Cache counters are published through expvar under "cache", see /debug/vars, and
+// exposed to Prometheus as they are rather than counted twice.
+var (
+	cacheHits          = new(expvar.Int)
+	cacheMisses        = new(expvar.Int)
+	cacheSets          = new(expvar.Int)
+	cacheDeletes       = new(expvar.Int)
+	cacheInvalidations = new(expvar.Int)
+	cacheErrors        = new(expvar.Int)
+
+	cacheRebuilds       = new(expvar.Int)
+	cacheRebuildErrors  = new(expvar.Int)
+	cacheRebuildTotalMs = new(expvar.Float)
+	cacheRebuildLastMs  = new(expvar.Float)
+	cacheRebuildMaxMs   = new(expvar.Float)
+	cacheRebuildMaxMu   sync.Mutex
+)
+
+func init() {
+	m := expvar.NewMap("cache")
+	m.Set("hits", cacheHits)
+	m.Set("misses", cacheMisses)
+	m.Set("sets", cacheSets)
+	m.Set("deletes", cacheDeletes)
+	m.Set("invalidations", cacheInvalidations)
+	m.Set("errors", cacheErrors)
+	m.Set("rebuilds", cacheRebuilds)
+	m.Set("rebuild_errors", cacheRebuildErrors)
+	m.Set("rebuild_total_ms", cacheRebuildTotalMs)
+	m.Set("rebuild_last_ms", cacheRebuildLastMs)
+	m.Set("rebuild_max_ms", cacheRebuildMaxMs)
+	m.Set("hit_ratio", expvar.Func(func() any { return cacheHitRatio() }))
+
+	prometheus.MustRegister(
+		prometheus.NewCounterFunc(prometheus.CounterOpts{
+			Name: "cache_hits_total",
+			Help: "User cache hits.",
+		}, func() float64 { return float64(cacheHits.Value()) }),
+		prometheus.NewCounterFunc(prometheus.CounterOpts{
+			Name: "cache_misses_total",
+			Help: "User cache misses.",
+		}, func() float64 { return float64(cacheMisses.Value()) }),
+		prometheus.NewCounterFunc(prometheus.CounterOpts{
+			Name: "cache_errors_total",
+			Help: "Failed user cache operations.",
+		}, func() float64 { return float64(cacheErrors.Value()) }),
+		prometheus.NewGaugeFunc(prometheus.GaugeOpts{
+			Name: "cache_hit_ratio",
+			Help: "Share of user cache reads that were hits since the process started.",
+		}, cacheHitRatio),
+		prometheus.NewCounterFunc(prometheus.CounterOpts{
+			Name: "cache_rebuilds_total",
+			Help: "Rebuilds of the user listing from MySQL.",
+		}, func() float64 { return float64(cacheRebuilds.Value()) }),
+	)
+}
+
+// RegisterMetrics exposes whether Redis is reachable, as s has it, to Prometheus.
+func (s *State) RegisterMetrics() {
+	prometheus.MustRegister(prometheus.NewGaugeFunc(prometheus.GaugeOpts{
+		Name: "redis_up",
+		Help: "Whether Redis is currently reachable (1) or bypassed (0).",
+	}, func() float64 {
+		if s.RedisAvailable() {
+			return 1
+		}
+		return 0
+	}))
+}
+
+func cacheHitRatio() float64 {
+	hits, misses := cacheHits.Value(), cacheMisses.Value()
+	if hits+misses == 0 {
+		return 0
+	}
+	return float64(hits) / float64(hits+misses)
+}
+
+// recordRebuild tracks one rebuild of the users listing from MySQL.
+func recordRebuild(d time.Duration, err error) {
+	if err != nil {
+		cacheRebuildErrors.Add(1)
+		return
+	}
+	ms := float64(d) / float64(time.Millisecond)
+	cacheRebuilds.Add(1)
+	cacheRebuildTotalMs.Add(ms)
+	cacheRebuildLastMs.Set(ms)
+
+	cacheRebuildMaxMu.Lock()
+	if ms > cacheRebuildMaxMs.Value() {
+		cacheRebuildMaxMs.Set(ms)
+	}
+	cacheRebuildMaxMu.Unlock()
+}
+
+// instrumentedCache counts the operations passing through to the wrapped Cache.
+type instrumentedCache struct {
+	Cache
+	state *State
+}
+
+func (c instrumentedCache) Get(ctx context.Context, key string) ([]byte, error) {
+	val, err := c.Cache.Get(ctx, key)
+	switch err {
+	case nil:
+		cacheHits.Add(1)
+	case errCacheMiss:
+		cacheMisses.Add(1)
+	default:
+		c.state.reportError(ctx, err)
+	}
+	return val, err
+}
+
+func (c instrumentedCache) GetMulti(ctx context.Context, keys ...string) ([][]byte, error) {
+	vals, err := c.Cache.GetMulti(ctx, keys...)
+	if err != nil {
+		c.state.reportError(ctx, err)
+		return vals, err
+	}
+	for _, val := range vals {
+		if val != nil {
+			cacheHits.Add(1)
+		} else {
+			cacheMisses.Add(1)
+		}
+	}
+	return vals, nil
+}
+
+func (c instrumentedCache) Set(ctx context.Context, key string, value []byte, ttl time.Duration) error {
+	err := c.Cache.Set(ctx, key, value, ttl)
+	c.state.countResult(ctx, cacheSets, err)
+	return err
+}
+
+func (c instrumentedCache) Delete(ctx context.Context, keys ...string) error {
+	err := c.Cache.Delete(ctx, keys...)
+	c.state.countResult(ctx, cacheDeletes, err)
+	return err
+}
+
+func (c instrumentedCache) Invalidate(ctx context.Context, prefix string) error {
+	err := c.Cache.Invalidate(ctx, prefix)
+	c.state.countResult(ctx, cacheInvalidations, err)
+	return err
+}
+
+func (s *State) countResult(ctx context.Context, counter *expvar.Int, err error) {
+	if err != nil {
+		s.reportError(ctx, err)
+		return
+	}
+	counter.Add(1)
+}
+
+// LogStats prints a summary of the cache counters every interval until ctx is done.
+func LogStats(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		avgRebuild := 0.0
+		if n := cacheRebuilds.Value(); n > 0 {
+			avgRebuild = cacheRebuildTotalMs.Value() / float64(n)
+		}
+		logging.From(ctx).Info("Cache stats",
+			"hits", cacheHits.Value(), "misses", cacheMisses.Value(), "hit_ratio", cacheHitRatio(),
+			"sets", cacheSets.Value(), "deletes", cacheDeletes.Value(), "invalidations", cacheInvalidations.Value(),
+			"errors", cacheErrors.Value(), "rebuilds", cacheRebuilds.Value(),
+			"avg_rebuild_ms", avgRebuild, "max_rebuild_ms", cacheRebuildMaxMs.Value())
+	}
+}
@@ -0,0 +1,99 @@
+package cache
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+)
+
+// redisCache stores entries in Redis under prefix+key.
+//
+// Multi-key operations are pipelined as single-key commands rather than sent as
+// MGET/DEL, which cluster mode rejects when the keys live in different slots.
+type redisCache struct {
+	client redis.UniversalClient
+	prefix string
+}
+
+func (c *redisCache) Get(ctx context.Context, key string) ([]byte, error) {
+	val, err := c.client.Get(ctx, c.prefix+key).Bytes()
+	if err == redis.Nil {
+		return nil, errCacheMiss
+	}
+	return val, err
+}
+
+func (c *redisCache) GetMulti(ctx context.Context, keys ...string) ([][]byte, error) {
+	pipe := c.client.Pipeline()
+	cmds := make([]*redis.StringCmd, len(keys))
+	for i, key := range keys {
+		cmds[i] = pipe.Get(ctx, c.prefix+key)
+	}
+	_, err := pipe.Exec(ctx)
+	if err != nil && err != redis.Nil {
+		return nil, err
+	}
+
+	vals := make([][]byte, len(keys))
+	for i, cmd := range cmds {
+		val, err := cmd.Bytes()
+		if err == nil {
+			vals[i] = val
+		}
+	}
+	return vals, nil
+}
+
+func (c *redisCache) Set(ctx context.Context, key string, value []byte, ttl time.Duration) error {
+	return c.client.Set(ctx, c.prefix+key, value, ttl).Err()
+}
+
+func (c *redisCache) Delete(ctx context.Context, keys ...string) error {
+	pipe := c.client.Pipeline()
+	for _, key := range keys {
+		pipe.Del(ctx, c.prefix+key)
+	}
+	_, err := pipe.Exec(ctx)
+	return err
+}
+
+// Invalidate SCANs for matching keys (never KEYS), on every master in cluster mode.
+func (c *redisCache) Invalidate(ctx context.Context, prefix string) error {
+	return deleteMatching(ctx, c.client, escapeGlob(c.prefix+prefix)+"*")
+}
+
+// deleteMatching deletes the keys matching the MATCH pattern match.
+func deleteMatching(ctx context.Context, client redis.UniversalClient, match string) error {
+	if cluster, ok := client.(*redis.ClusterClient); ok {
+		return cluster.ForEachMaster(ctx, func(ctx context.Context, node *redis.Client) error {
+			return scanDelete(ctx, node, match)
+		})
+	}
+	return scanDelete(ctx, client, match)
+}
+
+func scanDelete(ctx context.Context, client redis.UniversalClient, match string) error {
+	iter := client.Scan(ctx, 0, match, 100).Iterator()
+	pipe := client.Pipeline()
+	for iter.Next(ctx) {
+		pipe.Del(ctx, iter.Val())
+		if pipe.Len() >= 100 {
+			_, err := pipe.Exec(ctx)
+			if err != nil {
+				return err
+			}
+		}
+	}
+	if err := iter.Err(); err != nil {
+		return err
+	}
+	_, err := pipe.Exec(ctx)
+	return err
+}
+
+// escapeGlob escapes the characters MATCH patterns treat specially.
+func escapeGlob(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `*`, `\*`, `?`, `\?`, `[`, `\[`, `]`, `\]`).Replace(s)
+}
@@ -0,0 +1,70 @@
+package cache
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+
+	"go-mysql/internal/logging"
+	"go-mysql/internal/models"
+	"go-mysql/internal/tenant"
+)
+
+// reservedUsernamesKey holds the usernames the tenant ctx belongs to reserved, as JSON.
+// Like the user statistics they live in Redis whatever the cache backend.
+func (c *UserCache) reservedUsernamesKey(ctx context.Context) string {
+	return c.state.Config().KeyPrefix + tenant.Key(ctx, "reserved_usernames")
+}
+
+// ReservedUsernames returns the cached reserved usernames of the tenant ctx belongs to.
+func (c *UserCache) ReservedUsernames(ctx context.Context) (names []models.ReservedUsername, ok bool) {
+	if !c.redisValuesUsable() {
+		return nil, false
+	}
+	data, err := c.rdb.Get(ctx, c.reservedUsernamesKey(ctx)).Bytes()
+	if err == redis.Nil {
+		cacheMisses.Add(1)
+		return nil, false
+	}
+	if err != nil {
+		c.state.reportError(ctx, err)
+		return nil, false
+	}
+	if json.Unmarshal(data, &names) != nil {
+		return nil, false
+	}
+	cacheHits.Add(1)
+	return names, true
+}
+
+// SetReservedUsernames caches names as the reserved usernames of the tenant ctx
+// belongs to, for ttl.
+func (c *UserCache) SetReservedUsernames(ctx context.Context, names []models.ReservedUsername, ttl time.Duration) {
+	if !c.redisValuesUsable() {
+		return
+	}
+	data, err := json.Marshal(names)
+	if err != nil {
+		return
+	}
+	err = c.rdb.Set(context.WithoutCancel(ctx), c.reservedUsernamesKey(ctx), data, ttl).Err()
+	if err != nil {
+		c.state.reportError(ctx, err)
+		logging.From(ctx).Warn("Failed to cache reserved usernames", "error", err)
+	}
+}
+
+// ForgetReservedUsernames drops the cached reserved usernames of the tenant ctx belongs
+// to, after they changed.
+func (c *UserCache) ForgetReservedUsernames(ctx context.Context) {
+	if !c.redisValuesUsable() {
+		return
+	}
+	err := c.rdb.Del(context.WithoutCancel(ctx), c.reservedUsernamesKey(ctx)).Err()
+	if err != nil {
+		c.state.reportError(ctx, err)
+		logging.From(ctx).Warn("Failed to forget cached reserved usernames", "error", err)
+	}
+}
@@ -0,0 +1,64 @@
+package cache
+
+import (
+	"context"
+	"embed"
+	"fmt"
+	"path"
+	"strings"
+
+	"github.com/go-redis/redis/v8"
+
+	"go-mysql/pkg/ratelimit"
+)
+
+// Lua scripts live in scripts/*.lua and are embedded in the binary. They are run with
+// EVALSHA; go-redis falls back to EVAL when Redis answers NOSCRIPT (after a restart or
+// failover, for example), which also caches the script again.
+//
+//go:embed scripts/*.lua
+var scriptFiles embed.FS
+
+// luaScripts maps each script's file name, without extension, to the script.
+var luaScripts = loadScripts()
+
+func loadScripts() map[string]*redis.Script {
+	entries, err := scriptFiles.ReadDir("scripts")
+	if err != nil {
+		panic(err)
+	}
+
+	scripts := make(map[string]*redis.Script, len(entries))
+	for _, entry := range entries {
+		src, err := scriptFiles.ReadFile("scripts/" + entry.Name())
+		if err != nil {
+			panic(err)
+		}
+		scripts[strings.TrimSuffix(entry.Name(), path.Ext(entry.Name()))] = redis.NewScript(string(src))
+	}
+	return scripts
+}
+
+// Script returns the embedded script called name and panics if there is none,
+// since that can only be a typo in the code.
+func Script(name string) *redis.Script {
+	script, ok := luaScripts[name]
+	if !ok {
+		panic(fmt.Sprintf("unknown Lua script %q", name))
+	}
+	return script
+}
+
+// PreloadScripts loads every script, along with those of the rate limiters, into Redis'
+// script cache up front, so the first EVALSHA of each doesn't have to fall back to EVAL.
+func PreloadScripts(ctx context.Context, rdb redis.UniversalClient) error {
+	for _, scripts := range []map[string]*redis.Script{luaScripts, ratelimit.Scripts()} {
+		for name, script := range scripts {
+			err := script.Load(ctx, rdb).Err()
+			if err != nil {
+				return fmt.Errorf("loading Lua script %s: %w", name, err)
+			}
+		}
+	}
+	return nil
+}
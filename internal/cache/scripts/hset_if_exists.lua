@@ -0,0 +1,8 @@
+-- Sets field ARGV[1] of hash KEYS[1] to ARGV[2], but only if the hash exists, so
+-- updating a user that isn't cached doesn't leave a partial entry behind.
+-- Returns 1 if the field was set, 0 otherwise.
+if redis.call("EXISTS", KEYS[1]) == 1 then
+	redis.call("HSET", KEYS[1], ARGV[1], ARGV[2])
+	return 1
+end
+return 0
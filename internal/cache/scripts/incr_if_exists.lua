@@ -0,0 +1,12 @@
+-- Increments every key in KEYS if ARGV[1] is "1", decrements them otherwise, but only
+-- the keys that exist, so a counter that isn't cached isn't started from zero.
+for _, key in ipairs(KEYS) do
+	if redis.call("EXISTS", key) == 1 then
+		if ARGV[1] == "1" then
+			redis.call("INCR", key)
+		else
+			redis.call("DECR", key)
+		end
+	end
+end
+return 0
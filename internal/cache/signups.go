@@ -0,0 +1,63 @@
+package cache
+
+import (
+	"context"
+	"strconv"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+
+	"go-mysql/internal/logging"
+	"go-mysql/internal/tenant"
+)
+
+// signupsKey holds how many users of the tenant ctx belongs to signed up on day, as
+// YYYY-MM-DD in UTC. Unlike the cached values these counters are the only record of
+// their counts, so they're kept while Redis is up whether or not the cache is enabled.
+func (c *UserCache) signupsKey(ctx context.Context, day string) string {
+	return c.state.Config().KeyPrefix + tenant.Key(ctx, "signups:"+day)
+}
+
+// CountSignup adds a signup of the tenant ctx belongs to on the day of at, keeping the
+// day's counter for retention.
+func (c *UserCache) CountSignup(ctx context.Context, at time.Time, retention time.Duration) {
+	if !c.state.RedisAvailable() {
+		return
+	}
+	key := c.signupsKey(ctx, at.UTC().Format(time.DateOnly))
+	_, err := c.rdb.TxPipelined(context.WithoutCancel(ctx), func(pipe redis.Pipeliner) error {
+		pipe.Incr(ctx, key)
+		pipe.Expire(ctx, key, retention)
+		return nil
+	})
+	if err != nil {
+		c.state.reportError(ctx, err)
+		logging.From(ctx).Warn("Failed to count signup", "error", err)
+	}
+}
+
+// Signups returns how many users of the tenant ctx belongs to signed up on each of
+// days, as YYYY-MM-DD in UTC, leaving out days without any. ok is false if Redis
+// can't be reached.
+func (c *UserCache) Signups(ctx context.Context, days []string) (counts map[string]int, ok bool) {
+	if !c.state.RedisAvailable() {
+		return nil, false
+	}
+	keys := make([]string, len(days))
+	for i, day := range days {
+		keys[i] = c.signupsKey(ctx, day)
+	}
+	values, err := c.rdb.MGet(ctx, keys...).Result()
+	if err != nil {
+		c.state.reportError(ctx, err)
+		return nil, false
+	}
+	counts = make(map[string]int, len(days))
+	for i, v := range values {
+		s, _ := v.(string)
+		if n, err := strconv.Atoi(s); err == nil {
+			counts[days[i]] = n
+		}
+	}
+	return counts, true
+}
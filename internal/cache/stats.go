@@ -0,0 +1,57 @@
+package cache
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+
+	"go-mysql/internal/logging"
+	"go-mysql/internal/models"
+	"go-mysql/internal/tenant"
+)
+
+// userStatsKey holds the user statistics of the tenant ctx belongs to, as JSON. Like
+// the follow counters they live in Redis whatever the cache backend.
+func (c *UserCache) userStatsKey(ctx context.Context) string {
+	return c.state.Config().KeyPrefix + tenant.Key(ctx, "stats:users")
+}
+
+// UserStats returns the cached user statistics of the tenant ctx belongs to.
+func (c *UserCache) UserStats(ctx context.Context) (stats models.UserStats, ok bool) {
+	if !c.redisValuesUsable() {
+		return models.UserStats{}, false
+	}
+	data, err := c.rdb.Get(ctx, c.userStatsKey(ctx)).Bytes()
+	if err == redis.Nil {
+		cacheMisses.Add(1)
+		return models.UserStats{}, false
+	}
+	if err != nil {
+		c.state.reportError(ctx, err)
+		return models.UserStats{}, false
+	}
+	if json.Unmarshal(data, &stats) != nil {
+		return models.UserStats{}, false
+	}
+	cacheHits.Add(1)
+	return stats, true
+}
+
+// SetUserStats caches stats as the user statistics of the tenant ctx belongs to, for
+// ttl.
+func (c *UserCache) SetUserStats(ctx context.Context, stats models.UserStats, ttl time.Duration) {
+	if !c.redisValuesUsable() {
+		return
+	}
+	data, err := json.Marshal(stats)
+	if err != nil {
+		return
+	}
+	err = c.rdb.Set(context.WithoutCancel(ctx), c.userStatsKey(ctx), data, ttl).Err()
+	if err != nil {
+		c.state.reportError(ctx, err)
+		logging.From(ctx).Warn("Failed to cache user statistics", "error", err)
+	}
+}
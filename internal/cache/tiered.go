@@ -0,0 +1,167 @@
+package cache
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+
+	"go-mysql/internal/logging"
+)
+
+// tieredCache keeps a per-process LRU in front of Redis. Reads are served locally when
+// possible; every instance watches Redis keyspace notifications and drops its local copy
+// of a key as soon as any instance changes, deletes, or expires it. localTTL bounds how
+// stale a local entry can get if notifications are lost, e.g. while reconnecting.
+type tieredCache struct {
+	local    *memoryCache
+	remote   *redisCache
+	localTTL time.Duration
+}
+
+func newTieredCache(remote *redisCache, maxEntries int, localTTL time.Duration) *tieredCache {
+	return &tieredCache{
+		local:    newMemoryCache(maxEntries),
+		remote:   remote,
+		localTTL: localTTL,
+	}
+}
+
+func (c *tieredCache) Get(ctx context.Context, key string) ([]byte, error) {
+	val, err := c.local.Get(ctx, key)
+	if err == nil {
+		return val, nil
+	}
+
+	val, err = c.remote.Get(ctx, key)
+	if err != nil {
+		return nil, err
+	}
+	c.local.Set(ctx, key, val, c.localTTL)
+	return val, nil
+}
+
+func (c *tieredCache) GetMulti(ctx context.Context, keys ...string) ([][]byte, error) {
+	vals, _ := c.local.GetMulti(ctx, keys...)
+
+	var missing []string
+	var missingIdx []int
+	for i, val := range vals {
+		if val == nil {
+			missing = append(missing, keys[i])
+			missingIdx = append(missingIdx, i)
+		}
+	}
+	if len(missing) == 0 {
+		return vals, nil
+	}
+
+	remoteVals, err := c.remote.GetMulti(ctx, missing...)
+	if err != nil {
+		return nil, err
+	}
+	for j, val := range remoteVals {
+		if val != nil {
+			vals[missingIdx[j]] = val
+			c.local.Set(ctx, missing[j], val, c.localTTL)
+		}
+	}
+	return vals, nil
+}
+
+func (c *tieredCache) Set(ctx context.Context, key string, value []byte, ttl time.Duration) error {
+	err := c.remote.Set(ctx, key, value, ttl)
+	if err != nil {
+		c.local.Delete(ctx, key)
+		return err
+	}
+
+	localTTL := c.localTTL
+	if ttl > 0 && ttl < localTTL {
+		localTTL = ttl
+	}
+	return c.local.Set(ctx, key, value, localTTL)
+}
+
+func (c *tieredCache) Delete(ctx context.Context, keys ...string) error {
+	c.local.Delete(ctx, keys...)
+	return c.remote.Delete(ctx, keys...)
+}
+
+func (c *tieredCache) Invalidate(ctx context.Context, prefix string) error {
+	c.local.Invalidate(ctx, prefix)
+	return c.remote.Invalidate(ctx, prefix)
+}
+
+// watch evicts local entries whenever Redis reports a keyspace event for one of the
+// cache's keys. It blocks until ctx is cancelled. In cluster mode notifications are
+// only published by the node owning the key, so every master is subscribed to.
+func (c *tieredCache) watch(ctx context.Context) {
+	enableKeyspaceNotifications(ctx, c.remote.client)
+
+	pattern := "__keyspace@*__:" + escapeGlob(c.remote.prefix) + "*"
+	if cluster, ok := c.remote.client.(*redis.ClusterClient); ok {
+		err := cluster.ForEachMaster(ctx, func(ctx context.Context, node *redis.Client) error {
+			go c.consume(ctx, node.PSubscribe(ctx, pattern))
+			return nil
+		})
+		if err != nil {
+			logging.From(ctx).Error("Failed to subscribe to keyspace notifications", "error", err)
+		}
+		<-ctx.Done()
+		return
+	}
+	c.consume(ctx, c.remote.client.PSubscribe(ctx, pattern))
+}
+
+func (c *tieredCache) consume(ctx context.Context, pubsub *redis.PubSub) {
+	defer pubsub.Close()
+
+	messages := pubsub.Channel()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case msg, ok := <-messages:
+			if !ok {
+				return
+			}
+			// Channels look like __keyspace@0__:<prefix><key>
+			_, key, found := strings.Cut(msg.Channel, "__:")
+			if !found {
+				continue
+			}
+			c.local.Delete(ctx, strings.TrimPrefix(key, c.remote.prefix))
+		}
+	}
+}
+
+// enableKeyspaceNotifications makes sure Redis publishes keyspace events for generic
+// commands (DEL, EXPIRE, ...), string commands, expirations and evictions, keeping any
+// flags that are already enabled. Managed Redis services often forbid CONFIG, in which
+// case the flags have to be set on the server and only a warning is logged.
+func enableKeyspaceNotifications(ctx context.Context, client redis.UniversalClient) {
+	const required = "Kg$xe"
+
+	current := ""
+	vals, err := client.ConfigGet(ctx, "notify-keyspace-events").Result()
+	if err == nil && len(vals) == 2 {
+		current, _ = vals[1].(string)
+	}
+
+	flags := current
+	for _, flag := range required {
+		if !strings.ContainsRune(flags, flag) && !(strings.ContainsRune(flags, 'A') && flag != 'K') {
+			flags += string(flag)
+		}
+	}
+	if flags == current {
+		return
+	}
+
+	err = client.ConfigSet(ctx, "notify-keyspace-events", flags).Err()
+	if err != nil {
+		logging.From(ctx).Warn("Failed to enable keyspace notifications, set notify-keyspace-events on the server", "required", required, "error", err)
+	}
+}
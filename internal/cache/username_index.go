@@ -0,0 +1,150 @@
+package cache
+
+import (
+	"context"
+	"database/sql"
+	"strconv"
+
+	"github.com/go-redis/redis/v8"
+
+	"go-mysql/internal/logging"
+	"go-mysql/internal/models"
+	"go-mysql/internal/tenant"
+)
+
+// usernameIndexKey is a Redis hash mapping each username to its user id, so requests
+// addressing users by username can find the id, and the cache entry to update, without
+// a MySQL round trip. The hash is kept in step with every write and filled lazily;
+// MySQL stays the source of truth, so writes still check the username along with the id.
+// Each tenant has its own index.
+const usernameIndexKey = "users:by_username"
+
+// usernameIndexUsable reports whether the index should be used right now.
+func (c *UserCache) usernameIndexUsable() bool {
+	return c.state.Config().Enabled && c.state.RedisAvailable()
+}
+
+// usernameIndex returns the index of the tenant ctx belongs to.
+func (c *UserCache) usernameIndex(ctx context.Context) string {
+	return c.state.Config().KeyPrefix + tenant.Key(ctx, usernameIndexKey)
+}
+
+// UserID returns the id of the user called username, or sql.ErrNoRows.
+func (c *UserCache) UserID(ctx context.Context, username string) (int, error) {
+	if c.usernameIndexUsable() {
+		id, err := c.rdb.HGet(ctx, c.usernameIndex(ctx), username).Int()
+		switch err {
+		case nil:
+			cacheHits.Add(1)
+			return id, nil
+		case redis.Nil:
+			cacheMisses.Add(1)
+		default:
+			c.state.reportError(ctx, err)
+		}
+	}
+
+	id, err := c.repo.UserIDByUsername(ctx, username)
+	if err != nil {
+		return 0, err
+	}
+	c.IndexUsername(ctx, username, id)
+	return id, nil
+}
+
+// IndexUsername records username's id. Like the cache writes, the index methods finish
+// even if ctx is cancelled.
+func (c *UserCache) IndexUsername(ctx context.Context, username string, id int) {
+	if !c.usernameIndexUsable() {
+		return
+	}
+	err := c.rdb.HSet(context.WithoutCancel(ctx), c.usernameIndex(ctx), username, strconv.Itoa(id)).Err()
+	if err != nil {
+		c.state.reportError(ctx, err)
+		logging.From(ctx).Warn("Failed to update username index", "error", err)
+	}
+}
+
+// indexUsernames records the ids of every user in users.
+func (c *UserCache) indexUsernames(ctx context.Context, users []models.User) {
+	if !c.usernameIndexUsable() || len(users) == 0 {
+		return
+	}
+	fields := make(map[string]any, len(users))
+	for _, user := range users {
+		fields[user.Username] = strconv.Itoa(user.ID)
+	}
+	err := c.rdb.HSet(ctx, c.usernameIndex(ctx), fields).Err()
+	if err != nil {
+		c.state.reportError(ctx, err)
+		logging.From(ctx).Warn("Failed to update username index", "error", err)
+	}
+}
+
+// UnindexUsername forgets username.
+func (c *UserCache) UnindexUsername(ctx context.Context, username string) {
+	if !c.usernameIndexUsable() {
+		return
+	}
+	err := c.rdb.HDel(context.WithoutCancel(ctx), c.usernameIndex(ctx), username).Err()
+	if err != nil {
+		c.state.reportError(ctx, err)
+		logging.From(ctx).Warn("Failed to update username index", "error", err)
+	}
+}
+
+// DropUsernameIndex forgets every username of every tenant, e.g. after bulk changes.
+func (c *UserCache) DropUsernameIndex(ctx context.Context) {
+	if !c.usernameIndexUsable() {
+		return
+	}
+	err := c.dropUsernameIndex(context.WithoutCancel(ctx))
+	if err != nil {
+		c.state.reportError(ctx, err)
+		logging.From(ctx).Warn("Failed to drop username index", "error", err)
+	}
+}
+
+func (c *UserCache) dropUsernameIndex(ctx context.Context) error {
+	match := escapeGlob(c.state.Config().KeyPrefix+tenant.KeyPrefix) + "*:" + escapeGlob(usernameIndexKey)
+	return deleteMatching(ctx, c.rdb, match)
+}
+
+// ExecByUsername calls exec with the id of the user called username; exec must only
+// act on the row matching both the id and username, and report whether it did. If the
+// index turns out to be stale (no row matched) the entry is dropped and the id looked
+// up again once.
+func (c *UserCache) ExecByUsername(ctx context.Context, username string, exec func(id int) (bool, error)) (id int, found bool, err error) {
+	for attempt := 0; attempt < 2; attempt++ {
+		id, err = c.UserID(ctx, username)
+		if err == sql.ErrNoRows {
+			return 0, false, nil
+		}
+		if err != nil {
+			return 0, false, err
+		}
+
+		matched, err := exec(id)
+		if err != nil {
+			return 0, false, err
+		}
+		if matched {
+			return id, true, nil
+		}
+
+		// Either nothing changed or the index is stale; only the database knows which
+		current, err := c.repo.UserIDByUsername(ctx, username)
+		if err == sql.ErrNoRows {
+			c.UnindexUsername(ctx, username)
+			return 0, false, nil
+		}
+		if err != nil {
+			return 0, false, err
+		}
+		if current == id {
+			return id, true, nil
+		}
+		c.UnindexUsername(ctx, username)
+	}
+	return 0, false, nil
+}
@@ -0,0 +1,24 @@
+package cache
+
+import (
+	"context"
+	"time"
+
+	"go-mysql/internal/logging"
+)
+
+// Warm loads every user from MySQL into the cache, which also stores each user
+// under its own key and fills the username index, so the first requests after a deploy
+// don't all miss.
+func (c *UserCache) Warm(ctx context.Context) {
+	if !c.state.Usable() {
+		return
+	}
+	start := time.Now()
+	users, err := c.LoadUsers(ctx)
+	if err != nil {
+		logging.From(ctx).Error("Failed to warm cache", "error", err)
+		return
+	}
+	logging.From(ctx).Info("Warmed cache", "users", len(users), "duration", time.Since(start))
+}
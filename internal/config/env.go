@@ -0,0 +1,186 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Env returns the setting key from the environment or config file (see value),
+// or fallback if it is unset or empty.
+func Env(key, fallback string) string {
+	if value := value(key); value != "" {
+		remember(key, value)
+		return value
+	}
+	remember(key, fallback)
+	return fallback
+}
+
+// EnvInt is like Env for integer values. Invalid values are fatal.
+func EnvInt(key string, fallback int) int {
+	value := value(key)
+	if value == "" {
+		remember(key, fallback)
+		return fallback
+	}
+	n, err := strconv.Atoi(value)
+	if err != nil {
+		fatal("Invalid setting", "name", key, "error", err)
+	}
+	remember(key, n)
+	return n
+}
+
+// EnvDuration is like Env for durations such as "90s" or "720h". Invalid values are fatal.
+func EnvDuration(key string, fallback time.Duration) time.Duration {
+	value := value(key)
+	if value == "" {
+		remember(key, fallback)
+		return fallback
+	}
+	d, err := time.ParseDuration(value)
+	if err != nil {
+		fatal("Invalid setting", "name", key, "error", err)
+	}
+	remember(key, d)
+	return d
+}
+
+// EnvFloat is like Env for floating point values. Invalid values are fatal.
+func EnvFloat(key string, fallback float64) float64 {
+	value := value(key)
+	if value == "" {
+		remember(key, fallback)
+		return fallback
+	}
+	f, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		fatal("Invalid setting", "name", key, "error", err)
+	}
+	remember(key, f)
+	return f
+}
+
+// EnvBool is like Env for boolean values such as "true" or "0". Invalid values are fatal.
+func EnvBool(key string, fallback bool) bool {
+	value := value(key)
+	if value == "" {
+		remember(key, fallback)
+		return fallback
+	}
+	b, err := strconv.ParseBool(value)
+	if err != nil {
+		fatal("Invalid setting", "name", key, "error", err)
+	}
+	remember(key, b)
+	return b
+}
+
+// EnvList is like Env for comma-separated lists such as "a,b,c", whose items it trims,
+// leaving out empty ones.
+func EnvList(key, fallback string) []string {
+	var items []string
+	for _, item := range strings.Split(Env(key, fallback), ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+// validating is set while Reload loads new settings, see fatal.
+var validating atomic.Bool
+
+// Error is an invalid setting found while reloading or validating.
+type Error struct {
+	msg  string
+	args []any
+}
+
+func (e Error) Error() string {
+	var b strings.Builder
+	b.WriteString(e.msg)
+	for i := 0; i+1 < len(e.args); i += 2 {
+		fmt.Fprintf(&b, " %v=%v", e.args[i], e.args[i+1])
+	}
+	return b.String()
+}
+
+// fatal logs msg at error level and exits, like log.Fatal, saying where the settings
+// it names were set when it's not in their environment variables. While Reload or
+// Validate is validating settings it panics with an Error instead, so a bad value is
+// rejected rather than taking the running process down.
+func fatal(msg string, args ...any) {
+	args = append(args, describeSources(msg, args)...)
+	if validating.Load() {
+		panic(Error{msg: msg, args: args})
+	}
+	slog.Error(msg, args...)
+	os.Exit(1)
+}
+
+var reloadMu sync.Mutex
+
+// Reload re-reads the config file and calls load, which should load every setting the
+// caller is about to apply. If any of them is invalid, the file's previous values are
+// restored and the Error returned; the caller then keeps its current settings.
+func Reload(load func()) (err error) {
+	reloadMu.Lock()
+	defer reloadMu.Unlock()
+
+	fileMu.RLock()
+	previous := file
+	fileMu.RUnlock()
+	err = readFile()
+	if err != nil {
+		return err
+	}
+
+	err = check(load)
+	if err != nil {
+		fileMu.Lock()
+		file = previous
+		fileMu.Unlock()
+	}
+	return err
+}
+
+// Validate calls each of loads, Load functions, and returns every invalid setting they
+// find, rather than exiting on the first, so they can all be fixed at once.
+func Validate(loads ...func()) error {
+	reloadMu.Lock()
+	defer reloadMu.Unlock()
+
+	var errs []error
+	for _, load := range loads {
+		err := check(load)
+		if err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// check calls load, returning the Error of the first invalid setting it finds.
+func check(load func()) (err error) {
+	validating.Store(true)
+	defer validating.Store(false)
+	defer func() {
+		if v := recover(); v != nil {
+			cfgErr, ok := v.(Error)
+			if !ok {
+				panic(v)
+			}
+			err = cfgErr
+		}
+	}()
+	load()
+	return nil
+}
@@ -0,0 +1,357 @@
+package config
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/BurntSushi/toml"
+	"gopkg.in/yaml.v3"
+)
+
+// Settings can also come from the file named by CONFIG_FILE. A .yaml, .yml or .toml
+// file has sections for the main settings, see sections, and may give any other
+// setting at its top level under the name of its environment variable; any other file
+// has one KEY=VALUE per line with # comments, using the names of the environment
+// variables. The environment takes precedence, so only settings left out of it can be
+// changed by editing the file and reloading (see Reload). A section's settings can
+// also be overridden by APP_<SECTION>_<SETTING> variables, such as APP_SERVER_ADDR,
+// which take precedence over the variables they stand for.
+var (
+	fileMu   sync.RWMutex
+	file     fileConfig
+	fileOnce sync.Once
+)
+
+// fileConfig is the content of the config file.
+type fileConfig struct {
+	path string
+	// values holds the settings by the names of their environment variables.
+	values map[string]string
+	// names holds the names settings have in a structured file, such as server.addr.
+	names map[string]string
+}
+
+// sections map the settings of each section of a structured config file to their
+// environment variables.
+var sections = map[string]map[string]string{
+	"server": {
+		"addr":                         "HTTP_ADDR",
+		"unix_socket":                  "HTTP_UNIX_SOCKET",
+		"unix_socket_mode":             "HTTP_UNIX_SOCKET_MODE",
+		"h2c":                          "HTTP_H2C",
+		"read_header_timeout":          "HTTP_READ_HEADER_TIMEOUT",
+		"read_timeout":                 "HTTP_READ_TIMEOUT",
+		"write_timeout":                "HTTP_WRITE_TIMEOUT",
+		"idle_timeout":                 "HTTP_IDLE_TIMEOUT",
+		"max_header_bytes":             "HTTP_MAX_HEADER_BYTES",
+		"http2_max_concurrent_streams": "HTTP2_MAX_CONCURRENT_STREAMS",
+		"tls_cert_file":                "TLS_CERT_FILE",
+		"tls_key_file":                 "TLS_KEY_FILE",
+		"admin_addr":                   "ADMIN_ADDR",
+		"shutdown_timeout":             "SHUTDOWN_TIMEOUT",
+	},
+	"database": {
+		"host":                 "MYSQL_HOST",
+		"port":                 "MYSQL_PORT",
+		"user":                 "MYSQL_USER",
+		"password":             "MYSQL_PASSWORD",
+		"name":                 "MYSQL_DATABASE",
+		"auto_migrate":         "AUTO_MIGRATE",
+		"schema_mismatch":      "SCHEMA_MISMATCH",
+		"slow_query_threshold": "SLOW_QUERY_THRESHOLD",
+	},
+	"redis": {
+		"mode":              "REDIS_MODE",
+		"addr":              "REDIS_ADDR",
+		"master_name":       "REDIS_MASTER_NAME",
+		"password":          "REDIS_PASSWORD",
+		"sentinel_password": "REDIS_SENTINEL_PASSWORD",
+		"db":                "REDIS_DB",
+		"demos_enabled":     "REDIS_DEMOS_ENABLED",
+		"probe_interval":    "REDIS_PROBE_INTERVAL",
+	},
+	"cache": {
+		"enabled":            "CACHE_ENABLED",
+		"backend":            "CACHE_BACKEND",
+		"layout":             "CACHE_LAYOUT",
+		"memory_max_entries": "CACHE_MEMORY_MAX_ENTRIES",
+		"local_ttl":          "CACHE_LOCAL_TTL",
+		"key_prefix":         "CACHE_KEY_PREFIX",
+		"list_ttl":           "CACHE_LIST_TTL",
+		"user_ttl":           "CACHE_USER_TTL",
+		"rebuild_lock_ttl":   "CACHE_REBUILD_LOCK_TTL",
+		"rebuild_wait":       "CACHE_REBUILD_WAIT",
+		"early_refresh_beta": "CACHE_EARLY_REFRESH_BETA",
+		"warm_on_start":      "CACHE_WARM_ON_START",
+		"warm_interval":      "CACHE_WARM_INTERVAL",
+		"stats_interval":     "CACHE_STATS_INTERVAL",
+	},
+	"auth": {
+		"admin_token":           "ADMIN_TOKEN",
+		"session_cookie_name":   "SESSION_COOKIE_NAME",
+		"session_cookie_domain": "SESSION_COOKIE_DOMAIN",
+		"session_cookie_secure": "SESSION_COOKIE_SECURE",
+		"session_max_age":       "SESSION_MAX_AGE",
+		"login_idle_timeout":    "LOGIN_IDLE_TIMEOUT",
+	},
+	"logging": {
+		"level":                  "LOG_LEVEL",
+		"format":                 "LOG_FORMAT",
+		"access_log_enabled":     "ACCESS_LOG_ENABLED",
+		"access_log_sample_rate": "ACCESS_LOG_SAMPLE_RATE",
+		"access_log_exclude":     "ACCESS_LOG_EXCLUDE",
+		"slow_request_threshold": "SLOW_REQUEST_THRESHOLD",
+	},
+}
+
+// overrides maps environment variables to the APP_ variables overriding them.
+var overrides = func() map[string]string {
+	m := map[string]string{}
+	for section, settings := range sections {
+		for name, key := range settings {
+			m[key] = "APP_" + strings.ToUpper(section+"_"+name)
+		}
+	}
+	return m
+}()
+
+// settingName matches the names of environment variables.
+var settingName = regexp.MustCompile(`^[A-Z][A-Z0-9_]*$`)
+
+// source is where the value of a setting comes from.
+type source struct {
+	// kind is "env", "file" or "default".
+	kind string
+	// name is the variable or the file setting the value comes from.
+	name string
+}
+
+func (s source) String() string {
+	switch s.kind {
+	case "env":
+		return "environment variable " + s.name
+	case "file":
+		return s.name
+	}
+	return "default"
+}
+
+// value returns the setting called key from the environment or the config file.
+func value(key string) string {
+	fileOnce.Do(func() {
+		err := readFile()
+		if err != nil {
+			fatal("Failed to read config file", "error", err)
+		}
+	})
+	value, _ := lookup(key)
+	return value
+}
+
+// lookup returns the setting called key and where it comes from: its APP_ override,
+// its environment variable or the config file, in that order. The file is only
+// consulted once read, see value.
+func lookup(key string) (string, source) {
+	if name, ok := overrides[key]; ok {
+		if value := os.Getenv(name); value != "" {
+			return value, source{kind: "env", name: name}
+		}
+	}
+	if value := os.Getenv(key); value != "" {
+		return value, source{kind: "env", name: key}
+	}
+	fileMu.RLock()
+	defer fileMu.RUnlock()
+	value := file.values[key]
+	if value == "" {
+		return "", source{kind: "default"}
+	}
+	name := key
+	if n, ok := file.names[key]; ok {
+		name = n
+	}
+	return value, source{kind: "file", name: fmt.Sprintf("%s in %s", name, file.path)}
+}
+
+// UseFile makes path the config file, as if CONFIG_FILE named it, and reads it. The
+// few settings packages read as they're initialized keep the values they were read
+// with; CONFIG_FILE covers those too.
+func UseFile(path string) error {
+	err := os.Setenv("CONFIG_FILE", path)
+	if err != nil {
+		return err
+	}
+	fileOnce.Do(func() {})
+	return readFile()
+}
+
+// readFile (re)loads the file named by CONFIG_FILE, if any.
+func readFile() error {
+	path := os.Getenv("CONFIG_FILE")
+	if path == "" {
+		return nil
+	}
+	var cfg fileConfig
+	var err error
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml", ".toml":
+		cfg, err = parseStructuredFile(path)
+	default:
+		cfg.values, err = parseFile(path)
+	}
+	if err != nil {
+		return err
+	}
+	cfg.path = path
+	fileMu.Lock()
+	file = cfg
+	fileMu.Unlock()
+	return nil
+}
+
+func parseFile(path string) (map[string]string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	values := map[string]string{}
+	scanner := bufio.NewScanner(f)
+	for n := 1; scanner.Scan(); n++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		key, value, ok := strings.Cut(line, "=")
+		if !ok {
+			return nil, fmt.Errorf("%s:%d: expected KEY=VALUE", path, n)
+		}
+		value = strings.TrimSpace(value)
+		if len(value) >= 2 && (value[0] == '"' || value[0] == '\'') && value[len(value)-1] == value[0] {
+			value = value[1 : len(value)-1]
+		}
+		values[strings.TrimSpace(key)] = value
+	}
+	return values, scanner.Err()
+}
+
+// parseStructuredFile reads a YAML or TOML config file, rejecting sections and settings
+// it doesn't know with the ones it does.
+func parseStructuredFile(path string) (fileConfig, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fileConfig{}, err
+	}
+	var doc map[string]any
+	if strings.ToLower(filepath.Ext(path)) == ".toml" {
+		err = toml.Unmarshal(data, &doc)
+	} else {
+		err = yaml.Unmarshal(data, &doc)
+	}
+	if err != nil {
+		return fileConfig{}, fmt.Errorf("%s: %w", path, err)
+	}
+
+	cfg := fileConfig{values: map[string]string{}, names: map[string]string{}}
+	for _, top := range sortedKeys(doc) {
+		if settingName.MatchString(top) {
+			v, err := scalar(doc[top])
+			if err != nil {
+				return fileConfig{}, fmt.Errorf("%s: %s: %w", path, top, err)
+			}
+			cfg.values[top] = v
+			continue
+		}
+		settings, ok := sections[top]
+		if !ok {
+			return fileConfig{}, fmt.Errorf("%s: unknown section %q, expected one of %s, or settings named like their environment variables",
+				path, top, strings.Join(sortedKeys(sections), ", "))
+		}
+		section, ok := doc[top].(map[string]any)
+		if !ok {
+			return fileConfig{}, fmt.Errorf("%s: %s must be a section of settings", path, top)
+		}
+		for _, name := range sortedKeys(section) {
+			key, ok := settings[name]
+			if !ok {
+				return fileConfig{}, fmt.Errorf("%s: unknown setting %s.%s, expected one of %s",
+					path, top, name, strings.Join(sortedKeys(settings), ", "))
+			}
+			v, err := scalar(section[name])
+			if err != nil {
+				return fileConfig{}, fmt.Errorf("%s: %s.%s: %w", path, top, name, err)
+			}
+			cfg.values[key] = v
+			cfg.names[key] = top + "." + name
+		}
+	}
+	return cfg, nil
+}
+
+// scalar returns v, a value of a YAML or TOML file, the way the environment would
+// have it, lists being comma-separated.
+func scalar(v any) (string, error) {
+	switch v := v.(type) {
+	case nil:
+		return "", nil
+	case map[string]any:
+		return "", fmt.Errorf("expected a value, not a section")
+	case []any:
+		items := make([]string, len(v))
+		for i, item := range v {
+			s, err := scalar(item)
+			if err != nil {
+				return "", err
+			}
+			if _, ok := item.([]any); ok {
+				return "", fmt.Errorf("expected a list of values, not of lists")
+			}
+			items[i] = s
+		}
+		return strings.Join(items, ","), nil
+	}
+	return fmt.Sprint(v), nil
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// describeSources returns the name and source of each setting named in msg or args
+// which isn't at its default, for error messages to say where to fix it.
+func describeSources(msg string, args []any) []any {
+	keys := strings.FieldsFunc(msg, func(r rune) bool {
+		return !(r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || r == '_')
+	})
+	for i := 0; i+1 < len(args); i += 2 {
+		if args[i] == "name" {
+			keys = append(keys, fmt.Sprint(args[i+1]))
+		}
+	}
+
+	var described []any
+	var seen []string
+	for _, key := range keys {
+		if !settingName.MatchString(key) || !strings.Contains(key, "_") || slices.Contains(seen, key) {
+			continue
+		}
+		seen = append(seen, key)
+		if _, src := lookup(key); src.kind != "default" && src.name != key {
+			described = append(described, "source", src.String())
+		}
+	}
+	return described
+}
@@ -0,0 +1,65 @@
+package config
+
+import (
+	"fmt"
+	"net/url"
+	"slices"
+	"strings"
+	"sync"
+)
+
+// Setting is a setting as it was last read, for showing the effective configuration.
+type Setting struct {
+	Key   string
+	Value string
+	// Source is where the value came from: "env", "file" or "default".
+	Source string
+}
+
+var (
+	settingsMu sync.Mutex
+	settings   = map[string]Setting{}
+)
+
+// secretKeys are the parts of setting names whose values are secrets.
+var secretKeys = []string{"PASSWORD", "SECRET", "TOKEN", "DSN", "ACCESS_KEY"}
+
+// remember records the value the setting key was read as.
+func remember(key string, v any) {
+	_, src := lookup(key)
+	settingsMu.Lock()
+	settings[key] = Setting{Key: key, Value: fmt.Sprint(v), Source: src.kind}
+	settingsMu.Unlock()
+}
+
+// Settings returns every setting read so far, ordered by name, with secrets masked:
+// passwords, tokens and keys, and the credentials of URLs. Settings are read by the
+// Load functions, so only those of the ones called are there.
+func Settings() []Setting {
+	settingsMu.Lock()
+	list := make([]Setting, 0, len(settings))
+	for _, s := range settings {
+		list = append(list, s)
+	}
+	settingsMu.Unlock()
+
+	slices.SortFunc(list, func(a, b Setting) int { return strings.Compare(a.Key, b.Key) })
+	for i, s := range list {
+		list[i].Value = mask(s.Key, s.Value)
+	}
+	return list
+}
+
+// mask returns value with the secret it may hold masked.
+func mask(key, value string) string {
+	if value == "" {
+		return value
+	}
+	if slices.ContainsFunc(secretKeys, func(part string) bool { return strings.Contains(key, part) }) {
+		return "********"
+	}
+	if u, err := url.Parse(value); err == nil && u.User != nil {
+		return u.Redacted()
+	}
+	return value
+}
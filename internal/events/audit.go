@@ -0,0 +1,22 @@
+package events
+
+import (
+	"context"
+
+	"go-mysql/internal/logging"
+)
+
+// SubscribeAuditLog records every user change in the log, tagged audit=true so the
+// entries can be routed separately. The request's logger carries the request id.
+func SubscribeAuditLog(b *Bus) {
+	Subscribe(b, func(ctx context.Context, e UserCreated) {
+		logging.From(ctx).Info("User created", "audit", true, "user_id", e.User.ID, "username", e.User.Username)
+	})
+	Subscribe(b, func(ctx context.Context, e UserUpdated) {
+		logging.From(ctx).Info("User updated", "audit", true, "user_id", e.User.ID, "username", e.User.Username, "fields", e.Fields,
+			"previous_username", e.PreviousUsername)
+	})
+	Subscribe(b, func(ctx context.Context, e UserDeleted) {
+		logging.From(ctx).Info("User deleted", "audit", true, "user_id", e.ID, "username", e.Username)
+	})
+}
@@ -0,0 +1,124 @@
+// Package events carries typed user, group and tenant events between parts of the service
+// in-process.
+// Handlers publish what happened; the cache, the audit log and notifications
+// subscribe, so none of them has to be called inline.
+//
+// This bus is for reactions within this process. Events other services rely on go
+// through the transactional outbox instead.
+package events
+
+import (
+	"context"
+	"runtime/debug"
+	"sync"
+
+	"go-mysql/internal/logging"
+	"go-mysql/internal/models"
+)
+
+// Event is implemented by every event type.
+type Event interface {
+	EventName() string
+}
+
+// UserCreated is published after a user is inserted.
+type UserCreated struct {
+	User models.User
+}
+
+// UserUpdated is published after a user changes. Fields names the fields that
+// changed, which User holds along with ID and Username; nil means all of User is
+// current. PreviousUsername is set if the username changed, to the one it replaced.
+type UserUpdated struct {
+	User             models.User
+	Fields           []string
+	PreviousUsername string
+}
+
+// UserDeleted is published after a user is deleted.
+type UserDeleted struct {
+	ID       int
+	Username string
+}
+
+// UserMerged is published after a duplicate user is merged into another, along with a
+// UserUpdated for its new status. Related are the other users whose follows changed.
+type UserMerged struct {
+	Merge   models.UserMerge
+	Related []int
+}
+
+// UserFollowed is published after a user starts following another.
+type UserFollowed struct {
+	FollowerID int
+	FolloweeID int
+}
+
+// GroupJoined is published after a user is added to a group, including the owner of a
+// new group.
+type GroupJoined struct {
+	Group  models.Group
+	UserID int
+	Role   string
+}
+
+// TenantCreated is published after a tenant is created.
+type TenantCreated struct {
+	Tenant models.Tenant
+}
+
+// TenantDeleted is published after a tenant is deleted.
+type TenantDeleted struct {
+	ID int
+}
+
+func (UserCreated) EventName() string   { return "user.created" }
+func (UserUpdated) EventName() string   { return "user.updated" }
+func (UserDeleted) EventName() string   { return "user.deleted" }
+func (UserMerged) EventName() string    { return "user.merged" }
+func (UserFollowed) EventName() string  { return "user.followed" }
+func (GroupJoined) EventName() string   { return "group.joined" }
+func (TenantCreated) EventName() string { return "tenant.created" }
+func (TenantDeleted) EventName() string { return "tenant.deleted" }
+
+// Bus delivers each published event to the subscribers of its type.
+type Bus struct {
+	mu   sync.RWMutex
+	subs map[string][]func(ctx context.Context, e Event)
+}
+
+func NewBus() *Bus {
+	return &Bus{subs: make(map[string][]func(ctx context.Context, e Event))}
+}
+
+// Subscribe registers fn to receive every event of type E published on b.
+func Subscribe[E Event](b *Bus, fn func(ctx context.Context, e E)) {
+	var zero E
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.subs[zero.EventName()] = append(b.subs[zero.EventName()], func(ctx context.Context, e Event) {
+		fn(ctx, e.(E))
+	})
+}
+
+// Publish calls the subscribers of e's type in the order they subscribed, before
+// returning, so a response sent afterwards reflects their work (such as an updated
+// cache). Subscribers that need to do slow work should hand it off. A panicking
+// subscriber is logged and doesn't stop the others.
+func (b *Bus) Publish(ctx context.Context, e Event) {
+	b.mu.RLock()
+	subs := b.subs[e.EventName()]
+	b.mu.RUnlock()
+	for _, fn := range subs {
+		deliver(ctx, fn, e)
+	}
+}
+
+func deliver(ctx context.Context, fn func(ctx context.Context, e Event), e Event) {
+	defer func() {
+		if v := recover(); v != nil {
+			logging.From(ctx).Error("Event subscriber panicked", "event", e.EventName(), "panic", v, "stack", string(debug.Stack()))
+		}
+	}()
+	fn(ctx, e)
+}
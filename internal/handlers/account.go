@@ -0,0 +1,67 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+
+	"go-mysql/internal/auth"
+	"go-mysql/internal/models"
+	"go-mysql/pkg/middleware"
+)
+
+// RegisterAccountRoutes adds the endpoints changing a user's account to g, which must
+// authenticate the caller, see server.RequireRole. Users may only change their own
+// account, and administrators anyone's but their email.
+func (a *App) RegisterAccountRoutes(g *middleware.Group) {
+	g.HandleFunc("/user/update", a.updateUser)
+	g.HandleFunc("/user/delete", a.deleteUser)
+	g.HandleFunc("POST /users/{id}/avatar", selfOrAdmin(a.uploadAvatar))
+	g.HandleFunc("DELETE /users/{id}/avatar", selfOrAdmin(a.deleteAvatar))
+	g.HandleFunc("PUT /users/{id}/preferences", selfOrAdmin(a.setPreferences))
+	g.HandleFunc("POST /users/{id}/deactivate", selfOrAdmin(a.deactivateUser))
+	g.HandleFunc("POST /users/{id}/reactivate", selfOrAdmin(a.reactivateUser))
+	g.HandleFunc("PATCH /users/{username}/profile", selfOrAdminByUsername(a.updateProfile))
+	g.HandleFunc("PUT /users/{id}/username", selfOrAdmin(a.renameUser))
+}
+
+// selfOrAdmin only lets requests for user {id} through to next if they're made by that
+// user or an administrator, answering 403 otherwise.
+func selfOrAdmin(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id, err := strconv.Atoi(r.PathValue("id"))
+		if err != nil {
+			http.Error(w, "Invalid user id", http.StatusBadRequest)
+			return
+		}
+		if !isSelfOrAdmin(r, id) {
+			http.Error(w, "Forbidden", http.StatusForbidden)
+			return
+		}
+		next(w, r)
+	}
+}
+
+// selfOrAdminByUsername is selfOrAdmin for routes naming the user by {username}.
+func selfOrAdminByUsername(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if !isUsernameOrAdmin(r, r.PathValue("username")) {
+			http.Error(w, "Forbidden", http.StatusForbidden)
+			return
+		}
+		next(w, r)
+	}
+}
+
+// isUsernameOrAdmin reports whether r is made by the user called username or by an
+// administrator.
+func isUsernameOrAdmin(r *http.Request, username string) bool {
+	p, ok := auth.Principal(r.Context())
+	return ok && (p.Username == username || p.Role == models.RoleAdmin)
+}
+
+// isSelfOrAdmin reports whether r is made by the user with the given id or by an
+// administrator.
+func isSelfOrAdmin(r *http.Request, id int) bool {
+	p, ok := auth.Principal(r.Context())
+	return ok && (p.UserID == id || p.Role == models.RoleAdmin)
+}
@@ -0,0 +1,173 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"go-mysql/internal/auth"
+	"go-mysql/internal/config"
+	"go-mysql/internal/logging"
+	"go-mysql/internal/tenant"
+)
+
+// Daily active users are tracked in one bitmap per day, with bit n set when the user with
+// id n made an authenticated request that day. A million users fit in 125KB per day, and
+// counts and cohort overlaps are single BITCOUNT/BITOP calls. Keys share the {active} hash
+// tag so BITOP works in cluster mode. Like the cache, they're kept per tenant.
+var activeUsersRetention = config.EnvDuration("ACTIVE_USERS_RETENTION", 90*24*time.Hour)
+
+func (a *App) activeUsersKey(ctx context.Context, day time.Time) string {
+	return a.statsKey(ctx, "{active}:"+day.UTC().Format("2006-01-02"))
+}
+
+// statsKey returns the key called name for the tenant ctx belongs to, under the cache's
+// key prefix.
+func (a *App) statsKey(ctx context.Context, name string) string {
+	return a.cache.Config().KeyPrefix + tenant.Key(ctx, name)
+}
+
+// ActiveUserMiddleware marks the user a request is authenticated as active today. It
+// must come after server.RequireRole, which puts that user in the request's context.
+func (a *App) ActiveUserMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if p, ok := auth.Principal(r.Context()); ok && a.cache.RedisAvailable() {
+			id := p.UserID
+			key := a.activeUsersKey(r.Context(), time.Now())
+			err := a.pool.Submit(r.Context(), "record active user", func(ctx context.Context) error {
+				// The id is a bit offset, so it's bounded to keep the bitmap small
+				ok, err := a.isUserID(ctx, id)
+				if !ok || err != nil {
+					return err
+				}
+				pipe := a.rdb.Pipeline()
+				pipe.SetBit(ctx, key, int64(id), 1)
+				pipe.Expire(ctx, key, activeUsersRetention)
+				_, err = pipe.Exec(ctx)
+				return err
+			})
+			if err != nil {
+				logging.From(r.Context()).Warn("Failed to record active user", "user_id", id, "error", err)
+			}
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// isUserID reports whether id is positive and at most the highest user id, which is
+// remembered until an id above it shows up.
+func (a *App) isUserID(ctx context.Context, id int) (bool, error) {
+	if id <= 0 {
+		return false, nil
+	}
+	if int64(id) <= a.maxUserID.Load() {
+		return true, nil
+	}
+	max, err := a.stats.MaxUserID(ctx)
+	if err != nil {
+		return false, err
+	}
+	a.maxUserID.Store(int64(max))
+	return id <= max, nil
+}
+
+// parseDay reads a YYYY-MM-DD query parameter, defaulting to today (UTC).
+func parseDay(r *http.Request, param string) (time.Time, error) {
+	s := r.URL.Query().Get(param)
+	if s == "" {
+		return time.Now().UTC(), nil
+	}
+	return time.Parse("2006-01-02", s)
+}
+
+// getDailyActiveUsers counts users active on ?date= (default today).
+func (a *App) getDailyActiveUsers(w http.ResponseWriter, r *http.Request) {
+	day, err := parseDay(r, "date")
+	if err != nil {
+		http.Error(w, "Invalid date parameter, expected YYYY-MM-DD", http.StatusBadRequest)
+		return
+	}
+
+	count, err := a.rdb.BitCount(r.Context(), a.activeUsersKey(r.Context(), day), nil).Result()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]any{"date": day.Format("2006-01-02"), "active_users": count})
+}
+
+// getMonthlyActiveUsers counts users active on any of the 30 days ending on ?date=.
+func (a *App) getMonthlyActiveUsers(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	day, err := parseDay(r, "date")
+	if err != nil {
+		http.Error(w, "Invalid date parameter, expected YYYY-MM-DD", http.StatusBadRequest)
+		return
+	}
+
+	keys := make([]string, 30)
+	for i := range keys {
+		keys[i] = a.activeUsersKey(ctx, day.AddDate(0, 0, -i))
+	}
+	dest := a.statsKey(ctx, "{active}:mau:"+day.Format("2006-01-02"))
+
+	pipe := a.rdb.Pipeline()
+	pipe.BitOpOr(ctx, dest, keys...)
+	pipe.Expire(ctx, dest, time.Minute)
+	count := pipe.BitCount(ctx, dest, nil)
+	_, err = pipe.Exec(ctx)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]any{
+		"from":         day.AddDate(0, 0, -29).Format("2006-01-02"),
+		"to":           day.Format("2006-01-02"),
+		"active_users": count.Val(),
+	})
+}
+
+// getRetention reports how many of the users active on ?from= were active again on ?to=.
+func (a *App) getRetention(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	from, err := parseDay(r, "from")
+	if err != nil || r.URL.Query().Get("from") == "" {
+		http.Error(w, "Missing or invalid from parameter, expected YYYY-MM-DD", http.StatusBadRequest)
+		return
+	}
+	to, err := parseDay(r, "to")
+	if err != nil {
+		http.Error(w, "Invalid to parameter, expected YYYY-MM-DD", http.StatusBadRequest)
+		return
+	}
+	dest := a.statsKey(ctx, "{active}:retention:"+from.Format("2006-01-02")+":"+to.Format("2006-01-02"))
+
+	pipe := a.rdb.Pipeline()
+	cohort := pipe.BitCount(ctx, a.activeUsersKey(ctx, from), nil)
+	pipe.BitOpAnd(ctx, dest, a.activeUsersKey(ctx, from), a.activeUsersKey(ctx, to))
+	pipe.Expire(ctx, dest, time.Minute)
+	retained := pipe.BitCount(ctx, dest, nil)
+	_, err = pipe.Exec(ctx)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	rate := 0.0
+	if cohort.Val() > 0 {
+		rate = float64(retained.Val()) / float64(cohort.Val())
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]any{
+		"from":     from.Format("2006-01-02"),
+		"to":       to.Format("2006-01-02"),
+		"cohort":   cohort.Val(),
+		"retained": retained.Val(),
+		"rate":     rate,
+	})
+}
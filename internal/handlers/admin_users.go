@@ -0,0 +1,232 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+
+	"go-mysql/internal/auth"
+	"go-mysql/internal/repository"
+	"go-mysql/internal/service"
+	"go-mysql/pkg/middleware"
+)
+
+// RegisterAdminUserRoutes adds the endpoints administrators manage the users of their
+// tenant with to g, which must only let administrators through, see
+// server.RequireRole.
+func (a *App) RegisterAdminUserRoutes(g *middleware.Group) {
+	g.HandleFunc("GET /admin/users", a.adminListUsers)
+	g.HandleFunc("GET /admin/users/duplicates", a.adminFindDuplicates)
+	g.HandleFunc("POST /admin/users/import", a.adminImportUsers)
+	g.HandleFunc("PUT /admin/users/{id}/role", a.adminSetRole)
+	g.HandleFunc("POST /admin/users/{id}/password-reset", a.adminForcePasswordReset)
+	g.HandleFunc("PUT /admin/users/{id}/ban", a.adminBan)
+	g.HandleFunc("DELETE /admin/users/{id}/ban", a.adminUnban)
+	g.HandleFunc("POST /admin/users/{id}/merge", a.adminMerge)
+	g.HandleFunc("GET /admin/reserved-usernames", a.adminListReservedUsernames)
+	g.HandleFunc("POST /admin/reserved-usernames", a.adminReserveUsername)
+	g.HandleFunc("DELETE /admin/reserved-usernames/{name}", a.adminReleaseUsername)
+	g.HandleFunc("PUT /users/{id}/tags/{tag}", a.tagUser)
+	g.HandleFunc("DELETE /users/{id}/tags/{tag}", a.untagUser)
+}
+
+// adminListUsers returns a page of users with their roles, flags and last login and
+// seen times, filtered by ?role=, ?status=, ?banned= (true or false) and
+// ?inactive_days=, which only selects users not seen for that many days, in a
+// pageEnvelope.
+func (a *App) adminListUsers(w http.ResponseWriter, r *http.Request) {
+	page, perPage, err := parsePage(r, 50, 500)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	filter, err := parseAdminUserFilter(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	filter.Offset, filter.Limit = (page-1)*perPage, perPage
+
+	users, total, err := a.admin.List(r.Context(), filter)
+	if err != nil {
+		writeUserError(w, err)
+		return
+	}
+	writePage(w, users, pageMeta{Total: total, Page: page, PerPage: perPage}, newPageLinks(r, page, perPage, total))
+}
+
+// adminFindDuplicates returns up to ?limit= (50 by default, at most 500) groups of
+// users whose usernames or emails are at least ?similarity= alike (0.85 by default, 1
+// for the same normalized values), for review, see service.Admin.Duplicates.
+func (a *App) adminFindDuplicates(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	similarity := service.DefaultDuplicateSimilarity
+	if s := query.Get("similarity"); s != "" {
+		var err error
+		similarity, err = strconv.ParseFloat(s, 64)
+		if err != nil {
+			http.Error(w, "Invalid similarity parameter", http.StatusBadRequest)
+			return
+		}
+	}
+	limit := 50
+	if s := query.Get("limit"); s != "" {
+		var err error
+		limit, err = strconv.Atoi(s)
+		if err != nil || limit < 1 || limit > 500 {
+			http.Error(w, "Invalid limit parameter", http.StatusBadRequest)
+			return
+		}
+	}
+
+	groups, err := a.admin.Duplicates(r.Context(), similarity, limit)
+	if err != nil {
+		writeUserError(w, err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(groups)
+}
+
+// parseAdminUserFilter parses the ?role=, ?status=, ?banned= and ?inactive_days=
+// filters of the users administrators list.
+func parseAdminUserFilter(r *http.Request) (repository.AdminUserFilter, error) {
+	query := r.URL.Query()
+	filter := repository.AdminUserFilter{Role: query.Get("role"), Status: query.Get("status")}
+	if s := query.Get("banned"); s != "" {
+		banned, err := strconv.ParseBool(s)
+		if err != nil {
+			return repository.AdminUserFilter{}, errors.New("Invalid banned parameter")
+		}
+		filter.Banned = &banned
+	}
+	if s := query.Get("inactive_days"); s != "" {
+		days, err := strconv.Atoi(s)
+		if err != nil || days < 1 {
+			return repository.AdminUserFilter{}, errors.New("Invalid inactive_days parameter")
+		}
+		filter.InactiveSince = time.Now().AddDate(0, 0, -days)
+	}
+	return filter, nil
+}
+
+// adminSetRole gives user {id} the role in a body like {"role": "admin"}.
+func (a *App) adminSetRole(w http.ResponseWriter, r *http.Request) {
+	id, ok := adminTarget(w, r)
+	if !ok {
+		return
+	}
+	var body struct {
+		Role string `json:"role"`
+	}
+	err := json.NewDecoder(r.Body).Decode(&body)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	err = a.admin.SetRole(r.Context(), actor(r), id, body.Role)
+	if err != nil {
+		writeUserError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// adminForcePasswordReset makes user {id} choose a new password, emailing them the link.
+func (a *App) adminForcePasswordReset(w http.ResponseWriter, r *http.Request) {
+	id, ok := adminTarget(w, r)
+	if !ok {
+		return
+	}
+	err := a.admin.ForcePasswordReset(r.Context(), actor(r), id)
+	if err != nil {
+		writeUserError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusAccepted)
+}
+
+// adminBan bans user {id}, with an optional body like {"reason": "spam"}.
+func (a *App) adminBan(w http.ResponseWriter, r *http.Request) {
+	id, ok := adminTarget(w, r)
+	if !ok {
+		return
+	}
+	var body struct {
+		Reason string `json:"reason"`
+	}
+	err := json.NewDecoder(r.Body).Decode(&body)
+	if err != nil && !errors.Is(err, io.EOF) {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	err = a.admin.Ban(r.Context(), actor(r), id, body.Reason)
+	if err != nil {
+		writeUserError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// adminUnban lifts the ban of user {id}.
+func (a *App) adminUnban(w http.ResponseWriter, r *http.Request) {
+	id, ok := adminTarget(w, r)
+	if !ok {
+		return
+	}
+	err := a.admin.Unban(r.Context(), actor(r), id)
+	if err != nil {
+		writeUserError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// adminMerge merges user {id} into the user given in the body, like {"into": 42}, and
+// answers with what that user took over, see service.Admin.Merge.
+func (a *App) adminMerge(w http.ResponseWriter, r *http.Request) {
+	id, ok := adminTarget(w, r)
+	if !ok {
+		return
+	}
+	var body struct {
+		Into int `json:"into"`
+	}
+	err := json.NewDecoder(r.Body).Decode(&body)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if body.Into <= 0 {
+		http.Error(w, "Missing into user id", http.StatusBadRequest)
+		return
+	}
+
+	merge, err := a.admin.Merge(r.Context(), actor(r), id, body.Into)
+	if err != nil {
+		writeUserError(w, err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(merge)
+}
+
+// adminTarget parses the id of the user an admin endpoint acts on, answering 400 if
+// it's invalid.
+func adminTarget(w http.ResponseWriter, r *http.Request) (int, bool) {
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		http.Error(w, "Invalid user id", http.StatusBadRequest)
+		return 0, false
+	}
+	return id, true
+}
+
+// actor returns the id of the administrator making r.
+func actor(r *http.Request) int {
+	p, _ := auth.Principal(r.Context())
+	return p.UserID
+}
@@ -0,0 +1,80 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"strconv"
+
+	"go-mysql/internal/service"
+)
+
+// avatarFormMemory is how much of an upload is held in memory; the rest of the form
+// goes to a temporary file.
+const avatarFormMemory = 1 << 20
+
+// uploadAvatar sets the avatar of user {id} to the image in the "avatar" field of a
+// multipart form, and answers with the user, whose avatar URLs are signed.
+func (a *App) uploadAvatar(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		http.Error(w, "Invalid user id", http.StatusBadRequest)
+		return
+	}
+
+	// Room for the rest of the form on top of the image
+	maxSize := a.users.MaxAvatarSize()
+	r.Body = http.MaxBytesReader(w, r.Body, int64(maxSize)+avatarFormMemory)
+	err = r.ParseMultipartForm(avatarFormMemory)
+	var tooLarge *http.MaxBytesError
+	if errors.As(err, &tooLarge) {
+		writeUserError(w, service.ErrAvatarTooLarge)
+		return
+	}
+	if err != nil {
+		http.Error(w, "Invalid multipart form", http.StatusBadRequest)
+		return
+	}
+	defer r.MultipartForm.RemoveAll()
+
+	file, header, err := r.FormFile("avatar")
+	if err != nil {
+		http.Error(w, "Missing avatar file", http.StatusBadRequest)
+		return
+	}
+	defer file.Close()
+	if header.Size > int64(maxSize) {
+		writeUserError(w, service.ErrAvatarTooLarge)
+		return
+	}
+	data, err := io.ReadAll(file)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	user, err := a.users.SetAvatar(r.Context(), id, data)
+	if err != nil {
+		writeUserError(w, err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(user)
+}
+
+// deleteAvatar removes the uploaded avatar of user {id}.
+func (a *App) deleteAvatar(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		http.Error(w, "Invalid user id", http.StatusBadRequest)
+		return
+	}
+
+	err = a.users.DeleteAvatar(r.Context(), id)
+	if err != nil {
+		writeUserError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,43 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"go-mysql/internal/config"
+	"go-mysql/pkg/middleware"
+)
+
+// RegisterDevRoutes adds the endpoints of dev mode to g, with cfg bounding what they
+// may do. They must only be registered in dev mode, see config.Dev.
+func (a *App) RegisterDevRoutes(g *middleware.Group, cfg config.Dev) {
+	g.HandleFunc("POST /dev/users/generate", func(w http.ResponseWriter, r *http.Request) {
+		a.generateUsers(w, r, cfg)
+	})
+}
+
+// generateUsers creates ?count= users with made-up profiles, 100 by default, to demo
+// pagination and search with, and answers with how many it created. A failure answers
+// with an error, leaving the batches created until then.
+func (a *App) generateUsers(w http.ResponseWriter, r *http.Request, cfg config.Dev) {
+	count := 100
+	if s := r.URL.Query().Get("count"); s != "" {
+		var err error
+		count, err = strconv.Atoi(s)
+		if err != nil || count < 1 || count > cfg.MaxGenerate {
+			http.Error(w, fmt.Sprintf("Invalid count parameter, must be between 1 and %d", cfg.MaxGenerate), http.StatusBadRequest)
+			return
+		}
+	}
+
+	created, err := a.users.GenerateUsers(r.Context(), count, cfg.GenerateBatch)
+	if err != nil {
+		writeUserError(w, err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(map[string]int{"created": created})
+}
@@ -0,0 +1,128 @@
+package handlers
+
+import (
+	"encoding/csv"
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+
+	"go-mysql/internal/auth"
+	"go-mysql/internal/logging"
+	"go-mysql/internal/models"
+	"go-mysql/internal/service"
+	"go-mysql/pkg/middleware"
+	"go-mysql/pkg/xlsx"
+)
+
+// RegisterExportRoutes adds the download of users to g, which must authenticate the
+// caller, see server.RequireRole.
+func (a *App) RegisterExportRoutes(g *middleware.Group) {
+	g.HandleFunc("GET /users/export", a.downloadUsers)
+}
+
+// exportFormats are the content types of the formats of GET /users/export.
+var exportFormats = map[string]string{
+	"csv":  "text/csv; charset=utf-8",
+	"xlsx": xlsx.ContentType,
+}
+
+// rowWriter writes the rows of a download; csv.Writer and xlsx.Writer are ones.
+type rowWriter interface {
+	Write(record []string) error
+}
+
+// downloadUsers streams the users the caller may export as a file to download, with a
+// header row, in ?format= csv (the default) or xlsx. ?fields= picks and orders the
+// columns among those the caller's role may export, see service.ExportFields, all of
+// them by default. Administrators may filter the users like GET /admin/users.
+func (a *App) downloadUsers(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	p, _ := auth.Principal(ctx)
+	query := r.URL.Query()
+	format := query.Get("format")
+	if format == "" {
+		format = "csv"
+	}
+	contentType, ok := exportFormats[format]
+	if !ok {
+		http.Error(w, "Invalid format parameter, expected csv or xlsx", http.StatusBadRequest)
+		return
+	}
+	allowed := service.ExportFields(p.Role)
+	fields := allowed
+	if s := query.Get("fields"); s != "" {
+		fields = strings.Split(s, ",")
+		for _, field := range fields {
+			if !slices.Contains(models.AdminUserFieldNames, field) {
+				http.Error(w, fmt.Sprintf("Unknown field %q", field), http.StatusBadRequest)
+				return
+			}
+			if !slices.Contains(allowed, field) {
+				http.Error(w, fmt.Sprintf("Field %q can only be exported by administrators", field), http.StatusForbidden)
+				return
+			}
+		}
+	}
+	filter, err := parseAdminUserFilter(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	// Large exports are meant to outlive the server's write timeout
+	http.NewResponseController(w).SetWriteDeadline(time.Time{})
+
+	// Nothing is written before the first user, so errors up to then still get a status
+	var out rowWriter
+	finish := func() error { return nil }
+	start := func() error {
+		w.Header().Set("Content-Type", contentType)
+		w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="users-%s.%s"`, time.Now().UTC().Format(time.DateOnly), format))
+		if format == "xlsx" {
+			xw, err := xlsx.NewWriter(w, "Users")
+			if err != nil {
+				return err
+			}
+			out, finish = xw, xw.Close
+		} else {
+			cw := csv.NewWriter(w)
+			out = cw
+			finish = func() error {
+				cw.Flush()
+				return cw.Error()
+			}
+		}
+		return out.Write(fields)
+	}
+	row := make([]string, len(fields))
+	err = a.admin.Export(ctx, p, filter, func(user models.AdminUser) error {
+		if out == nil {
+			err := start()
+			if err != nil {
+				return err
+			}
+		}
+		values := models.AdminUserFieldMap(user)
+		for i, field := range fields {
+			row[i] = values[field]
+		}
+		return out.Write(row)
+	})
+	if err == nil && out == nil {
+		err = start()
+	}
+	if err != nil && out == nil {
+		writeUserError(w, err)
+		return
+	}
+	if err == nil {
+		err = finish()
+	}
+	if err != nil {
+		// The status is sent; breaking the connection keeps a cut-off file from passing for a whole one
+		logging.From(ctx).Error("Failed to export users", "error", err)
+		panic(http.ErrAbortHandler)
+	}
+}
@@ -0,0 +1,50 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"go-mysql/internal/activity"
+)
+
+// getFeed returns the latest activities of user {id}, newest first, up to ?limit=
+// (20 by default, at most 100). When there are more, the X-Next-Cursor header holds
+// the ?cursor= of the next page.
+func (a *App) getFeed(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		http.Error(w, "Invalid user id", http.StatusBadRequest)
+		return
+	}
+	limit := 20
+	if s := r.URL.Query().Get("limit"); s != "" {
+		limit, err = strconv.Atoi(s)
+		if err != nil || limit < 1 || limit > 100 {
+			http.Error(w, "Invalid limit", http.StatusBadRequest)
+			return
+		}
+	}
+
+	// Users without activities have no feed, so this tells them from missing users
+	_, err = a.users.Get(r.Context(), id)
+	if err != nil {
+		writeUserError(w, err)
+		return
+	}
+	activities, next, err := a.feed.List(r.Context(), id, r.URL.Query().Get("cursor"), limit)
+	if errors.Is(err, activity.ErrInvalidCursor) {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if next != "" {
+		w.Header().Set("X-Next-Cursor", next)
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(activities)
+}
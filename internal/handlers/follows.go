@@ -0,0 +1,113 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"go-mysql/internal/models"
+	"go-mysql/internal/service"
+	"go-mysql/pkg/middleware"
+)
+
+// RegisterFollowRoutes adds the endpoints to follow and unfollow users to g, which
+// must authenticate the follower, see server.RequireRole. The followers of a user, the
+// users it follows and their counts are served by GET /users/{id}/followers, /following
+// and /follow-counts, see getUserResource.
+func (a *App) RegisterFollowRoutes(g *middleware.Group) {
+	g.HandleFunc("POST /users/{id}/follow", a.followUser)
+	g.HandleFunc("DELETE /users/{id}/follow", a.unfollowUser)
+}
+
+// followUser makes the caller follow user {id}.
+func (a *App) followUser(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		http.Error(w, "Invalid user id", http.StatusBadRequest)
+		return
+	}
+
+	err = a.follows.Follow(r.Context(), actor(r), id)
+	if err != nil {
+		writeUserError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// unfollowUser makes the caller stop following user {id}.
+func (a *App) unfollowUser(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		http.Error(w, "Invalid user id", http.StatusBadRequest)
+		return
+	}
+
+	err = a.follows.Unfollow(r.Context(), actor(r), id)
+	if errors.Is(err, service.ErrNotFollowing) {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		writeUserError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// getFollowers returns a page of the users following user {id} in a pageEnvelope,
+// latest first, see parsePage.
+func (a *App) getFollowers(w http.ResponseWriter, r *http.Request) {
+	a.listFollows(w, r, a.follows.Followers, func(c models.FollowCounts) int { return c.Followers })
+}
+
+// getFollowing returns a page of the users user {id} follows in a pageEnvelope, latest
+// first, see parsePage.
+func (a *App) getFollowing(w http.ResponseWriter, r *http.Request) {
+	a.listFollows(w, r, a.follows.Following, func(c models.FollowCounts) int { return c.Following })
+}
+
+// getFollowCounts returns how many users follow user {id} and how many it follows.
+func (a *App) getFollowCounts(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		http.Error(w, "Invalid user id", http.StatusBadRequest)
+		return
+	}
+
+	counts, err := a.follows.Counts(r.Context(), id)
+	if err != nil {
+		writeUserError(w, err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(counts)
+}
+
+type listFollowsFunc = func(ctx context.Context, id, offset, limit int) ([]models.Follow, models.FollowCounts, error)
+
+func (a *App) listFollows(w http.ResponseWriter, r *http.Request, list listFollowsFunc, total func(models.FollowCounts) int) {
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		http.Error(w, "Invalid user id", http.StatusBadRequest)
+		return
+	}
+	page, perPage, err := parsePage(r, 50, 500)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	follows, counts, err := list(r.Context(), id, (page-1)*perPage, perPage)
+	if err != nil {
+		writeUserError(w, err)
+		return
+	}
+	if follows == nil {
+		follows = []models.Follow{}
+	}
+	n := total(counts)
+	writePage(w, follows, pageMeta{Total: n, Page: page, PerPage: perPage}, newPageLinks(r, page, perPage, n))
+}
@@ -0,0 +1,109 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"github.com/go-redis/redis/v8"
+)
+
+type geoResult struct {
+	Member    string  `json:"member"`
+	Distance  float64 `json:"distance"`
+	Unit      string  `json:"unit"`
+	Longitude float64 `json:"longitude"`
+	Latitude  float64 `json:"latitude"`
+}
+
+// geoAdd stores a member's position with GEOADD.
+func (a *App) geoAdd(w http.ResponseWriter, r *http.Request) {
+	key := r.URL.Query().Get("key")
+	member := r.URL.Query().Get("member")
+	lon, lat, err := parseLonLat(r)
+	if key == "" || member == "" || err != nil {
+		http.Error(w, "Missing key or member parameters, or invalid lon/lat", http.StatusBadRequest)
+		return
+	}
+
+	err = a.rdb.GeoAdd(r.Context(), demoKey(key), &redis.GeoLocation{Name: member, Longitude: lon, Latitude: lat}).Err()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+}
+
+// geoSearch finds members within ?radius= ?unit= (m, km, ft or mi; default km) of
+// ?lon=&lat= with GEOSEARCH, nearest first, returning at most ?count= results.
+func (a *App) geoSearch(w http.ResponseWriter, r *http.Request) {
+	key := r.URL.Query().Get("key")
+	lon, lat, err := parseLonLat(r)
+	if key == "" || err != nil {
+		http.Error(w, "Missing key parameter, or invalid lon/lat", http.StatusBadRequest)
+		return
+	}
+	radius, err := strconv.ParseFloat(r.URL.Query().Get("radius"), 64)
+	if err != nil || radius <= 0 {
+		http.Error(w, "Invalid radius parameter", http.StatusBadRequest)
+		return
+	}
+	unit := r.URL.Query().Get("unit")
+	switch unit {
+	case "":
+		unit = "km"
+	case "m", "km", "ft", "mi":
+	default:
+		http.Error(w, "Invalid unit parameter", http.StatusBadRequest)
+		return
+	}
+	count := 0
+	if s := r.URL.Query().Get("count"); s != "" {
+		count, err = strconv.Atoi(s)
+		if err != nil || count < 0 {
+			http.Error(w, "Invalid count parameter", http.StatusBadRequest)
+			return
+		}
+	}
+
+	locations, err := a.rdb.GeoSearchLocation(r.Context(), demoKey(key), &redis.GeoSearchLocationQuery{
+		GeoSearchQuery: redis.GeoSearchQuery{
+			Longitude:  lon,
+			Latitude:   lat,
+			Radius:     radius,
+			RadiusUnit: unit,
+			Sort:       "ASC",
+			Count:      count,
+		},
+		WithCoord: true,
+		WithDist:  true,
+	}).Result()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	results := make([]geoResult, len(locations))
+	for i, loc := range locations {
+		results[i] = geoResult{
+			Member:    loc.Name,
+			Distance:  loc.Dist,
+			Unit:      unit,
+			Longitude: loc.Longitude,
+			Latitude:  loc.Latitude,
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(results)
+}
+
+func parseLonLat(r *http.Request) (lon, lat float64, err error) {
+	lon, err = strconv.ParseFloat(r.URL.Query().Get("lon"), 64)
+	if err != nil {
+		return 0, 0, err
+	}
+	lat, err = strconv.ParseFloat(r.URL.Query().Get("lat"), 64)
+	return lon, lat, err
+}
@@ -0,0 +1,205 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"strconv"
+
+	"go-mysql/internal/auth"
+	"go-mysql/internal/models"
+	"go-mysql/internal/service"
+	"go-mysql/pkg/middleware"
+)
+
+// RegisterGroupRoutes adds the group and membership endpoints to g, which must
+// authenticate the caller, see server.RequireRole. The groups of a user are served by
+// GET /users/{id}/groups, see getUserResource.
+func (a *App) RegisterGroupRoutes(g *middleware.Group) {
+	g.HandleFunc("POST /groups", a.createGroup)
+	g.HandleFunc("GET /groups", a.listGroups)
+	g.HandleFunc("GET /groups/{id}", a.getGroup)
+	g.HandleFunc("DELETE /groups/{id}", a.deleteGroup)
+	g.HandleFunc("GET /groups/{id}/members", a.listGroupMembers)
+	g.HandleFunc("PUT /groups/{id}/members/{user_id}", a.setGroupMember)
+	g.HandleFunc("DELETE /groups/{id}/members/{user_id}", a.removeGroupMember)
+}
+
+func writeGroupError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, service.ErrInvalidGroup):
+		http.Error(w, err.Error(), http.StatusBadRequest)
+	case errors.Is(err, service.ErrNotGroupOwner):
+		http.Error(w, err.Error(), http.StatusForbidden)
+	case errors.Is(err, service.ErrGroupNotFound), errors.Is(err, service.ErrNotMember):
+		http.Error(w, err.Error(), http.StatusNotFound)
+	case errors.Is(err, service.ErrGroupNameTaken), errors.Is(err, service.ErrLastOwner):
+		http.Error(w, err.Error(), http.StatusConflict)
+	default:
+		writeUserError(w, err)
+	}
+}
+
+// createGroup creates a group from a body like {"name": "Support", "description":
+// "..."} and answers with it. The caller owns it and is its first member.
+func (a *App) createGroup(w http.ResponseWriter, r *http.Request) {
+	p, _ := auth.Principal(r.Context())
+	var body models.Group
+	err := json.NewDecoder(r.Body).Decode(&body)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	group, err := a.groups.Create(r.Context(), body, p.UserID)
+	if err != nil {
+		writeGroupError(w, err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(group)
+}
+
+// listGroups returns a page of the groups ordered by name in a pageEnvelope, see
+// parsePage.
+func (a *App) listGroups(w http.ResponseWriter, r *http.Request) {
+	page, perPage, err := parsePage(r, 50, 500)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	groups, total, err := a.groups.List(r.Context(), (page-1)*perPage, perPage)
+	if err != nil {
+		writeGroupError(w, err)
+		return
+	}
+	writePage(w, groups, pageMeta{Total: total, Page: page, PerPage: perPage}, newPageLinks(r, page, perPage, total))
+}
+
+func (a *App) getGroup(w http.ResponseWriter, r *http.Request) {
+	id, ok := groupID(w, r)
+	if !ok {
+		return
+	}
+
+	group, err := a.groups.Get(r.Context(), id)
+	if err != nil {
+		writeGroupError(w, err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(group)
+}
+
+func (a *App) deleteGroup(w http.ResponseWriter, r *http.Request) {
+	id, ok := groupID(w, r)
+	if !ok {
+		return
+	}
+
+	p, _ := auth.Principal(r.Context())
+	err := a.groups.Delete(r.Context(), p, id)
+	if err != nil {
+		writeGroupError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// listGroupMembers returns the members of group {id}, owners first.
+func (a *App) listGroupMembers(w http.ResponseWriter, r *http.Request) {
+	id, ok := groupID(w, r)
+	if !ok {
+		return
+	}
+
+	members, err := a.groups.Members(r.Context(), id)
+	if err != nil {
+		writeGroupError(w, err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(members)
+}
+
+// setGroupMember adds user {user_id} to group {id}, or changes its role, taken from an
+// optional body like {"role": "owner"}; members by default.
+func (a *App) setGroupMember(w http.ResponseWriter, r *http.Request) {
+	id, ok := groupID(w, r)
+	if !ok {
+		return
+	}
+	userID, err := strconv.Atoi(r.PathValue("user_id"))
+	if err != nil {
+		http.Error(w, "Invalid user id", http.StatusBadRequest)
+		return
+	}
+	var body struct {
+		Role string `json:"role"`
+	}
+	err = json.NewDecoder(r.Body).Decode(&body)
+	if err != nil && !errors.Is(err, io.EOF) {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	p, _ := auth.Principal(r.Context())
+	err = a.groups.SetMember(r.Context(), p, id, userID, body.Role)
+	if err != nil {
+		writeGroupError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// removeGroupMember removes user {user_id} from group {id}. Members may remove
+// themselves; only owners may remove others.
+func (a *App) removeGroupMember(w http.ResponseWriter, r *http.Request) {
+	id, ok := groupID(w, r)
+	if !ok {
+		return
+	}
+	userID, err := strconv.Atoi(r.PathValue("user_id"))
+	if err != nil {
+		http.Error(w, "Invalid user id", http.StatusBadRequest)
+		return
+	}
+
+	p, _ := auth.Principal(r.Context())
+	err = a.groups.RemoveMember(r.Context(), p, id, userID)
+	if err != nil {
+		writeGroupError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// getUserGroups returns the groups user {id} belongs to, with its role in each.
+func (a *App) getUserGroups(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		http.Error(w, "Invalid user id", http.StatusBadRequest)
+		return
+	}
+
+	groups, err := a.groups.UserGroups(r.Context(), id)
+	if err != nil {
+		writeGroupError(w, err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(groups)
+}
+
+// groupID parses the {id} of a group, answering 400 if it's invalid.
+func groupID(w http.ResponseWriter, r *http.Request) (int, bool) {
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		http.Error(w, "Invalid group id", http.StatusBadRequest)
+		return 0, false
+	}
+	return id, true
+}
@@ -0,0 +1,168 @@
+// Package handlers implements the HTTP API: the user endpoints, backed by the user
+// service, and the Redis data structure demos.
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"sync/atomic"
+
+	"github.com/go-redis/redis/v8"
+
+	"go-mysql/internal/cache"
+	"go-mysql/pkg/middleware"
+	"go-mysql/pkg/sessions"
+	"go-mysql/pkg/workerpool"
+)
+
+// App serves the API. Its handlers are methods, so everything they depend on is
+// passed to New rather than reached through package state.
+type App struct {
+	users UserService
+	// registration signs up users through POST /users/register.
+	registration Registration
+	// admin backs the /admin/users endpoints.
+	admin Admin
+	// groups backs the /groups endpoints.
+	groups Groups
+	// follows backs the follow endpoints under /users/{id}.
+	follows Follows
+	// feed holds the activity feeds of GET /users/{id}/feed.
+	feed Feed
+	// stats backs GET /stats/users.
+	stats Stats
+	// reserved backs the /admin/reserved-usernames endpoints.
+	reserved ReservedUsernames
+	rdb      redis.UniversalClient
+	// cache says whether Redis is reachable.
+	cache *cache.State
+	// mux is what the routes are registered on.
+	mux      *http.ServeMux
+	sessions *sessions.Store
+	// pool runs the bookkeeping the middlewares do after responding.
+	pool *workerpool.Pool
+	// webhooks manages the webhooks user events are delivered to.
+	webhooks Webhooks
+	// maxUserID is the highest user id seen, bounding the active user bitmaps.
+	maxUserID atomic.Int64
+
+	// subscribersCtx is cancelled by CloseSubscribers, ending every open /redis/subscribe stream.
+	subscribersCtx  context.Context
+	stopSubscribers context.CancelFunc
+	// subscribers tracks open streams so shutdown can wait for them to unsubscribe.
+	subscribers sync.WaitGroup
+}
+
+// Deps are what the API is built on.
+type Deps struct {
+	Users        UserService
+	Registration Registration
+	Admin        Admin
+	Groups       Groups
+	Follows      Follows
+	Feed         Feed
+	Stats        Stats
+	Reserved     ReservedUsernames
+	// Redis runs the Redis demos and holds sessions and visitor counts.
+	Redis redis.UniversalClient
+	// Cache says whether Redis is reachable, and the key prefix sessions are kept under.
+	Cache *cache.State
+	// Pool runs background work.
+	Pool     *workerpool.Pool
+	Webhooks Webhooks
+	// Mux is what the routes are registered on, to look up the route of requests in.
+	Mux *http.ServeMux
+}
+
+// New returns the API on top of deps.
+func New(deps Deps) *App {
+	a := &App{
+		users:        deps.Users,
+		registration: deps.Registration,
+		admin:        deps.Admin,
+		groups:       deps.Groups,
+		follows:      deps.Follows,
+		feed:         deps.Feed,
+		stats:        deps.Stats,
+		reserved:     deps.Reserved,
+		rdb:          deps.Redis,
+		cache:        deps.Cache,
+		mux:          deps.Mux,
+		sessions:     newSessionStore(deps.Redis, deps.Cache),
+		pool:         deps.Pool,
+		webhooks:     deps.Webhooks,
+	}
+	a.subscribersCtx, a.stopSubscribers = context.WithCancel(context.Background())
+	return a
+}
+
+// RegisterUserRoutes adds the user endpoints to g.
+func (a *App) RegisterUserRoutes(g *middleware.Group) {
+	g.HandleFunc("/users", a.getUsers)
+	g.HandleFunc("/user", a.createUser)
+	g.HandleFunc("POST /users/register", a.registerUser)
+	g.HandleFunc("POST /users/email-change/confirm", a.confirmEmailChange)
+	g.HandleFunc("GET /users/search", a.searchUsers)
+	g.HandleFunc("GET /users/autocomplete", a.autocompleteUsers)
+	g.HandleFunc("GET /users/{id}", a.getUser)
+	g.HandleFunc("PUT /users/{username}", a.upsertUser)
+	g.HandleFunc("GET /tags", a.getTags)
+	g.HandleFunc("GET /users/{id}/{resource}", a.getUserResource)
+	g.HandleFunc("GET /users/by-username/{username}", a.getUserByUsername)
+	g.HandleFunc("GET /stats/users", a.getUserStats)
+	g.HandleFunc("GET /stats/signups", a.getSignupStats)
+}
+
+// demoKeyPrefix goes in front of every key the Redis demos are given, so they can't
+// read or overwrite the application's own keys.
+const demoKeyPrefix = "demo:"
+
+func demoKey(key string) string {
+	return demoKeyPrefix + key
+}
+
+// RegisterRedisDemos mounts the Redis data structure demos on g under /redis/.
+func (a *App) RegisterRedisDemos(g *middleware.Group) {
+	g.HandleFunc("/redis/set-string", a.setString)
+	g.HandleFunc("/redis/get-string", a.getString)
+	g.HandleFunc("/redis/set-list", a.setList)
+	g.HandleFunc("/redis/get-list", a.getList)
+	g.HandleFunc("/redis/set-hash", a.setHash)
+	g.HandleFunc("/redis/get-hash", a.getHash)
+	g.HandleFunc("/redis/pipeline-set", a.pipelineSet)
+	g.HandleFunc("/redis/pipeline-get", a.pipelineGet)
+	g.HandleFunc("/redis/tx-pipeline-set", a.txPipelineSet)
+	g.HandleFunc("/redis/publish", a.publish)
+	g.HandleFunc("/redis/subscribe", a.subscribe)
+	g.HandleFunc("/redis/stream-add", a.streamAdd)
+	g.HandleFunc("/redis/stream-pending", a.streamPending)
+	g.HandleFunc("/redis/stream-dead-letters", a.streamDeadLetters)
+	g.HandleFunc("/redis/leaderboard", a.getLeaderboard)
+	g.HandleFunc("/redis/leaderboard-add", a.leaderboardAdd)
+	g.HandleFunc("/redis/leaderboard-incr", a.leaderboardIncr)
+	g.HandleFunc("/redis/leaderboard-rank", a.leaderboardRank)
+	g.HandleFunc("/redis/geo-add", a.geoAdd)
+	g.HandleFunc("/redis/geo-search", a.geoSearch)
+	g.HandleFunc("/redis/expire", a.expireKey)
+	g.HandleFunc("/redis/persist", a.persistKey)
+	g.HandleFunc("/redis/ttl", a.keyTTL)
+	g.HandleFunc("/redis/cas-incr", a.casIncr)
+	g.HandleFunc("/redis/compare-and-delete", a.compareAndDelete)
+}
+
+// RegisterStatsRoutes adds the visitor and active user statistics to g.
+func (a *App) RegisterStatsRoutes(g *middleware.Group) {
+	g.HandleFunc("/stats/visitors", a.getVisitorStats)
+	g.HandleFunc("/stats/dau", a.getDailyActiveUsers)
+	g.HandleFunc("/stats/mau", a.getMonthlyActiveUsers)
+	g.HandleFunc("/stats/retention", a.getRetention)
+}
+
+// RegisterSessionRoutes adds the session endpoints to g.
+func (a *App) RegisterSessionRoutes(g *middleware.Group) {
+	g.HandleFunc("/session", a.getSession)
+	g.HandleFunc("/session-set", a.setSessionValue)
+	g.HandleFunc("/session-flash", a.addSessionFlash)
+	g.HandleFunc("/session-destroy", a.destroySession)
+}
@@ -0,0 +1,109 @@
+package handlers
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+
+	"go-mysql/internal/i18n"
+	"go-mysql/internal/models"
+)
+
+// maxImportSize is the largest import file accepted, in bytes.
+const maxImportSize = 10 << 20
+
+// importColumns are the columns an import file may have. Its first line names the
+// ones it has, in any order; username and email are required.
+var importColumns = append([]string{"username", "email"}, models.ProfileFieldNames...)
+
+// adminImportUsers creates the users of the CSV file in the body and answers with a
+// report of what it did, line by line. With ?dry_run=true every line is checked the
+// same way, but no user is created. A file that isn't valid CSV, or lacks the
+// required columns, is rejected as a whole. The report's messages are in the client's
+// language, see i18n.T.
+func (a *App) adminImportUsers(w http.ResponseWriter, r *http.Request) {
+	dryRun := false
+	if s := r.URL.Query().Get("dry_run"); s != "" {
+		var err error
+		dryRun, err = strconv.ParseBool(s)
+		if err != nil {
+			http.Error(w, "Invalid dry_run parameter", http.StatusBadRequest)
+			return
+		}
+	}
+
+	reader := csv.NewReader(http.MaxBytesReader(w, r.Body, maxImportSize))
+	header, err := reader.Read()
+	if err == io.EOF {
+		http.Error(w, "Empty import file", http.StatusBadRequest)
+		return
+	}
+	if err != nil {
+		writeImportReadError(w, err)
+		return
+	}
+	// Spreadsheet applications start the CSV files they save with a byte order mark
+	header[0] = strings.TrimPrefix(header[0], "\ufeff")
+	for i, column := range header {
+		if !slices.Contains(importColumns, column) {
+			http.Error(w, fmt.Sprintf("Unknown column %q", column), http.StatusBadRequest)
+			return
+		}
+		if slices.Contains(header[:i], column) {
+			http.Error(w, fmt.Sprintf("Repeated column %q", column), http.StatusBadRequest)
+			return
+		}
+	}
+	for _, column := range []string{"username", "email"} {
+		if !slices.Contains(header, column) {
+			http.Error(w, fmt.Sprintf("Missing column %q", column), http.StatusBadRequest)
+			return
+		}
+	}
+
+	var rows []models.ImportRow
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			writeImportReadError(w, err)
+			return
+		}
+		fields := map[string]string{"id": "0"}
+		for i, column := range header {
+			fields[column] = record[i]
+		}
+		user, _ := models.UserFromFieldMap(fields)
+		line, _ := reader.FieldPos(0)
+		rows = append(rows, models.ImportRow{Line: line, User: user})
+	}
+
+	report, err := a.users.Import(r.Context(), rows, dryRun)
+	if err != nil {
+		writeUserError(w, err)
+		return
+	}
+	for i := range report.Errors {
+		report.Errors[i].Message = i18n.T(r.Context(), report.Errors[i].Message)
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(report)
+}
+
+// writeImportReadError answers for an import file that couldn't be read.
+func writeImportReadError(w http.ResponseWriter, err error) {
+	var tooLarge *http.MaxBytesError
+	if errors.As(err, &tooLarge) {
+		http.Error(w, fmt.Sprintf("Import file is larger than %d bytes", tooLarge.Limit), http.StatusRequestEntityTooLarge)
+		return
+	}
+	http.Error(w, "Invalid CSV: "+err.Error(), http.StatusBadRequest)
+}
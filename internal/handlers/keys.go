@@ -0,0 +1,85 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/go-redis/redis/v8"
+)
+
+type keyInfo struct {
+	Key  string `json:"key"`
+	Type string `json:"type"`
+}
+
+type keysPage struct {
+	// Cursor continues the scan; "0" means it is complete.
+	Cursor string    `json:"cursor"`
+	Keys   []keyInfo `json:"keys"`
+}
+
+// ListDemoKeys browses the Redis demos' keys matching ?pattern= (default *) one SCAN
+// step at a time, never with KEYS, so it's safe against large production datasets.
+// Keys are matched and reported without the demo: prefix. Pass the returned cursor
+// back as ?cursor= to continue. ?count= is a hint for how many keys to examine per call;
+// SCAN may return fewer keys, or none, while the cursor is still non-zero. It belongs
+// on the admin listener, since the key names reveal what the demos were given.
+func (a *App) ListDemoKeys(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	if _, ok := a.rdb.(*redis.ClusterClient); ok {
+		http.Error(w, "Key browsing isn't supported in cluster mode", http.StatusNotImplemented)
+		return
+	}
+
+	pattern := r.URL.Query().Get("pattern")
+	if pattern == "" {
+		pattern = "*"
+	}
+	cursor := uint64(0)
+	if s := r.URL.Query().Get("cursor"); s != "" {
+		var err error
+		cursor, err = strconv.ParseUint(s, 10, 64)
+		if err != nil {
+			http.Error(w, "Invalid cursor parameter", http.StatusBadRequest)
+			return
+		}
+	}
+	count := int64(100)
+	if s := r.URL.Query().Get("count"); s != "" {
+		var err error
+		count, err = strconv.ParseInt(s, 10, 64)
+		if err != nil || count < 1 || count > 1000 {
+			http.Error(w, "Invalid count parameter, must be between 1 and 1000", http.StatusBadRequest)
+			return
+		}
+	}
+
+	keys, next, err := a.rdb.Scan(ctx, cursor, demoKey(pattern), count).Result()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	page := keysPage{Cursor: strconv.FormatUint(next, 10), Keys: make([]keyInfo, len(keys))}
+	if len(keys) > 0 {
+		pipe := a.rdb.Pipeline()
+		types := make([]*redis.StatusCmd, len(keys))
+		for i, key := range keys {
+			types[i] = pipe.Type(ctx, key)
+		}
+		_, err = pipe.Exec(ctx)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		for i, key := range keys {
+			// A key deleted since the SCAN reports type "none"
+			page.Keys[i] = keyInfo{Key: strings.TrimPrefix(key, demoKeyPrefix), Type: types[i].Val()}
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(page)
+}
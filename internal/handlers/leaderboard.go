@@ -0,0 +1,127 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"github.com/go-redis/redis/v8"
+)
+
+type leaderboardEntry struct {
+	Rank   int64   `json:"rank"`
+	Member string  `json:"member"`
+	Score  float64 `json:"score"`
+}
+
+func leaderboardKey(board string) string {
+	return demoKey("leaderboard:" + board)
+}
+
+// leaderboardAdd sets a member's score with ZADD.
+func (a *App) leaderboardAdd(w http.ResponseWriter, r *http.Request) {
+	board := r.URL.Query().Get("board")
+	member := r.URL.Query().Get("member")
+	score, err := strconv.ParseFloat(r.URL.Query().Get("score"), 64)
+	if board == "" || member == "" || err != nil {
+		http.Error(w, "Missing board or member parameters, or invalid score", http.StatusBadRequest)
+		return
+	}
+
+	err = a.rdb.ZAdd(r.Context(), leaderboardKey(board), &redis.Z{Score: score, Member: member}).Err()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	a.writeLeaderboardRank(w, r, board, member)
+}
+
+// leaderboardIncr adds ?by= (default 1) to a member's score with ZINCRBY.
+func (a *App) leaderboardIncr(w http.ResponseWriter, r *http.Request) {
+	board := r.URL.Query().Get("board")
+	member := r.URL.Query().Get("member")
+	by := 1.0
+	if s := r.URL.Query().Get("by"); s != "" {
+		var err error
+		by, err = strconv.ParseFloat(s, 64)
+		if err != nil {
+			http.Error(w, "Invalid by parameter", http.StatusBadRequest)
+			return
+		}
+	}
+	if board == "" || member == "" {
+		http.Error(w, "Missing board or member parameters", http.StatusBadRequest)
+		return
+	}
+
+	err := a.rdb.ZIncrBy(r.Context(), leaderboardKey(board), by, member).Err()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	a.writeLeaderboardRank(w, r, board, member)
+}
+
+// leaderboardRank returns a member's rank (1 is the top) and score.
+func (a *App) leaderboardRank(w http.ResponseWriter, r *http.Request) {
+	board := r.URL.Query().Get("board")
+	member := r.URL.Query().Get("member")
+	if board == "" || member == "" {
+		http.Error(w, "Missing board or member parameters", http.StatusBadRequest)
+		return
+	}
+
+	a.writeLeaderboardRank(w, r, board, member)
+}
+
+func (a *App) writeLeaderboardRank(w http.ResponseWriter, r *http.Request, board, member string) {
+	ctx := r.Context()
+	pipe := a.rdb.Pipeline()
+	rank := pipe.ZRevRank(ctx, leaderboardKey(board), member)
+	score := pipe.ZScore(ctx, leaderboardKey(board), member)
+	_, err := pipe.Exec(ctx)
+	if err == redis.Nil {
+		http.Error(w, "Member not found", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(leaderboardEntry{Rank: rank.Val() + 1, Member: member, Score: score.Val()})
+}
+
+// getLeaderboard returns one page of the board, highest score first.
+// Pages are numbered from 1 and hold ?per_page= entries (default 10, max 100).
+func (a *App) getLeaderboard(w http.ResponseWriter, r *http.Request) {
+	board := r.URL.Query().Get("board")
+	if board == "" {
+		http.Error(w, "Missing board parameter", http.StatusBadRequest)
+		return
+	}
+	page, perPage, err := parsePage(r, 10, 100)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	start := int64((page - 1) * perPage)
+	members, err := a.rdb.ZRevRangeWithScores(r.Context(), leaderboardKey(board), start, start+int64(perPage)-1).Result()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	entries := make([]leaderboardEntry, len(members))
+	for i, z := range members {
+		member, _ := z.Member.(string)
+		entries[i] = leaderboardEntry{Rank: start + int64(i) + 1, Member: member, Score: z.Score}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(entries)
+}
@@ -0,0 +1,81 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/url"
+	"strconv"
+)
+
+// parsePage reads the ?page= (from 1) and ?per_page= query parameters,
+// applying defaultPerPage and capping per_page at maxPerPage.
+func parsePage(r *http.Request, defaultPerPage, maxPerPage int) (page, perPage int, err error) {
+	page, perPage = 1, defaultPerPage
+	if s := r.URL.Query().Get("page"); s != "" {
+		page, err = strconv.Atoi(s)
+		if err != nil || page < 1 {
+			return 0, 0, errors.New("Invalid page parameter")
+		}
+	}
+	if s := r.URL.Query().Get("per_page"); s != "" {
+		perPage, err = strconv.Atoi(s)
+		if err != nil || perPage < 1 {
+			return 0, 0, errors.New("Invalid per_page parameter")
+		}
+	}
+	if perPage > maxPerPage {
+		perPage = maxPerPage
+	}
+	return page, perPage, nil
+}
+
+// pageEnvelope wraps a page of a listing with what UIs need to render a pager.
+type pageEnvelope struct {
+	Data  any       `json:"data"`
+	Meta  pageMeta  `json:"meta"`
+	Links pageLinks `json:"links"`
+}
+
+type pageMeta struct {
+	Total   int `json:"total"`
+	Page    int `json:"page"`
+	PerPage int `json:"per_page"`
+}
+
+// pageLinks are the URLs of the pages around a page, relative to the host, and left
+// out where there's none.
+type pageLinks struct {
+	Next string `json:"next,omitempty"`
+	Prev string `json:"prev,omitempty"`
+}
+
+// newPageLinks returns the links to the pages before and after page of the listing r
+// asked for, which holds total items. The page before one past the end is the last.
+func newPageLinks(r *http.Request, page, perPage, total int) pageLinks {
+	var links pageLinks
+	if page*perPage < total {
+		links.Next = pageURL(r, func(query url.Values) { query.Set("page", strconv.Itoa(page+1)) })
+	}
+	if page > 1 {
+		last := max((total+perPage-1)/perPage, 1)
+		links.Prev = pageURL(r, func(query url.Values) { query.Set("page", strconv.Itoa(min(page-1, last))) })
+	}
+	return links
+}
+
+// pageURL returns the URL r asked for with its query changed by edit.
+func pageURL(r *http.Request, edit func(url.Values)) string {
+	query := r.URL.Query()
+	edit(query)
+	u := url.URL{Path: r.URL.Path, RawQuery: query.Encode()}
+	return u.String()
+}
+
+// writePage writes data, a page of a listing, in a pageEnvelope. The total is also in
+// the X-Total-Count header, where clients found it before the envelope.
+func writePage(w http.ResponseWriter, data any, meta pageMeta, links pageLinks) {
+	w.Header().Set("X-Total-Count", strconv.Itoa(meta.Total))
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(pageEnvelope{Data: data, Meta: meta, Links: links})
+}
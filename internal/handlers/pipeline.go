@@ -0,0 +1,97 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/go-redis/redis/v8"
+)
+
+// commandResult reports the outcome of one command in a pipeline.
+type commandResult struct {
+	Command string `json:"command"`
+	Result  string `json:"result,omitempty"`
+	Error   string `json:"error,omitempty"`
+}
+
+// pipelineSet sets every key=value pair in a single round trip.
+// Pairs are given as repeated parameters: ?key=a&value=1&key=b&value=2
+func (a *App) pipelineSet(w http.ResponseWriter, r *http.Request) {
+	runSetPipeline(w, r, a.rdb.Pipeline())
+}
+
+// txPipelineSet is like pipelineSet but wraps the commands in MULTI/EXEC,
+// so either all of them are applied or none are.
+func (a *App) txPipelineSet(w http.ResponseWriter, r *http.Request) {
+	runSetPipeline(w, r, a.rdb.TxPipeline())
+}
+
+func runSetPipeline(w http.ResponseWriter, r *http.Request, pipe redis.Pipeliner) {
+	keys := r.URL.Query()["key"]
+	values := r.URL.Query()["value"]
+	if len(keys) == 0 || len(keys) != len(values) {
+		http.Error(w, "Missing key or value parameters, or their counts differ", http.StatusBadRequest)
+		return
+	}
+
+	for i, key := range keys {
+		pipe.Set(r.Context(), demoKey(key), values[i], 0)
+	}
+	cmds, err := pipe.Exec(r.Context())
+	if err != nil && len(cmds) == 0 {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	writeCommandResults(w, cmds)
+}
+
+// pipelineGet fetches every ?key= in a single round trip.
+func (a *App) pipelineGet(w http.ResponseWriter, r *http.Request) {
+	keys := r.URL.Query()["key"]
+	if len(keys) == 0 {
+		http.Error(w, "Missing key parameter", http.StatusBadRequest)
+		return
+	}
+
+	pipe := a.rdb.Pipeline()
+	for _, key := range keys {
+		pipe.Get(r.Context(), demoKey(key))
+	}
+	// A missing key fails only its own command, so Exec's error isn't fatal
+	cmds, err := pipe.Exec(r.Context())
+	if err != nil && err != redis.Nil && len(cmds) == 0 {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	writeCommandResults(w, cmds)
+}
+
+func writeCommandResults(w http.ResponseWriter, cmds []redis.Cmder) {
+	results := make([]commandResult, len(cmds))
+	for i, cmd := range cmds {
+		args := make([]string, len(cmd.Args()))
+		for j, arg := range cmd.Args() {
+			args[j] = fmt.Sprint(arg)
+		}
+		results[i].Command = strings.Join(args, " ")
+		if err := cmd.Err(); err != nil {
+			results[i].Error = err.Error()
+			continue
+		}
+		switch c := cmd.(type) {
+		case *redis.StatusCmd:
+			results[i].Result = c.Val()
+		case *redis.StringCmd:
+			results[i].Result = c.Val()
+		default:
+			results[i].Result = cmd.String()
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(results)
+}
@@ -0,0 +1,55 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"go-mysql/internal/service"
+)
+
+// maxPreferencesBody bounds the body of PUT /users/{id}/preferences.
+const maxPreferencesBody = 64 << 10
+
+// getPreferences returns the preferences of user {id}, with the defaults of the known
+// preferences it didn't set.
+func (a *App) getPreferences(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		http.Error(w, "Invalid user id", http.StatusBadRequest)
+		return
+	}
+
+	prefs, err := a.users.Preferences(r.Context(), id)
+	if err != nil {
+		writeUserError(w, err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(prefs)
+}
+
+// setPreferences replaces the preferences of user {id} with a body like
+// {"ui.theme": "dark", "myapp.sidebar": {"collapsed": true}} and answers with them,
+// defaults included.
+func (a *App) setPreferences(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		http.Error(w, "Invalid user id", http.StatusBadRequest)
+		return
+	}
+	var prefs service.Preferences
+	err = json.NewDecoder(http.MaxBytesReader(w, r.Body, maxPreferencesBody)).Decode(&prefs)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	prefs, err = a.users.SetPreferences(r.Context(), id, prefs)
+	if err != nil {
+		writeUserError(w, err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(prefs)
+}
@@ -0,0 +1,112 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+func (a *App) publish(w http.ResponseWriter, r *http.Request) {
+	channel := r.URL.Query().Get("channel")
+	message := r.URL.Query().Get("message")
+	if channel == "" || message == "" {
+		http.Error(w, "Missing channel or message parameters", http.StatusBadRequest)
+		return
+	}
+
+	receivers, err := a.rdb.Publish(r.Context(), channel, message).Result()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	fmt.Fprintf(w, "Message published to %s, received by %d subscribers\n", channel, receivers)
+}
+
+// subscribe streams messages from every ?channel= and every ?pattern= (e.g. news.*) as
+// server-sent events until the client disconnects or the server shuts down.
+func (a *App) subscribe(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	channels := r.URL.Query()["channel"]
+	patterns := r.URL.Query()["pattern"]
+	if len(channels) == 0 && len(patterns) == 0 {
+		http.Error(w, "Missing channel or pattern parameter", http.StatusBadRequest)
+		return
+	}
+
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "Streaming unsupported", http.StatusInternalServerError)
+		return
+	}
+
+	// The stream is meant to outlive the server's write timeout
+	err := http.NewResponseController(w).SetWriteDeadline(time.Time{})
+	if err != nil {
+		http.Error(w, "Streaming unsupported", http.StatusInternalServerError)
+		return
+	}
+
+	a.subscribers.Add(1)
+	defer a.subscribers.Done()
+
+	pubsub := a.rdb.Subscribe(ctx)
+	defer pubsub.Close()
+
+	if len(channels) > 0 {
+		err = pubsub.Subscribe(ctx, channels...)
+	}
+	if err == nil && len(patterns) > 0 {
+		err = pubsub.PSubscribe(ctx, patterns...)
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.WriteHeader(http.StatusOK)
+	flusher.Flush()
+
+	// Comments keep proxies from closing an idle stream
+	keepAlive := time.NewTicker(15 * time.Second)
+	defer keepAlive.Stop()
+
+	messages := pubsub.Channel()
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case <-a.subscribersCtx.Done():
+			fmt.Fprint(w, "event: shutdown\ndata: {}\n\n")
+			flusher.Flush()
+			return
+		case <-keepAlive.C:
+			fmt.Fprint(w, ": keep-alive\n\n")
+			flusher.Flush()
+		case msg, ok := <-messages:
+			if !ok {
+				return
+			}
+			data, err := json.Marshal(map[string]string{
+				"channel": msg.Channel,
+				"pattern": msg.Pattern,
+				"message": msg.Payload,
+			})
+			if err != nil {
+				continue
+			}
+			fmt.Fprintf(w, "event: message\ndata: %s\n\n", data)
+			flusher.Flush()
+		}
+	}
+}
+
+// CloseSubscribers ends every /redis/subscribe stream and waits for them to unsubscribe.
+func (a *App) CloseSubscribers() {
+	a.stopSubscribers()
+	a.subscribers.Wait()
+}
@@ -0,0 +1,61 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"go-mysql/internal/models"
+	"go-mysql/internal/service"
+)
+
+// adminListReservedUsernames returns the usernames reserved by configuration, then
+// those the tenant reserved.
+func (a *App) adminListReservedUsernames(w http.ResponseWriter, r *http.Request) {
+	names, err := a.reserved.List(r.Context())
+	if err != nil {
+		writeUserError(w, err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(names)
+}
+
+// adminReserveUsername reserves the username in a body like {"name": "billing"}, or
+// what usernames may not contain with "match": "contains", answering 201 if it wasn't
+// reserved already.
+func (a *App) adminReserveUsername(w http.ResponseWriter, r *http.Request) {
+	var name models.ReservedUsername
+	err := json.NewDecoder(r.Body).Decode(&name)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	name, added, err := a.reserved.Add(r.Context(), actor(r), name)
+	if err != nil {
+		writeUserError(w, err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	if added {
+		w.WriteHeader(http.StatusCreated)
+	}
+	json.NewEncoder(w).Encode(name)
+}
+
+// adminReleaseUsername releases the reserved username {name}, or with ?match=contains,
+// what usernames may not contain.
+func (a *App) adminReleaseUsername(w http.ResponseWriter, r *http.Request) {
+	name := models.ReservedUsername{Name: r.PathValue("name"), Match: r.URL.Query().Get("match")}
+	err := a.reserved.Remove(r.Context(), actor(r), name)
+	if errors.Is(err, service.ErrNotReserved) {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		writeUserError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
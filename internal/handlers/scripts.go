@@ -0,0 +1,30 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+
+	"go-mysql/internal/cache"
+)
+
+// compareAndDelete deletes ?key= only if its current value is ?value=.
+func (a *App) compareAndDelete(w http.ResponseWriter, r *http.Request) {
+	key := r.URL.Query().Get("key")
+	value := r.URL.Query().Get("value")
+	if key == "" || value == "" {
+		http.Error(w, "Missing key or value parameters", http.StatusBadRequest)
+		return
+	}
+
+	deleted, err := cache.Script("compare_and_delete").Run(r.Context(), a.rdb, []string{demoKey(key)}, value).Int()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if deleted == 0 {
+		http.Error(w, "Key is missing or holds a different value", http.StatusConflict)
+		return
+	}
+
+	fmt.Fprintf(w, "Key %s deleted\n", key)
+}
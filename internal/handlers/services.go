@@ -0,0 +1,119 @@
+package handlers
+
+import (
+	"context"
+
+	"go-mysql/internal/models"
+	"go-mysql/internal/readmodel"
+	"go-mysql/internal/repository"
+	"go-mysql/internal/service"
+)
+
+// The handlers depend on the services through the interfaces below, so they can be
+// tested against mocks of them.
+
+//go:generate mockgen -destination=mocks/mocks.go -package=mocks go-mysql/internal/handlers UserService,Registration,Admin,Groups,Follows,Feed,Stats,ReservedUsernames,Webhooks
+
+// UserService manages users, implemented by service.UserService.
+type UserService interface {
+	Autocomplete(ctx context.Context, prefix string, limit int) ([]models.User, error)
+	ByUsername(ctx context.Context, username string) (user models.User, renamed bool, err error)
+	ChangeEmail(ctx context.Context, username, email string) (change models.EmailChange, pending bool, err error)
+	ConfirmEmailChange(ctx context.Context, token string) (models.User, error)
+	Create(ctx context.Context, user models.User) (models.User, error)
+	Deactivate(ctx context.Context, id int) (models.User, error)
+	Delete(ctx context.Context, username string) error
+	DeleteAvatar(ctx context.Context, id int) error
+	FindByPhone(ctx context.Context, phone, country string, limit int) ([]models.User, error)
+	GenerateUsers(ctx context.Context, count, batch int) (int, error)
+	Get(ctx context.Context, id int) (models.User, error)
+	GetFields(ctx context.Context, id int, fields []string) (map[string]string, error)
+	Import(ctx context.Context, rows []models.ImportRow, dryRun bool) (models.ImportReport, error)
+	List(ctx context.Context) ([]models.User, error)
+	ListByTag(ctx context.Context, name string, offset, limit int) ([]models.User, int, error)
+	ListPage(ctx context.Context, opts readmodel.ListOptions) ([]models.User, int, error)
+	MaxAvatarSize() int
+	Preferences(ctx context.Context, id int) (service.Preferences, error)
+	Reactivate(ctx context.Context, id int) (models.User, error)
+	Rename(ctx context.Context, id int, username string) (models.User, error)
+	Search(ctx context.Context, prefix string, limit int) ([]models.User, error)
+	SetAvatar(ctx context.Context, id int, data []byte) (models.User, error)
+	SetPreferences(ctx context.Context, id int, prefs service.Preferences) (service.Preferences, error)
+	Tag(ctx context.Context, id int, name string) error
+	TagCounts(ctx context.Context) ([]models.TagCount, error)
+	Tags(ctx context.Context, id int) ([]string, error)
+	Untag(ctx context.Context, id int, name string) error
+	UpdateProfile(ctx context.Context, username string, update models.ProfileUpdate) (models.User, error)
+	Upsert(ctx context.Context, user models.User) (models.User, bool, error)
+	UsernameHistory(ctx context.Context, id int) ([]models.UsernameChange, error)
+}
+
+// Registration signs users up, implemented by service.Registration.
+type Registration interface {
+	Register(ctx context.Context, user models.User) (models.User, string, error)
+}
+
+// Admin runs the operations of the administrators of a tenant, implemented by
+// service.Admin.
+type Admin interface {
+	Ban(ctx context.Context, actor, id int, reason string) error
+	Duplicates(ctx context.Context, minSimilarity float64, limit int) ([]models.DuplicateGroup, error)
+	Export(ctx context.Context, p models.Principal, filter repository.AdminUserFilter, fn func(models.AdminUser) error) error
+	ForcePasswordReset(ctx context.Context, actor, id int) error
+	List(ctx context.Context, filter repository.AdminUserFilter) ([]models.AdminUser, int, error)
+	Merge(ctx context.Context, actor, id, into int) (models.UserMerge, error)
+	SetRole(ctx context.Context, actor, id int, role string) error
+	Unban(ctx context.Context, actor, id int) error
+}
+
+// Groups manages groups and their members, implemented by service.Groups.
+type Groups interface {
+	Create(ctx context.Context, group models.Group, ownerID int) (models.Group, error)
+	Delete(ctx context.Context, actor models.Principal, id int) error
+	Get(ctx context.Context, id int) (models.Group, error)
+	List(ctx context.Context, offset, limit int) ([]models.Group, int, error)
+	Members(ctx context.Context, id int) ([]models.Membership, error)
+	RemoveMember(ctx context.Context, actor models.Principal, groupID, userID int) error
+	SetMember(ctx context.Context, actor models.Principal, groupID, userID int, role string) error
+	UserGroups(ctx context.Context, userID int) ([]models.Membership, error)
+}
+
+// Follows manages who follows whom, implemented by service.Follows.
+type Follows interface {
+	Counts(ctx context.Context, id int) (models.FollowCounts, error)
+	Follow(ctx context.Context, followerID, followeeID int) error
+	Followers(ctx context.Context, id, offset, limit int) ([]models.Follow, models.FollowCounts, error)
+	Following(ctx context.Context, id, offset, limit int) ([]models.Follow, models.FollowCounts, error)
+	Unfollow(ctx context.Context, followerID, followeeID int) error
+}
+
+// Feed lists the activities of users, implemented by activity.Feed.
+type Feed interface {
+	List(ctx context.Context, userID int, cursor string, limit int) ([]models.Activity, string, error)
+}
+
+// Stats computes user statistics, implemented by service.Stats.
+type Stats interface {
+	MaxSignupDays() int
+	MaxUserID(ctx context.Context) (int, error)
+	Signups(ctx context.Context, days int) (models.SignupStats, error)
+	Users(ctx context.Context) (models.UserStats, error)
+}
+
+// ReservedUsernames manages the usernames nobody may take, implemented by
+// service.ReservedUsernames.
+type ReservedUsernames interface {
+	Add(ctx context.Context, actor int, name models.ReservedUsername) (models.ReservedUsername, bool, error)
+	List(ctx context.Context) ([]models.ReservedUsername, error)
+	Remove(ctx context.Context, actor int, name models.ReservedUsername) error
+}
+
+// Webhooks manages the webhooks user events are delivered to, implemented by
+// webhooks.Service.
+type Webhooks interface {
+	Delete(ctx context.Context, id int) (bool, error)
+	Deliveries(ctx context.Context, webhookID, limit int) ([]models.WebhookDelivery, error)
+	List(ctx context.Context) ([]models.Webhook, error)
+	Redeliver(ctx context.Context, id int64) (bool, error)
+	Register(ctx context.Context, hook models.Webhook) (models.Webhook, error)
+}
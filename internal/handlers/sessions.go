@@ -0,0 +1,132 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+
+	"go-mysql/internal/cache"
+	"go-mysql/internal/config"
+	"go-mysql/internal/logging"
+	"go-mysql/pkg/sessions"
+)
+
+func newSessionStore(rdb redis.UniversalClient, state *cache.State) *sessions.Store {
+	return sessions.NewStore(rdb, sessions.Options{
+		CookieName: config.Env("SESSION_COOKIE_NAME", "session_id"),
+		Domain:     config.Env("SESSION_COOKIE_DOMAIN", ""),
+		MaxAge:     config.EnvDuration("SESSION_MAX_AGE", 24*time.Hour),
+		Secure:     config.EnvBool("SESSION_COOKIE_SECURE", false),
+		HttpOnly:   true,
+		KeyPrefix:  state.Config().KeyPrefix + "session:",
+	})
+}
+
+// getSession shows the caller's session values and any pending flash messages,
+// which are cleared once shown.
+func (a *App) getSession(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	sess, err := a.sessions.Load(ctx, r)
+	if err == sessions.ErrNotFound {
+		http.Error(w, "No session", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	flashes := sess.PopFlashes()
+	if len(flashes) > 0 {
+		err = a.sessions.Save(ctx, w, sess)
+	} else {
+		err = a.sessions.Touch(ctx, w, sess)
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]any{
+		"values":  sess.Values,
+		"flashes": flashes,
+	})
+}
+
+// setSessionValue stores ?key=&value= in the caller's session, creating it if needed.
+func (a *App) setSessionValue(w http.ResponseWriter, r *http.Request) {
+	key := r.URL.Query().Get("key")
+	value := r.URL.Query().Get("value")
+	if key == "" || value == "" {
+		http.Error(w, "Missing key or value parameters", http.StatusBadRequest)
+		return
+	}
+
+	sess, err := a.sessions.LoadOrNew(r.Context(), w, r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	sess.Values[key] = value
+	err = a.sessions.Save(r.Context(), w, sess)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+}
+
+// addSessionFlash queues ?message= to be shown once by getSession.
+func (a *App) addSessionFlash(w http.ResponseWriter, r *http.Request) {
+	message := r.URL.Query().Get("message")
+	if message == "" {
+		http.Error(w, "Missing message parameter", http.StatusBadRequest)
+		return
+	}
+
+	sess, err := a.sessions.LoadOrNew(r.Context(), w, r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	sess.AddFlash(message)
+	err = a.sessions.Save(r.Context(), w, sess)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+}
+
+func (a *App) destroySession(w http.ResponseWriter, r *http.Request) {
+	sess, err := a.sessions.Load(r.Context(), r)
+	if err == sessions.ErrNotFound {
+		w.WriteHeader(http.StatusOK)
+		return
+	}
+	if err == nil {
+		err = a.sessions.Destroy(r.Context(), w, sess)
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+}
+
+// CleanupSessions deletes sessions that have lost their expiry, for the scheduler's
+// session_cleanup job.
+func (a *App) CleanupSessions(ctx context.Context) error {
+	deleted, err := a.sessions.Cleanup(ctx)
+	if deleted > 0 {
+		logging.From(ctx).Info("Deleted stale sessions", "count", deleted)
+	}
+	return err
+}
@@ -0,0 +1,38 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"go-mysql/internal/models"
+)
+
+// deactivateUser deactivates user {id} and answers with it. Its data is kept, but its
+// API keys stop working and it's left out of listings until reactivated.
+func (a *App) deactivateUser(w http.ResponseWriter, r *http.Request) {
+	a.setUserStatus(w, r, a.users.Deactivate)
+}
+
+// reactivateUser makes the deactivated user {id} active again and answers with it. A
+// deactivated user's API keys don't work, so it takes an administrator.
+func (a *App) reactivateUser(w http.ResponseWriter, r *http.Request) {
+	a.setUserStatus(w, r, a.users.Reactivate)
+}
+
+func (a *App) setUserStatus(w http.ResponseWriter, r *http.Request, set func(ctx context.Context, id int) (models.User, error)) {
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		http.Error(w, "Invalid user id", http.StatusBadRequest)
+		return
+	}
+
+	user, err := set(r.Context(), id)
+	if err != nil {
+		writeUserError(w, err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(user)
+}
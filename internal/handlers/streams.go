@@ -0,0 +1,229 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+
+	"go-mysql/internal/config"
+	"go-mysql/internal/logging"
+)
+
+// StreamWorker consumes a Redis stream as part of a consumer group. Entries are acked
+// once processed; entries left pending by a crashed or slow consumer are claimed after
+// minIdle, and entries that keep failing are moved to a dead-letter stream.
+type StreamWorker struct {
+	rdb           redis.UniversalClient
+	stream        string
+	group         string
+	consumer      string
+	deadLetter    string
+	maxDeliveries int64
+	minIdle       time.Duration
+}
+
+var (
+	eventsStream = config.Env("STREAM_NAME", "events")
+	eventsGroup  = config.Env("STREAM_GROUP", "workers")
+)
+
+func NewStreamWorker(rdb redis.UniversalClient) *StreamWorker {
+	hostname, _ := os.Hostname()
+	return &StreamWorker{
+		rdb:           rdb,
+		stream:        eventsStream,
+		group:         eventsGroup,
+		consumer:      config.Env("STREAM_CONSUMER", hostname),
+		deadLetter:    eventsStream + ":dead",
+		maxDeliveries: int64(config.EnvInt("STREAM_MAX_DELIVERIES", 5)),
+		minIdle:       config.EnvDuration("STREAM_CLAIM_MIN_IDLE", 30*time.Second),
+	}
+}
+
+// Run processes entries until ctx is cancelled.
+func (sw *StreamWorker) Run(ctx context.Context) {
+	err := sw.rdb.XGroupCreateMkStream(ctx, sw.stream, sw.group, "0").Err()
+	if err != nil && !strings.HasPrefix(err.Error(), "BUSYGROUP") {
+		logging.From(ctx).Error("Failed to create stream consumer group", "stream", sw.stream, "group", sw.group, "error", err)
+		return
+	}
+	logging.From(ctx).Info("Consuming stream", "stream", sw.stream, "group", sw.group, "consumer", sw.consumer)
+
+	lastClaim := time.Time{}
+	for ctx.Err() == nil {
+		if time.Since(lastClaim) >= sw.minIdle {
+			sw.claimStale(ctx)
+			lastClaim = time.Now()
+		}
+
+		streams, err := sw.rdb.XReadGroup(ctx, &redis.XReadGroupArgs{
+			Group:    sw.group,
+			Consumer: sw.consumer,
+			Streams:  []string{sw.stream, ">"},
+			Count:    10,
+			Block:    2 * time.Second,
+		}).Result()
+		if err == redis.Nil {
+			continue
+		}
+		if err != nil {
+			if ctx.Err() == nil {
+				logging.From(ctx).Error("Failed to read from stream", "stream", sw.stream, "error", err)
+				time.Sleep(time.Second)
+			}
+			continue
+		}
+
+		for _, s := range streams {
+			for _, msg := range s.Messages {
+				sw.handle(ctx, msg)
+			}
+		}
+	}
+}
+
+func (sw *StreamWorker) handle(ctx context.Context, msg redis.XMessage) {
+	err := processStreamEntry(ctx, msg)
+	if err != nil {
+		// Leave it pending; claimStale retries it later
+		logging.From(ctx).Warn("Failed to process stream entry", "id", msg.ID, "error", err)
+		return
+	}
+	err = sw.rdb.XAck(ctx, sw.stream, sw.group, msg.ID).Err()
+	if err != nil {
+		logging.From(ctx).Error("Failed to ack stream entry", "id", msg.ID, "error", err)
+	}
+}
+
+// claimStale takes over entries that have been pending longer than minIdle, retrying
+// them or dead-lettering them once they've been delivered maxDeliveries times.
+func (sw *StreamWorker) claimStale(ctx context.Context) {
+	start := "0-0"
+	for {
+		msgs, next, err := sw.rdb.XAutoClaim(ctx, &redis.XAutoClaimArgs{
+			Stream:   sw.stream,
+			Group:    sw.group,
+			Consumer: sw.consumer,
+			MinIdle:  sw.minIdle,
+			Start:    start,
+			Count:    10,
+		}).Result()
+		if err != nil {
+			logging.From(ctx).Error("Failed to claim pending stream entries", "stream", sw.stream, "error", err)
+			return
+		}
+
+		for _, msg := range msgs {
+			if sw.deliveries(ctx, msg.ID) > sw.maxDeliveries {
+				sw.deadLetterEntry(ctx, msg)
+				continue
+			}
+			sw.handle(ctx, msg)
+		}
+
+		if next == "0-0" || len(msgs) == 0 {
+			return
+		}
+		start = next
+	}
+}
+
+func (sw *StreamWorker) deliveries(ctx context.Context, id string) int64 {
+	pending, err := sw.rdb.XPendingExt(ctx, &redis.XPendingExtArgs{
+		Stream: sw.stream,
+		Group:  sw.group,
+		Start:  id,
+		End:    id,
+		Count:  1,
+	}).Result()
+	if err != nil || len(pending) == 0 {
+		return 0
+	}
+	return pending[0].RetryCount
+}
+
+func (sw *StreamWorker) deadLetterEntry(ctx context.Context, msg redis.XMessage) {
+	values := map[string]any{"original_id": msg.ID}
+	for k, v := range msg.Values {
+		values[k] = v
+	}
+
+	pipe := sw.rdb.TxPipeline()
+	pipe.XAdd(ctx, &redis.XAddArgs{Stream: sw.deadLetter, Values: values})
+	pipe.XAck(ctx, sw.stream, sw.group, msg.ID)
+	_, err := pipe.Exec(ctx)
+	if err != nil {
+		logging.From(ctx).Error("Failed to dead-letter stream entry", "id", msg.ID, "error", err)
+		return
+	}
+	logging.From(ctx).Warn("Moved stream entry to dead letters", "id", msg.ID, "stream", sw.deadLetter)
+}
+
+// processStreamEntry is where real work would happen. Entries with fail=true always
+// fail, which makes it easy to watch retries and dead-lettering.
+func processStreamEntry(ctx context.Context, msg redis.XMessage) error {
+	if msg.Values["fail"] == "true" {
+		return errors.New("entry asked to fail")
+	}
+	logging.From(ctx).Info("Processed stream entry", "id", msg.ID, "values", msg.Values)
+	return nil
+}
+
+// streamAdd appends an entry built from repeated ?field=&value= pairs to the stream.
+func (a *App) streamAdd(w http.ResponseWriter, r *http.Request) {
+	fields := r.URL.Query()["field"]
+	values := r.URL.Query()["value"]
+	if len(fields) == 0 || len(fields) != len(values) {
+		http.Error(w, "Missing field or value parameters, or their counts differ", http.StatusBadRequest)
+		return
+	}
+
+	entry := make(map[string]any, len(fields))
+	for i, field := range fields {
+		entry[field] = values[i]
+	}
+	id, err := a.rdb.XAdd(r.Context(), &redis.XAddArgs{Stream: eventsStream, Values: entry}).Result()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	fmt.Fprintf(w, "Added entry %s to stream %s\n", id, eventsStream)
+}
+
+// streamPending lists entries delivered to a consumer but not yet acked.
+func (a *App) streamPending(w http.ResponseWriter, r *http.Request) {
+	pending, err := a.rdb.XPendingExt(r.Context(), &redis.XPendingExtArgs{
+		Stream: eventsStream,
+		Group:  eventsGroup,
+		Start:  "-",
+		End:    "+",
+		Count:  100,
+	}).Result()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(pending)
+}
+
+// streamDeadLetters lists entries that exhausted their deliveries.
+func (a *App) streamDeadLetters(w http.ResponseWriter, r *http.Request) {
+	msgs, err := a.rdb.XRangeN(r.Context(), eventsStream+":dead", "-", "+", 100).Result()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(msgs)
+}
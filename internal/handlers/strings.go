@@ -0,0 +1,108 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+)
+
+// Redis Functions
+func (a *App) setString(w http.ResponseWriter, r *http.Request) {
+	key := r.URL.Query().Get("key")
+	value := r.URL.Query().Get("value")
+	if key == "" || value == "" {
+		http.Error(w, "Missing key or value parameters", http.StatusBadRequest)
+		return
+	}
+
+	err := a.rdb.Set(r.Context(), demoKey(key), value, 0).Err()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+}
+
+func (a *App) getString(w http.ResponseWriter, r *http.Request) {
+	key := r.URL.Query().Get("key")
+	if key == "" {
+		http.Error(w, "Missing key parameter", http.StatusBadRequest)
+		return
+	}
+
+	val, err := a.rdb.Get(r.Context(), demoKey(key)).Result()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	fmt.Fprintf(w, "Value for key %s: %s\n", key, val)
+}
+
+func (a *App) setList(w http.ResponseWriter, r *http.Request) {
+	key := r.URL.Query().Get("key")
+	values := r.URL.Query()["value"]
+	if key == "" || len(values) == 0 {
+		http.Error(w, "Missing key or value parameters", http.StatusBadRequest)
+		return
+	}
+
+	err := a.rdb.RPush(r.Context(), demoKey(key), values).Err()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+}
+
+func (a *App) getList(w http.ResponseWriter, r *http.Request) {
+	key := r.URL.Query().Get("key")
+	if key == "" {
+		http.Error(w, "Missing key parameter", http.StatusBadRequest)
+		return
+	}
+
+	vals, err := a.rdb.LRange(r.Context(), demoKey(key), 0, -1).Result()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	fmt.Fprintf(w, "Values for key %s: %v\n", key, vals)
+}
+
+func (a *App) setHash(w http.ResponseWriter, r *http.Request) {
+	key := r.URL.Query().Get("key")
+	field := r.URL.Query().Get("field")
+	value := r.URL.Query().Get("value")
+	if key == "" || field == "" || value == "" {
+		http.Error(w, "Missing key, field, or value parameters", http.StatusBadRequest)
+		return
+	}
+
+	err := a.rdb.HSet(r.Context(), demoKey(key), field, value).Err()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+}
+
+func (a *App) getHash(w http.ResponseWriter, r *http.Request) {
+	key := r.URL.Query().Get("key")
+	field := r.URL.Query().Get("field")
+	if key == "" || field == "" {
+		http.Error(w, "Missing key or field parameter", http.StatusBadRequest)
+		return
+	}
+
+	val, err := a.rdb.HGet(r.Context(), demoKey(key), field).Result()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	fmt.Fprintf(w, "Value for field %s in key %s: %s\n", field, key, val)
+}
@@ -0,0 +1,97 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"go-mysql/internal/service"
+)
+
+// tagUser gives user {id} the tag {tag}.
+func (a *App) tagUser(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		http.Error(w, "Invalid user id", http.StatusBadRequest)
+		return
+	}
+
+	err = a.users.Tag(r.Context(), id, r.PathValue("tag"))
+	if err != nil {
+		writeUserError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// untagUser takes the tag {tag} from user {id}.
+func (a *App) untagUser(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		http.Error(w, "Invalid user id", http.StatusBadRequest)
+		return
+	}
+
+	err = a.users.Untag(r.Context(), id, r.PathValue("tag"))
+	if errors.Is(err, service.ErrNotTagged) {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		writeUserError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// getUserTags returns the tags of user {id}, in name order.
+func (a *App) getUserTags(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		http.Error(w, "Invalid user id", http.StatusBadRequest)
+		return
+	}
+
+	tags, err := a.users.Tags(r.Context(), id)
+	if err != nil {
+		writeUserError(w, err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(tags)
+}
+
+// getTags returns the tags listed users have, with how many have each, most used
+// first. GET /users?tag= lists the users with one.
+func (a *App) getTags(w http.ResponseWriter, r *http.Request) {
+	counts, err := a.users.TagCounts(r.Context())
+	if err != nil {
+		writeUserError(w, err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(counts)
+}
+
+// getUsersByTag serves GET /users?tag=, a page of the users with the tag in a
+// pageEnvelope, in id order, see parsePage.
+func (a *App) getUsersByTag(w http.ResponseWriter, r *http.Request, fields []string) {
+	query := r.URL.Query()
+	if query.Has("sort") || query.Has("order") || query.Has("cursor") {
+		http.Error(w, "The tag parameter can't be combined with sort, order or cursor", http.StatusBadRequest)
+		return
+	}
+	page, perPage, err := parsePage(r, 50, 500)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	users, total, err := a.users.ListByTag(r.Context(), query.Get("tag"), (page-1)*perPage, perPage)
+	if err != nil {
+		writeUserError(w, err)
+		return
+	}
+	writePage(w, userData(users, fields), pageMeta{Total: total, Page: page, PerPage: perPage}, newPageLinks(r, page, perPage, total))
+}
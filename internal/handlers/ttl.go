@@ -0,0 +1,102 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// expireKey sets a key's expiration, either relative with ?seconds= (EXPIRE) or
+// absolute with ?at= as a Unix timestamp or RFC 3339 time (EXPIREAT).
+func (a *App) expireKey(w http.ResponseWriter, r *http.Request) {
+	key := r.URL.Query().Get("key")
+	seconds := r.URL.Query().Get("seconds")
+	at := r.URL.Query().Get("at")
+	if key == "" || (seconds == "") == (at == "") {
+		http.Error(w, "Missing key parameter, or not exactly one of seconds and at", http.StatusBadRequest)
+		return
+	}
+
+	var ok bool
+	var err error
+	if seconds != "" {
+		n, convErr := strconv.Atoi(seconds)
+		if convErr != nil || n <= 0 {
+			http.Error(w, "Invalid seconds parameter", http.StatusBadRequest)
+			return
+		}
+		ok, err = a.rdb.Expire(r.Context(), demoKey(key), time.Duration(n)*time.Second).Result()
+	} else {
+		tm, parseErr := parseTime(at)
+		if parseErr != nil {
+			http.Error(w, "Invalid at parameter", http.StatusBadRequest)
+			return
+		}
+		ok, err = a.rdb.ExpireAt(r.Context(), demoKey(key), tm).Result()
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if !ok {
+		http.Error(w, "Key not found", http.StatusNotFound)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+}
+
+// parseTime accepts a Unix timestamp in seconds or an RFC 3339 time.
+func parseTime(s string) (time.Time, error) {
+	if n, err := strconv.ParseInt(s, 10, 64); err == nil {
+		return time.Unix(n, 0), nil
+	}
+	return time.Parse(time.RFC3339, s)
+}
+
+// persistKey removes a key's expiration.
+func (a *App) persistKey(w http.ResponseWriter, r *http.Request) {
+	key := r.URL.Query().Get("key")
+	if key == "" {
+		http.Error(w, "Missing key parameter", http.StatusBadRequest)
+		return
+	}
+
+	ok, err := a.rdb.Persist(r.Context(), demoKey(key)).Result()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if !ok {
+		fmt.Fprintf(w, "Key %s does not exist or has no expiration\n", key)
+		return
+	}
+
+	fmt.Fprintf(w, "Key %s no longer expires\n", key)
+}
+
+// keyTTL reports how long a key has left to live.
+func (a *App) keyTTL(w http.ResponseWriter, r *http.Request) {
+	key := r.URL.Query().Get("key")
+	if key == "" {
+		http.Error(w, "Missing key parameter", http.StatusBadRequest)
+		return
+	}
+
+	ttl, err := a.rdb.PTTL(r.Context(), demoKey(key)).Result()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	// Redis answers -2 for missing keys and -1 for keys without an expiration
+	switch ttl {
+	case -2:
+		http.Error(w, "Key not found", http.StatusNotFound)
+	case -1:
+		fmt.Fprintf(w, "Key %s does not expire\n", key)
+	default:
+		fmt.Fprintf(w, "Key %s expires in %s (at %s)\n", key, ttl, time.Now().Add(ttl).UTC().Format(time.RFC3339))
+	}
+}
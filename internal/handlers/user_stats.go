@@ -0,0 +1,51 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"go-mysql/internal/service"
+)
+
+// getUserStats returns the user statistics of the request's tenant: how many users
+// there are, how many were active lately and the signups of each of the last 30 days.
+// They're counted every few minutes, as of their generated_at.
+func (a *App) getUserStats(w http.ResponseWriter, r *http.Request) {
+	stats, err := a.stats.Users(r.Context())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(stats)
+}
+
+// getSignupStats returns the signups of the request's tenant on each of the last
+// ?days= days (7 by default), and their total, read from counters kept in Redis as
+// users are created, so unlike getUserStats it's up to date and never touches MySQL.
+func (a *App) getSignupStats(w http.ResponseWriter, r *http.Request) {
+	days := 7
+	if s := r.URL.Query().Get("days"); s != "" {
+		var err error
+		days, err = strconv.Atoi(s)
+		if err != nil || days < 1 || days > a.stats.MaxSignupDays() {
+			http.Error(w, fmt.Sprintf("Invalid days parameter, must be between 1 and %d", a.stats.MaxSignupDays()), http.StatusBadRequest)
+			return
+		}
+	}
+
+	stats, err := a.stats.Signups(r.Context(), days)
+	if errors.Is(err, service.ErrSignupsUnavailable) {
+		http.Error(w, err.Error(), http.StatusServiceUnavailable)
+		return
+	}
+	if err != nil {
+		writeUserError(w, err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(stats)
+}
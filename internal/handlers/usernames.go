@@ -0,0 +1,96 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"strconv"
+)
+
+// getUserResource serves GET /users/{id}/{resource}. The mux can't tell a pattern
+// like /users/{id}/username-history apart from /users/export/{id}, so the
+// sub-resources of a user share this pattern, which is more general than the export
+// one, and are dispatched here.
+func (a *App) getUserResource(w http.ResponseWriter, r *http.Request) {
+	switch r.PathValue("resource") {
+	case "username-history":
+		a.getUsernameHistory(w, r)
+	case "groups":
+		a.getUserGroups(w, r)
+	case "followers":
+		a.getFollowers(w, r)
+	case "following":
+		a.getFollowing(w, r)
+	case "follow-counts":
+		a.getFollowCounts(w, r)
+	case "feed":
+		a.getFeed(w, r)
+	case "preferences":
+		a.getPreferences(w, r)
+	case "tags":
+		a.getUserTags(w, r)
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+// getUsernameHistory returns the username changes of user {id}, latest first.
+func (a *App) getUsernameHistory(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		http.Error(w, "Invalid user id", http.StatusBadRequest)
+		return
+	}
+
+	changes, err := a.users.UsernameHistory(r.Context(), id)
+	if err != nil {
+		writeUserError(w, err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(changes)
+}
+
+// renameUser changes the username of user {id} to the "username" of the body and
+// answers with the user.
+func (a *App) renameUser(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		http.Error(w, "Invalid user id", http.StatusBadRequest)
+		return
+	}
+	var body struct {
+		Username string `json:"username"`
+	}
+	err = json.NewDecoder(r.Body).Decode(&body)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	user, err := a.users.Rename(r.Context(), id, body.Username)
+	if err != nil {
+		writeUserError(w, err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(user)
+}
+
+// getUserByUsername returns the user called {username}. A username the user had until
+// recently redirects to its current one.
+func (a *App) getUserByUsername(w http.ResponseWriter, r *http.Request) {
+	user, renamed, err := a.users.ByUsername(r.Context(), r.PathValue("username"))
+	if err != nil {
+		writeUserError(w, err)
+		return
+	}
+	if renamed {
+		// Relative to the request path, so it works wherever the API is mounted.
+		// Temporary, since another user may take the username later
+		http.Redirect(w, r, url.PathEscape(user.Username), http.StatusTemporaryRedirect)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(user)
+}
@@ -0,0 +1,431 @@
+package handlers
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"go-mysql/internal/auth"
+	"go-mysql/internal/models"
+	"go-mysql/internal/readmodel"
+	"go-mysql/internal/service"
+	"go-mysql/pkg/flags"
+)
+
+// writeUserError answers with the status matching an error from the user service.
+func writeUserError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, service.ErrInvalid), errors.Is(err, service.ErrInvalidEmailChangeToken):
+		http.Error(w, err.Error(), http.StatusBadRequest)
+	case errors.Is(err, service.ErrNotFound):
+		http.Error(w, err.Error(), http.StatusNotFound)
+	case errors.Is(err, service.ErrUsernameTaken), errors.Is(err, service.ErrEmailTaken), errors.Is(err, service.ErrBanned),
+		errors.Is(err, service.ErrAlreadyMerged), errors.Is(err, service.ErrUsernameReserved):
+		http.Error(w, err.Error(), http.StatusConflict)
+	case errors.Is(err, service.ErrForbidden):
+		http.Error(w, err.Error(), http.StatusForbidden)
+	case errors.Is(err, service.ErrAvatarTooLarge):
+		http.Error(w, err.Error(), http.StatusRequestEntityTooLarge)
+	case errors.Is(err, service.ErrAvatarsDisabled), errors.Is(err, service.ErrEmailChangesDisabled):
+		http.Error(w, err.Error(), http.StatusNotImplemented)
+	default:
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
+
+// flagCursorPagination turns on ?cursor= for GET /users, see getUsers.
+const flagCursorPagination = "cursor_pagination"
+
+// getUsers returns every user, or a page of them in a pageEnvelope when ?page=,
+// ?per_page=, ?sort= (created_at or username) or ?order= (asc or desc) is given. Like
+// for a single user, ?fields= returns only those fields of each user. ?tag= only lists
+// the users with that tag, see getUsersByTag.
+//
+// With the cursor_pagination flag on, pages also carry an X-Next-Cursor header, and
+// passing it as ?cursor= returns the next page, which page numbers can skip or repeat
+// users for when users are added or removed in between. The next link then holds the
+// cursor, and pages after a cursor have no previous link.
+func (a *App) getUsers(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	fields, err := parseFields(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if query.Has("tag") {
+		a.getUsersByTag(w, r, fields)
+		return
+	}
+	if !query.Has("page") && !query.Has("per_page") && !query.Has("sort") && !query.Has("order") && !query.Has("cursor") {
+		users, err := a.users.List(r.Context())
+		if err != nil {
+			writeUserError(w, err)
+			return
+		}
+		writeUsers(w, users, fields)
+		return
+	}
+
+	page, perPage, err := parsePage(r, 50, 500)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	opts := readmodel.ListOptions{Sort: query.Get("sort"), Offset: (page - 1) * perPage, Limit: perPage}
+	if opts.Sort == "" {
+		opts.Sort = readmodel.SortCreatedAt
+	}
+	if opts.Sort != readmodel.SortCreatedAt && opts.Sort != readmodel.SortUsername {
+		http.Error(w, "Invalid sort parameter", http.StatusBadRequest)
+		return
+	}
+	switch query.Get("order") {
+	case "", "asc":
+	case "desc":
+		opts.Desc = true
+	default:
+		http.Error(w, "Invalid order parameter", http.StatusBadRequest)
+		return
+	}
+
+	cursors := flags.Enabled(r.Context(), flagCursorPagination)
+	if cursor := query.Get("cursor"); cursor != "" {
+		if !cursors {
+			http.Error(w, "Cursor pagination is not enabled", http.StatusBadRequest)
+			return
+		}
+		opts.After, err = decodeCursor(cursor)
+		if err != nil {
+			http.Error(w, readmodel.ErrCursorNotFound.Error(), http.StatusBadRequest)
+			return
+		}
+		opts.Offset = 0
+	}
+
+	users, total, err := a.users.ListPage(r.Context(), opts)
+	if errors.Is(err, readmodel.ErrCursorNotFound) {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if err != nil {
+		writeUserError(w, err)
+		return
+	}
+	links := newPageLinks(r, page, perPage, total)
+	if cursors {
+		links.Next = ""
+		if len(users) == perPage {
+			cursor := encodeCursor(users[len(users)-1].ID)
+			w.Header().Set("X-Next-Cursor", cursor)
+			links.Next = pageURL(r, func(query url.Values) {
+				query.Del("page")
+				query.Set("cursor", cursor)
+			})
+		}
+		if opts.After != 0 {
+			links.Prev = ""
+		}
+	}
+	writePage(w, userData(users, fields), pageMeta{Total: total, Page: page, PerPage: perPage}, links)
+}
+
+// encodeCursor returns the opaque cursor of the page after the user with the given id.
+func encodeCursor(id int) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(strconv.Itoa(id)))
+}
+
+func decodeCursor(cursor string) (int, error) {
+	b, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return 0, err
+	}
+	id, err := strconv.Atoi(string(b))
+	if err == nil && id <= 0 {
+		err = errors.New("invalid cursor")
+	}
+	return id, err
+}
+
+// searchUsers returns the users whose username starts with ?username=, or whose phone
+// is ?phone=, up to ?limit= (20 by default, at most 100), with only their ?fields= if
+// given. A phone without a calling code is a national number of ?country=.
+func (a *App) searchUsers(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	prefix, phone := query.Get("username"), query.Get("phone")
+	if (prefix == "") == (phone == "") {
+		http.Error(w, "Exactly one of the username and phone parameters is required", http.StatusBadRequest)
+		return
+	}
+	// A + left unescaped in the query arrives as a space
+	if rest, ok := strings.CutPrefix(phone, " "); ok {
+		phone = "+" + rest
+	}
+	fields, err := parseFields(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	limit := 20
+	if s := query.Get("limit"); s != "" {
+		limit, err = strconv.Atoi(s)
+		if err != nil || limit < 1 || limit > 100 {
+			http.Error(w, "Invalid limit parameter", http.StatusBadRequest)
+			return
+		}
+	}
+
+	var users []models.User
+	if phone != "" {
+		users, err = a.users.FindByPhone(r.Context(), phone, query.Get("country"), limit)
+	} else {
+		users, err = a.users.Search(r.Context(), prefix, limit)
+	}
+	if err != nil {
+		writeUserError(w, err)
+		return
+	}
+	writeUsers(w, users, fields)
+}
+
+// autocompleteUsers suggests up to ?limit= (10 by default, at most 50) users whose
+// username starts with ?prefix=, ignoring letter case, as their ids and usernames.
+func (a *App) autocompleteUsers(w http.ResponseWriter, r *http.Request) {
+	prefix := r.URL.Query().Get("prefix")
+	if prefix == "" {
+		http.Error(w, "Missing prefix parameter", http.StatusBadRequest)
+		return
+	}
+	limit := 10
+	if s := r.URL.Query().Get("limit"); s != "" {
+		var err error
+		limit, err = strconv.Atoi(s)
+		if err != nil || limit < 1 || limit > 50 {
+			http.Error(w, "Invalid limit parameter", http.StatusBadRequest)
+			return
+		}
+	}
+
+	users, err := a.users.Autocomplete(r.Context(), prefix, limit)
+	if err != nil {
+		writeUserError(w, err)
+		return
+	}
+	writeUsers(w, users, []string{"id", "username"})
+}
+
+// parseFields parses ?fields=, returning nil for all fields.
+func parseFields(r *http.Request) ([]string, error) {
+	s := r.URL.Query().Get("fields")
+	if s == "" {
+		return nil, nil
+	}
+	return models.ParseUserFields(s)
+}
+
+// writeUsers writes users, or only the given fields of each if fields isn't nil.
+func writeUsers(w http.ResponseWriter, users []models.User, fields []string) {
+	// Marshal users data to JSON
+	usersJSON, err := json.Marshal(userData(users, fields))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	// Return data
+	w.Header().Set("Content-Type", "application/json")
+	w.Write(usersJSON)
+}
+
+// userData returns users to write, or only the given fields of each if fields isn't
+// nil, as a JSON array even when there are none.
+func userData(users []models.User, fields []string) any {
+	if fields != nil {
+		selected := make([]map[string]string, len(users))
+		for i, user := range users {
+			selected[i] = models.SelectUserFields(user, fields)
+		}
+		return selected
+	}
+	if users == nil {
+		return []models.User{}
+	}
+	return users
+}
+
+func (a *App) getUser(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		http.Error(w, "Invalid user id", http.StatusBadRequest)
+		return
+	}
+
+	// ?fields=username,email returns only those fields
+	fields, err := parseFields(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if fields != nil {
+		selected, err := a.users.GetFields(r.Context(), id, fields)
+		if err != nil {
+			writeUserError(w, err)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(selected)
+		return
+	}
+
+	user, err := a.users.Get(r.Context(), id)
+	if err != nil {
+		writeUserError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(user)
+}
+
+func (a *App) createUser(w http.ResponseWriter, r *http.Request) {
+	var user models.User
+	err := json.NewDecoder(r.Body).Decode(&user)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	_, err = a.users.Create(r.Context(), user)
+	if err != nil {
+		writeUserError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusCreated)
+}
+
+// registerUser signs up a user and answers with it and its API key, which is shown
+// only this once.
+func (a *App) registerUser(w http.ResponseWriter, r *http.Request) {
+	var user models.User
+	err := json.NewDecoder(r.Body).Decode(&user)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	user, key, err := a.registration.Register(r.Context(), user)
+	if err != nil {
+		writeUserError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(struct {
+		User   models.User `json:"user"`
+		APIKey string      `json:"api_key"`
+	}{user, key})
+}
+
+// updateUser asks for the email of the user in the body to change, answering 202 with
+// the pending change; it takes effect once confirmed from the new address, see
+// confirmEmailChange. Users may only change their own email.
+func (a *App) updateUser(w http.ResponseWriter, r *http.Request) {
+	var user models.User
+	err := json.NewDecoder(r.Body).Decode(&user)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	p, ok := auth.Principal(r.Context())
+	if !ok || p.Username != user.Username {
+		http.Error(w, "Forbidden", http.StatusForbidden)
+		return
+	}
+
+	change, pending, err := a.users.ChangeEmail(r.Context(), user.Username, user.Email)
+	if err != nil {
+		writeUserError(w, err)
+		return
+	}
+	if !pending {
+		w.WriteHeader(http.StatusOK)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusAccepted)
+	json.NewEncoder(w).Encode(change)
+}
+
+// updateProfile changes the profile fields given in the body of the user called
+// {username}, leaving the others alone, and answers with the updated user.
+func (a *App) updateProfile(w http.ResponseWriter, r *http.Request) {
+	var update models.ProfileUpdate
+	err := json.NewDecoder(r.Body).Decode(&update)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	user, err := a.users.UpdateProfile(r.Context(), r.PathValue("username"), update)
+	if err != nil {
+		writeUserError(w, err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(user)
+}
+
+// deleteUser deletes the user called ?username=, if made by that user or an
+// administrator. It answers 200 for a missing user, as it always has.
+func (a *App) deleteUser(w http.ResponseWriter, r *http.Request) {
+	username := r.URL.Query().Get("username")
+	if username == "" {
+		http.Error(w, "Missing username parameter", http.StatusBadRequest)
+		return
+	}
+	if !isUsernameOrAdmin(r, username) {
+		http.Error(w, "Forbidden", http.StatusForbidden)
+		return
+	}
+
+	err := a.users.Delete(r.Context(), username)
+	if err != nil && !errors.Is(err, service.ErrNotFound) {
+		writeUserError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+}
+
+func (a *App) upsertUser(w http.ResponseWriter, r *http.Request) {
+	username := r.PathValue("username")
+
+	var user models.User
+	err := json.NewDecoder(r.Body).Decode(&user)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if user.Username != "" && user.Username != username {
+		http.Error(w, "Username in body does not match path", http.StatusBadRequest)
+		return
+	}
+	user.Username = username
+
+	user, created, err := a.users.Upsert(r.Context(), user)
+	if err != nil {
+		writeUserError(w, err)
+		return
+	}
+
+	status := http.StatusOK
+	if created {
+		status = http.StatusCreated
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(user)
+}
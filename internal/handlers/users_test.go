@@ -0,0 +1,101 @@
+package handlers_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"go.uber.org/mock/gomock"
+
+	"go-mysql/internal/cache"
+	"go-mysql/internal/config"
+	"go-mysql/internal/handlers"
+	"go-mysql/internal/handlers/mocks"
+	"go-mysql/internal/models"
+	"go-mysql/internal/server"
+	"go-mysql/internal/service"
+	"go-mysql/pkg/middleware"
+)
+
+// newServer serves the user routes on top of users, timing requests out after timeout
+// like the server does.
+func newServer(users handlers.UserService, timeout time.Duration) http.Handler {
+	mux := http.NewServeMux()
+	app := handlers.New(handlers.Deps{
+		Users: users,
+		Cache: cache.NewState(config.Cache{}),
+		Mux:   mux,
+	})
+	app.RegisterUserRoutes(middleware.NewGroup(mux, nil))
+	return server.Timeout(config.Timeout{Read: timeout, Write: timeout}, mux)(mux)
+}
+
+func TestGetUser(t *testing.T) {
+	user := models.User{ID: 1, Username: "alice", Email: "alice@example.com"}
+
+	tests := []struct {
+		name       string
+		expect     func(users *mocks.MockUserService)
+		wantStatus int
+	}{
+		{
+			name: "found",
+			expect: func(users *mocks.MockUserService) {
+				users.EXPECT().Get(gomock.Any(), 1).Return(user, nil)
+			},
+			wantStatus: http.StatusOK,
+		},
+		{
+			name: "not found",
+			expect: func(users *mocks.MockUserService) {
+				users.EXPECT().Get(gomock.Any(), 1).Return(models.User{}, service.ErrNotFound)
+			},
+			wantStatus: http.StatusNotFound,
+		},
+		{
+			name: "database error",
+			expect: func(users *mocks.MockUserService) {
+				users.EXPECT().Get(gomock.Any(), 1).Return(models.User{}, errors.New("connection refused"))
+			},
+			wantStatus: http.StatusInternalServerError,
+		},
+		{
+			name: "slow database",
+			expect: func(users *mocks.MockUserService) {
+				users.EXPECT().Get(gomock.Any(), 1).DoAndReturn(func(ctx context.Context, id int) (models.User, error) {
+					<-ctx.Done()
+					return models.User{}, ctx.Err()
+				})
+			},
+			wantStatus: http.StatusServiceUnavailable,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			users := mocks.NewMockUserService(ctrl)
+			tt.expect(users)
+
+			rec := httptest.NewRecorder()
+			newServer(users, 50*time.Millisecond).ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/users/1", nil))
+			if rec.Code != tt.wantStatus {
+				t.Fatalf("GET /users/1 = %d %s, want %d", rec.Code, rec.Body, tt.wantStatus)
+			}
+			if tt.wantStatus != http.StatusOK {
+				return
+			}
+			var got models.User
+			err := json.NewDecoder(rec.Body).Decode(&got)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != user {
+				t.Errorf("GET /users/1 = %+v, want %+v", got, user)
+			}
+		})
+	}
+}
@@ -0,0 +1,129 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"net"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+
+	"go-mysql/internal/config"
+	"go-mysql/internal/logging"
+)
+
+// Unique visitors are counted per route and per day in HyperLogLogs, which use at most
+// 12KB each regardless of traffic. The route is wrapped in a hash tag so every day of one
+// route lands in the same cluster slot and can be merged. They're kept per tenant.
+const allRoutes = "all"
+
+var visitorsRetention = config.EnvDuration("VISITORS_RETENTION", 90*24*time.Hour)
+
+func (a *App) visitorsKey(ctx context.Context, route string, day time.Time) string {
+	return a.statsKey(ctx, "visitors:{"+route+"}:"+day.Format("2006-01-02"))
+}
+
+// VisitorMiddleware records the caller as a visitor of the matched route and of the
+// service as a whole. Recording happens in the background and never fails a request.
+// It must come after server.Tenant for visitors to be counted in their tenant.
+func (a *App) VisitorMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, route := a.mux.Handler(r)
+		if route != "" && a.cache.RedisAvailable() {
+			visitor := visitorID(r)
+			today := time.Now().UTC()
+			keys := []string{a.visitorsKey(r.Context(), route, today), a.visitorsKey(r.Context(), allRoutes, today)}
+			err := a.pool.Submit(r.Context(), "record visitor", func(ctx context.Context) error {
+				pipe := a.rdb.Pipeline()
+				for _, key := range keys {
+					pipe.PFAdd(ctx, key, visitor)
+					pipe.Expire(ctx, key, visitorsRetention)
+				}
+				_, err := pipe.Exec(ctx)
+				return err
+			})
+			if err != nil {
+				logging.From(r.Context()).Warn("Failed to record visitor", "error", err)
+			}
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// visitorID identifies a visitor by the X-Visitor-ID header if the client sends one,
+// and by IP address and user agent otherwise.
+func visitorID(r *http.Request) string {
+	if id := r.Header.Get("X-Visitor-ID"); id != "" {
+		return id
+	}
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		host = r.RemoteAddr
+	}
+	return host + "|" + r.UserAgent()
+}
+
+type visitorDay struct {
+	Date   string `json:"date"`
+	Unique int64  `json:"unique"`
+}
+
+type visitorStats struct {
+	Route  string       `json:"route"`
+	Days   []visitorDay `json:"days"`
+	Unique int64        `json:"unique"`
+}
+
+// getVisitorStats returns approximate unique visitors of ?route= (a registered route
+// pattern such as /users; all routes if omitted) for each of the last ?days= days
+// (default 7) plus the number of distinct visitors across the whole window.
+func (a *App) getVisitorStats(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	route := r.URL.Query().Get("route")
+	if route == "" {
+		route = allRoutes
+	}
+	days := 7
+	if s := r.URL.Query().Get("days"); s != "" {
+		var err error
+		days, err = strconv.Atoi(s)
+		if err != nil || days < 1 || days > int(visitorsRetention/(24*time.Hour)) {
+			http.Error(w, "Invalid days parameter", http.StatusBadRequest)
+			return
+		}
+	}
+
+	today := time.Now().UTC()
+	keys := make([]string, days)
+	stats := visitorStats{Route: route, Days: make([]visitorDay, days)}
+	pipe := a.rdb.Pipeline()
+	counts := make([]*redis.IntCmd, days)
+	for i := range keys {
+		day := today.AddDate(0, 0, -i)
+		keys[i] = a.visitorsKey(ctx, route, day)
+		stats.Days[i].Date = day.Format("2006-01-02")
+		counts[i] = pipe.PFCount(ctx, keys[i])
+	}
+
+	// Merging the window into one HyperLogLog counts each visitor once
+	// even if they came back on several days
+	windowKey := a.statsKey(ctx, "visitors:{"+route+"}:last"+strconv.Itoa(days)+"d")
+	pipe.PFMerge(ctx, windowKey, keys...)
+	pipe.Expire(ctx, windowKey, time.Minute)
+	unique := pipe.PFCount(ctx, windowKey)
+	_, err := pipe.Exec(ctx)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	for i, count := range counts {
+		stats.Days[i].Unique = count.Val()
+	}
+	stats.Unique = unique.Val()
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(stats)
+}
@@ -0,0 +1,90 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+
+	"go-mysql/pkg/retry"
+)
+
+var errTooManyConflicts = errors.New("transaction kept conflicting with concurrent writes")
+
+// watchAndRetry runs fn as an optimistic transaction over keys: fn reads them through
+// tx and queues its writes with tx.TxPipelined. If another client modifies a watched key
+// before EXEC, nothing is written and fn runs again, up to maxAttempts times, after a
+// short random pause so the clients conflicting don't keep doing it in lockstep.
+func (a *App) watchAndRetry(ctx context.Context, maxAttempts int, fn func(tx *redis.Tx) error, keys ...string) error {
+	policy := retry.Policy{
+		MaxAttempts:  maxAttempts,
+		InitialDelay: time.Millisecond,
+		MaxDelay:     20 * time.Millisecond,
+		Jitter:       1,
+		Retryable:    func(err error) bool { return err == redis.TxFailedErr },
+	}
+	err := retry.Do(ctx, policy, func(ctx context.Context) error {
+		return a.rdb.Watch(ctx, fn, keys...)
+	})
+	if err == redis.TxFailedErr {
+		return errTooManyConflicts
+	}
+	return err
+}
+
+var errCounterLimit = errors.New("counter would exceed its limit")
+
+// casIncr adds ?by= (default 1) to the counter at ?key=, but only if the result doesn't
+// exceed ?max=. The check and the write are atomic thanks to WATCH/MULTI/EXEC.
+func (a *App) casIncr(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	key := r.URL.Query().Get("key")
+	max, err := strconv.ParseInt(r.URL.Query().Get("max"), 10, 64)
+	if key == "" || err != nil {
+		http.Error(w, "Missing key parameter, or invalid max", http.StatusBadRequest)
+		return
+	}
+	by := int64(1)
+	if s := r.URL.Query().Get("by"); s != "" {
+		by, err = strconv.ParseInt(s, 10, 64)
+		if err != nil {
+			http.Error(w, "Invalid by parameter", http.StatusBadRequest)
+			return
+		}
+	}
+
+	var value int64
+	err = a.watchAndRetry(ctx, 10, func(tx *redis.Tx) error {
+		current, err := tx.Get(ctx, demoKey(key)).Int64()
+		if err != nil && err != redis.Nil {
+			return err
+		}
+		value = current + by
+		if value > max {
+			return errCounterLimit
+		}
+		_, err = tx.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+			pipe.Set(ctx, demoKey(key), value, redis.KeepTTL)
+			return nil
+		})
+		return err
+	}, demoKey(key))
+	switch err {
+	case nil:
+	case errCounterLimit:
+		http.Error(w, err.Error(), http.StatusConflict)
+		return
+	case errTooManyConflicts:
+		http.Error(w, err.Error(), http.StatusServiceUnavailable)
+		return
+	default:
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	fmt.Fprintf(w, "Value for key %s: %d\n", key, value)
+}
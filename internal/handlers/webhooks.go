@@ -0,0 +1,126 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"go-mysql/internal/models"
+	"go-mysql/internal/webhooks"
+	"go-mysql/pkg/middleware"
+)
+
+// RegisterWebhookRoutes adds the webhook management endpoints to g, which must only let
+// the tenant's administrators through, see server.RequireRole: webhooks receive every
+// user event of the tenant.
+func (a *App) RegisterWebhookRoutes(g *middleware.Group) {
+	g.HandleFunc("POST /webhooks", a.createWebhook)
+	g.HandleFunc("GET /webhooks", a.listWebhooks)
+	g.HandleFunc("DELETE /webhooks/{id}", a.deleteWebhook)
+	g.HandleFunc("GET /webhooks/{id}/deliveries", a.listWebhookDeliveries)
+	g.HandleFunc("POST /webhooks/deliveries/{id}/redeliver", a.redeliverWebhook)
+}
+
+// createWebhook registers {"url": "...", "events": [...], "secret": "..."} and
+// answers with the webhook, including its secret. Events defaults to every event and
+// the secret to a random one.
+func (a *App) createWebhook(w http.ResponseWriter, r *http.Request) {
+	var hook models.Webhook
+	err := json.NewDecoder(r.Body).Decode(&hook)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	hook, err = a.webhooks.Register(r.Context(), hook)
+	if errors.Is(err, webhooks.ErrInvalid) {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(hook)
+}
+
+func (a *App) listWebhooks(w http.ResponseWriter, r *http.Request) {
+	hooks, err := a.webhooks.List(r.Context())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(hooks)
+}
+
+func (a *App) deleteWebhook(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		http.Error(w, "Invalid webhook id", http.StatusBadRequest)
+		return
+	}
+
+	found, err := a.webhooks.Delete(r.Context(), id)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if !found {
+		http.Error(w, "Webhook not found", http.StatusNotFound)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// listWebhookDeliveries returns the latest deliveries to a webhook, newest first;
+// ?limit= sets how many, 50 by default and at most 500.
+func (a *App) listWebhookDeliveries(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		http.Error(w, "Invalid webhook id", http.StatusBadRequest)
+		return
+	}
+	limit := 50
+	if s := r.URL.Query().Get("limit"); s != "" {
+		limit, err = strconv.Atoi(s)
+		if err != nil || limit < 1 || limit > 500 {
+			http.Error(w, "Invalid limit", http.StatusBadRequest)
+			return
+		}
+	}
+
+	deliveries, err := a.webhooks.Deliveries(r.Context(), id, limit)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(deliveries)
+}
+
+// redeliverWebhook queues a logged delivery to be sent again.
+func (a *App) redeliverWebhook(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseInt(r.PathValue("id"), 10, 64)
+	if err != nil {
+		http.Error(w, "Invalid delivery id", http.StatusBadRequest)
+		return
+	}
+
+	found, err := a.webhooks.Redeliver(r.Context(), id)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if !found {
+		http.Error(w, "Delivery not found", http.StatusNotFound)
+		return
+	}
+	w.WriteHeader(http.StatusAccepted)
+}
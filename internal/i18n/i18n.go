@@ -0,0 +1,253 @@
+// Package i18n translates the messages of API errors, which are written in English,
+// into the languages clients ask for with Accept-Language.
+//
+// Translations live in locales/<language>.json, embedded in the binary, each mapping
+// English messages to their translation. Messages may have placeholders, {0}, {1} and
+// so on, standing for the values that vary, such as field names and limits, which are
+// copied into the translation as they are. Errors wrapping others read like "Invalid
+// user: missing username", so messages are translated one ": "-separated part at a
+// time, leaving parts no bundle has, such as database errors, in English.
+package i18n
+
+import (
+	"context"
+	"embed"
+	"encoding/json"
+	"fmt"
+	"path"
+	"regexp"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Source is the language messages are written in, which needs no bundle.
+const Source = "en"
+
+//go:embed locales/*.json
+var localeFiles embed.FS
+
+// placeholder matches the placeholders of messages.
+var placeholder = regexp.MustCompile(`\{(\d+)\}`)
+
+// Bundle holds the translations of every embedded language.
+type Bundle struct {
+	catalogs map[string]*catalog
+	// fallback is the language used when a client accepts none of the bundle's.
+	fallback string
+}
+
+// catalog holds the translations of one language.
+type catalog struct {
+	exact     map[string]string
+	templates []template
+}
+
+// template is a message with placeholders.
+type template struct {
+	pattern      *regexp.Regexp
+	placeholders []string
+	translation  string
+}
+
+// New returns the bundle of the embedded translations, falling back to the language
+// fallback for clients accepting none of them, which must be Source or one of them.
+func New(fallback string) (*Bundle, error) {
+	entries, err := localeFiles.ReadDir("locales")
+	if err != nil {
+		return nil, err
+	}
+	b := &Bundle{catalogs: make(map[string]*catalog, len(entries)), fallback: normalizeTag(fallback)}
+	for _, entry := range entries {
+		data, err := localeFiles.ReadFile("locales/" + entry.Name())
+		if err != nil {
+			return nil, err
+		}
+		var messages map[string]string
+		err = json.Unmarshal(data, &messages)
+		if err != nil {
+			return nil, fmt.Errorf("locale %s: %w", entry.Name(), err)
+		}
+		language := normalizeTag(strings.TrimSuffix(entry.Name(), path.Ext(entry.Name())))
+		b.catalogs[language], err = newCatalog(messages)
+		if err != nil {
+			return nil, fmt.Errorf("locale %s: %w", entry.Name(), err)
+		}
+	}
+	if _, ok := b.catalogs[b.fallback]; !ok && b.fallback != Source {
+		return nil, fmt.Errorf("no translations for fallback language %q", fallback)
+	}
+	return b, nil
+}
+
+func newCatalog(messages map[string]string) (*catalog, error) {
+	c := &catalog{exact: make(map[string]string)}
+	for message, translation := range messages {
+		if !placeholder.MatchString(message) {
+			c.exact[message] = translation
+			continue
+		}
+		t := template{translation: translation}
+		var pattern strings.Builder
+		pattern.WriteString("^")
+		last := 0
+		for _, m := range placeholder.FindAllStringSubmatchIndex(message, -1) {
+			pattern.WriteString(regexp.QuoteMeta(message[last:m[0]]))
+			pattern.WriteString("(.+?)")
+			t.placeholders = append(t.placeholders, message[m[0]:m[1]])
+			last = m[1]
+		}
+		pattern.WriteString(regexp.QuoteMeta(message[last:]))
+		pattern.WriteString("$")
+		t.pattern = regexp.MustCompile(pattern.String())
+		for _, p := range placeholder.FindAllString(translation, -1) {
+			if !slices.Contains(t.placeholders, p) {
+				return nil, fmt.Errorf("translation of %q has unknown placeholder %s", message, p)
+			}
+		}
+		c.templates = append(c.templates, t)
+	}
+	// Longer messages first, so "Invalid {0} parameter, expected YYYY-MM-DD" wins over
+	// "Invalid {0} parameter"
+	sort.Slice(c.templates, func(i, j int) bool {
+		return len(c.templates[i].pattern.String()) > len(c.templates[j].pattern.String())
+	})
+	return c, nil
+}
+
+// Languages returns the languages the bundle translates into, in order.
+func (b *Bundle) Languages() []string {
+	languages := make([]string, 0, len(b.catalogs))
+	for language := range b.catalogs {
+		languages = append(languages, language)
+	}
+	slices.Sort(languages)
+	return languages
+}
+
+// Translate returns message in the first of languages, language tags in order of
+// preference, the bundle has, or else in the fallback language, along with the
+// language it's in. Regional tags fall back to their language, so pt-BR is served
+// pt unless there's a pt-BR bundle. Parts no bundle has stay in English.
+func (b *Bundle) Translate(message string, languages []string) (string, string) {
+	language := b.Negotiate(languages)
+	c, ok := b.catalogs[language]
+	if !ok {
+		return message, Source
+	}
+	parts := strings.Split(message, ": ")
+	for i, part := range parts {
+		parts[i] = c.translate(part)
+	}
+	return strings.Join(parts, ": "), language
+}
+
+// Negotiate returns the first of languages, in order of preference, the bundle can
+// serve, trying regional tags without their region too, or else the fallback language.
+func (b *Bundle) Negotiate(languages []string) string {
+	for _, language := range languages {
+		for tag := normalizeTag(language); tag != ""; tag = parentTag(tag) {
+			if tag == Source {
+				return Source
+			}
+			if _, ok := b.catalogs[tag]; ok {
+				return tag
+			}
+		}
+	}
+	return b.fallback
+}
+
+func (c *catalog) translate(message string) string {
+	if translation, ok := c.exact[message]; ok {
+		return translation
+	}
+	for _, t := range c.templates {
+		m := t.pattern.FindStringSubmatch(message)
+		if m == nil {
+			continue
+		}
+		pairs := make([]string, 0, 2*len(t.placeholders))
+		for i, p := range t.placeholders {
+			pairs = append(pairs, p, m[i+1])
+		}
+		return strings.NewReplacer(pairs...).Replace(t.translation)
+	}
+	return message
+}
+
+// ParseAcceptLanguage returns the languages of an Accept-Language header, such as
+// "pt-BR, pt;q=0.9, en;q=0.5", most preferred first, leaving out those with q=0 and
+// the * wildcard, which the fallback language stands for.
+func ParseAcceptLanguage(header string) []string {
+	type accepted struct {
+		tag string
+		q   float64
+	}
+	var languages []accepted
+	for _, part := range strings.Split(header, ",") {
+		tag, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		q := 1.0
+		if v, ok := strings.CutPrefix(strings.TrimSpace(params), "q="); ok {
+			var err error
+			q, err = strconv.ParseFloat(v, 64)
+			if err != nil {
+				continue
+			}
+		}
+		tag = strings.TrimSpace(tag)
+		if tag == "" || tag == "*" || q <= 0 {
+			continue
+		}
+		languages = append(languages, accepted{tag, q})
+	}
+	sort.SliceStable(languages, func(i, j int) bool { return languages[i].q > languages[j].q })
+
+	tags := make([]string, len(languages))
+	for i, language := range languages {
+		tags[i] = language.tag
+	}
+	return tags
+}
+
+// normalizeTag returns a language tag lowercased, with dashes, so en_US and en-us
+// both come out en-us.
+func normalizeTag(tag string) string {
+	return strings.ToLower(strings.ReplaceAll(strings.TrimSpace(tag), "_", "-"))
+}
+
+// parentTag returns tag without its last subtag, or "" if it has one only.
+func parentTag(tag string) string {
+	i := strings.LastIndex(tag, "-")
+	if i < 0 {
+		return ""
+	}
+	return tag[:i]
+}
+
+type contextKey struct{}
+
+// localizer is what a request's context carries to translate messages with.
+type localizer struct {
+	bundle    *Bundle
+	languages []string
+}
+
+// WithLanguages returns a copy of ctx translating messages with bundle into the first
+// of languages it has, see T.
+func WithLanguages(ctx context.Context, bundle *Bundle, languages []string) context.Context {
+	return context.WithValue(ctx, contextKey{}, localizer{bundle: bundle, languages: languages})
+}
+
+// T translates message into the language of the request ctx belongs to, for messages
+// sent in response bodies, such as those of an import report. Messages are left as
+// they are outside of requests.
+func T(ctx context.Context, message string) string {
+	l, ok := ctx.Value(contextKey{}).(localizer)
+	if !ok {
+		return message
+	}
+	message, _ = l.bundle.Translate(message, l.languages)
+	return message
+}
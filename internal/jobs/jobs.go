@@ -0,0 +1,117 @@
+// Package jobs defines the service's durable background jobs and runs them on a
+// jobqueue.Queue in Redis.
+package jobs
+
+import (
+	"context"
+	"log/slog"
+
+	"github.com/go-redis/redis/v8"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
+
+	"go-mysql/internal/config"
+	"go-mysql/internal/events"
+	"go-mysql/internal/logging"
+	"go-mysql/internal/mailer"
+	"go-mysql/internal/models"
+	"go-mysql/internal/repository"
+	"go-mysql/internal/requestid"
+	"go-mysql/pkg/jobqueue"
+)
+
+// Job types.
+const (
+	TypeWelcomeEmail = "welcome_email"
+	TypeArchiveUsers = "archive_users"
+)
+
+// WelcomeEmail is the payload of a TypeWelcomeEmail job.
+type WelcomeEmail struct {
+	UserID   int    `json:"user_id"`
+	Username string `json:"username"`
+	Email    string `json:"email"`
+}
+
+// NewQueue returns the queue configured by cfg, keeping its jobs in rdb. A job is
+// processed with the request id and trace context it was enqueued with.
+func NewQueue(cfg config.Jobs, rdb redis.UniversalClient, logger *slog.Logger) *jobqueue.Queue {
+	maxRetries := cfg.MaxRetries
+	if maxRetries == 0 {
+		maxRetries = -1 // no retries; jobqueue treats 0 as its default
+	}
+	return jobqueue.NewQueue(rdb, jobqueue.Options{
+		Name:         cfg.Queue,
+		Workers:      cfg.Workers,
+		MaxRetries:   maxRetries,
+		RetryBackoff: cfg.RetryBackoff,
+		MaxBackoff:   cfg.MaxBackoff,
+		Timeout:      cfg.Timeout,
+		Inject:       injectContext,
+		Extract:      extractContext,
+	}, logger)
+}
+
+// injectContext records the request id and trace context of ctx in a job's headers.
+func injectContext(ctx context.Context, headers map[string]string) {
+	if id := requestid.From(ctx); id != "" {
+		headers[requestid.Header] = id
+	}
+	otel.GetTextMapPropagator().Inject(ctx, propagation.MapCarrier(headers))
+}
+
+// extractContext puts what injectContext recorded back into ctx, logging the request
+// id with every line.
+func extractContext(ctx context.Context, headers map[string]string) context.Context {
+	ctx = otel.GetTextMapPropagator().Extract(ctx, propagation.MapCarrier(headers))
+	if id := headers[requestid.Header]; requestid.Valid(id) {
+		ctx = requestid.With(ctx, id)
+		ctx = logging.WithLogger(ctx, logging.From(ctx).With("request_id", id))
+	}
+	return ctx
+}
+
+// Register installs the handler for every job type on q, with emails sent by m.
+// onArchived is called after an archive run that moved users, to drop them from caches.
+func Register(q *jobqueue.Queue, repo *repository.Repository, m *mailer.Mailer, onArchived func(ctx context.Context)) {
+	q.Handle(TypeWelcomeEmail, func(ctx context.Context, job jobqueue.Job) error {
+		return sendWelcomeEmail(ctx, job, m)
+	})
+	q.Handle(TypeArchiveUsers, func(ctx context.Context, job jobqueue.Job) error {
+		archived, err := repo.ArchiveInactiveUsers(ctx)
+		if err != nil {
+			return err
+		}
+		logging.From(ctx).Info("Archived inactive users", "count", archived, "job_id", job.ID)
+		if archived > 0 {
+			onArchived(ctx)
+		}
+		return nil
+	})
+}
+
+// sendWelcomeEmail sends the email and waits for the transport to accept it, so a
+// failed send is retried by the queue.
+func sendWelcomeEmail(ctx context.Context, job jobqueue.Job, m *mailer.Mailer) error {
+	var p WelcomeEmail
+	err := job.Decode(&p)
+	if err != nil {
+		return err
+	}
+	err = m.SendWelcome(ctx, models.User{ID: p.UserID, Username: p.Username, Email: p.Email})
+	if err != nil {
+		return err
+	}
+	logging.From(ctx).Info("Sent welcome email", "user_id", p.UserID, "username", p.Username)
+	return nil
+}
+
+// SubscribeNotifications enqueues the emails triggered by user events on bus.
+func SubscribeNotifications(bus *events.Bus, q *jobqueue.Queue) {
+	events.Subscribe(bus, func(ctx context.Context, e events.UserCreated) {
+		_, err := q.Enqueue(ctx, TypeWelcomeEmail, WelcomeEmail{UserID: e.User.ID, Username: e.User.Username, Email: e.User.Email})
+		if err != nil {
+			logging.From(ctx).Error("Failed to enqueue welcome email", "user_id", e.User.ID, "error", err)
+		}
+	})
+}
@@ -0,0 +1,42 @@
+// Package logging sets up the service logger and carries it through contexts.
+package logging
+
+import (
+	"context"
+	"log/slog"
+	"os"
+
+	"go-mysql/internal/config"
+)
+
+// Level is the minimum level logged. It can be changed at runtime, through a config
+// reload or the admin listener.
+var Level = new(slog.LevelVar)
+
+// New builds the service logger from cfg. "json" writes one JSON object per line
+// for log collectors; "console" writes key=value pairs that are easier to read locally.
+func New(cfg config.Log) *slog.Logger {
+	Level.Set(cfg.Level)
+	opts := &slog.HandlerOptions{Level: Level}
+	if cfg.Format == "json" {
+		return slog.New(slog.NewJSONHandler(os.Stderr, opts))
+	}
+	return slog.New(slog.NewTextHandler(os.Stderr, opts))
+}
+
+type loggerKey struct{}
+
+// WithLogger returns a copy of ctx carrying logger. Code below the HTTP handlers
+// and background jobs logs through From, so whoever sets up the context decides
+// which fields every line carries.
+func WithLogger(ctx context.Context, logger *slog.Logger) context.Context {
+	return context.WithValue(ctx, loggerKey{}, logger)
+}
+
+// From returns the logger carried by ctx, or the default logger if it has none.
+func From(ctx context.Context) *slog.Logger {
+	if logger, ok := ctx.Value(loggerKey{}).(*slog.Logger); ok {
+		return logger
+	}
+	return slog.Default()
+}
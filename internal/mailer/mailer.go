@@ -0,0 +1,206 @@
+// Package mailer renders the service's emails from templates and sends them through
+// a pluggable transport: SMTP, Amazon SES, or the log for development.
+//
+// Every email has a plain text template, templates/<name>.txt, which also defines its
+// "subject", and an HTML template, templates/<name>.html.
+package mailer
+
+import (
+	"bytes"
+	"context"
+	"embed"
+	"fmt"
+	htmltemplate "html/template"
+	"io/fs"
+	"log/slog"
+	"path"
+	"strings"
+	"text/template"
+	"time"
+
+	"go-mysql/internal/config"
+	"go-mysql/internal/logging"
+	"go-mysql/internal/models"
+	"go-mysql/pkg/retry"
+	"go-mysql/pkg/workerpool"
+)
+
+// Email templates.
+const (
+	TemplateWelcome       = "welcome"
+	TemplatePasswordReset = "password_reset"
+	TemplateVerifyEmail   = "verify_email"
+	TemplateAPIKeyCreated = "api_key_created"
+
+	TemplateEmailChangeRequested = "email_change_requested"
+	TemplateConfirmEmailChange   = "confirm_email_change"
+)
+
+//go:embed templates
+var templateFS embed.FS
+
+// Message is a rendered email.
+type Message struct {
+	To      string
+	Subject string
+	Text    string
+	HTML    string
+}
+
+// Transport delivers messages.
+type Transport interface {
+	Send(ctx context.Context, from string, msg Message) error
+}
+
+// NewTransport returns the transport selected by cfg.Transport.
+func NewTransport(ctx context.Context, cfg config.Mail, logger *slog.Logger) (Transport, error) {
+	switch cfg.Transport {
+	case "smtp":
+		return NewSMTPTransport(cfg.SMTPHost, cfg.SMTPPort, cfg.SMTPUsername, cfg.SMTPPassword), nil
+	case "ses":
+		return NewSESTransport(ctx, cfg.SESRegion)
+	}
+	return LogTransport{Logger: logger}, nil
+}
+
+// LogTransport logs every message instead of sending it, links included, which is
+// all a development setup needs.
+type LogTransport struct {
+	Logger *slog.Logger
+}
+
+func (t LogTransport) Send(_ context.Context, from string, msg Message) error {
+	t.Logger.Info("Email", "from", from, "to", msg.To, "subject", msg.Subject, "text", msg.Text)
+	return nil
+}
+
+// Data is what the templates are executed with.
+type Data struct {
+	User models.User
+	// Link is the action the email asks for, such as a password reset URL.
+	Link    string
+	BaseURL string
+	// Values holds what's particular to one template, such as "key_prefix".
+	Values map[string]string
+}
+
+// Mailer renders and sends emails.
+type Mailer struct {
+	cfg       config.Mail
+	transport Transport
+	pool      *workerpool.Pool
+	// text holds a template set per email, since each defines its own "subject".
+	text map[string]*template.Template
+	html *htmltemplate.Template
+}
+
+// New returns a Mailer sending through transport, with SendAsync handing sends to
+// pool. It panics if the embedded templates don't parse.
+func New(cfg config.Mail, transport Transport, pool *workerpool.Pool) *Mailer {
+	m := &Mailer{
+		cfg:       cfg,
+		transport: transport,
+		pool:      pool,
+		text:      make(map[string]*template.Template),
+		html:      htmltemplate.Must(htmltemplate.ParseFS(templateFS, "templates/*.html")),
+	}
+	paths, _ := fs.Glob(templateFS, "templates/*.txt")
+	for _, p := range paths {
+		name := strings.TrimSuffix(path.Base(p), ".txt")
+		m.text[name] = template.Must(template.ParseFS(templateFS, p))
+	}
+	return m
+}
+
+// Render executes the templates called name for an email to to.
+func (m *Mailer) Render(name, to string, data Data) (Message, error) {
+	if data.BaseURL == "" {
+		data.BaseURL = m.cfg.BaseURL
+	}
+	tmpl, ok := m.text[name]
+	if !ok {
+		return Message{}, fmt.Errorf("no email template %q", name)
+	}
+
+	var subject, text, html bytes.Buffer
+	err := tmpl.Execute(&text, data)
+	if err != nil {
+		return Message{}, err
+	}
+	err = tmpl.ExecuteTemplate(&subject, "subject", data)
+	if err != nil {
+		return Message{}, err
+	}
+	err = m.html.ExecuteTemplate(&html, name+".html", data)
+	if err != nil {
+		return Message{}, err
+	}
+	return Message{To: to, Subject: subject.String(), Text: text.String(), HTML: html.String()}, nil
+}
+
+// Send renders the email called name and sends it to to, returning once the transport
+// has accepted it. Callers that already run in the background, such as jobs that
+// retry on failure, use it.
+func (m *Mailer) Send(ctx context.Context, name, to string, data Data) error {
+	msg, err := m.Render(name, to, data)
+	if err != nil {
+		return err
+	}
+	return m.transport.Send(ctx, m.cfg.From, msg)
+}
+
+// asyncSendPolicy retries the sends made by SendAsync, which nothing else retries,
+// over about half a minute.
+var asyncSendPolicy = retry.Policy{MaxAttempts: 5, InitialDelay: 2 * time.Second, MaxDelay: 15 * time.Second, Jitter: 0.2}
+
+// SendAsync renders the email called name and hands it to the worker pool, so the
+// request asking for it doesn't wait on the mail server. Rendering errors and a full
+// pool are returned; a send is retried a few times, and only logged if it still fails.
+func (m *Mailer) SendAsync(ctx context.Context, name, to string, data Data) error {
+	msg, err := m.Render(name, to, data)
+	if err != nil {
+		return err
+	}
+	return m.pool.Submit(ctx, "send "+name+" email", func(ctx context.Context) error {
+		policy := asyncSendPolicy
+		policy.OnRetry = func(attempt int, err error, delay time.Duration) {
+			logging.From(ctx).Warn("Failed to send email, retrying", "template", name, "attempt", attempt, "retry_in", delay, "error", err)
+		}
+		return retry.Do(ctx, policy, func(ctx context.Context) error {
+			return m.transport.Send(ctx, m.cfg.From, msg)
+		})
+	})
+}
+
+// SendWelcome sends the welcome email to a new user.
+func (m *Mailer) SendWelcome(ctx context.Context, user models.User) error {
+	return m.Send(ctx, TemplateWelcome, user.Email, Data{User: user})
+}
+
+// SendPasswordReset sends user the link to reset their password.
+func (m *Mailer) SendPasswordReset(ctx context.Context, user models.User, link string) error {
+	return m.SendAsync(ctx, TemplatePasswordReset, user.Email, Data{User: user, Link: link})
+}
+
+// SendVerification sends user the link confirming their email address.
+func (m *Mailer) SendVerification(ctx context.Context, user models.User, link string) error {
+	return m.SendAsync(ctx, TemplateVerifyEmail, user.Email, Data{User: user, Link: link})
+}
+
+// SendAPIKeyCreated tells user an API key starting with keyPrefix was issued to them,
+// returning once the transport has accepted the email.
+func (m *Mailer) SendAPIKeyCreated(ctx context.Context, user models.User, keyPrefix string) error {
+	return m.Send(ctx, TemplateAPIKeyCreated, user.Email, Data{User: user, Values: map[string]string{"key_prefix": keyPrefix}})
+}
+
+// SendEmailChangeRequested tells user, at the address they still have, that a change
+// of their email to email awaits confirmation.
+func (m *Mailer) SendEmailChangeRequested(ctx context.Context, user models.User, email string) error {
+	return m.SendAsync(ctx, TemplateEmailChangeRequested, user.Email, Data{User: user, Values: map[string]string{"email": email}})
+}
+
+// SendEmailChangeConfirmation sends the link confirming the change of user's email to
+// email, to that new address.
+func (m *Mailer) SendEmailChangeConfirmation(ctx context.Context, user models.User, email, link string) error {
+	return m.SendAsync(ctx, TemplateConfirmEmailChange, email, Data{User: user, Link: link, Values: map[string]string{"email": email}})
+}
@@ -0,0 +1,43 @@
+package mailer
+
+import (
+	"context"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	awsconfig "github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/service/sesv2"
+	"github.com/aws/aws-sdk-go-v2/service/sesv2/types"
+)
+
+// SESTransport sends messages with the Amazon SES API.
+type SESTransport struct {
+	client *sesv2.Client
+}
+
+// NewSESTransport returns a transport for SES in region, with credentials from the
+// environment, shared config files or the instance role.
+func NewSESTransport(ctx context.Context, region string) (*SESTransport, error) {
+	cfg, err := awsconfig.LoadDefaultConfig(ctx, awsconfig.WithRegion(region))
+	if err != nil {
+		return nil, err
+	}
+	return &SESTransport{client: sesv2.NewFromConfig(cfg)}, nil
+}
+
+func (t *SESTransport) Send(ctx context.Context, from string, msg Message) error {
+	utf8 := aws.String("UTF-8")
+	_, err := t.client.SendEmail(ctx, &sesv2.SendEmailInput{
+		FromEmailAddress: aws.String(from),
+		Destination:      &types.Destination{ToAddresses: []string{msg.To}},
+		Content: &types.EmailContent{
+			Simple: &types.Message{
+				Subject: &types.Content{Data: aws.String(msg.Subject), Charset: utf8},
+				Body: &types.Body{
+					Text: &types.Content{Data: aws.String(msg.Text), Charset: utf8},
+					Html: &types.Content{Data: aws.String(msg.HTML), Charset: utf8},
+				},
+			},
+		},
+	})
+	return err
+}
@@ -0,0 +1,147 @@
+package mailer
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"mime"
+	"mime/multipart"
+	"net"
+	"net/mail"
+	"net/smtp"
+	"net/textproto"
+	"strconv"
+	"time"
+
+	"go-mysql/pkg/retry"
+)
+
+// SMTPTransport sends messages through an SMTP server, upgrading to TLS when the
+// server offers STARTTLS.
+type SMTPTransport struct {
+	addr string
+	auth smtp.Auth
+}
+
+// NewSMTPTransport returns a transport for the server at host:port, authenticating
+// with PLAIN auth unless username is empty.
+func NewSMTPTransport(host string, port int, username, password string) *SMTPTransport {
+	t := &SMTPTransport{addr: net.JoinHostPort(host, strconv.Itoa(port))}
+	if username != "" {
+		t.auth = smtp.PlainAuth("", username, password, host)
+	}
+	return t
+}
+
+// Send delivers msg as a multipart/alternative email. net/smtp takes no context, so
+// ctx isn't honoured once the connection is made.
+func (t *SMTPTransport) Send(ctx context.Context, from string, msg Message) error {
+	fromAddr, err := mail.ParseAddress(from)
+	if err != nil {
+		return retry.Permanent(fmt.Errorf("parsing sender: %w", err))
+	}
+	toAddr, err := mail.ParseAddress(msg.To)
+	if err != nil {
+		return retry.Permanent(fmt.Errorf("parsing recipient: %w", err))
+	}
+	body, err := buildMIME(from, msg)
+	if err != nil {
+		return err
+	}
+	err = t.send(ctx, fromAddr.Address, toAddr.Address, body)
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+	var protoErr *textproto.Error
+	if errors.As(err, &protoErr) && protoErr.Code >= 500 {
+		// A 5xx reply is the server refusing the message, not failing to take it
+		return retry.Permanent(err)
+	}
+	return err
+}
+
+// send is smtp.SendMail bounded by ctx: the connection is closed, failing whatever
+// the client is waiting for, once ctx is done.
+func (t *SMTPTransport) send(ctx context.Context, from, to string, body []byte) error {
+	var dialer net.Dialer
+	conn, err := dialer.DialContext(ctx, "tcp", t.addr)
+	if err != nil {
+		return err
+	}
+	stop := context.AfterFunc(ctx, func() { conn.Close() })
+	defer stop()
+
+	host, _, _ := net.SplitHostPort(t.addr)
+	c, err := smtp.NewClient(conn, host)
+	if err != nil {
+		conn.Close()
+		return err
+	}
+	defer c.Close()
+	if ok, _ := c.Extension("STARTTLS"); ok {
+		err = c.StartTLS(&tls.Config{ServerName: host})
+		if err != nil {
+			return err
+		}
+	}
+	if t.auth != nil {
+		err = c.Auth(t.auth)
+		if err != nil {
+			return err
+		}
+	}
+	err = c.Mail(from)
+	if err != nil {
+		return err
+	}
+	err = c.Rcpt(to)
+	if err != nil {
+		return err
+	}
+	w, err := c.Data()
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(body)
+	if err != nil {
+		return err
+	}
+	err = w.Close()
+	if err != nil {
+		return err
+	}
+	return c.Quit()
+}
+
+// buildMIME returns msg with its headers, the text and HTML bodies as alternatives.
+func buildMIME(from string, msg Message) ([]byte, error) {
+	var buf bytes.Buffer
+	mw := multipart.NewWriter(&buf)
+
+	header := fmt.Sprintf("From: %s\r\nTo: %s\r\nSubject: %s\r\nDate: %s\r\nMIME-Version: 1.0\r\nContent-Type: multipart/alternative; boundary=%q\r\n\r\n",
+		from, msg.To, mime.QEncoding.Encode("utf-8", msg.Subject), time.Now().Format(time.RFC1123Z), mw.Boundary())
+
+	for _, part := range []struct{ contentType, body string }{
+		{"text/plain; charset=utf-8", msg.Text},
+		{"text/html; charset=utf-8", msg.HTML},
+	} {
+		w, err := mw.CreatePart(textproto.MIMEHeader{
+			"Content-Type":              {part.contentType},
+			"Content-Transfer-Encoding": {"8bit"},
+		})
+		if err != nil {
+			return nil, err
+		}
+		_, err = w.Write([]byte(part.body))
+		if err != nil {
+			return nil, err
+		}
+	}
+	err := mw.Close()
+	if err != nil {
+		return nil, err
+	}
+	return append([]byte(header), buf.Bytes()...), nil
+}
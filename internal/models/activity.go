@@ -0,0 +1,21 @@
+package models
+
+import "time"
+
+// Activity types recorded in users' feeds.
+const (
+	ActivityProfileUpdated = "profile_updated"
+	ActivityGroupJoined    = "group_joined"
+	ActivityFollowed       = "followed"
+)
+
+// Activity is something a user did that shows up in its feed. Details depend on Type:
+// the fields that changed for profile_updated, the group_id, group_name and role for
+// group_joined, and the user_id and username of the user followed for followed.
+type Activity struct {
+	// ID orders activities; it's also the cursor of the page after this one.
+	ID        string            `json:"id"`
+	Type      string            `json:"type"`
+	Details   map[string]string `json:"details,omitempty"`
+	CreatedAt time.Time         `json:"created_at"`
+}
@@ -0,0 +1,70 @@
+package models
+
+import (
+	"slices"
+	"strconv"
+	"time"
+)
+
+// Roles a user can have. Admins can manage the other users of their tenant.
+const (
+	RoleUser  = "user"
+	RoleAdmin = "admin"
+)
+
+// Roles lists every role.
+var Roles = []string{RoleUser, RoleAdmin}
+
+// AdminUser is a user as administrators see it, with its role and the flags set on it.
+type AdminUser struct {
+	User
+	Role string `json:"role"`
+	// BannedAt is when the user was banned, nil unless it is.
+	BannedAt  *time.Time `json:"banned_at,omitempty"`
+	BanReason string     `json:"ban_reason,omitempty"`
+	// PasswordResetRequired is set when an administrator forced a password reset,
+	// until the user picks a new password.
+	PasswordResetRequired bool `json:"password_reset_required"`
+	// LastLoginAt is when the user started its latest visit, and LastSeenAt when it
+	// last made an authenticated request, to within LAST_SEEN_INTERVAL; nil if it
+	// never did.
+	LastLoginAt *time.Time `json:"last_login_at,omitempty"`
+	LastSeenAt  *time.Time `json:"last_seen_at,omitempty"`
+	CreatedAt   time.Time  `json:"created_at"`
+}
+
+// Principal is the user a request was authenticated as.
+type Principal struct {
+	UserID   int
+	TenantID int
+	Username string
+	Role     string
+	Status   string
+}
+
+// AdminUserFieldNames lists the fields of an AdminUser, by their JSON names: those of
+// UserFieldNames followed by the ones only administrators see.
+var AdminUserFieldNames = append(slices.Clip(UserFieldNames), "role", "banned_at", "ban_reason", "password_reset_required",
+	"last_login_at", "last_seen_at", "created_at")
+
+// AdminUserFieldMap returns the fields of AdminUserFieldNames of user as text, times
+// in RFC 3339 and unset ones empty.
+func AdminUserFieldMap(user AdminUser) map[string]string {
+	fields := UserFieldMap(user.User)
+	delete(fields, "avatar_key")
+	fields["role"] = user.Role
+	fields["banned_at"] = formatTime(user.BannedAt)
+	fields["ban_reason"] = user.BanReason
+	fields["password_reset_required"] = strconv.FormatBool(user.PasswordResetRequired)
+	fields["last_login_at"] = formatTime(user.LastLoginAt)
+	fields["last_seen_at"] = formatTime(user.LastSeenAt)
+	fields["created_at"] = formatTime(&user.CreatedAt)
+	return fields
+}
+
+func formatTime(t *time.Time) string {
+	if t == nil {
+		return ""
+	}
+	return t.UTC().Format(time.RFC3339)
+}
@@ -0,0 +1,13 @@
+package models
+
+import "time"
+
+// APIKey identifies a key issued to a user. The key itself is only shown when it's
+// issued; the database keeps its hash.
+type APIKey struct {
+	ID     int64 `json:"id"`
+	UserID int   `json:"user_id"`
+	// Prefix is the start of the key, enough to tell keys apart.
+	Prefix    string    `json:"prefix"`
+	CreatedAt time.Time `json:"created_at"`
+}
@@ -0,0 +1,105 @@
+package models
+
+import (
+	"regexp"
+	"strings"
+)
+
+// Country holds what validating phone numbers and addresses of a country takes.
+type Country struct {
+	// CallingCode is the country's code in international phone numbers, without the +.
+	CallingCode string
+	// TrunkPrefix is dialed before national numbers and dropped from international
+	// ones, if the country has one.
+	TrunkPrefix string
+	// MinDigits and MaxDigits bound the digits of phone numbers after the calling code.
+	MinDigits, MaxDigits int
+	// PostalCode matches the country's postal codes, as NormalizePostalCode leaves
+	// them. Addresses in countries with one must have a postal code.
+	PostalCode *regexp.Regexp
+	// Regions lists the codes of the states or provinces addresses in the country must
+	// name, if it has any.
+	Regions []string
+}
+
+// Countries are the countries whose phone numbers and addresses are checked in
+// detail, by ISO 3166-1 alpha-2 code. Users may live elsewhere, but must then give
+// their phone in E.164 form.
+var Countries = map[string]Country{
+	"AT": {CallingCode: "43", TrunkPrefix: "0", MinDigits: 4, MaxDigits: 13, PostalCode: regexp.MustCompile(`^\d{4}$`)},
+	"AU": {CallingCode: "61", TrunkPrefix: "0", MinDigits: 9, MaxDigits: 9, PostalCode: regexp.MustCompile(`^\d{4}$`),
+		Regions: []string{"ACT", "NSW", "NT", "QLD", "SA", "TAS", "VIC", "WA"}},
+	"BE": {CallingCode: "32", TrunkPrefix: "0", MinDigits: 8, MaxDigits: 9, PostalCode: regexp.MustCompile(`^\d{4}$`)},
+	"BR": {CallingCode: "55", TrunkPrefix: "0", MinDigits: 10, MaxDigits: 11, PostalCode: regexp.MustCompile(`^\d{5}-?\d{3}$`)},
+	"CA": {CallingCode: "1", TrunkPrefix: "1", MinDigits: 10, MaxDigits: 10, PostalCode: regexp.MustCompile(`^[A-Z]\d[A-Z] ?\d[A-Z]\d$`),
+		Regions: []string{"AB", "BC", "MB", "NB", "NL", "NS", "NT", "NU", "ON", "PE", "QC", "SK", "YT"}},
+	"CH": {CallingCode: "41", TrunkPrefix: "0", MinDigits: 9, MaxDigits: 9, PostalCode: regexp.MustCompile(`^\d{4}$`)},
+	"DE": {CallingCode: "49", TrunkPrefix: "0", MinDigits: 6, MaxDigits: 13, PostalCode: regexp.MustCompile(`^\d{5}$`)},
+	"DK": {CallingCode: "45", MinDigits: 8, MaxDigits: 8, PostalCode: regexp.MustCompile(`^\d{4}$`)},
+	"ES": {CallingCode: "34", MinDigits: 9, MaxDigits: 9, PostalCode: regexp.MustCompile(`^\d{5}$`)},
+	"FI": {CallingCode: "358", TrunkPrefix: "0", MinDigits: 5, MaxDigits: 12, PostalCode: regexp.MustCompile(`^\d{5}$`)},
+	"FR": {CallingCode: "33", TrunkPrefix: "0", MinDigits: 9, MaxDigits: 9, PostalCode: regexp.MustCompile(`^\d{5}$`)},
+	"GB": {CallingCode: "44", TrunkPrefix: "0", MinDigits: 9, MaxDigits: 10, PostalCode: regexp.MustCompile(`^[A-Z]{1,2}\d[A-Z\d]? ?\d[A-Z]{2}$`)},
+	// Irish addresses needn't have an Eircode
+	"IE": {CallingCode: "353", TrunkPrefix: "0", MinDigits: 7, MaxDigits: 9},
+	"IN": {CallingCode: "91", TrunkPrefix: "0", MinDigits: 10, MaxDigits: 10, PostalCode: regexp.MustCompile(`^\d{6}$`)},
+	// Italian numbers keep their leading 0 after the calling code
+	"IT": {CallingCode: "39", MinDigits: 6, MaxDigits: 11, PostalCode: regexp.MustCompile(`^\d{5}$`)},
+	"JP": {CallingCode: "81", TrunkPrefix: "0", MinDigits: 9, MaxDigits: 10, PostalCode: regexp.MustCompile(`^\d{3}-?\d{4}$`)},
+	"MX": {CallingCode: "52", MinDigits: 10, MaxDigits: 10, PostalCode: regexp.MustCompile(`^\d{5}$`)},
+	"NL": {CallingCode: "31", TrunkPrefix: "0", MinDigits: 9, MaxDigits: 9, PostalCode: regexp.MustCompile(`^\d{4} ?[A-Z]{2}$`)},
+	"NO": {CallingCode: "47", MinDigits: 8, MaxDigits: 8, PostalCode: regexp.MustCompile(`^\d{4}$`)},
+	"NZ": {CallingCode: "64", TrunkPrefix: "0", MinDigits: 8, MaxDigits: 10, PostalCode: regexp.MustCompile(`^\d{4}$`)},
+	"PL": {CallingCode: "48", MinDigits: 9, MaxDigits: 9, PostalCode: regexp.MustCompile(`^\d{2}-\d{3}$`)},
+	"PT": {CallingCode: "351", MinDigits: 9, MaxDigits: 9, PostalCode: regexp.MustCompile(`^\d{4}-\d{3}$`)},
+	"SE": {CallingCode: "46", TrunkPrefix: "0", MinDigits: 7, MaxDigits: 10, PostalCode: regexp.MustCompile(`^\d{3} ?\d{2}$`)},
+	"SG": {CallingCode: "65", MinDigits: 8, MaxDigits: 8, PostalCode: regexp.MustCompile(`^\d{6}$`)},
+	"US": {CallingCode: "1", TrunkPrefix: "1", MinDigits: 10, MaxDigits: 10, PostalCode: regexp.MustCompile(`^\d{5}(-\d{4})?$`),
+		Regions: []string{"AK", "AL", "AR", "AS", "AZ", "CA", "CO", "CT", "DC", "DE", "FL", "GA", "GU", "HI", "IA", "ID", "IL", "IN",
+			"KS", "KY", "LA", "MA", "MD", "ME", "MI", "MN", "MO", "MP", "MS", "MT", "NC", "ND", "NE", "NH", "NJ", "NM", "NV", "NY",
+			"OH", "OK", "OR", "PA", "PR", "RI", "SC", "SD", "TN", "TX", "UT", "VA", "VI", "VT", "WA", "WI", "WV", "WY"}},
+	"ZA": {CallingCode: "27", TrunkPrefix: "0", MinDigits: 9, MaxDigits: 9, PostalCode: regexp.MustCompile(`^\d{4}$`)},
+}
+
+// phoneFormatting are the characters people write phone numbers with besides digits.
+var phoneFormatting = strings.NewReplacer(" ", "", "-", "", ".", "", "(", "", ")", "", "/", "")
+
+// NormalizePhone returns phone the way it's stored, in E.164 form where it can tell:
+// without formatting, with 00 replaced by +, and national numbers of country, a code
+// of Countries, given its calling code. What it can't make sense of is only stripped
+// of formatting, for validation to reject.
+func NormalizePhone(phone, country string) string {
+	phone = phoneFormatting.Replace(strings.TrimSpace(phone))
+	if rest, ok := strings.CutPrefix(phone, "00"); ok {
+		return "+" + rest
+	}
+	c, ok := Countries[NormalizeCountry(country)]
+	if phone == "" || strings.HasPrefix(phone, "+") || !ok {
+		return phone
+	}
+	if c.TrunkPrefix != "" {
+		phone = strings.TrimPrefix(phone, c.TrunkPrefix)
+	}
+	return "+" + c.CallingCode + phone
+}
+
+// NormalizeCountry returns a country code the way it's stored: trimmed and uppercased.
+func NormalizeCountry(country string) string {
+	return strings.ToUpper(strings.TrimSpace(country))
+}
+
+// NormalizePostalCode returns a postal code the way it's stored: trimmed, uppercased,
+// with single spaces.
+func NormalizePostalCode(code string) string {
+	return strings.Join(strings.Fields(strings.ToUpper(code)), " ")
+}
+
+// NormalizeRegion returns a region the way it's stored: trimmed, and uppercased in
+// countries whose regions are codes.
+func NormalizeRegion(region, country string) string {
+	region = strings.TrimSpace(region)
+	if Countries[NormalizeCountry(country)].Regions != nil {
+		return strings.ToUpper(region)
+	}
+	return region
+}
@@ -0,0 +1,33 @@
+package models
+
+// DuplicateGroup is a set of users who look like the same person, for an administrator
+// to review, and the matches that linked them.
+type DuplicateGroup struct {
+	Users   []User           `json:"users"`
+	Matches []DuplicateMatch `json:"matches"`
+}
+
+// Fields of users a DuplicateMatch compares.
+const (
+	DuplicateUsername = "username"
+	DuplicateEmail    = "email"
+)
+
+// DuplicateMatch is a pair of users whose Field is alike. Similarity goes from 0 to 1,
+// where the normalized values are the same.
+type DuplicateMatch struct {
+	UserIDs    [2]int  `json:"user_ids"`
+	Field      string  `json:"field"`
+	Similarity float64 `json:"similarity"`
+}
+
+// UserMerge is the merge of a duplicate user into the user it duplicated, and how many
+// of its group memberships, follows and tags that user took over; those it already
+// had, and follows between the two, were dropped.
+type UserMerge struct {
+	UserID  int `json:"user_id"`
+	IntoID  int `json:"into_id"`
+	Groups  int `json:"groups"`
+	Follows int `json:"follows"`
+	Tags    int `json:"tags"`
+}
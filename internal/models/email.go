@@ -0,0 +1,38 @@
+package models
+
+import (
+	"strings"
+	"time"
+)
+
+// NormalizeEmail returns email the way it's stored: trimmed and lowercased, so
+// Foo@Bar.com and foo@bar.com are the same address.
+func NormalizeEmail(email string) string {
+	return strings.ToLower(strings.TrimSpace(email))
+}
+
+// gmailDomains are the domains of Gmail, which ignores dots in the local part.
+var gmailDomains = map[string]bool{"gmail.com": true, "googlemail.com": true}
+
+// CanonicalEmail returns the form of email no two users of a tenant may share: the
+// normalized email, with the dots of Gmail addresses dropped if ignoreGmailDots is
+// set, since f.oo@gmail.com and foo@gmail.com reach the same inbox.
+func CanonicalEmail(email string, ignoreGmailDots bool) string {
+	email = NormalizeEmail(email)
+	if !ignoreGmailDots {
+		return email
+	}
+	local, domain, ok := strings.Cut(email, "@")
+	if !ok || !gmailDomains[domain] {
+		return email
+	}
+	return strings.ReplaceAll(local, ".", "") + "@gmail.com"
+}
+
+// EmailChange is a change of a user's email waiting to be confirmed from the new
+// address, which it takes effect with.
+type EmailChange struct {
+	UserID    int       `json:"user_id"`
+	Email     string    `json:"email"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
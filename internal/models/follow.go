@@ -0,0 +1,17 @@
+package models
+
+import "time"
+
+// Follow is a user in a listing of another user's followers, or of the users it
+// follows, with when the follow started.
+type Follow struct {
+	UserID     int       `json:"user_id"`
+	Username   string    `json:"username"`
+	FollowedAt time.Time `json:"followed_at"`
+}
+
+// FollowCounts is how many users follow a user, and how many it follows.
+type FollowCounts struct {
+	Followers int `json:"followers"`
+	Following int `json:"following"`
+}
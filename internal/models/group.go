@@ -0,0 +1,33 @@
+package models
+
+import "time"
+
+// Roles a member can have in a group. When its owners are deleted, a group's oldest
+// member becomes its owner.
+const (
+	GroupRoleOwner  = "owner"
+	GroupRoleMember = "member"
+)
+
+// GroupRoles lists every group role.
+var GroupRoles = []string{GroupRoleOwner, GroupRoleMember}
+
+// Group is a team of users of the same tenant.
+type Group struct {
+	ID          int       `json:"id"`
+	Name        string    `json:"name"`
+	Description string    `json:"description,omitempty"`
+	MemberCount int       `json:"member_count"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+// Membership is a user belonging to a group. Listings of a group's members fill in
+// Username, listings of a user's groups GroupName.
+type Membership struct {
+	GroupID   int       `json:"group_id"`
+	GroupName string    `json:"group_name,omitempty"`
+	UserID    int       `json:"user_id"`
+	Username  string    `json:"username,omitempty"`
+	Role      string    `json:"role"`
+	JoinedAt  time.Time `json:"joined_at"`
+}
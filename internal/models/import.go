@@ -0,0 +1,39 @@
+package models
+
+// ImportRow is a user read from a line of an import file.
+type ImportRow struct {
+	Line int
+	User User
+}
+
+// Import error codes.
+const (
+	// ImportInvalid is a user that fails validation.
+	ImportInvalid = "invalid"
+	// ImportDuplicate is a username or email an earlier line of the file has.
+	ImportDuplicate = "duplicate"
+	// ImportTaken is a username or email another user has.
+	ImportTaken = "taken"
+)
+
+// ImportReport is what an import found in its file and, unless it's a dry run, did
+// with it.
+type ImportReport struct {
+	DryRun  bool `json:"dry_run"`
+	Rows    int  `json:"rows"`
+	Valid   int  `json:"valid"`
+	Invalid int  `json:"invalid"`
+	// Created is how many users the import created, none for a dry run.
+	Created int           `json:"created"`
+	Errors  []ImportError `json:"errors"`
+}
+
+// ImportError is why a line of an import file was rejected. Field is the field at
+// fault, if it's a single one.
+type ImportError struct {
+	Line     int    `json:"line"`
+	Username string `json:"username,omitempty"`
+	Field    string `json:"field,omitempty"`
+	Code     string `json:"code"`
+	Message  string `json:"message"`
+}
@@ -0,0 +1,22 @@
+package models
+
+// How a ReservedUsername matches usernames.
+const (
+	// ReservedExact matches the username that is its name, in any letter case.
+	ReservedExact = "exact"
+	// ReservedContains matches usernames containing its name, ignoring letter case and
+	// anything but letters and digits, which its name is made of.
+	ReservedContains = "contains"
+)
+
+// ReservedMatches lists every way a ReservedUsername can match.
+var ReservedMatches = []string{ReservedExact, ReservedContains}
+
+// ReservedUsername is a name new or renamed users can't have, such as admin.
+type ReservedUsername struct {
+	Name  string `json:"name"`
+	Match string `json:"match"`
+	// Builtin is set for the names reserved by configuration, which apply to every
+	// tenant and can't be removed through the API.
+	Builtin bool `json:"builtin,omitempty"`
+}
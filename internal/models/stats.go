@@ -0,0 +1,28 @@
+package models
+
+import "time"
+
+// UserStats sums up the users of a tenant.
+type UserStats struct {
+	Total int `json:"total"`
+	// Active is how many users were seen in the last ActiveDays days.
+	Active     int `json:"active"`
+	ActiveDays int `json:"active_days"`
+	// Signups has a day for each of the last days, oldest first, today included.
+	Signups     []DailyCount `json:"signups"`
+	GeneratedAt time.Time    `json:"generated_at"`
+}
+
+// SignupStats counts the signups of a tenant over a rolling window of days.
+type SignupStats struct {
+	Days  int `json:"days"`
+	Total int `json:"total"`
+	// Signups has a day for each of the last Days days, oldest first, today included.
+	Signups []DailyCount `json:"signups"`
+}
+
+// DailyCount is how many times something happened on a day, as YYYY-MM-DD in UTC.
+type DailyCount struct {
+	Date  string `json:"date"`
+	Count int    `json:"count"`
+}
@@ -0,0 +1,7 @@
+package models
+
+// TagCount is a tag and how many listed users have it.
+type TagCount struct {
+	Name  string `json:"name"`
+	Users int    `json:"users"`
+}
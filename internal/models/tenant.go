@@ -0,0 +1,12 @@
+package models
+
+import "time"
+
+// Tenant is a customer whose users are kept apart from every other tenant's.
+type Tenant struct {
+	ID int `json:"id"`
+	// Slug names the tenant in the X-Tenant header and subdomains.
+	Slug      string    `json:"slug"`
+	Name      string    `json:"name"`
+	CreatedAt time.Time `json:"created_at"`
+}
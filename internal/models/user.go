@@ -0,0 +1,196 @@
+// Package models holds the types shared by the repository, the cache and the handlers.
+package models
+
+import (
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// Statuses of a user account. Deactivated and banned users keep their data but can't
+// authenticate and are left out of listings; users deactivate themselves, while only
+// administrators ban and unban. Merged users duplicated another user, which took over
+// what they had; they're kept for the record, but can't be changed any more.
+const (
+	StatusActive      = "active"
+	StatusDeactivated = "deactivated"
+	StatusBanned      = "banned"
+	StatusMerged      = "merged"
+)
+
+// Statuses lists every status.
+var Statuses = []string{StatusActive, StatusDeactivated, StatusBanned, StatusMerged}
+
+type User struct {
+	ID       int    `json:"id"`
+	Username string `json:"username"`
+	Email    string `json:"email"`
+	Status   string `json:"status,omitempty"`
+
+	// The profile is optional; empty fields are left out of responses.
+	FirstName   string `json:"first_name,omitempty"`
+	LastName    string `json:"last_name,omitempty"`
+	DisplayName string `json:"display_name,omitempty"`
+	Bio         string `json:"bio,omitempty"`
+	AvatarURL   string `json:"avatar_url,omitempty"`
+
+	// Phone is in E.164 form, such as +14155550100. The address is made of the fields
+	// of AddressFieldNames, and is either complete or empty.
+	Phone        string `json:"phone,omitempty"`
+	AddressLine1 string `json:"address_line1,omitempty"`
+	AddressLine2 string `json:"address_line2,omitempty"`
+	City         string `json:"city,omitempty"`
+	Region       string `json:"region,omitempty"`
+	PostalCode   string `json:"postal_code,omitempty"`
+	Country      string `json:"country,omitempty"`
+
+	// AvatarKey locates an uploaded avatar in storage, which takes the place of
+	// AvatarURL. Responses carry signed URLs to it in AvatarURL and
+	// AvatarThumbnailURL instead, which aren't stored since they expire.
+	AvatarKey          string `json:"avatar_key,omitempty"`
+	AvatarThumbnailURL string `json:"avatar_thumbnail_url,omitempty"`
+}
+
+// UserFieldNames lists the fields of a User clients can select, by their JSON names,
+// which are also the names of their columns.
+var UserFieldNames = []string{"id", "username", "email", "status", "first_name", "last_name", "display_name", "bio", "avatar_url",
+	"phone", "address_line1", "address_line2", "city", "region", "postal_code", "country"}
+
+// ProfileFieldNames lists the profile fields of a User, which users can change
+// separately with a ProfileUpdate.
+var ProfileFieldNames = []string{"first_name", "last_name", "display_name", "bio", "avatar_url",
+	"phone", "address_line1", "address_line2", "city", "region", "postal_code", "country"}
+
+// AddressFieldNames lists the profile fields making up a User's address. The country
+// is an ISO 3166-1 alpha-2 code, such as US.
+var AddressFieldNames = []string{"address_line1", "address_line2", "city", "region", "postal_code", "country"}
+
+// UserFieldMap returns user's stored fields keyed by their JSON names: those of
+// UserFieldNames and avatar_key.
+func UserFieldMap(user User) map[string]string {
+	return map[string]string{
+		"id":            strconv.Itoa(user.ID),
+		"username":      user.Username,
+		"email":         user.Email,
+		"status":        user.Status,
+		"first_name":    user.FirstName,
+		"last_name":     user.LastName,
+		"display_name":  user.DisplayName,
+		"bio":           user.Bio,
+		"avatar_url":    user.AvatarURL,
+		"phone":         user.Phone,
+		"address_line1": user.AddressLine1,
+		"address_line2": user.AddressLine2,
+		"city":          user.City,
+		"region":        user.Region,
+		"postal_code":   user.PostalCode,
+		"country":       user.Country,
+		"avatar_key":    user.AvatarKey,
+	}
+}
+
+// UserFromFieldMap is the inverse of UserFieldMap. It fails if the id is missing.
+func UserFromFieldMap(fields map[string]string) (User, error) {
+	id, err := strconv.Atoi(fields["id"])
+	if err != nil {
+		return User{}, err
+	}
+	return User{
+		ID:           id,
+		Username:     fields["username"],
+		Email:        fields["email"],
+		Status:       fields["status"],
+		FirstName:    fields["first_name"],
+		LastName:     fields["last_name"],
+		DisplayName:  fields["display_name"],
+		Bio:          fields["bio"],
+		AvatarURL:    fields["avatar_url"],
+		Phone:        fields["phone"],
+		AddressLine1: fields["address_line1"],
+		AddressLine2: fields["address_line2"],
+		City:         fields["city"],
+		Region:       fields["region"],
+		PostalCode:   fields["postal_code"],
+		Country:      fields["country"],
+		AvatarKey:    fields["avatar_key"],
+	}, nil
+}
+
+// Listed reports whether user shows up in listings, which only active users do. Users
+// cached before statuses existed have none and are active.
+func (user User) Listed() bool {
+	return user.Status == StatusActive || user.Status == ""
+}
+
+// ProfileUpdate changes some profile fields of a user. Nil fields are left as they
+// are; an empty string clears the field. The address is replaced as a whole: setting
+// any of its fields clears those the update leaves nil.
+type ProfileUpdate struct {
+	FirstName    *string `json:"first_name"`
+	LastName     *string `json:"last_name"`
+	DisplayName  *string `json:"display_name"`
+	Bio          *string `json:"bio"`
+	AvatarURL    *string `json:"avatar_url"`
+	Phone        *string `json:"phone"`
+	AddressLine1 *string `json:"address_line1"`
+	AddressLine2 *string `json:"address_line2"`
+	City         *string `json:"city"`
+	Region       *string `json:"region"`
+	PostalCode   *string `json:"postal_code"`
+	Country      *string `json:"country"`
+}
+
+// Fields returns the fields the update sets, keyed by their JSON names.
+func (u ProfileUpdate) Fields() map[string]string {
+	fields := make(map[string]string)
+	for name, value := range map[string]*string{
+		"first_name":    u.FirstName,
+		"last_name":     u.LastName,
+		"display_name":  u.DisplayName,
+		"bio":           u.Bio,
+		"avatar_url":    u.AvatarURL,
+		"phone":         u.Phone,
+		"address_line1": u.AddressLine1,
+		"address_line2": u.AddressLine2,
+		"city":          u.City,
+		"region":        u.Region,
+		"postal_code":   u.PostalCode,
+		"country":       u.Country,
+	} {
+		if value != nil {
+			fields[name] = *value
+		}
+	}
+	address := slices.ContainsFunc(AddressFieldNames, func(name string) bool {
+		_, ok := fields[name]
+		return ok
+	})
+	for _, name := range AddressFieldNames {
+		if _, ok := fields[name]; address && !ok {
+			fields[name] = ""
+		}
+	}
+	return fields
+}
+
+// SelectUserFields returns only the named fields of user.
+func SelectUserFields(user User, fields []string) map[string]string {
+	all := UserFieldMap(user)
+	selected := make(map[string]string, len(fields))
+	for _, field := range fields {
+		selected[field] = all[field]
+	}
+	return selected
+}
+
+// ParseUserFields parses a comma-separated ?fields= list, rejecting unknown fields.
+func ParseUserFields(s string) ([]string, error) {
+	fields := strings.Split(s, ",")
+	for _, field := range fields {
+		if !slices.Contains(UserFieldNames, field) {
+			return nil, fmt.Errorf("Unknown field %q", field)
+		}
+	}
+	return fields, nil
+}
@@ -0,0 +1,10 @@
+package models
+
+import "time"
+
+// UsernameChange records a user's username changing from OldUsername to NewUsername.
+type UsernameChange struct {
+	OldUsername string    `json:"old_username"`
+	NewUsername string    `json:"new_username"`
+	ChangedAt   time.Time `json:"changed_at"`
+}
@@ -0,0 +1,236 @@
+// Package natsapi exposes the user endpoints over NATS request/reply, for internal
+// services that would rather not go through HTTP. Requests and replies are JSON;
+// every instance subscribes in the same queue group, so each request is handled once.
+//
+// Subjects, with the default "users" prefix:
+//
+//	users.list    {}                                  -> [user, ...]
+//	users.get     {"id": 1}                           -> user
+//	users.create  {"username": "...", "email": "..."} -> {"id": 1}
+//	users.update  {"username": "...", "email": "..."} -> {}
+//	users.delete  {"username": "..."}                 -> {}
+//
+// A request with an X-Tenant header acts on the users of the tenant with that slug;
+// without one, on the default tenant's.
+package natsapi
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+
+	"github.com/nats-io/nats.go"
+
+	"go-mysql/internal/config"
+	"go-mysql/internal/logging"
+	"go-mysql/internal/models"
+	"go-mysql/internal/requestid"
+	"go-mysql/internal/service"
+	"go-mysql/internal/tenant"
+)
+
+// reply wraps every response. Exactly one of Data and Error is set.
+type reply struct {
+	Data  any       `json:"data,omitempty"`
+	Error *replyErr `json:"error,omitempty"`
+}
+
+type replyErr struct {
+	// Code is "bad_request", "not_found", "conflict" or "internal".
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+// requestError is returned by handlers to reply with a specific code.
+type requestError struct {
+	code string
+	err  error
+}
+
+func (e requestError) Error() string { return e.err.Error() }
+
+func badRequest(msg string) error {
+	return requestError{code: "bad_request", err: errors.New(msg)}
+}
+
+const tenantHeader = "X-Tenant"
+
+// Server answers user requests on NATS.
+type Server struct {
+	cfg     config.NATS
+	users   *service.UserService
+	tenants *service.Tenants
+	logger  *slog.Logger
+}
+
+func NewServer(cfg config.NATS, users *service.UserService, tenants *service.Tenants, logger *slog.Logger) *Server {
+	return &Server{cfg: cfg, users: users, tenants: tenants, logger: logger}
+}
+
+// Run connects and serves until ctx is done, then drains the subscriptions so requests
+// in progress get their replies. The client keeps trying if NATS is down at startup
+// and reconnects on its own if the connection drops.
+func (s *Server) Run(ctx context.Context) error {
+	nc, err := nats.Connect(s.cfg.URL,
+		nats.Name("user-service"),
+		nats.MaxReconnects(-1),
+		nats.RetryOnFailedConnect(true),
+		nats.DisconnectErrHandler(func(_ *nats.Conn, err error) {
+			s.logger.Warn("Disconnected from NATS", "error", err)
+		}),
+		nats.ReconnectHandler(func(nc *nats.Conn) {
+			s.logger.Info("Reconnected to NATS", "url", nc.ConnectedUrl())
+		}),
+	)
+	if err != nil {
+		return err
+	}
+
+	handlers := map[string]func(ctx context.Context, data []byte) (any, error){
+		"list":   s.list,
+		"get":    s.get,
+		"create": s.create,
+		"update": s.update,
+		"delete": s.delete,
+	}
+	// Requests in progress when ctx is done are drained, not cancelled
+	serveCtx := context.WithoutCancel(ctx)
+	for name, h := range handlers {
+		subject := s.cfg.SubjectPrefix + "." + name
+		_, err := nc.QueueSubscribe(subject, s.cfg.QueueGroup, func(msg *nats.Msg) {
+			s.serve(serveCtx, msg, h)
+		})
+		if err != nil {
+			nc.Close()
+			return err
+		}
+	}
+	s.logger.Info("Serving users over NATS", "url", s.cfg.URL, "prefix", s.cfg.SubjectPrefix, "queue_group", s.cfg.QueueGroup)
+
+	<-ctx.Done()
+	closed := make(chan struct{})
+	nc.SetClosedHandler(func(*nats.Conn) { close(closed) })
+	err = nc.Drain()
+	if err != nil {
+		nc.Close()
+		return err
+	}
+	<-closed
+	return nil
+}
+
+// serve runs h for msg and sends the reply. The request runs with the values of ctx
+// and the request id in msg's X-Request-ID header, or a new one.
+func (s *Server) serve(ctx context.Context, msg *nats.Msg, h func(ctx context.Context, data []byte) (any, error)) {
+	id := msg.Header.Get(requestid.Header)
+	if !requestid.Valid(id) {
+		id = requestid.New()
+	}
+	logger := s.logger.With("request_id", id, "subject", msg.Subject)
+	ctx, cancel := context.WithTimeout(logging.WithLogger(requestid.With(ctx, id), logger), s.cfg.Timeout)
+	defer cancel()
+
+	var r reply
+	var data any
+	ctx, err := s.withTenant(ctx, msg)
+	if err == nil {
+		data, err = h(ctx, msg.Data)
+	}
+	if err == nil {
+		r.Data = data
+	} else {
+		var reqErr requestError
+		switch {
+		case errors.As(err, &reqErr):
+			r.Error = &replyErr{Code: reqErr.code, Message: reqErr.Error()}
+		case errors.Is(err, service.ErrInvalid):
+			r.Error = &replyErr{Code: "bad_request", Message: err.Error()}
+		case errors.Is(err, service.ErrNotFound), errors.Is(err, service.ErrTenantNotFound):
+			r.Error = &replyErr{Code: "not_found", Message: err.Error()}
+		case errors.Is(err, service.ErrUsernameTaken), errors.Is(err, service.ErrEmailTaken):
+			r.Error = &replyErr{Code: "conflict", Message: err.Error()}
+		default:
+			logger.Error("Failed to handle NATS request", "error", err)
+			r.Error = &replyErr{Code: "internal", Message: err.Error()}
+		}
+	}
+
+	body, err := json.Marshal(r)
+	if err != nil {
+		logger.Error("Failed to encode NATS reply", "error", err)
+		return
+	}
+	err = msg.Respond(body)
+	if err != nil && err != nats.ErrMsgNoReply {
+		logger.Error("Failed to send NATS reply", "error", err)
+	}
+}
+
+// withTenant returns ctx belonging to the tenant named by msg's X-Tenant header.
+func (s *Server) withTenant(ctx context.Context, msg *nats.Msg) (context.Context, error) {
+	slug := msg.Header.Get(tenantHeader)
+	if slug == "" {
+		return ctx, nil
+	}
+	t, err := s.tenants.BySlug(ctx, slug)
+	if err != nil {
+		return ctx, err
+	}
+	return tenant.WithID(ctx, t.ID), nil
+}
+
+func (s *Server) list(ctx context.Context, data []byte) (any, error) {
+	return s.users.List(ctx)
+}
+
+func (s *Server) get(ctx context.Context, data []byte) (any, error) {
+	var req struct {
+		ID int `json:"id"`
+	}
+	if json.Unmarshal(data, &req) != nil || req.ID <= 0 {
+		return nil, badRequest("Invalid user id")
+	}
+	return s.users.Get(ctx, req.ID)
+}
+
+func (s *Server) create(ctx context.Context, data []byte) (any, error) {
+	var user models.User
+	if json.Unmarshal(data, &user) != nil {
+		return nil, badRequest("Invalid user")
+	}
+	user, err := s.users.Create(ctx, user)
+	if err != nil {
+		return nil, err
+	}
+	return map[string]int{"id": user.ID}, nil
+}
+
+func (s *Server) update(ctx context.Context, data []byte) (any, error) {
+	var user models.User
+	if json.Unmarshal(data, &user) != nil {
+		return nil, badRequest("Invalid user")
+	}
+	change, pending, err := s.users.ChangeEmail(ctx, user.Username, user.Email)
+	if err != nil {
+		return nil, err
+	}
+	if !pending {
+		return struct{}{}, nil
+	}
+	return change, nil
+}
+
+func (s *Server) delete(ctx context.Context, data []byte) (any, error) {
+	var req struct {
+		Username string `json:"username"`
+	}
+	if json.Unmarshal(data, &req) != nil {
+		return nil, badRequest("Invalid request")
+	}
+	err := s.users.Delete(ctx, req.Username)
+	if err != nil {
+		return nil, err
+	}
+	return struct{}{}, nil
+}
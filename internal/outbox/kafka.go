@@ -0,0 +1,69 @@
+package outbox
+
+import (
+	"context"
+	"encoding/json"
+	"strconv"
+	"time"
+
+	"github.com/segmentio/kafka-go"
+
+	"go-mysql/internal/repository"
+)
+
+// KafkaPublisher produces events to a Kafka topic, keyed by user id. The hash balancer
+// maps a key to the same partition every time, so consumers see each user's events
+// in order.
+type KafkaPublisher struct {
+	writer *kafka.Writer
+}
+
+// kafkaMessage is the value of every produced message.
+type kafkaMessage struct {
+	EventID int64           `json:"event_id"`
+	Type    string          `json:"type"`
+	UserID  int             `json:"user_id"`
+	Payload json.RawMessage `json:"payload"`
+}
+
+func NewKafkaPublisher(brokers []string, topic string) *KafkaPublisher {
+	return &KafkaPublisher{writer: &kafka.Writer{
+		Addr:     kafka.TCP(brokers...),
+		Topic:    topic,
+		Balancer: &kafka.Hash{},
+		// Wait for every in-sync replica, so an accepted event survives a broker failure
+		RequiredAcks: kafka.RequireAll,
+		MaxAttempts:  3,
+		WriteTimeout: 10 * time.Second,
+		// The relay publishes one event at a time and waits for it; don't hold it back
+		// hoping to fill a batch
+		BatchSize: 1,
+	}}
+}
+
+// Publish returns once the brokers have acknowledged the event. On an error the relay
+// keeps the event in the outbox and tries again later.
+func (p *KafkaPublisher) Publish(ctx context.Context, event repository.OutboxEvent) error {
+	value, err := json.Marshal(kafkaMessage{
+		EventID: event.ID,
+		Type:    event.Type,
+		UserID:  event.UserID,
+		Payload: event.Payload,
+	})
+	if err != nil {
+		return err
+	}
+	return p.writer.WriteMessages(ctx, kafka.Message{
+		Key:   []byte(strconv.Itoa(event.UserID)),
+		Value: value,
+		Headers: []kafka.Header{
+			{Key: "event_type", Value: []byte(event.Type)},
+			{Key: "event_id", Value: []byte(strconv.FormatInt(event.ID, 10))},
+		},
+	})
+}
+
+// Close flushes and closes the connections to the brokers.
+func (p *KafkaPublisher) Close() error {
+	return p.writer.Close()
+}
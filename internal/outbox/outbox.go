@@ -0,0 +1,114 @@
+// Package outbox relays the user events recorded in MySQL's outbox table to a message
+// broker. Because events are written in the same transaction as the change they
+// describe, a committed change is never lost and a rolled back one is never
+// published, even if the broker is down when it happens: the relay simply catches up.
+package outbox
+
+import (
+	"context"
+	"io"
+	"strconv"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+
+	"go-mysql/internal/config"
+	"go-mysql/internal/logging"
+	"go-mysql/internal/repository"
+	"go-mysql/pkg/retry"
+)
+
+// Publisher delivers one event to a broker. It returns once the broker has accepted it.
+type Publisher interface {
+	Publish(ctx context.Context, event repository.OutboxEvent) error
+}
+
+// Relay moves events from the outbox to a Publisher.
+type Relay struct {
+	repo      *repository.Repository
+	publisher Publisher
+	interval  time.Duration
+	batchSize int
+}
+
+func NewRelay(cfg config.Outbox, repo *repository.Repository, publisher Publisher) *Relay {
+	return &Relay{repo: repo, publisher: publisher, interval: cfg.Interval, batchSize: cfg.BatchSize}
+}
+
+// NewPublisher returns the publisher selected by cfg.
+func NewPublisher(cfg config.Outbox, rdb redis.UniversalClient) Publisher {
+	if cfg.Publisher == "kafka" {
+		return NewKafkaPublisher(cfg.KafkaBrokers, cfg.KafkaTopic)
+	}
+	return NewRedisPublisher(rdb, cfg.Stream)
+}
+
+// Run publishes events until ctx is done, then closes the publisher if it's an
+// io.Closer. It drains the outbox batch by batch, then polls every interval. While the
+// broker is failing it backs off, up to a minute between attempts.
+//
+// A batch in progress when ctx is done is finished, unless drain is done first; then
+// its transaction rolls back and its events stay in the outbox for the next relay,
+// which may publish some of them twice.
+func (r *Relay) Run(ctx, drain context.Context) {
+	if c, ok := r.publisher.(io.Closer); ok {
+		defer c.Close()
+	}
+	backoff := retry.Policy{InitialDelay: r.interval, MaxDelay: time.Minute}.Backoff()
+	for {
+		var wait time.Duration
+		published, err := r.relayBatch(ctx, drain)
+		switch {
+		case err != nil && ctx.Err() == nil:
+			wait = backoff.Next()
+			logging.From(ctx).Error("Failed to relay outbox events", "published", published, "retry_in", wait, "error", err)
+		case published == r.batchSize:
+			// There may be more; don't wait
+			backoff.Reset()
+		default:
+			backoff.Reset()
+			wait = r.interval
+		}
+		if published > 0 {
+			logging.From(ctx).Debug("Relayed outbox events", "count", published)
+		}
+
+		if retry.Sleep(ctx, wait) != nil {
+			return
+		}
+	}
+}
+
+// relayBatch publishes one batch, cancelled when drain is done rather than ctx.
+func (r *Relay) relayBatch(ctx, drain context.Context) (int, error) {
+	batchCtx, cancel := context.WithCancel(context.WithoutCancel(ctx))
+	defer cancel()
+	stop := context.AfterFunc(drain, cancel)
+	defer stop()
+	return r.repo.RelayOutbox(batchCtx, r.batchSize, r.publisher.Publish)
+}
+
+// RedisPublisher appends events to a Redis stream.
+type RedisPublisher struct {
+	rdb    redis.UniversalClient
+	stream string
+}
+
+func NewRedisPublisher(rdb redis.UniversalClient, stream string) *RedisPublisher {
+	return &RedisPublisher{rdb: rdb, stream: stream}
+}
+
+func (p *RedisPublisher) Publish(ctx context.Context, event repository.OutboxEvent) error {
+	return p.rdb.XAdd(ctx, &redis.XAddArgs{
+		Stream: p.stream,
+		MaxLen: 100000,
+		Approx: true,
+		Values: map[string]any{
+			"event_id":  strconv.FormatInt(event.ID, 10),
+			"type":      event.Type,
+			"tenant_id": event.TenantID,
+			"user_id":   event.UserID,
+			"payload":   string(event.Payload),
+		},
+	}).Err()
+}
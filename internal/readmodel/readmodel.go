@@ -0,0 +1,481 @@
+// Package readmodel maintains a denormalized copy of the users in Redis, built for
+// reads: a hash per user, sorted indexes by username and creation time, and a
+// case-insensitive username index for autocompletion. It follows
+// the user events on the bus, so MySQL only has to take writes; every instance updates
+// the same keys with the changes it makes.
+//
+// Unlike the cache, nothing in the read model expires. Redis must not evict its keys,
+// or reads will silently miss users until it's rebuilt.
+package readmodel
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+
+	"go-mysql/internal/events"
+	"go-mysql/internal/logging"
+	"go-mysql/internal/models"
+	"go-mysql/internal/repository"
+	"go-mysql/internal/tenant"
+)
+
+const (
+	usersByUsernameKey   = "users:by_username"
+	usersByCreatedKey    = "users:by_created"
+	usersAutocompleteKey = "users:autocomplete"
+	// readyKey changes whenever a new index needs a rebuild to be filled, so read
+	// models built before it are rebuilt on startup.
+	readyKey       = "ready:2"
+	rebuildLockKey = "rebuild_lock"
+)
+
+// rebuildBatchSize is how many users a rebuild writes per round trip.
+const rebuildBatchSize = 500
+
+// Sort orders for List.
+const (
+	SortCreatedAt = "created_at"
+	SortUsername  = "username"
+)
+
+// ListOptions selects a page of users.
+type ListOptions struct {
+	// Sort is SortCreatedAt, the default, or SortUsername.
+	Sort string
+	Desc bool
+	// Offset skips users; Limit caps how many are returned, 0 meaning all of them.
+	Offset int
+	Limit  int
+	// After, if not 0, starts the page right after the user with that id, in place of
+	// Offset. Unlike an offset it isn't thrown off by users added or removed before it.
+	After int
+}
+
+// ErrCursorNotFound is returned by List when the user of ListOptions.After doesn't
+// exist, such as after it was deleted.
+var ErrCursorNotFound = errors.New("Invalid or expired cursor")
+
+// Model reads and writes the read model.
+type Model struct {
+	client redis.UniversalClient
+	prefix string
+}
+
+// New returns the read model kept in client under keys starting with prefix.
+func New(client redis.UniversalClient, prefix string) *Model {
+	return &Model{client: client, prefix: prefix}
+}
+
+// key returns the key called name of the tenant ctx belongs to. Each tenant has its
+// own read model, built and marked ready separately.
+func (m *Model) key(ctx context.Context, name string) string {
+	return m.prefix + tenant.Key(ctx, name)
+}
+
+func (m *Model) userKey(ctx context.Context, id string) string {
+	return m.key(ctx, "user:"+id)
+}
+
+// usernameMember is a user's member in the username index. All members score 0 so
+// they sort by username, which the id follows to keep members unique and findable.
+func usernameMember(username string, id int) string {
+	return username + "\x00" + strconv.Itoa(id)
+}
+
+// autocompleteMember is a user's member in the autocomplete index, which sorts by the
+// lowercased username. The username and id follow to keep members unique and
+// readable without the hash.
+func autocompleteMember(username string, id int) string {
+	return strings.ToLower(username) + "\x00" + username + "\x00" + strconv.Itoa(id)
+}
+
+// Ready reports whether the read model has been built and can serve reads.
+func (m *Model) Ready(ctx context.Context) bool {
+	n, err := m.client.Exists(ctx, m.key(ctx, readyKey)).Result()
+	return err == nil && n > 0
+}
+
+// User returns the user with the given id, or ok false if there's none.
+func (m *Model) User(ctx context.Context, id int) (user models.User, ok bool, err error) {
+	fields, err := m.client.HGetAll(ctx, m.userKey(ctx, strconv.Itoa(id))).Result()
+	if err != nil || len(fields) == 0 {
+		return models.User{}, false, err
+	}
+	user, err = models.UserFromFieldMap(fields)
+	return user, err == nil, err
+}
+
+// List returns a page of users and how many users there are in total.
+func (m *Model) List(ctx context.Context, opts ListOptions) ([]models.User, int, error) {
+	start := int64(opts.Offset)
+	if opts.After != 0 {
+		rank, err := m.rank(ctx, opts.After, opts.Sort, opts.Desc)
+		if err != nil {
+			return nil, 0, err
+		}
+		start = rank + 1
+	}
+	stop := int64(-1)
+	if opts.Limit > 0 {
+		stop = start + int64(opts.Limit) - 1
+	}
+	rangeArgs := redis.ZRangeArgs{Start: start, Stop: stop, Rev: opts.Desc}
+
+	var ids []string
+	var total int64
+	if opts.Sort == SortUsername {
+		rangeArgs.Key = m.key(ctx, usersByUsernameKey)
+		members, err := m.client.ZRangeArgs(ctx, rangeArgs).Result()
+		if err != nil {
+			return nil, 0, err
+		}
+		ids = idsOfMembers(members)
+		total, err = m.client.ZCard(ctx, m.key(ctx, usersByUsernameKey)).Result()
+		if err != nil {
+			return nil, 0, err
+		}
+	} else {
+		rangeArgs.Key = m.key(ctx, usersByCreatedKey)
+		var err error
+		ids, err = m.client.ZRangeArgs(ctx, rangeArgs).Result()
+		if err != nil {
+			return nil, 0, err
+		}
+		total, err = m.client.ZCard(ctx, m.key(ctx, usersByCreatedKey)).Result()
+		if err != nil {
+			return nil, 0, err
+		}
+	}
+
+	users, err := m.users(ctx, ids)
+	return users, int(total), err
+}
+
+// rank returns the position of the user with the given id in the order of sort.
+func (m *Model) rank(ctx context.Context, id int, sort string, desc bool) (int64, error) {
+	key, member := m.key(ctx, usersByCreatedKey), strconv.Itoa(id)
+	if sort == SortUsername {
+		username, err := m.client.HGet(ctx, m.userKey(ctx, member), "username").Result()
+		if err == redis.Nil {
+			return 0, ErrCursorNotFound
+		}
+		if err != nil {
+			return 0, err
+		}
+		key, member = m.key(ctx, usersByUsernameKey), usernameMember(username, id)
+	}
+
+	rankCmd := m.client.ZRank
+	if desc {
+		rankCmd = m.client.ZRevRank
+	}
+	rank, err := rankCmd(ctx, key, member).Result()
+	if err == redis.Nil {
+		return 0, ErrCursorNotFound
+	}
+	return rank, err
+}
+
+// Search returns up to limit users whose username starts with prefix, in username order.
+func (m *Model) Search(ctx context.Context, prefix string, limit int) ([]models.User, error) {
+	members, err := m.client.ZRangeByLex(ctx, m.key(ctx, usersByUsernameKey), &redis.ZRangeBy{
+		Min:   "[" + prefix,
+		Max:   "[" + prefix + "\xff",
+		Count: int64(limit),
+	}).Result()
+	if err != nil {
+		return nil, err
+	}
+	return m.users(ctx, idsOfMembers(members))
+}
+
+// Autocomplete returns up to limit users whose username starts with prefix in any
+// letter case, in the order of their lowercased usernames. It reads the one index, so
+// the users only have their ID and Username.
+func (m *Model) Autocomplete(ctx context.Context, prefix string, limit int) ([]models.User, error) {
+	prefix = strings.ToLower(prefix)
+	members, err := m.client.ZRangeByLex(ctx, m.key(ctx, usersAutocompleteKey), &redis.ZRangeBy{
+		Min:   "[" + prefix,
+		Max:   "[" + prefix + "\xff",
+		Count: int64(limit),
+	}).Result()
+	if err != nil {
+		return nil, err
+	}
+	users := make([]models.User, 0, len(members))
+	for _, member := range members {
+		parts := strings.Split(member, "\x00")
+		if len(parts) != 3 {
+			continue
+		}
+		id, err := strconv.Atoi(parts[2])
+		if err != nil {
+			continue
+		}
+		users = append(users, models.User{ID: id, Username: parts[1]})
+	}
+	return users, nil
+}
+
+func idsOfMembers(members []string) []string {
+	ids := make([]string, len(members))
+	for i, member := range members {
+		_, ids[i], _ = strings.Cut(member, "\x00")
+	}
+	return ids
+}
+
+// users reads the hashes of the users with the given ids, in order. Ids without a
+// hash, deleted between reading the index and the hashes, are skipped.
+func (m *Model) users(ctx context.Context, ids []string) ([]models.User, error) {
+	pipe := m.client.Pipeline()
+	cmds := make([]*redis.StringStringMapCmd, len(ids))
+	for i, id := range ids {
+		cmds[i] = pipe.HGetAll(ctx, m.userKey(ctx, id))
+	}
+	_, err := pipe.Exec(ctx)
+	if err != nil && err != redis.Nil {
+		return nil, err
+	}
+
+	users := make([]models.User, 0, len(ids))
+	for _, cmd := range cmds {
+		if len(cmd.Val()) == 0 {
+			continue
+		}
+		user, err := models.UserFromFieldMap(cmd.Val())
+		if err != nil {
+			return nil, err
+		}
+		users = append(users, user)
+	}
+	return users, nil
+}
+
+// queuePut writes user, created at createdAt, on pipe. Users that aren't listed, see
+// models.User.Listed, are kept out of the indexes listings and searches read.
+func (m *Model) queuePut(ctx context.Context, pipe redis.Pipeliner, user models.User, createdAt time.Time) {
+	id := strconv.Itoa(user.ID)
+	fields := models.UserFieldMap(user)
+	fields["created_at"] = createdAt.UTC().Format(time.RFC3339)
+	pipe.HSet(ctx, m.userKey(ctx, id), fields)
+	if !user.Listed() {
+		pipe.ZRem(ctx, m.key(ctx, usersByUsernameKey), usernameMember(user.Username, user.ID))
+		pipe.ZRem(ctx, m.key(ctx, usersByCreatedKey), id)
+		pipe.ZRem(ctx, m.key(ctx, usersAutocompleteKey), autocompleteMember(user.Username, user.ID))
+		return
+	}
+	pipe.ZAdd(ctx, m.key(ctx, usersByUsernameKey), &redis.Z{Member: usernameMember(user.Username, user.ID)})
+	pipe.ZAdd(ctx, m.key(ctx, usersAutocompleteKey), &redis.Z{Member: autocompleteMember(user.Username, user.ID)})
+	// NX keeps the original creation time when an existing user is written again
+	pipe.ZAddNX(ctx, m.key(ctx, usersByCreatedKey), &redis.Z{Score: float64(createdAt.UnixMilli()), Member: id})
+}
+
+// updateFieldsScript sets fields of an existing user's hash, leaving missing users
+// alone rather than creating a partial entry.
+var updateFieldsScript = redis.NewScript(`
+if redis.call("EXISTS", KEYS[1]) == 0 then
+	return 0
+end
+redis.call("HSET", KEYS[1], unpack(ARGV))
+return 1
+`)
+
+// Subscribe keeps the read model in step with the user events published on bus.
+// Failures are logged; the entry stays stale until the user changes again or the
+// read model is rebuilt.
+func (m *Model) Subscribe(bus *events.Bus) {
+	events.Subscribe(bus, func(ctx context.Context, e events.UserCreated) {
+		pipe := m.client.TxPipeline()
+		m.queuePut(ctx, pipe, e.User, time.Now())
+		_, err := pipe.Exec(ctx)
+		logUpdateError(ctx, e, err)
+	})
+	events.Subscribe(bus, func(ctx context.Context, e events.UserUpdated) {
+		if e.Fields == nil {
+			// A user listed again, such as a reactivated one, keeps its creation time
+			createdAt := time.Now()
+			if s, err := m.client.HGet(ctx, m.userKey(ctx, strconv.Itoa(e.User.ID)), "created_at").Result(); err == nil {
+				if t, err := time.Parse(time.RFC3339, s); err == nil {
+					createdAt = t
+				}
+			}
+			pipe := m.client.TxPipeline()
+			m.queuePut(ctx, pipe, e.User, createdAt)
+			_, err := pipe.Exec(ctx)
+			logUpdateError(ctx, e, err)
+			return
+		}
+		fields := models.SelectUserFields(e.User, e.Fields)
+		args := make([]any, 0, 2*len(fields))
+		for field, value := range fields {
+			args = append(args, field, value)
+		}
+		updated, err := updateFieldsScript.Run(ctx, m.client, []string{m.userKey(ctx, strconv.Itoa(e.User.ID))}, args...).Int()
+		if err == nil && updated == 1 && e.PreviousUsername != "" {
+			pipe := m.client.TxPipeline()
+			pipe.ZRem(ctx, m.key(ctx, usersByUsernameKey), usernameMember(e.PreviousUsername, e.User.ID))
+			pipe.ZAdd(ctx, m.key(ctx, usersByUsernameKey), &redis.Z{Member: usernameMember(e.User.Username, e.User.ID)})
+			pipe.ZRem(ctx, m.key(ctx, usersAutocompleteKey), autocompleteMember(e.PreviousUsername, e.User.ID))
+			pipe.ZAdd(ctx, m.key(ctx, usersAutocompleteKey), &redis.Z{Member: autocompleteMember(e.User.Username, e.User.ID)})
+			_, err = pipe.Exec(ctx)
+		}
+		logUpdateError(ctx, e, err)
+	})
+	events.Subscribe(bus, func(ctx context.Context, e events.UserDeleted) {
+		id := strconv.Itoa(e.ID)
+		pipe := m.client.TxPipeline()
+		pipe.Del(ctx, m.userKey(ctx, id))
+		pipe.ZRem(ctx, m.key(ctx, usersByUsernameKey), usernameMember(e.Username, e.ID))
+		pipe.ZRem(ctx, m.key(ctx, usersByCreatedKey), id)
+		pipe.ZRem(ctx, m.key(ctx, usersAutocompleteKey), autocompleteMember(e.Username, e.ID))
+		_, err := pipe.Exec(ctx)
+		logUpdateError(ctx, e, err)
+	})
+
+	// A new tenant has no users, so its read model is complete from the start
+	events.Subscribe(bus, func(ctx context.Context, e events.TenantCreated) {
+		ctx = tenant.WithID(ctx, e.Tenant.ID)
+		err := m.client.Set(ctx, m.key(ctx, readyKey), time.Now().UTC().Format(time.RFC3339), 0).Err()
+		logUpdateError(ctx, e, err)
+	})
+	events.Subscribe(bus, func(ctx context.Context, e events.TenantDeleted) {
+		ctx = tenant.WithID(ctx, e.ID)
+		err := m.client.Del(ctx, m.key(ctx, readyKey), m.key(ctx, usersByUsernameKey), m.key(ctx, usersByCreatedKey),
+			m.key(ctx, usersAutocompleteKey)).Err()
+		logUpdateError(ctx, e, err)
+	})
+}
+
+func logUpdateError(ctx context.Context, e events.Event, err error) {
+	if err != nil {
+		logging.From(ctx).Error("Failed to update the read model", "event", e.EventName(), "error", err)
+	}
+}
+
+// ErrRebuilding is returned by Rebuild when another instance holds the rebuild lock.
+var ErrRebuilding = errors.New("another instance is rebuilding the read model")
+
+// EnsureBuilt builds the read model of every tenant from repo, skipping the ones
+// that are ready or being built by another instance. Reads fall back to MySQL until
+// a tenant's is done.
+func (m *Model) EnsureBuilt(ctx context.Context, repo *repository.Repository) error {
+	return m.eachTenant(ctx, repo, func(ctx context.Context) error {
+		if m.Ready(ctx) {
+			return nil
+		}
+		err := m.Rebuild(ctx, repo)
+		if err == ErrRebuilding {
+			logging.From(ctx).Info("Read model is being built by another instance")
+			return nil
+		}
+		return err
+	})
+}
+
+// RebuildAll rebuilds the read model of every tenant, skipping the ones another
+// instance is rebuilding.
+func (m *Model) RebuildAll(ctx context.Context, repo *repository.Repository) error {
+	return m.eachTenant(ctx, repo, func(ctx context.Context) error {
+		err := m.Rebuild(ctx, repo)
+		if err == ErrRebuilding {
+			return nil
+		}
+		return err
+	})
+}
+
+// eachTenant calls fn with a context for every tenant in repo, going on past
+// failures, and returns their errors joined.
+func (m *Model) eachTenant(ctx context.Context, repo *repository.Repository, fn func(ctx context.Context) error) error {
+	tenants, err := repo.Tenants(ctx)
+	if err != nil {
+		return err
+	}
+	var errs []error
+	for _, t := range tenants {
+		err := fn(tenant.WithID(ctx, t.ID))
+		if err != nil {
+			errs = append(errs, fmt.Errorf("tenant %s: %w", t.Slug, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// Rebuild writes every user of the tenant ctx belongs to from repo into its read
+// model, removes the users it no longer has and marks it ready. Users changed while it runs are written
+// by their events as well, so they end up current; one deleted between being read and
+// written can linger until the next rebuild.
+func (m *Model) Rebuild(ctx context.Context, repo *repository.Repository) error {
+	acquired, err := m.client.SetNX(ctx, m.key(ctx, rebuildLockKey), 1, 10*time.Minute).Result()
+	if err != nil {
+		return err
+	}
+	if !acquired {
+		return ErrRebuilding
+	}
+	defer m.client.Del(context.WithoutCancel(ctx), m.key(ctx, rebuildLockKey))
+
+	start := time.Now()
+	seen := make(map[string]bool)
+	pipe := m.client.Pipeline()
+	err = repo.EachUser(ctx, func(user models.User, createdAt time.Time) error {
+		m.queuePut(ctx, pipe, user, createdAt)
+		seen[strconv.Itoa(user.ID)] = true
+		if len(seen)%rebuildBatchSize == 0 {
+			_, err := pipe.Exec(ctx)
+			return err
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	_, err = pipe.Exec(ctx)
+	if err != nil {
+		return err
+	}
+	removed, err := m.removeUnseen(ctx, seen)
+	if err != nil {
+		return err
+	}
+
+	err = m.client.Set(ctx, m.key(ctx, readyKey), time.Now().UTC().Format(time.RFC3339), 0).Err()
+	if err != nil {
+		return err
+	}
+	logging.From(ctx).Info("Built the read model", "tenant_id", tenant.ID(ctx), "users", len(seen), "removed", removed, "duration", time.Since(start))
+	return nil
+}
+
+// removeUnseen deletes the users not in seen, such as archived ones, which leave
+// MySQL without an event.
+func (m *Model) removeUnseen(ctx context.Context, seen map[string]bool) (int, error) {
+	members, err := m.client.ZRange(ctx, m.key(ctx, usersByUsernameKey), 0, -1).Result()
+	if err != nil {
+		return 0, err
+	}
+	removed := 0
+	pipe := m.client.Pipeline()
+	for _, member := range members {
+		username, id, _ := strings.Cut(member, "\x00")
+		if seen[id] {
+			continue
+		}
+		pipe.Del(ctx, m.userKey(ctx, id))
+		pipe.ZRem(ctx, m.key(ctx, usersByUsernameKey), member)
+		pipe.ZRem(ctx, m.key(ctx, usersByCreatedKey), id)
+		if n, err := strconv.Atoi(id); err == nil {
+			pipe.ZRem(ctx, m.key(ctx, usersAutocompleteKey), autocompleteMember(username, n))
+		}
+		removed++
+	}
+	_, err = pipe.Exec(ctx)
+	return removed, err
+}
@@ -0,0 +1,200 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"go-mysql/internal/models"
+	"go-mysql/internal/tenant"
+)
+
+// AdminUserFilter selects a page of users for administrators.
+type AdminUserFilter struct {
+	// Role, if set, only selects users with that role.
+	Role string
+	// Status, if set, only selects users with that status.
+	Status string
+	// Banned, if set, only selects users that are, or aren't, banned.
+	Banned *bool
+	// InactiveSince, if set, only selects users not seen since then, counting users
+	// never seen as seen when they were created.
+	InactiveSince time.Time
+	// Offset skips users; Limit caps how many are returned, 0 meaning all of them.
+	Offset int
+	Limit  int
+}
+
+// adminUserColumns are the columns scanAdminUser reads.
+const adminUserColumns = userColumns + ", role, banned_at, ban_reason, password_reset_required, last_login_at, last_seen_at, created_at"
+
+// AdminUsers returns the users of the tenant ctx belongs to matching filter, in id
+// order, and how many match in total.
+func (r *Repository) AdminUsers(ctx context.Context, filter AdminUserFilter) ([]models.AdminUser, int, error) {
+	where, args := adminUserWhere(ctx, filter)
+	users := []models.AdminUser{}
+	total := 0
+	err := r.eachAdminUser(ctx, where, args, filter, &total, func(u models.AdminUser) error {
+		users = append(users, u)
+		return nil
+	})
+	if err != nil {
+		return nil, 0, err
+	}
+	total, err = r.pageTotal(ctx, total, len(users), filter.Offset, "SELECT COUNT(*) FROM users"+where, args...)
+	return users, total, err
+}
+
+// EachAdminUser calls fn with each user of the tenant ctx belongs to matching filter,
+// in id order, as they're read, stopping at the first error. Unlike AdminUsers it
+// doesn't hold the users in memory.
+func (r *Repository) EachAdminUser(ctx context.Context, filter AdminUserFilter, fn func(models.AdminUser) error) error {
+	where, args := adminUserWhere(ctx, filter)
+	return r.eachAdminUser(ctx, where, args, filter, nil, fn)
+}
+
+// adminUserWhere returns the WHERE clause selecting the users matching filter, and its
+// arguments.
+func adminUserWhere(ctx context.Context, filter AdminUserFilter) (string, []any) {
+	where := " WHERE tenant_id = ?"
+	args := []any{tenant.ID(ctx)}
+	if filter.Role != "" {
+		where += " AND role = ?"
+		args = append(args, filter.Role)
+	}
+	if filter.Status != "" {
+		where += " AND status = ?"
+		args = append(args, filter.Status)
+	}
+	if filter.Banned != nil {
+		if *filter.Banned {
+			where += " AND banned_at IS NOT NULL"
+		} else {
+			where += " AND banned_at IS NULL"
+		}
+	}
+	if !filter.InactiveSince.IsZero() {
+		where += " AND COALESCE(last_seen_at, created_at) < ?"
+		args = append(args, filter.InactiveSince)
+	}
+	return where, args
+}
+
+// eachAdminUser calls fn with the page of filter of the users matching where. Unless
+// total is nil, it's set to how many match in total, from the totalColumn.
+func (r *Repository) eachAdminUser(ctx context.Context, where string, args []any, filter AdminUserFilter, total *int, fn func(models.AdminUser) error) error {
+	columns := adminUserColumns
+	var extra []any
+	if total != nil {
+		columns += totalColumn
+		extra = append(extra, total)
+	}
+	query := "SELECT " + columns + " FROM users" + where + " ORDER BY id"
+	if filter.Limit > 0 {
+		query += " LIMIT ? OFFSET ?"
+		args = append(args, filter.Limit, filter.Offset)
+	}
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var u models.AdminUser
+		var bannedAt, lastLoginAt, lastSeenAt sql.NullTime
+		u.User, err = scanUser(rows, append([]any{&u.Role, &bannedAt, &u.BanReason, &u.PasswordResetRequired, &lastLoginAt, &lastSeenAt, &u.CreatedAt}, extra...)...)
+		if err != nil {
+			return err
+		}
+		u.BannedAt = nullTime(bannedAt)
+		u.LastLoginAt = nullTime(lastLoginAt)
+		u.LastSeenAt = nullTime(lastSeenAt)
+		err = fn(u)
+		if err != nil {
+			return err
+		}
+	}
+	return rows.Err()
+}
+
+// SetUserRole sets the role of the user with the given id, or returns sql.ErrNoRows.
+func (r *Repository) SetUserRole(ctx context.Context, id int, role string) error {
+	return r.updateUser(ctx, id, "role = ?", role)
+}
+
+// BanUser bans the user with the given id for reason, or returns sql.ErrNoRows. Banning
+// a banned user only changes the reason.
+func (r *Repository) BanUser(ctx context.Context, id int, reason string) error {
+	return r.updateUser(ctx, id, "status = ?, banned_at = COALESCE(banned_at, ?), ban_reason = ?", models.StatusBanned, time.Now(), reason)
+}
+
+// UnbanUser lifts the ban of the user with the given id, if any, which makes it active
+// again, or returns sql.ErrNoRows.
+func (r *Repository) UnbanUser(ctx context.Context, id int) error {
+	return r.updateUser(ctx, id, "status = IF(status = ?, ?, status), banned_at = NULL, ban_reason = ''",
+		models.StatusBanned, models.StatusActive)
+}
+
+// RequirePasswordReset flags the user with the given id as having to reset its
+// password and returns it, or sql.ErrNoRows.
+func (r *Repository) RequirePasswordReset(ctx context.Context, id int) (models.User, error) {
+	err := r.updateUser(ctx, id, "password_reset_required = TRUE")
+	if err != nil {
+		return models.User{}, err
+	}
+	return r.UserByID(ctx, id)
+}
+
+// updateUser sets columns of the user with the given id, with set like "role = ?" and
+// args its arguments. It returns sql.ErrNoRows if there's no such user, which MySQL
+// can't tell from an update that changed nothing without looking. Merged users are left
+// alone, as if they didn't exist.
+func (r *Repository) updateUser(ctx context.Context, id int, set string, args ...any) error {
+	res, err := r.db.ExecContext(ctx, "UPDATE users SET "+set+" WHERE tenant_id = ? AND id = ? AND status <> ?",
+		append(args, tenant.ID(ctx), id, models.StatusMerged)...)
+	if err != nil {
+		return err
+	}
+	affected, err := res.RowsAffected()
+	if err != nil || affected > 0 {
+		return err
+	}
+	var exists bool
+	err = r.db.QueryRowContext(ctx, "SELECT EXISTS (SELECT 1 FROM users WHERE tenant_id = ? AND id = ? AND status <> ?)",
+		tenant.ID(ctx), id, models.StatusMerged).Scan(&exists)
+	if err == nil && !exists {
+		err = sql.ErrNoRows
+	}
+	return err
+}
+
+// TouchUser records that the user with the given id was just seen. If it hadn't been
+// for loginIdle, this starts a new visit, which counts as logging in.
+func (r *Repository) TouchUser(ctx context.Context, id int, loginIdle time.Duration) error {
+	now := time.Now()
+	// MySQL assigns left to right, so last_login_at sees the previous last_seen_at.
+	// Keeping updated_at keeps the archiver from taking a visit for a change
+	_, err := r.db.ExecContext(ctx, `UPDATE users SET
+			last_login_at = IF(last_seen_at IS NULL OR last_seen_at < ?, ?, last_login_at),
+			last_seen_at = ?, updated_at = updated_at
+		WHERE tenant_id = ? AND id = ?`, now.Add(-loginIdle), now, now, tenant.ID(ctx), id)
+	return err
+}
+
+func nullTime(t sql.NullTime) *time.Time {
+	if !t.Valid {
+		return nil
+	}
+	return &t.Time
+}
+
+// PrincipalByAPIKey returns who a key was issued to, or sql.ErrNoRows if no key has
+// that hash. Unlike TenantByAPIKey it finds banned and deactivated users, so callers
+// can tell them apart.
+func (r *Repository) PrincipalByAPIKey(ctx context.Context, keyHash string) (models.Principal, error) {
+	var p models.Principal
+	err := r.db.QueryRowContext(ctx, `SELECT u.id, u.tenant_id, u.username, u.role, u.status FROM api_keys k
+		JOIN users u ON u.id = k.user_id WHERE k.key_hash = ?`, keyHash).Scan(&p.UserID, &p.TenantID, &p.Username, &p.Role, &p.Status)
+	return p, err
+}
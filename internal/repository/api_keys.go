@@ -0,0 +1,23 @@
+package repository
+
+import "context"
+
+// CreateAPIKey stores the hash of a key issued to a user and returns the key's id.
+func (r *Repository) CreateAPIKey(ctx context.Context, userID int, prefix, keyHash string) (int64, error) {
+	res, err := r.db.ExecContext(ctx, "INSERT INTO api_keys (user_id, prefix, key_hash) VALUES (?, ?, ?)",
+		userID, prefix, keyHash)
+	if err != nil {
+		return 0, err
+	}
+	return res.LastInsertId()
+}
+
+// DeleteAPIKey revokes a key, reporting whether it existed.
+func (r *Repository) DeleteAPIKey(ctx context.Context, id int64) (bool, error) {
+	res, err := r.db.ExecContext(ctx, "DELETE FROM api_keys WHERE id = ?", id)
+	if err != nil {
+		return false, err
+	}
+	affected, err := res.RowsAffected()
+	return affected > 0, err
+}
@@ -0,0 +1,118 @@
+package repository
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"go-mysql/internal/config"
+	"go-mysql/internal/logging"
+)
+
+// RunArchiver periodically moves users that haven't been updated for ARCHIVE_INACTIVE_AFTER
+// into users_archive, until ctx is done; a run in progress then is finished first. It
+// returns right away unless ARCHIVE_INACTIVE_AFTER is set. onArchived is called after
+// every run that moved users, to drop them from caches.
+func (r *Repository) RunArchiver(ctx context.Context, onArchived func()) {
+	inactiveAfter := config.EnvDuration("ARCHIVE_INACTIVE_AFTER", 0)
+	if inactiveAfter <= 0 {
+		return
+	}
+	interval := config.EnvDuration("ARCHIVE_INTERVAL", time.Hour)
+	batchSize := config.EnvInt("ARCHIVE_BATCH_SIZE", 500)
+	logging.From(ctx).Info("Archiving inactive users", "inactive_after", inactiveAfter, "interval", interval)
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		// A run in progress when ctx is done is finished, not cancelled
+		archived, err := r.archiveInactiveUsers(context.WithoutCancel(ctx), time.Now().Add(-inactiveAfter), batchSize)
+		if err != nil {
+			logging.From(ctx).Error("Failed to archive users", "error", err)
+		}
+		if archived > 0 {
+			logging.From(ctx).Info("Archived inactive users", "count", archived)
+			onArchived()
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// ArchiveInactiveUsers does one archiver run outside the schedule and returns how many
+// users it moved. Like RunArchiver, it does nothing unless ARCHIVE_INACTIVE_AFTER is set.
+func (r *Repository) ArchiveInactiveUsers(ctx context.Context) (int, error) {
+	inactiveAfter := config.EnvDuration("ARCHIVE_INACTIVE_AFTER", 0)
+	if inactiveAfter <= 0 {
+		return 0, nil
+	}
+	return r.archiveInactiveUsers(ctx, time.Now().Add(-inactiveAfter), config.EnvInt("ARCHIVE_BATCH_SIZE", 500))
+}
+
+// archiveInactiveUsers moves users last updated before cutoff into users_archive,
+// batchSize rows per transaction, and returns how many were moved. It covers every
+// tenant.
+func (r *Repository) archiveInactiveUsers(ctx context.Context, cutoff time.Time, batchSize int) (int, error) {
+	total := 0
+	for {
+		n, err := r.archiveBatch(ctx, cutoff, batchSize)
+		total += n
+		if err != nil || n < batchSize {
+			return total, err
+		}
+	}
+}
+
+// archivedColumns are the columns of users copied to users_archive.
+const archivedColumns = "id, tenant_id, username, email, status, first_name, last_name, display_name, bio, avatar_url, avatar_key, phone, address_line1, address_line2, city, region, postal_code, country, merged_into, role, banned_at, ban_reason, password_reset_required, last_login_at, last_seen_at, deactivated_at, created_at, updated_at"
+
+func (r *Repository) archiveBatch(ctx context.Context, cutoff time.Time, batchSize int) (int, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	rows, err := tx.QueryContext(ctx, "SELECT id FROM users WHERE updated_at < ? ORDER BY id LIMIT ? FOR UPDATE", cutoff, batchSize)
+	if err != nil {
+		return 0, err
+	}
+	var ids []any
+	for rows.Next() {
+		var id int
+		err := rows.Scan(&id)
+		if err != nil {
+			rows.Close()
+			return 0, err
+		}
+		ids = append(ids, id)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return 0, err
+	}
+	if len(ids) == 0 {
+		return 0, nil
+	}
+
+	in := strings.TrimSuffix(strings.Repeat("?,", len(ids)), ",")
+	_, err = tx.ExecContext(ctx, `INSERT INTO users_archive (`+archivedColumns+`)
+		SELECT `+archivedColumns+` FROM users WHERE id IN (`+in+`)`, ids...)
+	if err != nil {
+		return 0, err
+	}
+	err = leaveGroups(ctx, tx, ids...)
+	if err != nil {
+		return 0, err
+	}
+	_, err = tx.ExecContext(ctx, "DELETE FROM users WHERE id IN ("+in+")", ids...)
+	if err != nil {
+		return 0, err
+	}
+
+	return len(ids), tx.Commit()
+}
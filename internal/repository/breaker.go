@@ -0,0 +1,133 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+
+	"github.com/go-sql-driver/mysql"
+
+	"go-mysql/pkg/breaker"
+)
+
+// IsMySQLFailure reports whether err means MySQL couldn't be reached or didn't answer,
+// for the breaker passed to New. Callers giving up on a query, or running out of time
+// for it, say nothing about MySQL.
+func IsMySQLFailure(err error) bool {
+	var mysqlErr *mysql.MySQLError
+	return !errors.As(err, &mysqlErr) && !errors.Is(err, context.Canceled) && !errors.Is(err, context.DeadlineExceeded)
+}
+
+// IsMySQLExcluded reports whether err says nothing about MySQL: driver.ErrSkip only
+// asks database/sql to prepare the statement first.
+func IsMySQLExcluded(err error) bool {
+	return err == driver.ErrSkip
+}
+
+type breakerKey struct{}
+
+// pool is the connection pool a Repository queries. It puts the repository's breaker,
+// if any, in the context of each call, for Driver to check before opening a connection
+// or running a statement.
+type pool struct {
+	*sql.DB
+	breaker *breaker.Breaker
+}
+
+func (p pool) context(ctx context.Context) context.Context {
+	if p.breaker == nil {
+		return ctx
+	}
+	return context.WithValue(ctx, breakerKey{}, p.breaker)
+}
+
+func (p pool) ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error) {
+	return p.DB.ExecContext(p.context(ctx), query, args...)
+}
+
+func (p pool) QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error) {
+	return p.DB.QueryContext(p.context(ctx), query, args...)
+}
+
+func (p pool) QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row {
+	return p.DB.QueryRowContext(p.context(ctx), query, args...)
+}
+
+func (p pool) BeginTx(ctx context.Context, opts *sql.TxOptions) (*sql.Tx, error) {
+	return p.DB.BeginTx(p.context(ctx), opts)
+}
+
+func (p pool) PingContext(ctx context.Context) error {
+	return p.DB.PingContext(p.context(ctx))
+}
+
+// breakerFrom returns the breaker pool put in ctx, if any.
+func breakerFrom(ctx context.Context) *breaker.Breaker {
+	b, _ := ctx.Value(breakerKey{}).(*breaker.Breaker)
+	return b
+}
+
+// breakerDriver checks the breaker before opening connections. Only its connectors
+// see the context connections are opened for, so database/sql must use them.
+type breakerDriver struct {
+	driver.Driver
+}
+
+func (d breakerDriver) OpenConnector(name string) (driver.Connector, error) {
+	return breakerConnector{name: name, driver: d}, nil
+}
+
+type breakerConnector struct {
+	name   string
+	driver breakerDriver
+}
+
+func (c breakerConnector) Connect(ctx context.Context) (driver.Conn, error) {
+	b := breakerFrom(ctx)
+	if b == nil {
+		return c.driver.Open(c.name)
+	}
+	var conn driver.Conn
+	err := b.Do(func() error {
+		var err error
+		conn, err = c.driver.Open(c.name)
+		return err
+	})
+	return conn, err
+}
+
+func (c breakerConnector) Driver() driver.Driver {
+	return c.driver
+}
+
+type breakerDoneKey struct{}
+
+// breakerHooks checks the breaker before every statement and records its outcome.
+type breakerHooks struct{}
+
+func (breakerHooks) Before(ctx context.Context, query string, args ...any) (context.Context, error) {
+	b := breakerFrom(ctx)
+	if b == nil {
+		return ctx, nil
+	}
+	done, err := b.Allow()
+	if err != nil {
+		return ctx, err
+	}
+	return context.WithValue(ctx, breakerDoneKey{}, done), nil
+}
+
+func (breakerHooks) After(ctx context.Context, query string, args ...any) (context.Context, error) {
+	if done, ok := ctx.Value(breakerDoneKey{}).(func(error)); ok {
+		done(nil)
+	}
+	return ctx, nil
+}
+
+func (breakerHooks) OnError(ctx context.Context, err error, query string, args ...any) error {
+	if done, ok := ctx.Value(breakerDoneKey{}).(func(error)); ok {
+		done(err)
+	}
+	return err
+}
@@ -0,0 +1,81 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"go-mysql/internal/models"
+	"go-mysql/internal/tenant"
+)
+
+// CreateEmailChange records that the user with the given id and username asked to
+// change their email to email, to be confirmed with the token hashing to tokenHash
+// before expiresAt, in place of any change they asked for before. If email is already
+// the user's, the change they asked for before is dropped instead. It returns the user
+// as it is, sql.ErrNoRows if there's no such user, or ErrDuplicateEmail if another
+// user has the email.
+func (r *Repository) CreateEmailChange(ctx context.Context, id int, username, email, tokenHash string, expiresAt time.Time) (models.User, error) {
+	var user models.User
+	err := r.withTx(ctx, func(tx *sql.Tx) error {
+		var err error
+		user, err = scanUser(tx.QueryRowContext(ctx, "SELECT "+userColumns+" FROM users WHERE tenant_id = ? AND id = ? AND username = ? FOR SHARE",
+			tenant.ID(ctx), id, username))
+		if err != nil {
+			return err
+		}
+		if user.Email == email {
+			_, err = tx.ExecContext(ctx, "DELETE FROM email_changes WHERE user_id = ?", id)
+			return err
+		}
+		// Confirming checks again, through the unique index; this spares the new
+		// address a confirmation that can't succeed
+		var owner int
+		err = tx.QueryRowContext(ctx, "SELECT id FROM users WHERE tenant_id = ? AND email_canonical = ?",
+			tenant.ID(ctx), CanonicalEmail(email)).Scan(&owner)
+		if err == nil && owner != id {
+			return ErrDuplicateEmail
+		}
+		if err != nil && err != sql.ErrNoRows {
+			return err
+		}
+		_, err = tx.ExecContext(ctx, `INSERT INTO email_changes (user_id, email, token_hash, expires_at) VALUES (?, ?, ?, ?)
+			ON DUPLICATE KEY UPDATE email = VALUES(email), token_hash = VALUES(token_hash), created_at = CURRENT_TIMESTAMP,
+			expires_at = VALUES(expires_at)`, id, email, tokenHash, expiresAt)
+		return err
+	})
+	return user, err
+}
+
+// ConfirmEmailChange applies the email change whose token hashes to tokenHash, if it
+// doesn't expire before now, and returns the user with its new email. It returns
+// sql.ErrNoRows if there's no such change, or ErrDuplicateEmail if another user took
+// the email since it was asked for. Confirming records a user.updated event.
+func (r *Repository) ConfirmEmailChange(ctx context.Context, tokenHash string, now time.Time) (models.User, error) {
+	var user models.User
+	err := r.withTx(ctx, func(tx *sql.Tx) error {
+		var id int
+		var email string
+		err := tx.QueryRowContext(ctx, `SELECT c.user_id, c.email FROM email_changes c JOIN users u ON u.id = c.user_id
+			WHERE u.tenant_id = ? AND c.token_hash = ? AND c.expires_at > ? FOR UPDATE`,
+			tenant.ID(ctx), tokenHash, now).Scan(&id, &email)
+		if err != nil {
+			return err
+		}
+		user, err = scanUser(tx.QueryRowContext(ctx, "SELECT "+userColumns+" FROM users WHERE id = ? FOR UPDATE", id))
+		if err != nil {
+			return err
+		}
+		_, err = tx.ExecContext(ctx, "UPDATE users SET email = ?, email_canonical = ? WHERE id = ?", email, CanonicalEmail(email), id)
+		if err != nil {
+			return translateErr(err)
+		}
+		_, err = tx.ExecContext(ctx, "DELETE FROM email_changes WHERE user_id = ?", id)
+		if err != nil {
+			return err
+		}
+		user.Email = email
+		return addOutboxEvent(ctx, tx, EventUserUpdated, id, user)
+	})
+	return user, err
+}
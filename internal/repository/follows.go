@@ -0,0 +1,90 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+
+	"go-mysql/internal/models"
+	"go-mysql/internal/tenant"
+)
+
+// Follow makes the user followerID follow the user followeeID, reporting whether it
+// didn't already. It returns sql.ErrNoRows unless both users belong to the tenant ctx
+// belongs to. Follows are deleted with either user.
+func (r *Repository) Follow(ctx context.Context, followerID, followeeID int) (bool, error) {
+	followed := false
+	err := r.withTx(ctx, func(tx *sql.Tx) error {
+		for _, id := range []int{followerID, followeeID} {
+			err := lockUser(ctx, tx, id)
+			if err != nil {
+				return err
+			}
+		}
+		res, err := tx.ExecContext(ctx, "INSERT IGNORE INTO follows (follower_id, followee_id) VALUES (?, ?)", followerID, followeeID)
+		if err != nil {
+			return err
+		}
+		affected, err := res.RowsAffected()
+		followed = affected > 0
+		return err
+	})
+	return followed, err
+}
+
+// Unfollow makes the user followerID stop following the user followeeID, reporting
+// whether it did.
+func (r *Repository) Unfollow(ctx context.Context, followerID, followeeID int) (bool, error) {
+	res, err := r.db.ExecContext(ctx, `DELETE f FROM follows f JOIN users u ON u.id = f.follower_id
+		WHERE u.tenant_id = ? AND f.follower_id = ? AND f.followee_id = ?`, tenant.ID(ctx), followerID, followeeID)
+	if err != nil {
+		return false, err
+	}
+	affected, err := res.RowsAffected()
+	return affected > 0, err
+}
+
+// Followers returns a page of the users following the user with the given id, latest
+// first.
+func (r *Repository) Followers(ctx context.Context, id, offset, limit int) ([]models.Follow, error) {
+	return r.follows(ctx, `SELECT u.id, u.username, f.created_at FROM follows f JOIN users u ON u.id = f.follower_id
+		WHERE u.tenant_id = ? AND f.followee_id = ? ORDER BY f.created_at DESC, u.id LIMIT ? OFFSET ?`,
+		tenant.ID(ctx), id, limit, offset)
+}
+
+// Following returns a page of the users the user with the given id follows, latest
+// first.
+func (r *Repository) Following(ctx context.Context, id, offset, limit int) ([]models.Follow, error) {
+	return r.follows(ctx, `SELECT u.id, u.username, f.created_at FROM follows f JOIN users u ON u.id = f.followee_id
+		WHERE u.tenant_id = ? AND f.follower_id = ? ORDER BY f.created_at DESC, u.id LIMIT ? OFFSET ?`,
+		tenant.ID(ctx), id, limit, offset)
+}
+
+func (r *Repository) follows(ctx context.Context, query string, args ...any) ([]models.Follow, error) {
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var follows []models.Follow
+	for rows.Next() {
+		var f models.Follow
+		err := rows.Scan(&f.UserID, &f.Username, &f.FollowedAt)
+		if err != nil {
+			return nil, err
+		}
+		follows = append(follows, f)
+	}
+	return follows, rows.Err()
+}
+
+// FollowCounts counts the followers of the user with the given id and the users it
+// follows. It returns sql.ErrNoRows if there's no such user.
+func (r *Repository) FollowCounts(ctx context.Context, id int) (models.FollowCounts, error) {
+	var counts models.FollowCounts
+	err := r.db.QueryRowContext(ctx, `SELECT
+			(SELECT COUNT(*) FROM follows WHERE followee_id = u.id),
+			(SELECT COUNT(*) FROM follows WHERE follower_id = u.id)
+		FROM users u WHERE u.tenant_id = ? AND u.id = ?`, tenant.ID(ctx), id).Scan(&counts.Followers, &counts.Following)
+	return counts, err
+}
@@ -0,0 +1,260 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"strings"
+
+	"go-mysql/internal/models"
+	"go-mysql/internal/tenant"
+)
+
+const groupColumns = "g.id, g.name, g.description, g.created_at, (SELECT COUNT(*) FROM group_members m WHERE m.group_id = g.id)"
+
+// scanGroup reads a row selected with groupColumns, followed by extra columns if any.
+func scanGroup(row interface{ Scan(...any) error }, extra ...any) (models.Group, error) {
+	var g models.Group
+	err := row.Scan(append([]any{&g.ID, &g.Name, &g.Description, &g.CreatedAt, &g.MemberCount}, extra...)...)
+	return g, err
+}
+
+// CreateGroup stores g for the tenant ctx belongs to, with the user ownerID as its
+// owner, and returns its id. It returns ErrDuplicate if the tenant has a group with the
+// same name and sql.ErrNoRows if it has no such user.
+func (r *Repository) CreateGroup(ctx context.Context, g models.Group, ownerID int) (int, error) {
+	var id int
+	err := r.withTx(ctx, func(tx *sql.Tx) error {
+		err := lockUser(ctx, tx, ownerID)
+		if err != nil {
+			return err
+		}
+		res, err := tx.ExecContext(ctx, "INSERT INTO user_groups (tenant_id, name, description) VALUES (?, ?, ?)",
+			tenant.ID(ctx), g.Name, g.Description)
+		if err != nil {
+			return translateErr(err)
+		}
+		groupID, err := res.LastInsertId()
+		if err != nil {
+			return err
+		}
+		id = int(groupID)
+		_, err = tx.ExecContext(ctx, "INSERT INTO group_members (group_id, user_id, role) VALUES (?, ?, ?)",
+			id, ownerID, models.GroupRoleOwner)
+		return err
+	})
+	return id, err
+}
+
+// Groups returns a page of the groups ordered by name, and how many there are in total.
+func (r *Repository) Groups(ctx context.Context, offset, limit int) ([]models.Group, int, error) {
+	rows, err := r.db.QueryContext(ctx, "SELECT "+groupColumns+totalColumn+" FROM user_groups g WHERE g.tenant_id = ? ORDER BY g.name LIMIT ? OFFSET ?",
+		tenant.ID(ctx), limit, offset)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+
+	groups := []models.Group{}
+	total := 0
+	for rows.Next() {
+		g, err := scanGroup(rows, &total)
+		if err != nil {
+			return nil, 0, err
+		}
+		groups = append(groups, g)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, 0, err
+	}
+	total, err = r.pageTotal(ctx, total, len(groups), offset, "SELECT COUNT(*) FROM user_groups WHERE tenant_id = ?", tenant.ID(ctx))
+	return groups, total, err
+}
+
+// GroupByID returns sql.ErrNoRows if there is no such group.
+func (r *Repository) GroupByID(ctx context.Context, id int) (models.Group, error) {
+	return scanGroup(r.db.QueryRowContext(ctx, "SELECT "+groupColumns+" FROM user_groups g WHERE g.tenant_id = ? AND g.id = ?",
+		tenant.ID(ctx), id))
+}
+
+// DeleteGroup deletes the group and its memberships, reporting whether it existed.
+func (r *Repository) DeleteGroup(ctx context.Context, id int) (bool, error) {
+	res, err := r.db.ExecContext(ctx, "DELETE FROM user_groups WHERE tenant_id = ? AND id = ?", tenant.ID(ctx), id)
+	if err != nil {
+		return false, err
+	}
+	affected, err := res.RowsAffected()
+	return affected > 0, err
+}
+
+// GroupMembers returns the members of the group with the given id, owners first, then
+// by when they joined.
+func (r *Repository) GroupMembers(ctx context.Context, groupID int) ([]models.Membership, error) {
+	return r.memberships(ctx, `SELECT m.group_id, '', m.user_id, u.username, m.role, m.joined_at
+		FROM group_members m JOIN user_groups g ON g.id = m.group_id JOIN users u ON u.id = m.user_id
+		WHERE g.tenant_id = ? AND m.group_id = ? ORDER BY m.role = 'owner' DESC, m.joined_at, m.user_id`, tenant.ID(ctx), groupID)
+}
+
+// UserGroups returns the memberships of the user with the given id, ordered by group
+// name.
+func (r *Repository) UserGroups(ctx context.Context, userID int) ([]models.Membership, error) {
+	return r.memberships(ctx, `SELECT m.group_id, g.name, m.user_id, '', m.role, m.joined_at
+		FROM group_members m JOIN user_groups g ON g.id = m.group_id
+		WHERE g.tenant_id = ? AND m.user_id = ? ORDER BY g.name`, tenant.ID(ctx), userID)
+}
+
+func (r *Repository) memberships(ctx context.Context, query string, args ...any) ([]models.Membership, error) {
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var members []models.Membership
+	for rows.Next() {
+		var m models.Membership
+		err := rows.Scan(&m.GroupID, &m.GroupName, &m.UserID, &m.Username, &m.Role, &m.JoinedAt)
+		if err != nil {
+			return nil, err
+		}
+		members = append(members, m)
+	}
+	return members, rows.Err()
+}
+
+// SetGroupMember adds the user with the given id to the group with the given role, or
+// changes its role if it's a member already, reporting whether it was added. It
+// returns sql.ErrNoRows if the tenant has no such group or user, and ErrLastOwner when
+// demoting the group's only owner.
+func (r *Repository) SetGroupMember(ctx context.Context, groupID, userID int, role string) (bool, error) {
+	added := false
+	err := r.withTx(ctx, func(tx *sql.Tx) error {
+		owners, err := lockGroup(ctx, tx, groupID)
+		if err != nil {
+			return err
+		}
+		err = lockUser(ctx, tx, userID)
+		if err != nil {
+			return err
+		}
+		if role != models.GroupRoleOwner && len(owners) == 1 && owners[0] == userID {
+			return ErrLastOwner
+		}
+		res, err := tx.ExecContext(ctx, `INSERT INTO group_members (group_id, user_id, role) VALUES (?, ?, ?)
+			ON DUPLICATE KEY UPDATE role = VALUES(role)`, groupID, userID, role)
+		if err != nil {
+			return err
+		}
+		// 1 for an insert, 2 for an update and 0 for an unchanged row
+		affected, err := res.RowsAffected()
+		added = affected == 1
+		return err
+	})
+	return added, err
+}
+
+// RemoveGroupMember removes the user with the given id from the group, reporting
+// whether it was a member. It returns sql.ErrNoRows if the tenant has no such group,
+// and ErrLastOwner for the group's only owner: a group always has an owner, so it must
+// be deleted instead.
+func (r *Repository) RemoveGroupMember(ctx context.Context, groupID, userID int) (bool, error) {
+	removed := false
+	err := r.withTx(ctx, func(tx *sql.Tx) error {
+		owners, err := lockGroup(ctx, tx, groupID)
+		if err != nil {
+			return err
+		}
+		if len(owners) == 1 && owners[0] == userID {
+			return ErrLastOwner
+		}
+		res, err := tx.ExecContext(ctx, "DELETE FROM group_members WHERE group_id = ? AND user_id = ?", groupID, userID)
+		if err != nil {
+			return err
+		}
+		affected, err := res.RowsAffected()
+		removed = affected > 0
+		return err
+	})
+	return removed, err
+}
+
+// lockGroup locks the group with the given id, which must belong to the tenant ctx
+// belongs to, and returns the ids of its owners.
+func lockGroup(ctx context.Context, tx *sql.Tx, id int) ([]int, error) {
+	var locked int
+	err := tx.QueryRowContext(ctx, "SELECT id FROM user_groups WHERE tenant_id = ? AND id = ? FOR UPDATE", tenant.ID(ctx), id).Scan(&locked)
+	if err != nil {
+		return nil, err
+	}
+	rows, err := tx.QueryContext(ctx, "SELECT user_id FROM group_members WHERE group_id = ? AND role = ?", id, models.GroupRoleOwner)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var owners []int
+	for rows.Next() {
+		var owner int
+		err := rows.Scan(&owner)
+		if err != nil {
+			return nil, err
+		}
+		owners = append(owners, owner)
+	}
+	return owners, rows.Err()
+}
+
+// lockUser keeps the user with the given id, which must belong to the tenant ctx
+// belongs to, from being deleted until tx ends.
+func lockUser(ctx context.Context, tx *sql.Tx, id int) error {
+	var locked int
+	return tx.QueryRowContext(ctx, "SELECT id FROM users WHERE tenant_id = ? AND id = ? FOR SHARE", tenant.ID(ctx), id).Scan(&locked)
+}
+
+// leaveGroups removes the users with the given ids from their groups, before they're
+// deleted. A group that loses its last owner gets its oldest remaining member as owner,
+// and a group left without members is deleted.
+func leaveGroups(ctx context.Context, tx *sql.Tx, userIDs ...any) error {
+	in := strings.TrimSuffix(strings.Repeat("?,", len(userIDs)), ",")
+	rows, err := tx.QueryContext(ctx, "SELECT DISTINCT group_id FROM group_members WHERE user_id IN ("+in+") FOR UPDATE", userIDs...)
+	if err != nil {
+		return err
+	}
+	var groups []int
+	for rows.Next() {
+		var id int
+		err := rows.Scan(&id)
+		if err != nil {
+			rows.Close()
+			return err
+		}
+		groups = append(groups, id)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil || len(groups) == 0 {
+		return err
+	}
+
+	_, err = tx.ExecContext(ctx, "DELETE FROM group_members WHERE user_id IN ("+in+")", userIDs...)
+	if err != nil {
+		return err
+	}
+	for _, id := range groups {
+		var members, owners int
+		err := tx.QueryRowContext(ctx, "SELECT COUNT(*), COALESCE(SUM(role = ?), 0) FROM group_members WHERE group_id = ?",
+			models.GroupRoleOwner, id).Scan(&members, &owners)
+		if err != nil {
+			return err
+		}
+		switch {
+		case members == 0:
+			_, err = tx.ExecContext(ctx, "DELETE FROM user_groups WHERE id = ?", id)
+		case owners == 0:
+			_, err = tx.ExecContext(ctx, "UPDATE group_members SET role = ? WHERE group_id = ? ORDER BY joined_at, user_id LIMIT 1",
+				models.GroupRoleOwner, id)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,65 @@
+package repository
+
+import (
+	"context"
+	"strings"
+
+	"go-mysql/internal/tenant"
+)
+
+// lookupBatchSize is how many values one IN list holds at most.
+const lookupBatchSize = 500
+
+// TakenUsernamesAndEmails returns which of usernames, and of emails, users of the
+// tenant ctx belongs to already have. Emails are compared by CanonicalEmail, and the
+// emails returned are the canonical ones. Both are keyed in lower case, since MySQL
+// compares them ignoring case.
+func (r *Repository) TakenUsernamesAndEmails(ctx context.Context, usernames, emails []string) (takenUsernames, takenEmails map[string]bool, err error) {
+	takenUsernames, err = r.taken(ctx, "username", usernames)
+	if err != nil {
+		return nil, nil, err
+	}
+	canonical := make([]string, len(emails))
+	for i, email := range emails {
+		canonical[i] = CanonicalEmail(email)
+	}
+	takenEmails, err = r.taken(ctx, "email_canonical", canonical)
+	if err != nil {
+		return nil, nil, err
+	}
+	return takenUsernames, takenEmails, nil
+}
+
+// taken returns which of values the users of the tenant ctx belongs to have in column.
+func (r *Repository) taken(ctx context.Context, column string, values []string) (map[string]bool, error) {
+	taken := make(map[string]bool)
+	for len(values) > 0 {
+		batch := values[:min(len(values), lookupBatchSize)]
+		values = values[len(batch):]
+
+		in := strings.TrimSuffix(strings.Repeat("?,", len(batch)), ",")
+		args := []any{tenant.ID(ctx)}
+		for _, value := range batch {
+			args = append(args, value)
+		}
+		// column is one of ours, never the caller's
+		rows, err := r.db.QueryContext(ctx, "SELECT "+column+" FROM users WHERE tenant_id = ? AND "+column+" IN ("+in+")", args...)
+		if err != nil {
+			return nil, err
+		}
+		for rows.Next() {
+			var value string
+			err := rows.Scan(&value)
+			if err != nil {
+				rows.Close()
+				return nil, err
+			}
+			taken[strings.ToLower(value)] = true
+		}
+		rows.Close()
+		if err := rows.Err(); err != nil {
+			return nil, err
+		}
+	}
+	return taken, nil
+}
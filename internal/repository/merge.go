@@ -0,0 +1,156 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+
+	"go-mysql/internal/models"
+	"go-mysql/internal/tenant"
+)
+
+// MergeUsers merges the user with the given id into the user into, which takes over
+// its group memberships, follows, tags and username history, and marks it merged. In
+// groups both were in, into becomes an owner if either was; follows between the two
+// are dropped. It returns the merge and the merged user as it is afterwards, along with
+// the ids of the users whose follows changed, or sql.ErrNoRows if either user doesn't
+// exist, or ErrMerged if either was merged already. A merge records a user.merged event.
+func (r *Repository) MergeUsers(ctx context.Context, id, into int) (merge models.UserMerge, user models.User, related []int, err error) {
+	err = r.withTx(ctx, func(tx *sql.Tx) error {
+		merge, related = models.UserMerge{UserID: id, IntoID: into}, nil
+
+		// Both rows are locked in id order, so merging the same two users both ways at once
+		// can't deadlock
+		rows, err := tx.QueryContext(ctx, "SELECT "+userColumns+" FROM users WHERE tenant_id = ? AND id IN (?, ?) ORDER BY id FOR UPDATE",
+			tenant.ID(ctx), id, into)
+		if err != nil {
+			return err
+		}
+		locked := 0
+		for rows.Next() {
+			u, err := scanUser(rows)
+			if err != nil {
+				rows.Close()
+				return err
+			}
+			if u.Status == models.StatusMerged {
+				rows.Close()
+				return ErrMerged
+			}
+			if u.ID == id {
+				user = u
+			}
+			locked++
+		}
+		rows.Close()
+		if err := rows.Err(); err != nil {
+			return err
+		}
+		if locked < 2 {
+			return sql.ErrNoRows
+		}
+
+		merge.Groups, err = mergeGroups(ctx, tx, id, into)
+		if err != nil {
+			return err
+		}
+		merge.Follows, related, err = mergeFollows(ctx, tx, id, into)
+		if err != nil {
+			return err
+		}
+		merge.Tags, err = moveRows(ctx, tx, "user_tags", id, into)
+		if err != nil {
+			return err
+		}
+		_, err = tx.ExecContext(ctx, "UPDATE username_history SET user_id = ? WHERE user_id = ?", into, id)
+		if err != nil {
+			return err
+		}
+		_, err = tx.ExecContext(ctx, "DELETE FROM email_changes WHERE user_id = ?", id)
+		if err != nil {
+			return err
+		}
+
+		_, err = tx.ExecContext(ctx, "UPDATE users SET status = ?, merged_into = ?, deactivated_at = NULL WHERE id = ?",
+			models.StatusMerged, into, id)
+		if err != nil {
+			return err
+		}
+		user.Status = models.StatusMerged
+		return addOutboxEvent(ctx, tx, EventUserMerged, id, merge)
+	})
+	return merge, user, related, err
+}
+
+// mergeGroups moves the group memberships of the user id to the user into, and returns
+// how many into didn't have.
+func mergeGroups(ctx context.Context, tx *sql.Tx, id, into int) (int, error) {
+	_, err := tx.ExecContext(ctx, `UPDATE group_members m JOIN group_members d ON d.group_id = m.group_id AND d.user_id = ?
+		SET m.role = ? WHERE m.user_id = ? AND d.role = ?`,
+		id, models.GroupRoleOwner, into, models.GroupRoleOwner)
+	if err != nil {
+		return 0, err
+	}
+	return moveRows(ctx, tx, "group_members", id, into)
+}
+
+// mergeFollows moves the follows by and of the user id to the user into, dropping
+// those between the two, and returns how many into didn't have, and the ids of the
+// other users involved.
+func mergeFollows(ctx context.Context, tx *sql.Tx, id, into int) (int, []int, error) {
+	rows, err := tx.QueryContext(ctx, `SELECT followee_id FROM follows WHERE follower_id = ?
+		UNION SELECT follower_id FROM follows WHERE followee_id = ?`, id, id)
+	if err != nil {
+		return 0, nil, err
+	}
+	var related []int
+	for rows.Next() {
+		var other int
+		err := rows.Scan(&other)
+		if err != nil {
+			rows.Close()
+			return 0, nil, err
+		}
+		related = append(related, other)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return 0, nil, err
+	}
+
+	// They would become into following itself
+	_, err = tx.ExecContext(ctx, "DELETE FROM follows WHERE (follower_id = ? AND followee_id = ?) OR (follower_id = ? AND followee_id = ?)",
+		id, into, into, id)
+	if err != nil {
+		return 0, nil, err
+	}
+	moved := 0
+	for _, column := range []string{"follower_id", "followee_id"} {
+		res, err := tx.ExecContext(ctx, "UPDATE IGNORE follows SET "+column+" = ? WHERE "+column+" = ?", into, id)
+		if err != nil {
+			return 0, nil, err
+		}
+		n, err := res.RowsAffected()
+		if err != nil {
+			return 0, nil, err
+		}
+		moved += int(n)
+	}
+	_, err = tx.ExecContext(ctx, "DELETE FROM follows WHERE follower_id = ? OR followee_id = ?", id, id)
+	return moved, related, err
+}
+
+// moveRows gives the rows of table whose user_id is id to the user into, dropping
+// those into has already, and returns how many moved.
+func moveRows(ctx context.Context, tx *sql.Tx, table string, id, into int) (int, error) {
+	// IGNORE skips the rows that would duplicate one of into's
+	res, err := tx.ExecContext(ctx, "UPDATE IGNORE "+table+" SET user_id = ? WHERE user_id = ?", into, id)
+	if err != nil {
+		return 0, err
+	}
+	moved, err := res.RowsAffected()
+	if err != nil {
+		return 0, err
+	}
+	_, err = tx.ExecContext(ctx, "DELETE FROM "+table+" WHERE user_id = ?", id)
+	return int(moved), err
+}
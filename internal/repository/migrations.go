@@ -0,0 +1,598 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"go-mysql/internal/config"
+	"go-mysql/internal/logging"
+	"go-mysql/internal/models"
+)
+
+type migration struct {
+	version int
+	name    string
+	up      func(ctx context.Context, db *sql.DB) error
+	down    func(ctx context.Context, db *sql.DB) error
+}
+
+// migrations lists every schema change in the order it must be applied.
+// Append new entries to the end; never edit or reorder existing ones.
+var migrations = []migration{
+	{
+		version: 1,
+		name:    "create users table",
+		up: execAll(`CREATE TABLE IF NOT EXISTS users (
+			id INT AUTO_INCREMENT PRIMARY KEY,
+			username VARCHAR(50) NOT NULL,
+			email VARCHAR(50) NOT NULL
+		)`),
+		down: execAll("DROP TABLE IF EXISTS users"),
+	},
+	{
+		version: 2,
+		name:    "unique index on users.username",
+		up:      ensureUsernameUniqueIndex,
+		down:    execAll("ALTER TABLE users DROP INDEX uniq_username"),
+	},
+	{
+		version: 3,
+		name:    "users created_at and updated_at",
+		up: execAll(`ALTER TABLE users
+			ADD COLUMN created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+			ADD COLUMN updated_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+			ADD INDEX idx_users_updated_at (updated_at)`),
+		down: execAll("ALTER TABLE users DROP INDEX idx_users_updated_at, DROP COLUMN created_at, DROP COLUMN updated_at"),
+	},
+	{
+		version: 4,
+		name:    "create users_archive table",
+		up: execAll(`CREATE TABLE IF NOT EXISTS users_archive (
+			id INT PRIMARY KEY,
+			username VARCHAR(50) NOT NULL,
+			email VARCHAR(50) NOT NULL,
+			created_at DATETIME NOT NULL,
+			updated_at DATETIME NOT NULL,
+			archived_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP
+		)`),
+		down: execAll("DROP TABLE IF EXISTS users_archive"),
+	},
+	{
+		version: 5,
+		name:    "create outbox table",
+		up: execAll(`CREATE TABLE IF NOT EXISTS outbox (
+			id BIGINT AUTO_INCREMENT PRIMARY KEY,
+			event_type VARCHAR(64) NOT NULL,
+			user_id INT NOT NULL,
+			payload JSON NOT NULL,
+			created_at DATETIME(6) NOT NULL DEFAULT CURRENT_TIMESTAMP(6),
+			published_at DATETIME(6) NULL,
+			INDEX idx_outbox_published_at (published_at, id)
+		)`),
+		down: execAll("DROP TABLE IF EXISTS outbox"),
+	},
+	{
+		version: 6,
+		name:    "create webhooks and webhook_deliveries tables",
+		up: execAll(`CREATE TABLE IF NOT EXISTS webhooks (
+			id INT AUTO_INCREMENT PRIMARY KEY,
+			url VARCHAR(2048) NOT NULL,
+			secret VARCHAR(128) NOT NULL,
+			events VARCHAR(255) NOT NULL,
+			created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP
+		)`, `CREATE TABLE IF NOT EXISTS webhook_deliveries (
+			id BIGINT AUTO_INCREMENT PRIMARY KEY,
+			webhook_id INT NOT NULL,
+			event_type VARCHAR(64) NOT NULL,
+			payload JSON NOT NULL,
+			status VARCHAR(16) NOT NULL DEFAULT 'pending',
+			attempts INT NOT NULL DEFAULT 0,
+			response_code INT NOT NULL DEFAULT 0,
+			last_error VARCHAR(1024) NOT NULL DEFAULT '',
+			created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+			updated_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+			INDEX idx_webhook_deliveries_webhook (webhook_id, id),
+			FOREIGN KEY (webhook_id) REFERENCES webhooks (id) ON DELETE CASCADE
+		)`),
+		down: execAll("DROP TABLE IF EXISTS webhook_deliveries", "DROP TABLE IF EXISTS webhooks"),
+	},
+	{
+		version: 7,
+		name:    "create api_keys table",
+		up: execAll(`CREATE TABLE IF NOT EXISTS api_keys (
+			id BIGINT AUTO_INCREMENT PRIMARY KEY,
+			user_id INT NOT NULL,
+			prefix VARCHAR(16) NOT NULL,
+			key_hash CHAR(64) NOT NULL,
+			created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+			UNIQUE KEY uniq_api_keys_hash (key_hash),
+			FOREIGN KEY (user_id) REFERENCES users (id) ON DELETE CASCADE
+		)`),
+		down: execAll("DROP TABLE IF EXISTS api_keys"),
+	},
+	{
+		version: 8,
+		name:    "create tenants table and scope users, webhooks and the outbox by tenant",
+		up: execAll(`CREATE TABLE IF NOT EXISTS tenants (
+			id INT AUTO_INCREMENT PRIMARY KEY,
+			slug VARCHAR(50) NOT NULL,
+			name VARCHAR(100) NOT NULL,
+			created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+			UNIQUE KEY uniq_tenants_slug (slug)
+		)`,
+			// Existing users, webhooks and events belong to the default tenant
+			"INSERT IGNORE INTO tenants (id, slug, name) VALUES (1, 'default', 'Default')",
+			`ALTER TABLE users
+			ADD COLUMN tenant_id INT NOT NULL DEFAULT 1 AFTER id,
+			DROP INDEX uniq_username,
+			ADD UNIQUE KEY uniq_tenant_username (tenant_id, username),
+			ADD CONSTRAINT fk_users_tenant FOREIGN KEY (tenant_id) REFERENCES tenants (id)`,
+			"ALTER TABLE users_archive ADD COLUMN tenant_id INT NOT NULL DEFAULT 1 AFTER id",
+			`ALTER TABLE webhooks
+			ADD COLUMN tenant_id INT NOT NULL DEFAULT 1 AFTER id,
+			ADD INDEX idx_webhooks_tenant (tenant_id),
+			ADD CONSTRAINT fk_webhooks_tenant FOREIGN KEY (tenant_id) REFERENCES tenants (id) ON DELETE CASCADE`,
+			"ALTER TABLE outbox ADD COLUMN tenant_id INT NOT NULL DEFAULT 1 AFTER event_type"),
+		down: execAll(
+			"ALTER TABLE outbox DROP COLUMN tenant_id",
+			"ALTER TABLE webhooks DROP FOREIGN KEY fk_webhooks_tenant, DROP INDEX idx_webhooks_tenant, DROP COLUMN tenant_id",
+			"ALTER TABLE users_archive DROP COLUMN tenant_id",
+			`ALTER TABLE users DROP FOREIGN KEY fk_users_tenant, DROP INDEX uniq_tenant_username,
+			ADD UNIQUE KEY uniq_username (username), DROP COLUMN tenant_id`,
+			"DROP TABLE IF EXISTS tenants"),
+	},
+	{
+		version: 9,
+		name:    "users profile fields",
+		up: execAll(`ALTER TABLE users
+			ADD COLUMN first_name VARCHAR(50) NOT NULL DEFAULT '',
+			ADD COLUMN last_name VARCHAR(50) NOT NULL DEFAULT '',
+			ADD COLUMN display_name VARCHAR(100) NOT NULL DEFAULT '',
+			ADD COLUMN bio VARCHAR(500) NOT NULL DEFAULT '',
+			ADD COLUMN avatar_url VARCHAR(2048) NOT NULL DEFAULT ''`,
+			`ALTER TABLE users_archive
+			ADD COLUMN first_name VARCHAR(50) NOT NULL DEFAULT '',
+			ADD COLUMN last_name VARCHAR(50) NOT NULL DEFAULT '',
+			ADD COLUMN display_name VARCHAR(100) NOT NULL DEFAULT '',
+			ADD COLUMN bio VARCHAR(500) NOT NULL DEFAULT '',
+			ADD COLUMN avatar_url VARCHAR(2048) NOT NULL DEFAULT ''`),
+		down: execAll(
+			"ALTER TABLE users_archive DROP COLUMN first_name, DROP COLUMN last_name, DROP COLUMN display_name, DROP COLUMN bio, DROP COLUMN avatar_url",
+			"ALTER TABLE users DROP COLUMN first_name, DROP COLUMN last_name, DROP COLUMN display_name, DROP COLUMN bio, DROP COLUMN avatar_url"),
+	},
+	{
+		version: 10,
+		name:    "users avatar_key",
+		up: execAll(
+			"ALTER TABLE users ADD COLUMN avatar_key VARCHAR(255) NOT NULL DEFAULT ''",
+			"ALTER TABLE users_archive ADD COLUMN avatar_key VARCHAR(255) NOT NULL DEFAULT ''"),
+		down: execAll(
+			"ALTER TABLE users_archive DROP COLUMN avatar_key",
+			"ALTER TABLE users DROP COLUMN avatar_key"),
+	},
+	{
+		version: 11,
+		name:    "normalize users emails and make them unique",
+		up:      normalizeEmails,
+		down:    execAll("ALTER TABLE users DROP INDEX " + emailIndex + ", DROP COLUMN email_canonical"),
+	},
+	{
+		version: 12,
+		name:    "create username_history table",
+		up: execAll(`CREATE TABLE IF NOT EXISTS username_history (
+			id BIGINT AUTO_INCREMENT PRIMARY KEY,
+			tenant_id INT NOT NULL,
+			user_id INT NOT NULL,
+			old_username VARCHAR(50) NOT NULL,
+			new_username VARCHAR(50) NOT NULL,
+			changed_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+			INDEX idx_username_history_user (user_id, id),
+			INDEX idx_username_history_old (tenant_id, old_username, changed_at),
+			FOREIGN KEY (user_id) REFERENCES users (id) ON DELETE CASCADE
+		)`),
+		down: execAll("DROP TABLE IF EXISTS username_history"),
+	},
+	{
+		version: 13,
+		name:    "users role, ban and password reset flags",
+		up: execAll(`ALTER TABLE users
+			ADD COLUMN role VARCHAR(16) NOT NULL DEFAULT 'user',
+			ADD COLUMN banned_at DATETIME NULL,
+			ADD COLUMN ban_reason VARCHAR(255) NOT NULL DEFAULT '',
+			ADD COLUMN password_reset_required BOOLEAN NOT NULL DEFAULT FALSE,
+			ADD INDEX idx_users_tenant_role (tenant_id, role)`,
+			`ALTER TABLE users_archive
+			ADD COLUMN role VARCHAR(16) NOT NULL DEFAULT 'user',
+			ADD COLUMN banned_at DATETIME NULL,
+			ADD COLUMN ban_reason VARCHAR(255) NOT NULL DEFAULT '',
+			ADD COLUMN password_reset_required BOOLEAN NOT NULL DEFAULT FALSE`),
+		down: execAll(
+			"ALTER TABLE users_archive DROP COLUMN role, DROP COLUMN banned_at, DROP COLUMN ban_reason, DROP COLUMN password_reset_required",
+			`ALTER TABLE users DROP INDEX idx_users_tenant_role, DROP COLUMN role, DROP COLUMN banned_at, DROP COLUMN ban_reason,
+			DROP COLUMN password_reset_required`),
+	},
+	{
+		version: 14,
+		name:    "create user_groups and group_members tables",
+		// GROUPS is a reserved word, hence user_groups
+		up: execAll(`CREATE TABLE IF NOT EXISTS user_groups (
+			id INT AUTO_INCREMENT PRIMARY KEY,
+			tenant_id INT NOT NULL,
+			name VARCHAR(100) NOT NULL,
+			description VARCHAR(500) NOT NULL DEFAULT '',
+			created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+			UNIQUE KEY uniq_user_groups_tenant_name (tenant_id, name),
+			FOREIGN KEY (tenant_id) REFERENCES tenants (id) ON DELETE CASCADE
+		)`, `CREATE TABLE IF NOT EXISTS group_members (
+			group_id INT NOT NULL,
+			user_id INT NOT NULL,
+			role VARCHAR(16) NOT NULL DEFAULT 'member',
+			joined_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+			PRIMARY KEY (group_id, user_id),
+			INDEX idx_group_members_user (user_id),
+			FOREIGN KEY (group_id) REFERENCES user_groups (id) ON DELETE CASCADE,
+			FOREIGN KEY (user_id) REFERENCES users (id) ON DELETE CASCADE
+		)`),
+		down: execAll("DROP TABLE IF EXISTS group_members", "DROP TABLE IF EXISTS user_groups"),
+	},
+	{
+		version: 15,
+		name:    "create follows table",
+		up: execAll(`CREATE TABLE IF NOT EXISTS follows (
+			follower_id INT NOT NULL,
+			followee_id INT NOT NULL,
+			created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+			PRIMARY KEY (follower_id, followee_id),
+			INDEX idx_follows_followee (followee_id, created_at),
+			FOREIGN KEY (follower_id) REFERENCES users (id) ON DELETE CASCADE,
+			FOREIGN KEY (followee_id) REFERENCES users (id) ON DELETE CASCADE
+		)`),
+		down: execAll("DROP TABLE IF EXISTS follows"),
+	},
+	{
+		version: 16,
+		name:    "users last login and last seen times",
+		up: execAll(`ALTER TABLE users
+			ADD COLUMN last_login_at DATETIME NULL,
+			ADD COLUMN last_seen_at DATETIME NULL,
+			ADD INDEX idx_users_tenant_last_seen (tenant_id, last_seen_at)`,
+			`ALTER TABLE users_archive
+			ADD COLUMN last_login_at DATETIME NULL,
+			ADD COLUMN last_seen_at DATETIME NULL`),
+		down: execAll(
+			"ALTER TABLE users_archive DROP COLUMN last_login_at, DROP COLUMN last_seen_at",
+			"ALTER TABLE users DROP INDEX idx_users_tenant_last_seen, DROP COLUMN last_login_at, DROP COLUMN last_seen_at"),
+	},
+	{
+		version: 17,
+		name:    "create user_preferences table",
+		up: execAll(`CREATE TABLE IF NOT EXISTS user_preferences (
+			user_id INT PRIMARY KEY,
+			preferences JSON NOT NULL,
+			updated_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+			FOREIGN KEY (user_id) REFERENCES users (id) ON DELETE CASCADE
+		)`),
+		down: execAll("DROP TABLE IF EXISTS user_preferences"),
+	},
+	{
+		version: 18,
+		name:    "users status",
+		up: execAll(`ALTER TABLE users
+			ADD COLUMN status VARCHAR(16) NOT NULL DEFAULT 'active' AFTER email,
+			ADD COLUMN deactivated_at DATETIME NULL,
+			ADD INDEX idx_users_tenant_status (tenant_id, status)`,
+			"UPDATE users SET status = 'banned', updated_at = updated_at WHERE banned_at IS NOT NULL",
+			`ALTER TABLE users_archive
+			ADD COLUMN status VARCHAR(16) NOT NULL DEFAULT 'active' AFTER email,
+			ADD COLUMN deactivated_at DATETIME NULL`,
+			"UPDATE users_archive SET status = 'banned' WHERE banned_at IS NOT NULL"),
+		down: execAll(
+			"ALTER TABLE users_archive DROP COLUMN status, DROP COLUMN deactivated_at",
+			"ALTER TABLE users DROP INDEX idx_users_tenant_status, DROP COLUMN status, DROP COLUMN deactivated_at"),
+	},
+	{
+		version: 19,
+		name:    "create email_changes table",
+		up: execAll(`CREATE TABLE IF NOT EXISTS email_changes (
+			user_id INT PRIMARY KEY,
+			email VARCHAR(50) NOT NULL,
+			token_hash CHAR(64) NOT NULL,
+			created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+			expires_at DATETIME NOT NULL,
+			UNIQUE KEY uniq_email_changes_token (token_hash),
+			FOREIGN KEY (user_id) REFERENCES users (id) ON DELETE CASCADE
+		)`),
+		down: execAll("DROP TABLE IF EXISTS email_changes"),
+	},
+	{
+		version: 20,
+		name:    "create tags and user_tags tables",
+		up: execAll(`CREATE TABLE IF NOT EXISTS tags (
+			id INT AUTO_INCREMENT PRIMARY KEY,
+			tenant_id INT NOT NULL,
+			name VARCHAR(32) NOT NULL,
+			created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+			UNIQUE KEY uniq_tags_tenant_name (tenant_id, name),
+			FOREIGN KEY (tenant_id) REFERENCES tenants (id) ON DELETE CASCADE
+		)`, `CREATE TABLE IF NOT EXISTS user_tags (
+			user_id INT NOT NULL,
+			tag_id INT NOT NULL,
+			created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+			PRIMARY KEY (user_id, tag_id),
+			INDEX idx_user_tags_tag (tag_id, user_id),
+			FOREIGN KEY (user_id) REFERENCES users (id) ON DELETE CASCADE,
+			FOREIGN KEY (tag_id) REFERENCES tags (id) ON DELETE CASCADE
+		)`),
+		down: execAll("DROP TABLE IF EXISTS user_tags", "DROP TABLE IF EXISTS tags"),
+	},
+	{
+		version: 21,
+		name:    "users phone and address",
+		up: execAll(`ALTER TABLE users
+			ADD COLUMN phone VARCHAR(16) NOT NULL DEFAULT '',
+			ADD COLUMN address_line1 VARCHAR(100) NOT NULL DEFAULT '',
+			ADD COLUMN address_line2 VARCHAR(100) NOT NULL DEFAULT '',
+			ADD COLUMN city VARCHAR(50) NOT NULL DEFAULT '',
+			ADD COLUMN region VARCHAR(50) NOT NULL DEFAULT '',
+			ADD COLUMN postal_code VARCHAR(16) NOT NULL DEFAULT '',
+			ADD COLUMN country CHAR(2) NOT NULL DEFAULT '',
+			ADD INDEX idx_users_tenant_phone (tenant_id, phone)`,
+			`ALTER TABLE users_archive
+			ADD COLUMN phone VARCHAR(16) NOT NULL DEFAULT '',
+			ADD COLUMN address_line1 VARCHAR(100) NOT NULL DEFAULT '',
+			ADD COLUMN address_line2 VARCHAR(100) NOT NULL DEFAULT '',
+			ADD COLUMN city VARCHAR(50) NOT NULL DEFAULT '',
+			ADD COLUMN region VARCHAR(50) NOT NULL DEFAULT '',
+			ADD COLUMN postal_code VARCHAR(16) NOT NULL DEFAULT '',
+			ADD COLUMN country CHAR(2) NOT NULL DEFAULT ''`),
+		down: execAll(
+			"ALTER TABLE users_archive DROP COLUMN phone, DROP COLUMN address_line1, DROP COLUMN address_line2, DROP COLUMN city, DROP COLUMN region, DROP COLUMN postal_code, DROP COLUMN country",
+			"ALTER TABLE users DROP INDEX idx_users_tenant_phone, DROP COLUMN phone, DROP COLUMN address_line1, DROP COLUMN address_line2, DROP COLUMN city, DROP COLUMN region, DROP COLUMN postal_code, DROP COLUMN country"),
+	},
+	{
+		version: 22,
+		name:    "users merged_into",
+		up: execAll(
+			`ALTER TABLE users ADD COLUMN merged_into INT NULL,
+			ADD CONSTRAINT fk_users_merged_into FOREIGN KEY (merged_into) REFERENCES users (id) ON DELETE SET NULL`,
+			"ALTER TABLE users_archive ADD COLUMN merged_into INT NULL"),
+		down: execAll(
+			"ALTER TABLE users_archive DROP COLUMN merged_into",
+			"ALTER TABLE users DROP FOREIGN KEY fk_users_merged_into, DROP COLUMN merged_into"),
+	},
+	{
+		version: 23,
+		name:    "create reserved_usernames table",
+		up: execAll(`CREATE TABLE IF NOT EXISTS reserved_usernames (
+			id INT AUTO_INCREMENT PRIMARY KEY,
+			tenant_id INT NOT NULL,
+			name VARCHAR(50) NOT NULL,
+			kind VARCHAR(16) NOT NULL,
+			created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+			UNIQUE KEY uniq_reserved_usernames (tenant_id, kind, name),
+			FOREIGN KEY (tenant_id) REFERENCES tenants (id) ON DELETE CASCADE
+		)`),
+		down: execAll("DROP TABLE IF EXISTS reserved_usernames"),
+	},
+}
+
+// ExpectedSchemaVersion is the schema version this binary was built against.
+var ExpectedSchemaVersion = migrations[len(migrations)-1].version
+
+// execAll returns a migration step that executes the given statements in order.
+func execAll(stmts ...string) func(ctx context.Context, db *sql.DB) error {
+	return func(ctx context.Context, db *sql.DB) error {
+		for _, stmt := range stmts {
+			_, err := db.ExecContext(ctx, stmt)
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+}
+
+// CheckSchema applies pending migrations (unless AUTO_MIGRATE=false) and compares the
+// resulting schema version with ExpectedSchemaVersion. On a mismatch it returns an error,
+// or reports readOnly=true when SCHEMA_MISMATCH=readonly so the server can keep serving reads.
+func (r *Repository) CheckSchema(ctx context.Context) (readOnly bool, err error) {
+	err = r.createMigrationsTable(ctx)
+	if err != nil {
+		return false, err
+	}
+
+	if config.Env("AUTO_MIGRATE", "true") == "true" {
+		err = r.migrateUp(ctx)
+		if err != nil {
+			return false, err
+		}
+	}
+
+	version, err := r.SchemaVersion(ctx)
+	if err != nil {
+		return false, err
+	}
+	if version == ExpectedSchemaVersion {
+		logging.From(ctx).Info("Schema is up to date", "version", version)
+		return false, nil
+	}
+
+	mismatch := fmt.Errorf("schema version is %d but this binary expects %d", version, ExpectedSchemaVersion)
+	if config.Env("SCHEMA_MISMATCH", "fail") == "readonly" {
+		logging.From(ctx).Warn("Schema mismatch, serving read-only", "error", mismatch)
+		return true, nil
+	}
+	return false, mismatch
+}
+
+// SchemaVersion returns the highest applied migration version, or 0 if none have run.
+func (r *Repository) SchemaVersion(ctx context.Context) (int, error) {
+	var version sql.NullInt64
+	err := r.db.QueryRowContext(ctx, "SELECT MAX(version) FROM schema_migrations").Scan(&version)
+	if err != nil {
+		return 0, err
+	}
+	return int(version.Int64), nil
+}
+
+// MigrateUp applies every pending migration, whatever AUTO_MIGRATE says, and returns
+// the resulting schema version.
+func (r *Repository) MigrateUp(ctx context.Context) (int, error) {
+	err := r.createMigrationsTable(ctx)
+	if err != nil {
+		return 0, err
+	}
+	err = r.migrateUp(ctx)
+	if err != nil {
+		return 0, err
+	}
+	return r.SchemaVersion(ctx)
+}
+
+// MigrateDown reverts the applied migrations newer than version, newest first, leaving
+// the schema at that version. Reverting drops what those migrations added, data included.
+func (r *Repository) MigrateDown(ctx context.Context, version int) error {
+	if version < 0 {
+		return fmt.Errorf("invalid schema version %d", version)
+	}
+	err := r.createMigrationsTable(ctx)
+	if err != nil {
+		return err
+	}
+	current, err := r.SchemaVersion(ctx)
+	if err != nil {
+		return err
+	}
+
+	for i := len(migrations) - 1; i >= 0; i-- {
+		m := migrations[i]
+		if m.version <= version || m.version > current {
+			continue
+		}
+
+		err = m.down(ctx, r.db.DB)
+		if err != nil {
+			return fmt.Errorf("reverting migration %d (%s): %w", m.version, m.name, err)
+		}
+		_, err = r.db.ExecContext(ctx, "DELETE FROM schema_migrations WHERE version = ?", m.version)
+		if err != nil {
+			return err
+		}
+		logging.From(ctx).Info("Reverted migration", "version", m.version, "name", m.name)
+	}
+	return nil
+}
+
+func (r *Repository) createMigrationsTable(ctx context.Context) error {
+	_, err := r.db.ExecContext(ctx, `CREATE TABLE IF NOT EXISTS schema_migrations (
+			version INT PRIMARY KEY,
+			name VARCHAR(255) NOT NULL,
+			applied_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP
+		)`)
+	return err
+}
+
+// migrateUp applies every migration newer than the current schema version.
+func (r *Repository) migrateUp(ctx context.Context) error {
+	version, err := r.SchemaVersion(ctx)
+	if err != nil {
+		return err
+	}
+
+	for _, m := range migrations {
+		if m.version <= version {
+			continue
+		}
+
+		err = m.up(ctx, r.db.DB)
+		if err != nil {
+			return fmt.Errorf("migration %d (%s): %w", m.version, m.name, err)
+		}
+		_, err = r.db.ExecContext(ctx, "INSERT INTO schema_migrations (version, name) VALUES (?, ?)", m.version, m.name)
+		if err != nil {
+			return err
+		}
+		logging.From(ctx).Info("Applied migration", "version", m.version, "name", m.name)
+	}
+	return nil
+}
+
+// ensureUsernameUniqueIndex adds a unique index on users.username if the table doesn't have one yet.
+func ensureUsernameUniqueIndex(ctx context.Context, db *sql.DB) error {
+	var count int
+	err := db.QueryRowContext(ctx, `SELECT COUNT(*) FROM information_schema.statistics
+		WHERE table_schema = DATABASE() AND table_name = 'users' AND index_name = 'uniq_username'`).Scan(&count)
+	if err != nil {
+		return err
+	}
+	if count > 0 {
+		return nil
+	}
+
+	_, err = db.ExecContext(ctx, "ALTER TABLE users ADD UNIQUE KEY uniq_username (username)")
+	if err != nil {
+		return fmt.Errorf("adding unique index on users.username (are there duplicate usernames?): %w", err)
+	}
+	return nil
+}
+
+// normalizeEmails stores every email normalized and adds the canonical emails with
+// their unique index. When users already share a canonical email, the first one
+// created keeps it and the others are left without one, which the index ignores, and
+// logged; they can't register again, but fixing their email is up to an operator.
+func normalizeEmails(ctx context.Context, db *sql.DB) error {
+	_, err := db.ExecContext(ctx, "ALTER TABLE users ADD COLUMN email_canonical VARCHAR(50) NULL AFTER email")
+	if err != nil {
+		return err
+	}
+
+	rows, err := db.QueryContext(ctx, "SELECT id, tenant_id, email FROM users ORDER BY id")
+	if err != nil {
+		return err
+	}
+	type row struct {
+		id, tenantID int
+		email        string
+	}
+	var users []row
+	for rows.Next() {
+		var u row
+		err := rows.Scan(&u.id, &u.tenantID, &u.email)
+		if err != nil {
+			rows.Close()
+			return err
+		}
+		users = append(users, u)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	type tenantEmail struct {
+		tenantID int
+		email    string
+	}
+	owners := make(map[tenantEmail]int, len(users))
+	for _, u := range users {
+		canonical := sql.NullString{String: CanonicalEmail(u.email), Valid: true}
+		key := tenantEmail{u.tenantID, canonical.String}
+		if owner, taken := owners[key]; taken {
+			logging.From(ctx).Warn("Users share an email, leaving the later one without a canonical email",
+				"user_id", u.id, "owner_id", owner, "tenant_id", u.tenantID, "email", canonical.String)
+			canonical.Valid = false
+		} else {
+			owners[key] = u.id
+		}
+		// Keeping updated_at keeps the archiver from taking this for activity
+		_, err := db.ExecContext(ctx, "UPDATE users SET email = ?, email_canonical = ?, updated_at = updated_at WHERE id = ?",
+			models.NormalizeEmail(u.email), canonical, u.id)
+		if err != nil {
+			return err
+		}
+	}
+
+	_, err = db.ExecContext(ctx, "ALTER TABLE users ADD UNIQUE KEY "+emailIndex+" (tenant_id, email_canonical)")
+	return err
+}
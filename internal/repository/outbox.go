@@ -0,0 +1,139 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"strings"
+	"time"
+
+	"go-mysql/internal/tenant"
+	"go-mysql/pkg/retry"
+)
+
+// User event types recorded in the outbox.
+const (
+	EventUserCreated = "user.created"
+	EventUserUpdated = "user.updated"
+	EventUserDeleted = "user.deleted"
+	EventUserMerged  = "user.merged"
+)
+
+// OutboxEvent is a change to a user, recorded in the same transaction as the change
+// itself so it's published if and only if the change was committed.
+type OutboxEvent struct {
+	// ID increases with every event, so consumers can use it to drop duplicates.
+	ID       int64
+	Type     string
+	TenantID int
+	UserID   int
+	Payload  json.RawMessage
+}
+
+// withTx runs fn in a transaction, committing if it returns nil. A transaction
+// MySQL rolled back as a deadlock victim, or that timed out waiting for a lock, is
+// run again from the start, so fn must not have effects outside tx it can't repeat.
+func (r *Repository) withTx(ctx context.Context, fn func(tx *sql.Tx) error) error {
+	return retry.Do(ctx, txRetryPolicy, func(ctx context.Context) error {
+		tx, err := r.db.BeginTx(ctx, nil)
+		if err != nil {
+			return err
+		}
+		defer tx.Rollback()
+
+		err = fn(tx)
+		if err != nil {
+			return err
+		}
+		return tx.Commit()
+	})
+}
+
+// addOutboxEvent records an event about userID, of the tenant ctx belongs to, as part
+// of tx.
+func addOutboxEvent(ctx context.Context, tx *sql.Tx, eventType string, userID int, payload any) error {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+	_, err = tx.ExecContext(ctx, "INSERT INTO outbox (event_type, tenant_id, user_id, payload) VALUES (?, ?, ?, ?)",
+		eventType, tenant.ID(ctx), userID, data)
+	return err
+}
+
+// RelayOutbox passes up to limit unpublished events, oldest first, to publish and
+// marks the ones it accepted as published. It stops at the first event publish
+// rejects, so events are published in order, and returns how many were published.
+//
+// The batch stays locked until it's marked, keeping other instances' relays off it.
+// An event published just before the marking fails will be published again, so
+// delivery is at least once.
+func (r *Repository) RelayOutbox(ctx context.Context, limit int, publish func(ctx context.Context, event OutboxEvent) error) (int, error) {
+	published := 0
+	err := r.withTx(ctx, func(tx *sql.Tx) error {
+		rows, err := tx.QueryContext(ctx, `SELECT id, event_type, tenant_id, user_id, payload FROM outbox
+			WHERE published_at IS NULL ORDER BY id LIMIT ? FOR UPDATE SKIP LOCKED`, limit)
+		if err != nil {
+			return err
+		}
+		var events []OutboxEvent
+		for rows.Next() {
+			var e OutboxEvent
+			err := rows.Scan(&e.ID, &e.Type, &e.TenantID, &e.UserID, &e.Payload)
+			if err != nil {
+				rows.Close()
+				return err
+			}
+			events = append(events, e)
+		}
+		rows.Close()
+		if err := rows.Err(); err != nil {
+			return err
+		}
+
+		var ids []any
+		var publishErr error
+		for _, e := range events {
+			publishErr = publish(ctx, e)
+			if publishErr != nil {
+				break
+			}
+			ids = append(ids, e.ID)
+		}
+		if len(ids) > 0 {
+			in := strings.TrimSuffix(strings.Repeat("?,", len(ids)), ",")
+			_, err = tx.ExecContext(ctx, "UPDATE outbox SET published_at = CURRENT_TIMESTAMP(6) WHERE id IN ("+in+")", ids...)
+			if err != nil {
+				return err
+			}
+			published = len(ids)
+		}
+		if publishErr != nil {
+			// Commit what was published before returning the error
+			err = tx.Commit()
+			if err != nil {
+				published = 0
+				return err
+			}
+			return publishErr
+		}
+		return nil
+	})
+	return published, err
+}
+
+// PurgeOutbox deletes events published before cutoff and returns how many it deleted.
+func (r *Repository) PurgeOutbox(ctx context.Context, cutoff time.Time) (int64, error) {
+	res, err := r.db.ExecContext(ctx, "DELETE FROM outbox WHERE published_at < ? LIMIT 10000", cutoff)
+	if err != nil {
+		return 0, err
+	}
+	return res.RowsAffected()
+}
+
+// OutboxBacklog returns how many events are waiting to be published.
+func (r *Repository) OutboxBacklog(ctx context.Context) (int, error) {
+	var n int
+	err := r.db.QueryRowContext(ctx, "SELECT COUNT(*) FROM outbox WHERE published_at IS NULL").Scan(&n)
+	return n, err
+}
@@ -0,0 +1,19 @@
+package repository
+
+import "context"
+
+// totalColumn is added to the columns of paged queries so that every row also holds
+// how many rows match in total, read in the same query as the page. It takes the place
+// of SQL_CALC_FOUND_ROWS, which MySQL deprecated.
+const totalColumn = ", COUNT(*) OVER ()"
+
+// pageTotal returns total, read from the totalColumn of a page of n rows, unless the
+// page came back empty with rows before it, which leaves no row to read the total from;
+// countQuery then counts the rows with args.
+func (r *Repository) pageTotal(ctx context.Context, total, n, offset int, countQuery string, args ...any) (int, error) {
+	if n > 0 || offset == 0 {
+		return total, nil
+	}
+	err := r.db.QueryRowContext(ctx, countQuery, args...).Scan(&total)
+	return total, err
+}
@@ -0,0 +1,31 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+
+	"go-mysql/internal/tenant"
+)
+
+// UserPreferences returns the preferences stored for the user with the given id as a
+// JSON object, nil if it has none, or sql.ErrNoRows if there's no such user.
+func (r *Repository) UserPreferences(ctx context.Context, id int) ([]byte, error) {
+	var preferences []byte
+	err := r.db.QueryRowContext(ctx, `SELECT p.preferences FROM users u LEFT JOIN user_preferences p ON p.user_id = u.id
+		WHERE u.tenant_id = ? AND u.id = ?`, tenant.ID(ctx), id).Scan(&preferences)
+	return preferences, err
+}
+
+// SetUserPreferences replaces the preferences of the user with the given id with
+// preferences, a JSON object, or returns sql.ErrNoRows if there's no such user.
+func (r *Repository) SetUserPreferences(ctx context.Context, id int, preferences []byte) error {
+	return r.withTx(ctx, func(tx *sql.Tx) error {
+		err := lockUser(ctx, tx, id)
+		if err != nil {
+			return err
+		}
+		_, err = tx.ExecContext(ctx, `INSERT INTO user_preferences (user_id, preferences) VALUES (?, ?)
+			ON DUPLICATE KEY UPDATE preferences = VALUES(preferences)`, id, preferences)
+		return err
+	})
+}
@@ -0,0 +1,94 @@
+// Package repository runs the service's MySQL queries and manages the schema.
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/go-sql-driver/mysql"
+
+	"go-mysql/pkg/breaker"
+	"go-mysql/pkg/retry"
+)
+
+// ErrDuplicate is returned when a write would break a unique index, such as creating
+// a user with a taken username.
+var ErrDuplicate = errors.New("duplicate entry")
+
+// ErrDuplicateEmail is returned instead of ErrDuplicate when the broken index is the
+// one on users' canonical emails, see CanonicalEmail.
+var ErrDuplicateEmail = errors.New("duplicate email")
+
+// ErrReferenced is returned when deleting a row other rows still refer to, such as a
+// tenant that has users.
+var ErrReferenced = errors.New("row is referenced")
+
+// ErrMerged is returned when merging a user that was merged into another already, or
+// into such a user.
+var ErrMerged = errors.New("user already merged")
+
+// ErrLastOwner is returned when removing or demoting the only owner of a group.
+var ErrLastOwner = errors.New("last owner of the group")
+
+// MySQL error numbers translated by translateErr or retried by withTx.
+const (
+	duplicateEntry  = 1062 // ER_DUP_ENTRY
+	rowIsReferenced = 1451 // ER_ROW_IS_REFERENCED_2
+	lockWaitTimeout = 1205 // ER_LOCK_WAIT_TIMEOUT
+	deadlock        = 1213 // ER_LOCK_DEADLOCK
+)
+
+// txRetryPolicy retries transactions that lost a lock conflict a few times, quickly.
+var txRetryPolicy = retry.Policy{
+	MaxAttempts:  3,
+	InitialDelay: 20 * time.Millisecond,
+	MaxDelay:     200 * time.Millisecond,
+	Jitter:       0.5,
+	Retryable:    isLockConflict,
+}
+
+// isLockConflict reports whether err is MySQL giving up on a transaction because of
+// another one holding its locks; running it again usually succeeds.
+func isLockConflict(err error) bool {
+	var mysqlErr *mysql.MySQLError
+	return errors.As(err, &mysqlErr) && (mysqlErr.Number == deadlock || mysqlErr.Number == lockWaitTimeout)
+}
+
+// translateErr replaces MySQL errors callers act on with this package's errors.
+func translateErr(err error) error {
+	var mysqlErr *mysql.MySQLError
+	if !errors.As(err, &mysqlErr) {
+		return err
+	}
+	switch mysqlErr.Number {
+	case duplicateEntry:
+		if strings.Contains(mysqlErr.Message, emailIndex) {
+			return ErrDuplicateEmail
+		}
+		return ErrDuplicate
+	case rowIsReferenced:
+		return ErrReferenced
+	}
+	return err
+}
+
+// Repository runs queries on a MySQL connection pool.
+type Repository struct {
+	db pool
+}
+
+// New returns a Repository querying db, which must be opened with Driver. If b isn't
+// nil, it's put around MySQL: while it's open, opening a connection or running a
+// statement fails right away with breaker.ErrOpen instead of waiting on an unreachable
+// server. Only errors reaching MySQL count against it, not those MySQL answers with.
+func New(db *sql.DB, b *breaker.Breaker) *Repository {
+	return &Repository{db: pool{DB: db, breaker: b}}
+}
+
+// Ping checks that MySQL can be reached.
+func (r *Repository) Ping(ctx context.Context) error {
+	return r.db.PingContext(ctx)
+}
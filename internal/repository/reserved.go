@@ -0,0 +1,53 @@
+package repository
+
+import (
+	"context"
+
+	"go-mysql/internal/models"
+	"go-mysql/internal/tenant"
+)
+
+// ReservedUsernames returns the usernames the tenant ctx belongs to reserved, ordered
+// by name.
+func (r *Repository) ReservedUsernames(ctx context.Context) ([]models.ReservedUsername, error) {
+	rows, err := r.db.QueryContext(ctx, "SELECT name, kind FROM reserved_usernames WHERE tenant_id = ? ORDER BY name, kind", tenant.ID(ctx))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	reserved := []models.ReservedUsername{}
+	for rows.Next() {
+		var name models.ReservedUsername
+		err := rows.Scan(&name.Name, &name.Match)
+		if err != nil {
+			return nil, err
+		}
+		reserved = append(reserved, name)
+	}
+	return reserved, rows.Err()
+}
+
+// AddReservedUsername reserves name for the tenant ctx belongs to, reporting whether
+// it wasn't already.
+func (r *Repository) AddReservedUsername(ctx context.Context, name models.ReservedUsername) (bool, error) {
+	res, err := r.db.ExecContext(ctx, "INSERT IGNORE INTO reserved_usernames (tenant_id, name, kind) VALUES (?, ?, ?)",
+		tenant.ID(ctx), name.Name, name.Match)
+	if err != nil {
+		return false, err
+	}
+	n, err := res.RowsAffected()
+	return n > 0, err
+}
+
+// DeleteReservedUsername releases name for the tenant ctx belongs to, reporting whether
+// it was reserved.
+func (r *Repository) DeleteReservedUsername(ctx context.Context, name models.ReservedUsername) (bool, error) {
+	res, err := r.db.ExecContext(ctx, "DELETE FROM reserved_usernames WHERE tenant_id = ? AND name = ? AND kind = ?",
+		tenant.ID(ctx), name.Name, name.Match)
+	if err != nil {
+		return false, err
+	}
+	n, err := res.RowsAffected()
+	return n > 0, err
+}
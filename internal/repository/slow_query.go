@@ -0,0 +1,103 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/go-sql-driver/mysql"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/qustavo/sqlhooks/v2"
+
+	"go-mysql/internal/config"
+	"go-mysql/internal/logging"
+)
+
+// Driver is the name of the MySQL driver with slow query logging and the circuit
+// breaker passed to New, to open the connection pool with.
+const Driver = "mysql+slowlog"
+
+var slowQueries = promauto.NewCounter(prometheus.CounterOpts{
+	Name: "mysql_slow_queries_total",
+	Help: "SQL statements that took longer than SLOW_QUERY_THRESHOLD.",
+})
+
+func init() {
+	sql.Register(Driver, breakerDriver{sqlhooks.Wrap(&mysql.MySQLDriver{}, sqlhooks.Compose(
+		slowQueryHooks{threshold: config.EnvDuration("SLOW_QUERY_THRESHOLD", 200*time.Millisecond)},
+		breakerHooks{},
+	))})
+}
+
+type queryStartKey struct{}
+
+// slowQueryHooks logs statements taking longer than threshold, failed or not. Only the
+// shape of the arguments is logged, never their values.
+type slowQueryHooks struct {
+	threshold time.Duration
+}
+
+func (h slowQueryHooks) Before(ctx context.Context, query string, args ...any) (context.Context, error) {
+	return context.WithValue(ctx, queryStartKey{}, time.Now()), nil
+}
+
+func (h slowQueryHooks) After(ctx context.Context, query string, args ...any) (context.Context, error) {
+	h.check(ctx, nil, query, args)
+	return ctx, nil
+}
+
+func (h slowQueryHooks) OnError(ctx context.Context, err error, query string, args ...any) error {
+	h.check(ctx, err, query, args)
+	return err
+}
+
+func (h slowQueryHooks) check(ctx context.Context, err error, query string, args []any) {
+	start, ok := ctx.Value(queryStartKey{}).(time.Time)
+	if !ok || h.threshold <= 0 {
+		return
+	}
+	elapsed := time.Since(start)
+	if elapsed < h.threshold {
+		return
+	}
+	slowQueries.Inc()
+	attrs := []any{"query", sanitizeQuery(query), "args", argShapes(args), "duration", elapsed}
+	if err != nil {
+		attrs = append(attrs, "error", err)
+	}
+	logging.From(ctx).Warn("Slow query", attrs...)
+}
+
+var (
+	queryWhitespace = regexp.MustCompile(`\s+`)
+	queryLiterals   = regexp.MustCompile(`'(?:[^'\\]|\\.)*'|"(?:[^"\\]|\\.)*"|\b\d+(?:\.\d+)?\b`)
+)
+
+// sanitizeQuery collapses whitespace and replaces literals with ? so values inlined
+// into a statement don't end up in the logs.
+func sanitizeQuery(query string) string {
+	query = queryWhitespace.ReplaceAllString(strings.TrimSpace(query), " ")
+	return queryLiterals.ReplaceAllString(query, "?")
+}
+
+// argShapes describes args by type, and by length for strings and byte slices.
+func argShapes(args []any) []string {
+	shapes := make([]string, len(args))
+	for i, arg := range args {
+		switch v := arg.(type) {
+		case nil:
+			shapes[i] = "null"
+		case string:
+			shapes[i] = fmt.Sprintf("string(%d)", len(v))
+		case []byte:
+			shapes[i] = fmt.Sprintf("bytes(%d)", len(v))
+		default:
+			shapes[i] = fmt.Sprintf("%T", v)
+		}
+	}
+	return shapes
+}
@@ -0,0 +1,47 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"go-mysql/internal/tenant"
+)
+
+// CountUsers returns how many users the tenant ctx belongs to has, and how many of
+// them were last seen after activeSince.
+func (r *Repository) CountUsers(ctx context.Context, activeSince time.Time) (total, active int, err error) {
+	err = r.db.QueryRowContext(ctx, "SELECT COUNT(*), COUNT(CASE WHEN last_seen_at > ? THEN 1 END) FROM users WHERE tenant_id = ?",
+		activeSince, tenant.ID(ctx)).Scan(&total, &active)
+	return total, active, err
+}
+
+// MaxUserID returns the highest id of a user of any tenant, 0 if there are none.
+func (r *Repository) MaxUserID(ctx context.Context) (int, error) {
+	var id int
+	err := r.db.QueryRowContext(ctx, "SELECT COALESCE(MAX(id), 0) FROM users").Scan(&id)
+	return id, err
+}
+
+// SignupsPerDay returns how many of the users of the tenant ctx belongs to were created
+// on each day since since, keyed by the day as YYYY-MM-DD. Days without signups are
+// left out.
+func (r *Repository) SignupsPerDay(ctx context.Context, since time.Time) (map[string]int, error) {
+	rows, err := r.db.QueryContext(ctx, `SELECT DATE_FORMAT(created_at, '%Y-%m-%d'), COUNT(*) FROM users
+		WHERE tenant_id = ? AND created_at >= ? GROUP BY 1`, tenant.ID(ctx), since)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	signups := make(map[string]int)
+	for rows.Next() {
+		var day string
+		var count int
+		err := rows.Scan(&day, &count)
+		if err != nil {
+			return nil, err
+		}
+		signups[day] = count
+	}
+	return signups, rows.Err()
+}
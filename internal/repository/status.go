@@ -0,0 +1,33 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+
+	"go-mysql/internal/models"
+	"go-mysql/internal/tenant"
+)
+
+// SetUserStatus changes the status of the user with the given id from the status from
+// to status, and returns the user as it is afterwards, whose status is left alone if it
+// wasn't from. It returns sql.ErrNoRows if there's no such user. A change records a
+// user.updated event.
+func (r *Repository) SetUserStatus(ctx context.Context, id int, from, status string) (models.User, error) {
+	var user models.User
+	err := r.withTx(ctx, func(tx *sql.Tx) error {
+		var err error
+		user, err = scanUser(tx.QueryRowContext(ctx, "SELECT "+userColumns+" FROM users WHERE tenant_id = ? AND id = ? FOR UPDATE",
+			tenant.ID(ctx), id))
+		if err != nil || user.Status != from {
+			return err
+		}
+		_, err = tx.ExecContext(ctx, "UPDATE users SET status = ?, deactivated_at = IF(? = ?, NOW(), NULL) WHERE id = ?",
+			status, status, models.StatusDeactivated, id)
+		if err != nil {
+			return err
+		}
+		user.Status = status
+		return addOutboxEvent(ctx, tx, EventUserUpdated, id, user)
+	})
+	return user, err
+}
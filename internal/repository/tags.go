@@ -0,0 +1,148 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+
+	"go-mysql/internal/models"
+	"go-mysql/internal/tenant"
+)
+
+// TagUser gives the user with the given id the tag called name, creating the tag if
+// the tenant doesn't have it yet. It reports whether the user didn't have it, or
+// returns sql.ErrNoRows if there's no such user.
+func (r *Repository) TagUser(ctx context.Context, id int, name string) (bool, error) {
+	var added bool
+	err := r.withTx(ctx, func(tx *sql.Tx) error {
+		err := lockUser(ctx, tx, id)
+		if err != nil {
+			return err
+		}
+		// LAST_INSERT_ID(id) makes an existing tag's id available too
+		res, err := tx.ExecContext(ctx, "INSERT INTO tags (tenant_id, name) VALUES (?, ?) ON DUPLICATE KEY UPDATE id = LAST_INSERT_ID(id)",
+			tenant.ID(ctx), name)
+		if err != nil {
+			return err
+		}
+		tagID, err := res.LastInsertId()
+		if err != nil {
+			return err
+		}
+		res, err = tx.ExecContext(ctx, "INSERT IGNORE INTO user_tags (user_id, tag_id) VALUES (?, ?)", id, tagID)
+		if err != nil {
+			return err
+		}
+		affected, err := res.RowsAffected()
+		added = affected > 0
+		return err
+	})
+	return added, err
+}
+
+// UntagUser takes the tag called name from the user with the given id. It reports
+// whether the user had it, or returns sql.ErrNoRows if there's no such user.
+func (r *Repository) UntagUser(ctx context.Context, id int, name string) (bool, error) {
+	var removed bool
+	err := r.withTx(ctx, func(tx *sql.Tx) error {
+		err := lockUser(ctx, tx, id)
+		if err != nil {
+			return err
+		}
+		res, err := tx.ExecContext(ctx, `DELETE ut FROM user_tags ut JOIN tags t ON t.id = ut.tag_id
+			WHERE t.tenant_id = ? AND t.name = ? AND ut.user_id = ?`, tenant.ID(ctx), name, id)
+		if err != nil {
+			return err
+		}
+		affected, err := res.RowsAffected()
+		removed = affected > 0
+		return err
+	})
+	return removed, err
+}
+
+// UserTags returns the names of the tags of the user with the given id, in name order,
+// or sql.ErrNoRows if there's no such user.
+func (r *Repository) UserTags(ctx context.Context, id int) ([]string, error) {
+	rows, err := r.db.QueryContext(ctx, `SELECT t.name FROM users u
+		LEFT JOIN user_tags ut ON ut.user_id = u.id LEFT JOIN tags t ON t.id = ut.tag_id
+		WHERE u.tenant_id = ? AND u.id = ? ORDER BY t.name`, tenant.ID(ctx), id)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	found := false
+	tags := []string{}
+	for rows.Next() {
+		found = true
+		var name sql.NullString
+		err := rows.Scan(&name)
+		if err != nil {
+			return nil, err
+		}
+		if name.Valid {
+			tags = append(tags, name.String)
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	if !found {
+		return nil, sql.ErrNoRows
+	}
+	return tags, nil
+}
+
+// TagCounts returns the tags of the tenant ctx belongs to that active users have, with
+// how many have each, most used first.
+func (r *Repository) TagCounts(ctx context.Context) ([]models.TagCount, error) {
+	rows, err := r.db.QueryContext(ctx, `SELECT t.name, COUNT(*) AS users FROM tags t
+		JOIN user_tags ut ON ut.tag_id = t.id JOIN users u ON u.id = ut.user_id
+		WHERE t.tenant_id = ? AND u.status = ? GROUP BY t.id, t.name ORDER BY users DESC, t.name`,
+		tenant.ID(ctx), models.StatusActive)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	counts := []models.TagCount{}
+	for rows.Next() {
+		var c models.TagCount
+		err := rows.Scan(&c.Name, &c.Users)
+		if err != nil {
+			return nil, err
+		}
+		counts = append(counts, c)
+	}
+	return counts, rows.Err()
+}
+
+// UsersByTag returns the active users of the tenant ctx belongs to that have the tag
+// called name, in id order, skipping offset of them and returning at most limit, and
+// how many there are in total.
+func (r *Repository) UsersByTag(ctx context.Context, name string, offset, limit int) ([]models.User, int, error) {
+	const from = ` FROM users WHERE tenant_id = ? AND status = ? AND id IN
+		(SELECT ut.user_id FROM user_tags ut JOIN tags t ON t.id = ut.tag_id WHERE t.tenant_id = ? AND t.name = ?)`
+	args := []any{tenant.ID(ctx), models.StatusActive, tenant.ID(ctx), name}
+	rows, err := r.db.QueryContext(ctx, "SELECT "+userColumns+totalColumn+from+" ORDER BY id LIMIT ? OFFSET ?",
+		append(args, limit, offset)...)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+
+	users := []models.User{}
+	total := 0
+	for rows.Next() {
+		user, err := scanUser(rows, &total)
+		if err != nil {
+			return nil, 0, err
+		}
+		users = append(users, user)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, 0, err
+	}
+	total, err = r.pageTotal(ctx, total, len(users), offset, "SELECT COUNT(*)"+from, args...)
+	return users, total, err
+}
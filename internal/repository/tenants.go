@@ -0,0 +1,67 @@
+package repository
+
+import (
+	"context"
+
+	"go-mysql/internal/models"
+)
+
+const tenantColumns = "id, slug, name, created_at"
+
+func scanTenant(row interface{ Scan(...any) error }) (models.Tenant, error) {
+	var t models.Tenant
+	err := row.Scan(&t.ID, &t.Slug, &t.Name, &t.CreatedAt)
+	return t, err
+}
+
+// Tenants returns every tenant ordered by id.
+func (r *Repository) Tenants(ctx context.Context) ([]models.Tenant, error) {
+	rows, err := r.db.QueryContext(ctx, "SELECT "+tenantColumns+" FROM tenants ORDER BY id")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var tenants []models.Tenant
+	for rows.Next() {
+		t, err := scanTenant(rows)
+		if err != nil {
+			return nil, err
+		}
+		tenants = append(tenants, t)
+	}
+	return tenants, rows.Err()
+}
+
+// TenantBySlug returns sql.ErrNoRows if there is no such tenant.
+func (r *Repository) TenantBySlug(ctx context.Context, slug string) (models.Tenant, error) {
+	return scanTenant(r.db.QueryRowContext(ctx, "SELECT "+tenantColumns+" FROM tenants WHERE slug = ?", slug))
+}
+
+// TenantByAPIKey returns the tenant of the user a key was issued to, or sql.ErrNoRows
+// if no key has that hash or the user isn't active.
+func (r *Repository) TenantByAPIKey(ctx context.Context, keyHash string) (models.Tenant, error) {
+	return scanTenant(r.db.QueryRowContext(ctx, `SELECT t.id, t.slug, t.name, t.created_at FROM api_keys k
+		JOIN users u ON u.id = k.user_id JOIN tenants t ON t.id = u.tenant_id WHERE k.key_hash = ? AND u.status = 'active'`, keyHash))
+}
+
+// CreateTenant stores t and returns its id. It returns ErrDuplicate if the slug is taken.
+func (r *Repository) CreateTenant(ctx context.Context, t models.Tenant) (int, error) {
+	res, err := r.db.ExecContext(ctx, "INSERT INTO tenants (slug, name) VALUES (?, ?)", t.Slug, t.Name)
+	if err != nil {
+		return 0, translateErr(err)
+	}
+	id, err := res.LastInsertId()
+	return int(id), err
+}
+
+// DeleteTenant deletes a tenant along with its webhooks, reporting whether it existed.
+// It returns ErrReferenced if the tenant still has users.
+func (r *Repository) DeleteTenant(ctx context.Context, id int) (bool, error) {
+	res, err := r.db.ExecContext(ctx, "DELETE FROM tenants WHERE id = ?", id)
+	if err != nil {
+		return false, translateErr(err)
+	}
+	affected, err := res.RowsAffected()
+	return affected > 0, err
+}
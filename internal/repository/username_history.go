@@ -0,0 +1,70 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"go-mysql/internal/models"
+	"go-mysql/internal/tenant"
+)
+
+// RenameUser changes the username of the user with the given id and records the change
+// in its username history, returning the previous username. It returns sql.ErrNoRows
+// if there's no such user and ErrDuplicate if the username is taken. A change records
+// a user.updated event.
+func (r *Repository) RenameUser(ctx context.Context, id int, username string) (previous string, err error) {
+	err = r.withTx(ctx, func(tx *sql.Tx) error {
+		err := tx.QueryRowContext(ctx, "SELECT username FROM users WHERE tenant_id = ? AND id = ? FOR UPDATE",
+			tenant.ID(ctx), id).Scan(&previous)
+		if err != nil || previous == username {
+			return err
+		}
+		_, err = tx.ExecContext(ctx, "UPDATE users SET username = ? WHERE tenant_id = ? AND id = ?", username, tenant.ID(ctx), id)
+		if err != nil {
+			return translateErr(err)
+		}
+		_, err = tx.ExecContext(ctx, "INSERT INTO username_history (tenant_id, user_id, old_username, new_username) VALUES (?, ?, ?, ?)",
+			tenant.ID(ctx), id, previous, username)
+		if err != nil {
+			return err
+		}
+		return addOutboxEvent(ctx, tx, EventUserUpdated, id, struct {
+			models.User
+			PreviousUsername string `json:"previous_username"`
+		}{models.User{ID: id, Username: username}, previous})
+	})
+	return previous, err
+}
+
+// UsernameHistory returns the username changes of the user with the given id, latest
+// first.
+func (r *Repository) UsernameHistory(ctx context.Context, userID int) ([]models.UsernameChange, error) {
+	rows, err := r.db.QueryContext(ctx, `SELECT old_username, new_username, changed_at FROM username_history
+		WHERE tenant_id = ? AND user_id = ? ORDER BY id DESC`, tenant.ID(ctx), userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var changes []models.UsernameChange
+	for rows.Next() {
+		var c models.UsernameChange
+		err := rows.Scan(&c.OldUsername, &c.NewUsername, &c.ChangedAt)
+		if err != nil {
+			return nil, err
+		}
+		changes = append(changes, c)
+	}
+	return changes, rows.Err()
+}
+
+// UserIDByFormerUsername returns the id of the user that was last called username, if
+// it changed that username after since, or sql.ErrNoRows.
+func (r *Repository) UserIDByFormerUsername(ctx context.Context, username string, since time.Time) (int, error) {
+	var id int
+	err := r.db.QueryRowContext(ctx, `SELECT user_id FROM username_history
+		WHERE tenant_id = ? AND old_username = ? AND changed_at > ? ORDER BY id DESC LIMIT 1`,
+		tenant.ID(ctx), username, since).Scan(&id)
+	return id, err
+}
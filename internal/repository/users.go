@@ -0,0 +1,363 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"slices"
+	"strings"
+	"time"
+
+	"go-mysql/internal/config"
+	"go-mysql/internal/models"
+	"go-mysql/internal/tenant"
+)
+
+const userColumns = "id, username, email, status, first_name, last_name, display_name, bio, avatar_url, avatar_key, " +
+	"phone, address_line1, address_line2, city, region, postal_code, country"
+
+// emailIndex is the unique index on the canonical form of users' emails.
+const emailIndex = "uniq_tenant_email"
+
+// CanonicalEmail returns the form of email no two users of a tenant may share, see
+// models.CanonicalEmail; EMAIL_IGNORE_GMAIL_DOTS makes Gmail addresses that only
+// differ by dots the same. Changing it leaves the emails already stored as they are.
+func CanonicalEmail(email string) string {
+	return models.CanonicalEmail(email, config.EnvBool("EMAIL_IGNORE_GMAIL_DOTS", false))
+}
+
+// scanUser reads a row selected with userColumns, followed by extra columns if any.
+func scanUser(row interface{ Scan(...any) error }, extra ...any) (models.User, error) {
+	var user models.User
+	dest := []any{&user.ID, &user.Username, &user.Email, &user.Status, &user.FirstName, &user.LastName, &user.DisplayName, &user.Bio, &user.AvatarURL, &user.AvatarKey,
+		&user.Phone, &user.AddressLine1, &user.AddressLine2, &user.City, &user.Region, &user.PostalCode, &user.Country}
+	err := row.Scan(append(dest, extra...)...)
+	return user, err
+}
+
+// Users returns every user of the tenant ctx belongs to, ordered by id. Like every
+// query on users below, it only sees that tenant's users.
+func (r *Repository) Users(ctx context.Context) ([]models.User, error) {
+	rows, err := r.db.QueryContext(ctx, "SELECT "+userColumns+" FROM users WHERE tenant_id = ? ORDER BY id", tenant.ID(ctx))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var users []models.User
+	for rows.Next() {
+		user, err := scanUser(rows)
+		if err != nil {
+			return nil, err
+		}
+		users = append(users, user)
+	}
+	return users, rows.Err()
+}
+
+// EachUser calls fn with every user and when it was created, in id order, stopping at
+// the first error.
+func (r *Repository) EachUser(ctx context.Context, fn func(user models.User, createdAt time.Time) error) error {
+	rows, err := r.db.QueryContext(ctx, "SELECT "+userColumns+", created_at FROM users WHERE tenant_id = ? ORDER BY id", tenant.ID(ctx))
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var createdAt time.Time
+		user, err := scanUser(rows, &createdAt)
+		if err != nil {
+			return err
+		}
+		err = fn(user, createdAt)
+		if err != nil {
+			return err
+		}
+	}
+	return rows.Err()
+}
+
+// UserByID returns sql.ErrNoRows if there is no such user.
+func (r *Repository) UserByID(ctx context.Context, id int) (models.User, error) {
+	return scanUser(r.db.QueryRowContext(ctx, "SELECT "+userColumns+" FROM users WHERE tenant_id = ? AND id = ?", tenant.ID(ctx), id))
+}
+
+// UserIDByUsername returns the id of the user called username, or sql.ErrNoRows.
+func (r *Repository) UserIDByUsername(ctx context.Context, username string) (int, error) {
+	var id int
+	err := r.db.QueryRowContext(ctx, "SELECT id FROM users WHERE tenant_id = ? AND username = ?", tenant.ID(ctx), username).Scan(&id)
+	return id, err
+}
+
+// UsersByPhone returns up to limit active users whose phone is phone, stored in E.164
+// form, in id order. Users may share a phone, such as a family's landline.
+func (r *Repository) UsersByPhone(ctx context.Context, phone string, limit int) ([]models.User, error) {
+	rows, err := r.db.QueryContext(ctx, "SELECT "+userColumns+" FROM users WHERE tenant_id = ? AND phone = ? AND status = ? ORDER BY id LIMIT ?",
+		tenant.ID(ctx), phone, models.StatusActive, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	users := []models.User{}
+	for rows.Next() {
+		user, err := scanUser(rows)
+		if err != nil {
+			return nil, err
+		}
+		users = append(users, user)
+	}
+	return users, rows.Err()
+}
+
+// CreateUser inserts user and returns its id, recording a user.created event. It
+// returns ErrDuplicate if the username is taken, or ErrDuplicateEmail if the email is.
+func (r *Repository) CreateUser(ctx context.Context, user models.User) (int, error) {
+	err := r.withTx(ctx, func(tx *sql.Tx) error {
+		res, err := tx.ExecContext(ctx, `INSERT INTO users (tenant_id, username, email, email_canonical, first_name, last_name, display_name, bio, avatar_url,
+			phone, address_line1, address_line2, city, region, postal_code, country)
+			VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+			tenant.ID(ctx), user.Username, user.Email, CanonicalEmail(user.Email), user.FirstName, user.LastName, user.DisplayName, user.Bio, user.AvatarURL,
+			user.Phone, user.AddressLine1, user.AddressLine2, user.City, user.Region, user.PostalCode, user.Country)
+		if err != nil {
+			return translateErr(err)
+		}
+		id, err := res.LastInsertId()
+		if err != nil {
+			return err
+		}
+		user.ID = int(id)
+		return addOutboxEvent(ctx, tx, EventUserCreated, user.ID, user)
+	})
+	if err != nil {
+		return 0, err
+	}
+	return user.ID, nil
+}
+
+// CreateUsers inserts users in one statement and returns them with their ids, recording
+// a user.created event for each. Either all are inserted or none is: it returns
+// ErrDuplicate if a username is taken, or ErrDuplicateEmail if an email is.
+func (r *Repository) CreateUsers(ctx context.Context, users []models.User) ([]models.User, error) {
+	if len(users) == 0 {
+		return nil, nil
+	}
+	created := slices.Clone(users)
+	err := r.withTx(ctx, func(tx *sql.Tx) error {
+		rows := make([]string, len(created))
+		args := make([]any, 0, 16*len(created))
+		usernames := make([]any, 0, len(created)+1)
+		usernames = append(usernames, tenant.ID(ctx))
+		for i, user := range created {
+			rows[i] = "(?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)"
+			args = append(args, tenant.ID(ctx), user.Username, user.Email, CanonicalEmail(user.Email), user.FirstName, user.LastName, user.DisplayName, user.Bio, user.AvatarURL,
+				user.Phone, user.AddressLine1, user.AddressLine2, user.City, user.Region, user.PostalCode, user.Country)
+			usernames = append(usernames, user.Username)
+		}
+		_, err := tx.ExecContext(ctx, `INSERT INTO users (tenant_id, username, email, email_canonical, first_name, last_name, display_name, bio, avatar_url,
+			phone, address_line1, address_line2, city, region, postal_code, country)
+			VALUES `+strings.Join(rows, ", "), args...)
+		if err != nil {
+			return translateErr(err)
+		}
+
+		// Auto-increment ids of a multi-row insert aren't guaranteed to be consecutive, so
+		// they're read back
+		in := strings.TrimSuffix(strings.Repeat("?,", len(created)), ",")
+		idRows, err := tx.QueryContext(ctx, "SELECT id, username FROM users WHERE tenant_id = ? AND username IN ("+in+")", usernames...)
+		if err != nil {
+			return err
+		}
+		ids := make(map[string]int, len(created))
+		for idRows.Next() {
+			var id int
+			var username string
+			err := idRows.Scan(&id, &username)
+			if err != nil {
+				idRows.Close()
+				return err
+			}
+			ids[strings.ToLower(username)] = id
+		}
+		idRows.Close()
+		if err := idRows.Err(); err != nil {
+			return err
+		}
+
+		for i := range created {
+			created[i].ID = ids[strings.ToLower(created[i].Username)]
+			err := addOutboxEvent(ctx, tx, EventUserCreated, created[i].ID, created[i])
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return created, nil
+}
+
+// UpsertUser creates the user called user.Username, or updates its profile if it
+// exists; the email of an existing user only changes through ConfirmEmailChange.
+// created reports which happened. It records a user.created or user.updated event, or
+// none if nothing changed. It returns ErrDuplicateEmail if another user has the email.
+func (r *Repository) UpsertUser(ctx context.Context, user models.User) (id int, created bool, err error) {
+	canonical := CanonicalEmail(user.Email)
+	err = r.withTx(ctx, func(tx *sql.Tx) error {
+		// ON DUPLICATE KEY UPDATE would update whichever row has the email if it's
+		// another user's, so that's ruled out first. The locking read also keeps another
+		// user from taking the email meanwhile.
+		var owner string
+		err := tx.QueryRowContext(ctx, "SELECT username FROM users WHERE tenant_id = ? AND email_canonical = ? FOR UPDATE",
+			tenant.ID(ctx), canonical).Scan(&owner)
+		if err == nil && owner != user.Username {
+			return ErrDuplicateEmail
+		}
+		if err != nil && err != sql.ErrNoRows {
+			return err
+		}
+
+		// LAST_INSERT_ID(id) makes the existing row's id available on update too
+		res, err := tx.ExecContext(ctx, `INSERT INTO users (tenant_id, username, email, email_canonical, first_name, last_name, display_name, bio, avatar_url,
+			phone, address_line1, address_line2, city, region, postal_code, country)
+			VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+			ON DUPLICATE KEY UPDATE id = LAST_INSERT_ID(id),
+			first_name = VALUES(first_name), last_name = VALUES(last_name), display_name = VALUES(display_name), bio = VALUES(bio),
+			avatar_url = VALUES(avatar_url), phone = VALUES(phone), address_line1 = VALUES(address_line1),
+			address_line2 = VALUES(address_line2), city = VALUES(city), region = VALUES(region), postal_code = VALUES(postal_code),
+			country = VALUES(country)`,
+			tenant.ID(ctx), user.Username, user.Email, canonical, user.FirstName, user.LastName, user.DisplayName, user.Bio, user.AvatarURL,
+			user.Phone, user.AddressLine1, user.AddressLine2, user.City, user.Region, user.PostalCode, user.Country)
+		if err != nil {
+			return translateErr(err)
+		}
+
+		lastID, err := res.LastInsertId()
+		if err != nil {
+			return err
+		}
+		user.ID = int(lastID)
+
+		// MySQL reports 1 affected row for an insert, 2 for an update and 0 when nothing changed
+		affected, err := res.RowsAffected()
+		if err != nil {
+			return err
+		}
+		switch affected {
+		case 1:
+			created = true
+			return addOutboxEvent(ctx, tx, EventUserCreated, user.ID, user)
+		case 2:
+			// The stored email may not be the one asked for
+			stored, err := scanUser(tx.QueryRowContext(ctx, "SELECT "+userColumns+" FROM users WHERE id = ?", user.ID))
+			if err != nil {
+				return err
+			}
+			return addOutboxEvent(ctx, tx, EventUserUpdated, user.ID, stored)
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, false, err
+	}
+	return user.ID, created, nil
+}
+
+// UpdateUserProfile sets the given profile fields, keyed by their names in
+// models.ProfileFieldNames, of the user with the given id and username. It reports
+// whether a row changed, recording a user.updated event if so; MySQL doesn't count rows
+// updated to the value they had. Fields that aren't profile fields are ignored.
+func (r *Repository) UpdateUserProfile(ctx context.Context, id int, username string, fields map[string]string) (bool, error) {
+	user := models.User{ID: id, Username: username}
+	values := models.UserFieldMap(user)
+	var set []string
+	var args []any
+	for _, name := range models.ProfileFieldNames {
+		value, ok := fields[name]
+		if !ok {
+			continue
+		}
+		// The names are the column names, and come from the list rather than the caller
+		set = append(set, name+" = ?")
+		args = append(args, value)
+		values[name] = value
+	}
+	if len(set) == 0 {
+		return false, nil
+	}
+	user, err := models.UserFromFieldMap(values)
+	if err != nil {
+		return false, err
+	}
+	args = append(args, tenant.ID(ctx), id, username)
+	return r.execAffects(ctx, EventUserUpdated, user,
+		"UPDATE users SET "+strings.Join(set, ", ")+" WHERE tenant_id = ? AND id = ? AND username = ?", args...)
+}
+
+// SetUserAvatar sets the avatar_key of the user with the given id, "" removing it, and
+// returns the user's username and previous key, or sql.ErrNoRows if there's no such
+// user. A change records a user.updated event.
+func (r *Repository) SetUserAvatar(ctx context.Context, id int, key string) (username, previous string, err error) {
+	err = r.withTx(ctx, func(tx *sql.Tx) error {
+		err := tx.QueryRowContext(ctx, "SELECT username, avatar_key FROM users WHERE tenant_id = ? AND id = ? FOR UPDATE",
+			tenant.ID(ctx), id).Scan(&username, &previous)
+		if err != nil || previous == key {
+			return err
+		}
+		_, err = tx.ExecContext(ctx, "UPDATE users SET avatar_key = ? WHERE tenant_id = ? AND id = ?", key, tenant.ID(ctx), id)
+		if err != nil {
+			return err
+		}
+		return addOutboxEvent(ctx, tx, EventUserUpdated, id, models.User{ID: id, Username: username, AvatarKey: key})
+	})
+	return username, previous, err
+}
+
+// DeleteUser deletes the user with the given id and username, reporting whether it
+// existed. The user leaves its groups first, see leaveGroups. A deletion records a
+// user.deleted event.
+func (r *Repository) DeleteUser(ctx context.Context, id int, username string) (bool, error) {
+	deleted := false
+	err := r.withTx(ctx, func(tx *sql.Tx) error {
+		err := tx.QueryRowContext(ctx, "SELECT id FROM users WHERE tenant_id = ? AND id = ? AND username = ? FOR UPDATE",
+			tenant.ID(ctx), id, username).Scan(&id)
+		if err == sql.ErrNoRows {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		err = leaveGroups(ctx, tx, id)
+		if err != nil {
+			return err
+		}
+		_, err = tx.ExecContext(ctx, "DELETE FROM users WHERE id = ?", id)
+		if err != nil {
+			return translateErr(err)
+		}
+		deleted = true
+		return addOutboxEvent(ctx, tx, EventUserDeleted, id, models.User{ID: id, Username: username})
+	})
+	return deleted, err
+}
+
+// execAffects runs query and, if it changed a row, records an event of type eventType
+// about user in the same transaction.
+func (r *Repository) execAffects(ctx context.Context, eventType string, user models.User, query string, args ...any) (bool, error) {
+	changed := false
+	err := r.withTx(ctx, func(tx *sql.Tx) error {
+		res, err := tx.ExecContext(ctx, query, args...)
+		if err != nil {
+			return translateErr(err)
+		}
+		affected, err := res.RowsAffected()
+		if err != nil || affected == 0 {
+			return err
+		}
+		changed = true
+		return addOutboxEvent(ctx, tx, eventType, user.ID, user)
+	})
+	return changed, err
+}
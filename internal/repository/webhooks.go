@@ -0,0 +1,153 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"strings"
+
+	"go-mysql/internal/models"
+	"go-mysql/internal/tenant"
+)
+
+const webhookColumns = "id, url, secret, events, created_at"
+
+func scanWebhook(row interface{ Scan(...any) error }) (models.Webhook, error) {
+	var hook models.Webhook
+	var events string
+	err := row.Scan(&hook.ID, &hook.URL, &hook.Secret, &events, &hook.CreatedAt)
+	hook.Events = strings.Split(events, ",")
+	return hook, err
+}
+
+// CreateWebhook stores hook for the tenant ctx belongs to and returns its id. Like
+// users, webhooks and their deliveries are only seen by their tenant.
+func (r *Repository) CreateWebhook(ctx context.Context, hook models.Webhook) (int, error) {
+	res, err := r.db.ExecContext(ctx, "INSERT INTO webhooks (tenant_id, url, secret, events) VALUES (?, ?, ?, ?)",
+		tenant.ID(ctx), hook.URL, hook.Secret, strings.Join(hook.Events, ","))
+	if err != nil {
+		return 0, err
+	}
+	id, err := res.LastInsertId()
+	return int(id), err
+}
+
+// Webhooks returns every webhook ordered by id, secrets included.
+func (r *Repository) Webhooks(ctx context.Context) ([]models.Webhook, error) {
+	rows, err := r.db.QueryContext(ctx, "SELECT "+webhookColumns+" FROM webhooks WHERE tenant_id = ? ORDER BY id", tenant.ID(ctx))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var hooks []models.Webhook
+	for rows.Next() {
+		hook, err := scanWebhook(rows)
+		if err != nil {
+			return nil, err
+		}
+		hooks = append(hooks, hook)
+	}
+	return hooks, rows.Err()
+}
+
+// WebhookByID returns sql.ErrNoRows if there is no such webhook.
+func (r *Repository) WebhookByID(ctx context.Context, id int) (models.Webhook, error) {
+	return scanWebhook(r.db.QueryRowContext(ctx, "SELECT "+webhookColumns+" FROM webhooks WHERE tenant_id = ? AND id = ?", tenant.ID(ctx), id))
+}
+
+// DeleteWebhook deletes the webhook and its delivery log, reporting whether it existed.
+func (r *Repository) DeleteWebhook(ctx context.Context, id int) (bool, error) {
+	res, err := r.db.ExecContext(ctx, "DELETE FROM webhooks WHERE tenant_id = ? AND id = ?", tenant.ID(ctx), id)
+	if err != nil {
+		return false, err
+	}
+	affected, err := res.RowsAffected()
+	return affected > 0, err
+}
+
+// tenantWebhooks restricts deliveries to the webhooks of the tenant in the query's
+// argument.
+const tenantWebhooks = "webhook_id IN (SELECT id FROM webhooks WHERE tenant_id = ?)"
+
+const deliveryColumns = "id, webhook_id, event_type, payload, status, attempts, response_code, last_error, created_at, updated_at"
+
+func scanDelivery(row interface{ Scan(...any) error }) (models.WebhookDelivery, error) {
+	var d models.WebhookDelivery
+	err := row.Scan(&d.ID, &d.WebhookID, &d.EventType, &d.Payload, &d.Status, &d.Attempts, &d.ResponseCode, &d.LastError, &d.CreatedAt, &d.UpdatedAt)
+	return d, err
+}
+
+// CreateWebhookDelivery logs a pending delivery of payload to a webhook and returns its id.
+func (r *Repository) CreateWebhookDelivery(ctx context.Context, webhookID int, eventType string, payload []byte) (int64, error) {
+	res, err := r.db.ExecContext(ctx, "INSERT INTO webhook_deliveries (webhook_id, event_type, payload) VALUES (?, ?, ?)",
+		webhookID, eventType, payload)
+	if err != nil {
+		return 0, err
+	}
+	return res.LastInsertId()
+}
+
+// WebhookDelivery returns sql.ErrNoRows if there is no such delivery.
+func (r *Repository) WebhookDelivery(ctx context.Context, id int64) (models.WebhookDelivery, error) {
+	return scanDelivery(r.db.QueryRowContext(ctx, "SELECT "+deliveryColumns+" FROM webhook_deliveries WHERE "+tenantWebhooks+" AND id = ?",
+		tenant.ID(ctx), id))
+}
+
+// WebhookDeliveries returns the latest limit deliveries to a webhook, newest first.
+func (r *Repository) WebhookDeliveries(ctx context.Context, webhookID, limit int) ([]models.WebhookDelivery, error) {
+	rows, err := r.db.QueryContext(ctx, "SELECT "+deliveryColumns+" FROM webhook_deliveries WHERE "+tenantWebhooks+" AND webhook_id = ? ORDER BY id DESC LIMIT ?",
+		tenant.ID(ctx), webhookID, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var deliveries []models.WebhookDelivery
+	for rows.Next() {
+		d, err := scanDelivery(rows)
+		if err != nil {
+			return nil, err
+		}
+		deliveries = append(deliveries, d)
+	}
+	return deliveries, rows.Err()
+}
+
+// RecordWebhookAttempt logs the outcome of one attempt at a delivery. An empty
+// lastError marks it succeeded, anything else failed.
+func (r *Repository) RecordWebhookAttempt(ctx context.Context, id int64, responseCode int, lastError string) error {
+	status := "succeeded"
+	if lastError != "" {
+		status = "failed"
+	}
+	if len(lastError) > 1024 {
+		lastError = lastError[:1024]
+	}
+	_, err := r.db.ExecContext(ctx, `UPDATE webhook_deliveries
+		SET status = ?, attempts = attempts + 1, response_code = ?, last_error = ? WHERE id = ?`,
+		status, responseCode, lastError, id)
+	return err
+}
+
+// ResetWebhookDelivery marks a delivery pending again before it's redelivered,
+// reporting whether it exists.
+func (r *Repository) ResetWebhookDelivery(ctx context.Context, id int64) (bool, error) {
+	res, err := r.db.ExecContext(ctx, "UPDATE webhook_deliveries SET status = 'pending' WHERE "+tenantWebhooks+" AND id = ?",
+		tenant.ID(ctx), id)
+	if err != nil {
+		return false, err
+	}
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	if affected > 0 {
+		return true, nil
+	}
+	// MySQL doesn't count rows that already had the value
+	_, err = r.WebhookDelivery(ctx, id)
+	if err == sql.ErrNoRows {
+		return false, nil
+	}
+	return err == nil, err
+}
@@ -0,0 +1,47 @@
+// Package requestid carries the id of the request a piece of work was started by in
+// its context, so the log lines and spans of everything it leads to, such as jobs,
+// webhook deliveries and NATS replies, can be matched with it.
+package requestid
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+)
+
+// Header is the header, HTTP or NATS, carrying a request id between services.
+const Header = "X-Request-ID"
+
+type contextKey struct{}
+
+// With returns a copy of ctx carrying the request id.
+func With(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, contextKey{}, id)
+}
+
+// From returns the request id ctx carries, or "" for work no request started.
+func From(ctx context.Context) string {
+	id, _ := ctx.Value(contextKey{}).(string)
+	return id
+}
+
+// New returns a random request id.
+func New() string {
+	b := make([]byte, 16)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+// Valid accepts ids of up to 128 printable ASCII characters, so a client can't
+// inject arbitrary data into the logs.
+func Valid(id string) bool {
+	if id == "" || len(id) > 128 {
+		return false
+	}
+	for i := 0; i < len(id); i++ {
+		if id[i] < 0x21 || id[i] > 0x7e {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,88 @@
+package server
+
+import (
+	"log/slog"
+	"math/rand"
+	"net/http"
+	"time"
+
+	"go-mysql/internal/config"
+	"go-mysql/internal/logging"
+	"go-mysql/pkg/ratelimit"
+)
+
+// AccessLog logs one line per request. Excluded paths are never logged, and
+// successful requests only with probability cfg.SampleRate; 4xx and 5xx responses
+// always are, at warn and error level respectively.
+func AccessLog(cfg config.AccessLog) func(http.Handler) http.Handler {
+	excluded := make(map[string]bool, len(cfg.Exclude))
+	for _, path := range cfg.Exclude {
+		excluded[path] = true
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if excluded[r.URL.Path] {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			start := time.Now()
+			rec := &responseRecorder{ResponseWriter: w, status: http.StatusOK}
+			next.ServeHTTP(rec, r)
+
+			level := slog.LevelInfo
+			switch {
+			case rec.status >= 500:
+				level = slog.LevelError
+			case rec.status >= 400:
+				level = slog.LevelWarn
+			case cfg.SampleRate < 1 && rand.Float64() >= cfg.SampleRate:
+				return
+			}
+			logging.From(r.Context()).Log(r.Context(), level, "Request",
+				"path", r.URL.Path,
+				"status", rec.status,
+				"bytes", rec.bytes,
+				"latency", time.Since(start),
+				"remote_ip", ratelimit.ClientIP(r),
+				"user_agent", r.UserAgent(),
+			)
+		})
+	}
+}
+
+// responseRecorder captures the status code and body size written by a handler.
+type responseRecorder struct {
+	http.ResponseWriter
+	status      int
+	bytes       int
+	wroteHeader bool
+}
+
+func (rec *responseRecorder) WriteHeader(status int) {
+	if !rec.wroteHeader {
+		rec.status = status
+		rec.wroteHeader = true
+	}
+	rec.ResponseWriter.WriteHeader(status)
+}
+
+func (rec *responseRecorder) Write(b []byte) (int, error) {
+	rec.wroteHeader = true
+	n, err := rec.ResponseWriter.Write(b)
+	rec.bytes += n
+	return n, err
+}
+
+// Flush keeps streaming handlers such as /redis/subscribe working behind the recorder.
+func (rec *responseRecorder) Flush() {
+	if f, ok := rec.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+// Unwrap lets http.ResponseController reach the underlying writer.
+func (rec *responseRecorder) Unwrap() http.ResponseWriter {
+	return rec.ResponseWriter
+}
@@ -0,0 +1,280 @@
+package server
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"expvar"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/pprof"
+	"strings"
+
+	"github.com/felixge/fgprof"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+
+	"go-mysql/internal/cache"
+	"go-mysql/internal/config"
+	"go-mysql/internal/logging"
+	"go-mysql/internal/service"
+	"go-mysql/pkg/flags"
+	"go-mysql/pkg/jobqueue"
+	"go-mysql/pkg/scheduler"
+)
+
+// NewAdminHandler serves operational endpoints that don't belong on the public API:
+// metrics, health, profiles, controls for readiness, configuration, the cache and the
+// job queue, the status of scheduled jobs, feature flags, tenants and user roles, which
+// is how a tenant gets its first administrator. reload is called
+// by POST /admin/reload to re-read the configuration. demoKeys, if not nil, lists the
+// Redis demos' keys at GET /admin/redis/keys, but only when an admin token is set.
+func NewAdminHandler(cfg config.Admin, readyz http.Handler, reload func(context.Context) error, users *cache.UserCache, queue *jobqueue.Queue, sched *scheduler.Scheduler, featureFlags *flags.Flags, tenants *service.Tenants, admin *service.Admin, demoKeys http.Handler) http.Handler {
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", promhttp.Handler())
+	mux.Handle("/debug/vars", expvar.Handler())
+	mux.HandleFunc("GET /healthz", Livez)
+	mux.Handle("GET /readyz", readyz)
+
+	mux.HandleFunc("/debug/pprof/", pprof.Index)
+	mux.HandleFunc("/debug/pprof/cmdline", pprof.Cmdline)
+	mux.HandleFunc("/debug/pprof/profile", pprof.Profile)
+	mux.HandleFunc("/debug/pprof/symbol", pprof.Symbol)
+	mux.HandleFunc("/debug/pprof/trace", pprof.Trace)
+	// fgprof samples goroutines whether they're on or off CPU, so time spent waiting
+	// on MySQL and Redis shows up too
+	mux.Handle("/debug/fgprof", fgprof.Handler())
+
+	mux.HandleFunc("PUT /admin/ready", setReadiness)
+	mux.HandleFunc("PUT /admin/loglevel", SetLogLevel)
+	mux.HandleFunc("POST /admin/reload", reloadHandler(reload))
+	mux.HandleFunc("POST /admin/cache/invalidate", invalidateCache(users))
+	mux.HandleFunc("POST /admin/cache/warm", warmCacheNow(users))
+	mux.HandleFunc("GET /admin/jobs", jobStats(queue))
+	mux.HandleFunc("POST /admin/jobs/enqueue/{type}", enqueueJob(queue))
+	mux.HandleFunc("GET /admin/jobs/dead", deadJobs(queue))
+	mux.HandleFunc("POST /admin/jobs/dead/requeue", requeueDeadJobs(queue))
+	mux.HandleFunc("POST /admin/jobs/dead/{id}/requeue", requeueDeadJob(queue))
+	mux.HandleFunc("GET /admin/scheduler", schedulerStatus(sched))
+	mux.HandleFunc("GET /admin/flags", listFlags(featureFlags))
+	mux.HandleFunc("PUT /admin/flags/{name}", setFlag(featureFlags))
+	mux.HandleFunc("DELETE /admin/flags/{name}", clearFlag(featureFlags))
+	mux.HandleFunc("GET /admin/tenants", listTenants(tenants))
+	mux.HandleFunc("POST /admin/tenants", createTenant(tenants))
+	mux.HandleFunc("DELETE /admin/tenants/{id}", deleteTenant(tenants))
+	mux.HandleFunc("PUT /admin/users/{id}/role", setUserRole(admin))
+	if demoKeys != nil && cfg.Token != "" {
+		mux.Handle("GET /admin/redis/keys", demoKeys)
+	}
+
+	if cfg.Token == "" {
+		return mux
+	}
+	return requireToken(cfg.Token, mux)
+}
+
+// invalidateCache drops every cached user and the username index.
+func invalidateCache(users *cache.UserCache) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		users.InvalidateUsers(r.Context())
+		users.DropUsernameIndex(r.Context())
+		logging.From(r.Context()).Info("Cache invalidated by operator")
+		w.WriteHeader(http.StatusOK)
+	}
+}
+
+// warmCacheNow reloads the cache from MySQL.
+func warmCacheNow(users *cache.UserCache) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if !users.State().Config().Enabled || !users.State().Usable() {
+			http.Error(w, "Cache is disabled or unavailable", http.StatusConflict)
+			return
+		}
+		loaded, err := users.LoadUsers(r.Context())
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		fmt.Fprintf(w, "Cached %d users\n", len(loaded))
+	}
+}
+
+// jobStats counts the queued jobs by state.
+func jobStats(queue *jobqueue.Queue) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		stats, err := queue.Stats(r.Context())
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(stats)
+	}
+}
+
+// enqueueJob runs a job of the given type, such as an archive run, outside its usual
+// trigger. The request body, if any, is the JSON payload.
+func enqueueJob(queue *jobqueue.Queue) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var payload json.RawMessage
+		err := json.NewDecoder(r.Body).Decode(&payload)
+		if err != nil && err != io.EOF {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		var p any
+		if payload != nil {
+			p = payload
+		}
+
+		job, err := queue.Enqueue(r.Context(), r.PathValue("type"), p)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		logging.From(r.Context()).Info("Job enqueued by operator", "id", job.ID, "type", job.Type)
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusAccepted)
+		json.NewEncoder(w).Encode(job)
+	}
+}
+
+// deadJobs lists the most recent jobs that exhausted their retries, with their last error.
+func deadJobs(queue *jobqueue.Queue) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		jobs, err := queue.Dead(r.Context(), 100)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(jobs)
+	}
+}
+
+// requeueDeadJob gives a dead job a fresh set of retries.
+func requeueDeadJob(queue *jobqueue.Queue) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ok, err := queue.Requeue(r.Context(), r.PathValue("id"))
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		if !ok {
+			http.Error(w, "Dead job not found", http.StatusNotFound)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}
+}
+
+// requeueDeadJobs gives every dead job a fresh set of retries.
+func requeueDeadJobs(queue *jobqueue.Queue) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		n, err := queue.RequeueAll(r.Context())
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		fmt.Fprintf(w, "Requeued %d jobs\n", n)
+	}
+}
+
+// schedulerStatus reports when each scheduled job last ran, how it went, and when it
+// runs next.
+func schedulerStatus(sched *scheduler.Scheduler) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(sched.Status())
+	}
+}
+
+// listFlags returns every feature flag with its percentage and whether it's overridden.
+func listFlags(featureFlags *flags.Flags) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(featureFlags.All())
+	}
+}
+
+// setFlag overrides a feature flag on every instance. The body is
+// {"percentage": 25}, or {"value": "on"}, "off" or "25%".
+func setFlag(featureFlags *flags.Flags) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var body struct {
+			Percentage *int   `json:"percentage"`
+			Value      string `json:"value"`
+		}
+		err := json.NewDecoder(r.Body).Decode(&body)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		var pct int
+		switch {
+		case body.Percentage != nil:
+			pct = *body.Percentage
+		case body.Value != "":
+			pct, err = flags.ParsePercentage(body.Value)
+		default:
+			err = fmt.Errorf("Missing percentage or value")
+		}
+		if err == nil && (pct < 0 || pct > 100) {
+			err = fmt.Errorf("Percentage must be between 0 and 100")
+		}
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		name := r.PathValue("name")
+		err = featureFlags.Set(r.Context(), name, pct)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		logging.From(r.Context()).Info("Feature flag overridden by operator", "flag", name, "percentage", pct)
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(featureFlags.Lookup(name))
+	}
+}
+
+// clearFlag drops a feature flag's override, returning it to its configured default.
+func clearFlag(featureFlags *flags.Flags) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		name := r.PathValue("name")
+		err := featureFlags.Clear(r.Context(), name)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		logging.From(r.Context()).Info("Feature flag override cleared by operator", "flag", name)
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(featureFlags.Lookup(name))
+	}
+}
+
+// reloadHandler returns the admin endpoint doing what SIGHUP does.
+func reloadHandler(reload func(context.Context) error) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		err := reload(r.Context())
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}
+}
+
+// requireToken rejects requests that don't carry "Authorization: Bearer <token>".
+func requireToken(token string, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+		if !ok || subtle.ConstantTimeCompare([]byte(got), []byte(token)) != 1 {
+			w.Header().Set("WWW-Authenticate", "Bearer")
+			http.Error(w, "Unauthorized", http.StatusUnauthorized)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
@@ -0,0 +1,78 @@
+package server
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"go-mysql/internal/logging"
+	"go-mysql/internal/models"
+	"go-mysql/internal/service"
+)
+
+// listTenants returns every tenant.
+func listTenants(tenants *service.Tenants) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		list, err := tenants.List(r.Context())
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(list)
+	}
+}
+
+// createTenant creates a tenant from a body like {"slug": "acme", "name": "Acme"}.
+func createTenant(tenants *service.Tenants) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var t models.Tenant
+		err := json.NewDecoder(r.Body).Decode(&t)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		t, err = tenants.Create(r.Context(), t)
+		if err != nil {
+			writeTenantError(w, err)
+			return
+		}
+		logging.From(r.Context()).Info("Tenant created by operator", "tenant_id", t.ID, "slug", t.Slug)
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusCreated)
+		json.NewEncoder(w).Encode(t)
+	}
+}
+
+// deleteTenant deletes a tenant that has no users left.
+func deleteTenant(tenants *service.Tenants) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id, err := strconv.Atoi(r.PathValue("id"))
+		if err != nil {
+			http.Error(w, "Invalid tenant id", http.StatusBadRequest)
+			return
+		}
+		err = tenants.Delete(r.Context(), id)
+		if err != nil {
+			writeTenantError(w, err)
+			return
+		}
+		logging.From(r.Context()).Info("Tenant deleted by operator", "tenant_id", id)
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+// writeTenantError answers with the status matching an error from service.Tenants.
+func writeTenantError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, service.ErrInvalidTenant):
+		http.Error(w, err.Error(), http.StatusBadRequest)
+	case errors.Is(err, service.ErrTenantNotFound):
+		http.Error(w, err.Error(), http.StatusNotFound)
+	case errors.Is(err, service.ErrSlugTaken), errors.Is(err, service.ErrTenantInUse):
+		http.Error(w, err.Error(), http.StatusConflict)
+	default:
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
@@ -0,0 +1,56 @@
+package server
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"go-mysql/internal/logging"
+	"go-mysql/internal/service"
+	"go-mysql/internal/tenant"
+)
+
+// setUserRole gives user {id} of the tenant in ?tenant= (the default tenant if
+// missing) the role in a body like {"role": "admin"}. Unlike PUT /admin/users/{id}/role
+// on the public API, it doesn't need an administrator of the tenant to exist.
+func setUserRole(admin *service.Admin) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id, err := strconv.Atoi(r.PathValue("id"))
+		if err != nil {
+			http.Error(w, "Invalid user id", http.StatusBadRequest)
+			return
+		}
+		tenantID := tenant.DefaultID
+		if s := r.URL.Query().Get("tenant"); s != "" {
+			tenantID, err = strconv.Atoi(s)
+			if err != nil {
+				http.Error(w, "Invalid tenant id", http.StatusBadRequest)
+				return
+			}
+		}
+		var body struct {
+			Role string `json:"role"`
+		}
+		err = json.NewDecoder(r.Body).Decode(&body)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		// Operators aren't users, so there's no actor to keep from changing its own role
+		ctx := tenant.WithID(r.Context(), tenantID)
+		err = admin.SetRole(ctx, 0, id, body.Role)
+		switch {
+		case errors.Is(err, service.ErrInvalid):
+			http.Error(w, err.Error(), http.StatusBadRequest)
+		case errors.Is(err, service.ErrNotFound):
+			http.Error(w, err.Error(), http.StatusNotFound)
+		case err != nil:
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+		default:
+			logging.From(ctx).Info("User role changed by operator", "tenant_id", tenantID, "user_id", id, "role", body.Role)
+			w.WriteHeader(http.StatusNoContent)
+		}
+	}
+}
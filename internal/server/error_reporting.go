@@ -0,0 +1,82 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net/http"
+	"strings"
+	"time"
+
+	"go-mysql/internal/config"
+	"go-mysql/internal/requestid"
+	"go-mysql/pkg/errreport"
+)
+
+// NewErrorReporter returns the reporter selected by cfg.
+func NewErrorReporter(cfg config.ErrorReporting, logger *slog.Logger) (errreport.Reporter, error) {
+	switch cfg.Backend {
+	case "sentry":
+		return errreport.NewSentry(cfg.SentryDSN, cfg.Release, cfg.Environment)
+	case "log":
+		return errreport.Log{Logger: logger, Release: cfg.Release}, nil
+	default:
+		return errreport.Noop{}, nil
+	}
+}
+
+// ErrorReporting reports 5xx responses to reporter, except those written by
+// Recovery, which reports panics itself along with their stack trace.
+func ErrorReporting(reporter errreport.Reporter) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			rec := &errorRecorder{responseRecorder: &responseRecorder{ResponseWriter: w, status: http.StatusOK}}
+			reported := false
+			next.ServeHTTP(rec, r.WithContext(context.WithValue(r.Context(), reportedKey{}, &reported)))
+
+			if rec.status >= 500 && !reported {
+				msg := strings.TrimSpace(string(rec.body))
+				if msg == "" {
+					msg = http.StatusText(rec.status)
+				}
+				reporter.Report(r.Context(), errreport.Event{
+					Err:       errors.New(msg),
+					Request:   r,
+					RequestID: requestid.From(r.Context()),
+					Status:    rec.status,
+				})
+			}
+		})
+	}
+}
+
+type reportedKey struct{}
+
+// markReported tells ErrorReporting that the error behind the response for
+// the request ctx belongs to has already been reported.
+func markReported(ctx context.Context) {
+	if reported, ok := ctx.Value(reportedKey{}).(*bool); ok {
+		*reported = true
+	}
+}
+
+// errorRecorder keeps the start of error response bodies, which is where handlers put
+// the error message.
+type errorRecorder struct {
+	*responseRecorder
+	body []byte
+}
+
+func (rec *errorRecorder) Write(b []byte) (int, error) {
+	if rec.status >= 500 && len(rec.body) < 1024 {
+		rec.body = append(rec.body, b[:min(len(b), 1024-len(rec.body))]...)
+	}
+	return rec.responseRecorder.Write(b)
+}
+
+// FlushErrorReports gives queued reports a chance to be delivered before exiting.
+func FlushErrorReports(reporter errreport.Reporter) {
+	if !reporter.Flush(5 * time.Second) {
+		slog.Warn("Some error reports could not be delivered")
+	}
+}
@@ -0,0 +1,99 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"sync/atomic"
+	"time"
+
+	"go-mysql/internal/cache"
+	"go-mysql/internal/logging"
+	"go-mysql/internal/repository"
+	"go-mysql/pkg/breaker"
+)
+
+// markedNotReady is set to take the instance out of load balancing, either by an
+// operator through the admin listener or at the start of shutdown.
+var markedNotReady atomic.Bool
+
+// Livez reports that the process is up and serving HTTP. It checks nothing else, so
+// an orchestrator only restarts the process when it's truly stuck.
+func Livez(w http.ResponseWriter, r *http.Request) {
+	fmt.Fprintln(w, "ok")
+}
+
+// NewReadyz returns the readiness probe. The instance is ready when it hasn't been
+// marked not-ready, MySQL answers and the schema is at the expected version (or the
+// server was started read-only against another version). Redis is reported but
+// optional, since requests are served from MySQL while it's down. The state of each
+// of breakers is reported too; an open one fails its dependency's check by itself.
+func NewReadyz(repo *repository.Repository, state *cache.State, readOnly bool, breakers ...*breaker.Breaker) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx, cancel := context.WithTimeout(r.Context(), 2*time.Second)
+		defer cancel()
+
+		ready := true
+		checks := map[string]string{}
+
+		if markedNotReady.Load() {
+			ready = false
+			checks["admin"] = "marked not ready"
+		}
+
+		err := repo.Ping(ctx)
+		if err != nil {
+			ready = false
+			checks["mysql"] = err.Error()
+		} else {
+			checks["mysql"] = "ok"
+		}
+
+		version, err := repo.SchemaVersion(ctx)
+		switch {
+		case err != nil:
+			ready = false
+			checks["schema"] = err.Error()
+		case version == repository.ExpectedSchemaVersion:
+			checks["schema"] = "ok"
+		case readOnly:
+			checks["schema"] = fmt.Sprintf("version %d, expected %d, serving read-only", version, repository.ExpectedSchemaVersion)
+		default:
+			ready = false
+			checks["schema"] = fmt.Sprintf("version %d, expected %d", version, repository.ExpectedSchemaVersion)
+		}
+
+		if state.RedisAvailable() {
+			checks["redis"] = "ok"
+		} else {
+			checks["redis"] = "unavailable, bypassing the cache"
+		}
+
+		for _, b := range breakers {
+			checks[b.Name()+"_breaker"] = b.State().String()
+		}
+
+		status := http.StatusOK
+		if !ready {
+			status = http.StatusServiceUnavailable
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(status)
+		json.NewEncoder(w).Encode(map[string]any{"ready": ready, "checks": checks})
+	}
+}
+
+// setReadiness marks the instance ready or not ready, e.g. PUT /admin/ready?ready=false
+// to drain it ahead of maintenance.
+func setReadiness(w http.ResponseWriter, r *http.Request) {
+	ready, err := strconv.ParseBool(r.URL.Query().Get("ready"))
+	if err != nil {
+		http.Error(w, "Invalid ready parameter", http.StatusBadRequest)
+		return
+	}
+	markedNotReady.Store(!ready)
+	logging.From(r.Context()).Info("Readiness changed by operator", "ready", ready)
+	w.WriteHeader(http.StatusOK)
+}
@@ -0,0 +1,25 @@
+package server
+
+import (
+	"net/http"
+
+	"golang.org/x/net/http2"
+	"golang.org/x/net/http2/h2c"
+
+	"go-mysql/internal/config"
+)
+
+// ConfigureHTTP2 applies cfg's HTTP/2 settings to server. Over TLS, HTTP/2 is negotiated
+// with ALPN. With cfg.H2C, cleartext HTTP/2 is accepted too, for proxies that speak it
+// to their backends; h2c connections are taken over from the server, so Shutdown
+// doesn't wait for their requests.
+func ConfigureHTTP2(server *http.Server, cfg config.Server) error {
+	h2 := &http2.Server{
+		MaxConcurrentStreams: cfg.HTTP2MaxConcurrentStreams,
+		IdleTimeout:          cfg.IdleTimeout,
+	}
+	if cfg.H2C {
+		server.Handler = h2c.NewHandler(server.Handler, h2)
+	}
+	return http2.ConfigureServer(server, h2)
+}
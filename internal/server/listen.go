@@ -0,0 +1,74 @@
+// Package server holds the HTTP plumbing around the handlers: listeners, middleware,
+// probes, the admin endpoints and graceful shutdown.
+package server
+
+import (
+	"errors"
+	"io/fs"
+	"net"
+	"net/http"
+	"os"
+
+	"go-mysql/internal/config"
+)
+
+// Listen opens the listeners the server accepts connections on: TCP on cfg.Addr unless
+// it's empty, and a unix domain socket at cfg.UnixSocket if that is set.
+func Listen(cfg config.Server) ([]net.Listener, error) {
+	var listeners []net.Listener
+	if cfg.Addr != "" {
+		ln, err := net.Listen("tcp", cfg.Addr)
+		if err != nil {
+			return nil, err
+		}
+		listeners = append(listeners, ln)
+	}
+	if cfg.UnixSocket != "" {
+		ln, err := listenUnix(cfg.UnixSocket, cfg.UnixSocketMode)
+		if err != nil {
+			for _, l := range listeners {
+				l.Close()
+			}
+			return nil, err
+		}
+		listeners = append(listeners, ln)
+	}
+	return listeners, nil
+}
+
+// listenUnix listens on a unix socket at path with the given permissions. A socket
+// left behind by a previous run that didn't exit cleanly is replaced; any other file
+// at path is an error. The socket file is removed when the listener is closed.
+func listenUnix(path string, mode fs.FileMode) (net.Listener, error) {
+	info, err := os.Lstat(path)
+	switch {
+	case err == nil && info.Mode().Type() == fs.ModeSocket:
+		err = os.Remove(path)
+		if err != nil {
+			return nil, err
+		}
+	case err == nil:
+		return nil, &fs.PathError{Op: "listen", Path: path, Err: errors.New("file exists and is not a socket")}
+	case !errors.Is(err, fs.ErrNotExist):
+		return nil, err
+	}
+
+	ln, err := net.Listen("unix", path)
+	if err != nil {
+		return nil, err
+	}
+	err = os.Chmod(path, mode)
+	if err != nil {
+		ln.Close()
+		return nil, err
+	}
+	return ln, nil
+}
+
+// Serve accepts connections on ln until the server is shut down.
+func Serve(server *http.Server, ln net.Listener, cfg config.Server) error {
+	if cfg.TLSCertFile != "" {
+		return server.ServeTLS(ln, cfg.TLSCertFile, cfg.TLSKeyFile)
+	}
+	return server.Serve(ln)
+}
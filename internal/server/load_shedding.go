@@ -0,0 +1,21 @@
+package server
+
+import (
+	"net/http"
+	"time"
+
+	"go-mysql/internal/logging"
+	"go-mysql/pkg/middleware"
+)
+
+// ConcurrencyLimit returns the load shedding middleware for a route group, counting
+// rejected requests under group, or nil if limit is 0.
+func ConcurrencyLimit(group string, limit int, wait time.Duration) middleware.Middleware {
+	if limit == 0 {
+		return nil
+	}
+	return middleware.ConcurrencyLimit(limit, wait, func(r *http.Request) {
+		httpRequestsShed.WithLabelValues(group).Inc()
+		logging.From(r.Context()).Warn("Request shed, too many in flight", "group", group, "limit", limit)
+	})
+}
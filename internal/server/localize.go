@@ -0,0 +1,102 @@
+package server
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+
+	"go-mysql/internal/i18n"
+)
+
+// Localize translates the plain-text error responses written with http.Error into the
+// language the client asks for with Accept-Language, see i18n.Bundle.Translate, and
+// lets handlers translate the messages they put in other responses with i18n.T.
+// Translated responses carry a Content-Language header.
+func Localize(bundle *i18n.Bundle) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			languages := i18n.ParseAcceptLanguage(r.Header.Get("Accept-Language"))
+			r = r.WithContext(i18n.WithLanguages(r.Context(), bundle, languages))
+			lw := &localizeWriter{ResponseWriter: w, bundle: bundle, languages: languages}
+			defer lw.close()
+			next.ServeHTTP(lw, r)
+		})
+	}
+}
+
+// localizeWriter holds back the body of error responses until it's complete, to
+// translate it.
+type localizeWriter struct {
+	http.ResponseWriter
+	bundle    *i18n.Bundle
+	languages []string
+
+	wroteHeader bool
+	// held is set while an error response is held back, with its status.
+	held   bool
+	status int
+	buf    []byte
+}
+
+func (lw *localizeWriter) WriteHeader(status int) {
+	if lw.wroteHeader || status < 200 {
+		lw.ResponseWriter.WriteHeader(status)
+		return
+	}
+	lw.wroteHeader = true
+	contentType := lw.Header().Get("Content-Type")
+	if status >= 400 && (contentType == "" || strings.HasPrefix(contentType, "text/plain")) {
+		lw.held, lw.status = true, status
+		return
+	}
+	lw.ResponseWriter.WriteHeader(status)
+}
+
+func (lw *localizeWriter) Write(b []byte) (int, error) {
+	if !lw.wroteHeader {
+		lw.WriteHeader(http.StatusOK)
+	}
+	if lw.held {
+		lw.buf = append(lw.buf, b...)
+		return len(b), nil
+	}
+	return lw.ResponseWriter.Write(b)
+}
+
+// Flush passes on to the underlying writer, unless an error response is held back,
+// since error responses aren't streamed.
+func (lw *localizeWriter) Flush() {
+	if lw.held {
+		return
+	}
+	if f, ok := lw.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+// Unwrap lets http.ResponseController reach the underlying writer.
+func (lw *localizeWriter) Unwrap() http.ResponseWriter {
+	return lw.ResponseWriter
+}
+
+// close writes the error response held back, translated, once the handler has
+// returned.
+func (lw *localizeWriter) close() {
+	if !lw.held {
+		return
+	}
+	h := lw.Header()
+	h.Add("Vary", "Accept-Language")
+	// http.Error ends the message with a newline
+	message, newline := strings.CutSuffix(string(lw.buf), "\n")
+	message, language := lw.bundle.Translate(message, lw.languages)
+	if newline {
+		message += "\n"
+	}
+	h.Set("Content-Language", language)
+	if h.Get("Content-Length") != "" {
+		h.Set("Content-Length", strconv.Itoa(len(message)))
+	}
+	lw.ResponseWriter.WriteHeader(lw.status)
+	lw.ResponseWriter.Write([]byte(message))
+}
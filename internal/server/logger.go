@@ -0,0 +1,35 @@
+package server
+
+import (
+	"log/slog"
+	"net/http"
+
+	"go-mysql/internal/logging"
+	"go-mysql/internal/requestid"
+)
+
+// Logger gives every request a logger tagged with its id, method and route in mux.
+func Logger(logger *slog.Logger, mux *http.ServeMux) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			_, route := mux.Handler(r)
+			reqLogger := logger.With("request_id", requestid.From(r.Context()), "method", r.Method, "route", route)
+			next.ServeHTTP(w, r.WithContext(logging.WithLogger(r.Context(), reqLogger)))
+		})
+	}
+}
+
+// SetLogLevel changes the log level until the next restart or reload, e.g.
+// PUT /admin/loglevel?level=debug while investigating an incident.
+func SetLogLevel(w http.ResponseWriter, r *http.Request) {
+	var level slog.Level
+	err := level.UnmarshalText([]byte(r.URL.Query().Get("level")))
+	if err != nil {
+		http.Error(w, "Invalid level parameter: must be debug, info, warn or error", http.StatusBadRequest)
+		return
+	}
+	previous := logging.Level.Level()
+	logging.Level.Set(level)
+	logging.From(r.Context()).Log(r.Context(), max(level, previous), "Log level changed by operator", "level", level, "previous", previous)
+	w.WriteHeader(http.StatusOK)
+}
@@ -0,0 +1,123 @@
+package server
+
+import (
+	"database/sql"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+
+	"go-mysql/pkg/breaker"
+	"go-mysql/pkg/workerpool"
+)
+
+// Prometheus metrics, served at /metrics on the admin listener. The default registry
+// also carries the Go runtime and process collectors.
+var (
+	httpRequests = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "http_requests_total",
+		Help: "HTTP requests by route, method and status code.",
+	}, []string{"route", "method", "status"})
+
+	httpRequestDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "http_request_duration_seconds",
+		Help:    "HTTP request latency by route, method and status code.",
+		Buckets: prometheus.DefBuckets,
+	}, []string{"route", "method", "status"})
+
+	httpRequestsShed = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "http_requests_shed_total",
+		Help: "Requests rejected by the concurrency limit, by route group.",
+	}, []string{"group"})
+)
+
+// RegisterBreakerMetrics exposes the state and counters of b, labeled with its name.
+func RegisterBreakerMetrics(b *breaker.Breaker) {
+	labels := prometheus.Labels{"breaker": b.Name()}
+	prometheus.MustRegister(
+		prometheus.NewGaugeFunc(prometheus.GaugeOpts{
+			Name:        "circuit_breaker_state",
+			Help:        "State of the circuit breaker around a dependency: 0 closed, 1 half-open, 2 open.",
+			ConstLabels: labels,
+		}, func() float64 { return float64(b.State()) }),
+		prometheus.NewCounterFunc(prometheus.CounterOpts{
+			Name:        "circuit_breaker_rejected_total",
+			Help:        "Calls to a dependency failed fast by its circuit breaker.",
+			ConstLabels: labels,
+		}, func() float64 { return float64(b.Stats().Rejected) }),
+		prometheus.NewCounterFunc(prometheus.CounterOpts{
+			Name:        "circuit_breaker_opened_total",
+			Help:        "Times the circuit breaker around a dependency opened.",
+			ConstLabels: labels,
+		}, func() float64 { return float64(b.Stats().Opened) }),
+	)
+}
+
+// RegisterDBMetrics exposes the connection pool statistics of db.
+func RegisterDBMetrics(db *sql.DB) {
+	prometheus.MustRegister(collectors.NewDBStatsCollector(db, "mysql"))
+}
+
+// RegisterWorkerPoolMetrics exposes the queue depth, busy workers and task outcomes of
+// pool, labelled with name.
+func RegisterWorkerPoolMetrics(name string, pool *workerpool.Pool) {
+	labels := prometheus.Labels{"pool": name}
+	task := func(result string, count func(workerpool.Stats) int64) prometheus.Collector {
+		return prometheus.NewCounterFunc(prometheus.CounterOpts{
+			Name:        "worker_pool_tasks_total",
+			Help:        "Background tasks by outcome: ok, failed, rejected because the queue was full, fallback when handed to their fallback, or dropped at shutdown.",
+			ConstLabels: prometheus.Labels{"pool": name, "result": result},
+		}, func() float64 { return float64(count(pool.Stats())) })
+	}
+	prometheus.MustRegister(
+		prometheus.NewGaugeFunc(prometheus.GaugeOpts{
+			Name:        "worker_pool_queue_depth",
+			Help:        "Background tasks waiting for a worker.",
+			ConstLabels: labels,
+		}, func() float64 { return float64(pool.Stats().Queued) }),
+		prometheus.NewGaugeFunc(prometheus.GaugeOpts{
+			Name:        "worker_pool_queue_capacity",
+			Help:        "Background tasks that may wait for a worker before new ones are rejected.",
+			ConstLabels: labels,
+		}, func() float64 { return float64(pool.Stats().QueueSize) }),
+		prometheus.NewGaugeFunc(prometheus.GaugeOpts{
+			Name:        "worker_pool_busy_workers",
+			Help:        "Workers currently running a task.",
+			ConstLabels: labels,
+		}, func() float64 { return float64(pool.Stats().Busy) }),
+		prometheus.NewGaugeFunc(prometheus.GaugeOpts{
+			Name:        "worker_pool_workers",
+			Help:        "Workers in the pool.",
+			ConstLabels: labels,
+		}, func() float64 { return float64(pool.Stats().Workers) }),
+		task("ok", func(s workerpool.Stats) int64 { return s.Completed }),
+		task("failed", func(s workerpool.Stats) int64 { return s.Failed }),
+		task("rejected", func(s workerpool.Stats) int64 { return s.Rejected }),
+		task("fallback", func(s workerpool.Stats) int64 { return s.FellBack }),
+		task("dropped", func(s workerpool.Stats) int64 { return s.Dropped }),
+	)
+}
+
+// Metrics counts requests and observes their latency, labelled with the route pattern
+// they match in mux rather than the raw path to keep cardinality bounded.
+func Metrics(mux *http.ServeMux) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			_, route := mux.Handler(r)
+			if route == "" {
+				route = "unmatched"
+			}
+
+			start := time.Now()
+			rec := &responseRecorder{ResponseWriter: w, status: http.StatusOK}
+			next.ServeHTTP(rec, r)
+
+			status := strconv.Itoa(rec.status)
+			httpRequests.WithLabelValues(route, r.Method, status).Inc()
+			httpRequestDuration.WithLabelValues(route, r.Method, status).Observe(time.Since(start).Seconds())
+		})
+	}
+}
@@ -0,0 +1,79 @@
+package server
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+	"sync/atomic"
+
+	"github.com/go-redis/redis/v8"
+
+	"go-mysql/internal/cache"
+	"go-mysql/internal/config"
+	"go-mysql/pkg/ratelimit"
+)
+
+// NewRateLimiter returns the limiter selected by cfg, keeping its state in rdb under
+// the key prefix of the cache, or nil if rate limiting is disabled.
+func NewRateLimiter(cfg config.RateLimit, rdb redis.UniversalClient, state *cache.State) ratelimit.Limiter {
+	prefix := state.Config().KeyPrefix + "ratelimit:"
+	switch cfg.Algorithm {
+	case "token_bucket":
+		rate := float64(cfg.Limit) / cfg.Window.Seconds()
+		return ratelimit.NewTokenBucket(rdb, prefix, rate, cfg.Burst)
+	case "fixed_window":
+		return ratelimit.NewFixedWindow(rdb, prefix, cfg.Limit, cfg.Window)
+	case "sliding_log":
+		return ratelimit.NewSlidingWindowLog(rdb, prefix, cfg.Limit, cfg.Window)
+	default:
+		return nil
+	}
+}
+
+// redisOptionalLimiter lets every request through while Redis is unreachable, rather
+// than making each one wait for the limiter's connection to time out.
+type redisOptionalLimiter struct {
+	ratelimit.Limiter
+	cache *cache.State
+}
+
+func (l redisOptionalLimiter) Allow(ctx context.Context, key string) (ratelimit.Result, error) {
+	if !l.cache.RedisAvailable() {
+		return ratelimit.Result{Allowed: true}, nil
+	}
+	return l.Limiter.Allow(ctx, key)
+}
+
+// RateLimit applies whichever limiter is current, letting a config reload change the
+// limits or turn rate limiting on or off.
+type RateLimit struct {
+	limiter atomic.Pointer[ratelimit.Limiter]
+	// cache says whether Redis, which the limiters keep their state in, is reachable.
+	cache  *cache.State
+	logger *slog.Logger
+}
+
+func NewRateLimit(limiter ratelimit.Limiter, state *cache.State, logger *slog.Logger) *RateLimit {
+	l := &RateLimit{cache: state, logger: logger}
+	l.Set(limiter)
+	return l
+}
+
+// Set replaces the current limiter; nil disables rate limiting.
+func (l *RateLimit) Set(limiter ratelimit.Limiter) {
+	if limiter != nil {
+		limiter = redisOptionalLimiter{limiter, l.cache}
+	}
+	l.limiter.Store(&limiter)
+}
+
+func (l *RateLimit) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		limiter := *l.limiter.Load()
+		if limiter == nil {
+			next.ServeHTTP(w, r)
+			return
+		}
+		ratelimit.Middleware(limiter, ratelimit.ClientIP, l.logger)(next).ServeHTTP(w, r)
+	})
+}
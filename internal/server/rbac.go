@@ -0,0 +1,49 @@
+package server
+
+import (
+	"errors"
+	"net/http"
+	"slices"
+
+	"go-mysql/internal/auth"
+	"go-mysql/internal/logging"
+	"go-mysql/internal/models"
+	"go-mysql/internal/service"
+	"go-mysql/internal/tenant"
+)
+
+// RequireRole only lets through requests with an API key in X-API-Key issued to a user
+// that has one of roles and isn't banned, puts that user in their context, see package
+// auth, and records it as seen, see service.Admin.Seen. It must come after Tenant, and
+// rejects keys of users of another tenant than the request's.
+func RequireRole(admin *service.Admin, roles ...string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			ctx := r.Context()
+			key := r.Header.Get(apiKeyHeader)
+			if key == "" {
+				http.Error(w, "Missing API key", http.StatusUnauthorized)
+				return
+			}
+			p, err := admin.Authenticate(ctx, key)
+			if errors.Is(err, service.ErrUnauthenticated) {
+				http.Error(w, err.Error(), http.StatusUnauthorized)
+				return
+			}
+			if err != nil {
+				logging.From(ctx).Error("Failed to authenticate request", "error", err)
+				http.Error(w, "Failed to authenticate request", http.StatusInternalServerError)
+				return
+			}
+			if p.Status != models.StatusActive || !slices.Contains(roles, p.Role) || p.TenantID != tenant.ID(ctx) {
+				http.Error(w, "Forbidden", http.StatusForbidden)
+				return
+			}
+
+			admin.Seen(ctx, p)
+			ctx = auth.WithPrincipal(ctx, p)
+			ctx = logging.WithLogger(ctx, logging.From(ctx).With("principal_id", p.UserID))
+			next.ServeHTTP(w, r.WithContext(ctx))
+		})
+	}
+}
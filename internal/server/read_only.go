@@ -0,0 +1,15 @@
+package server
+
+import "net/http"
+
+// ReadOnly rejects every request that could modify data. It guards the API while the
+// schema is ahead of this binary, see repository.CheckSchema.
+func ReadOnly(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet && r.Method != http.MethodHead {
+			http.Error(w, "Service is read-only until the schema is migrated", http.StatusServiceUnavailable)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
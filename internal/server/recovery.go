@@ -0,0 +1,58 @@
+package server
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"runtime/debug"
+
+	"go-mysql/internal/logging"
+	"go-mysql/internal/requestid"
+	"go-mysql/pkg/errreport"
+)
+
+// Recovery turns a panicking handler into a 500 response instead of a dropped
+// connection. The panic is logged and reported with its stack trace, and the client gets
+// a JSON error carrying the request id to quote when asking about it. If the handler
+// had already started the response it can't be replaced, so the connection is aborted
+// to make sure the client doesn't take a truncated body for a complete one.
+func Recovery(reporter errreport.Reporter) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			rec := &responseRecorder{ResponseWriter: w, status: http.StatusOK}
+			defer func() {
+				v := recover()
+				if v == nil {
+					return
+				}
+				if v == http.ErrAbortHandler {
+					panic(v)
+				}
+
+				stack := debug.Stack()
+				requestID := requestid.From(r.Context())
+				logging.From(r.Context()).Error("Panic serving request", "panic", v, "stack", string(stack))
+				reporter.Report(r.Context(), errreport.Event{
+					Err:       fmt.Errorf("panic: %v", v),
+					Stack:     stack,
+					Request:   r,
+					RequestID: requestID,
+					Status:    http.StatusInternalServerError,
+				})
+				markReported(r.Context())
+
+				if rec.wroteHeader {
+					panic(http.ErrAbortHandler)
+				}
+				w.Header().Set("Content-Type", "application/json")
+				w.WriteHeader(http.StatusInternalServerError)
+				json.NewEncoder(w).Encode(map[string]string{
+					"error":      "Internal server error",
+					"request_id": requestID,
+				})
+			}()
+
+			next.ServeHTTP(rec, r)
+		})
+	}
+}
@@ -0,0 +1,41 @@
+package server
+
+import (
+	"context"
+	"net/http"
+
+	"go.opentelemetry.io/otel/attribute"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/trace"
+
+	"go-mysql/internal/requestid"
+)
+
+// RequestID gives every request an id, taken from the X-Request-ID header when
+// a proxy or client already assigned one, and echoes it in the response so a failing
+// request can be matched with its log lines and spans.
+func RequestID(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id := r.Header.Get(requestid.Header)
+		if !requestid.Valid(id) {
+			id = requestid.New()
+		}
+		w.Header().Set(requestid.Header, id)
+		trace.SpanFromContext(r.Context()).SetAttributes(attribute.String("request.id", id))
+		next.ServeHTTP(w, r.WithContext(requestid.With(r.Context(), id)))
+	})
+}
+
+// requestIDSpanProcessor tags every span started on behalf of a request, including the
+// MySQL and Redis spans, with the request id.
+type requestIDSpanProcessor struct{}
+
+func (requestIDSpanProcessor) OnStart(parent context.Context, s sdktrace.ReadWriteSpan) {
+	if id := requestid.From(parent); id != "" {
+		s.SetAttributes(attribute.String("request.id", id))
+	}
+}
+
+func (requestIDSpanProcessor) OnEnd(sdktrace.ReadOnlySpan)      {}
+func (requestIDSpanProcessor) Shutdown(context.Context) error   { return nil }
+func (requestIDSpanProcessor) ForceFlush(context.Context) error { return nil }
@@ -0,0 +1,87 @@
+package server
+
+import (
+	"context"
+	"net/http"
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+	"time"
+
+	"go-mysql/internal/config"
+	"go-mysql/internal/logging"
+	"go-mysql/pkg/lifecycle"
+)
+
+// ShutdownOnSignal waits for SIGINT or SIGTERM, or for one of components to fail,
+// then shuts the service down in order:
+// it fails the readiness probe and waits cfg.ReadinessDelay for load balancers to stop
+// sending traffic, stops the servers from accepting connections and lets in-flight
+// requests finish, then stops the background workers and waits for them. Everything
+// has to be done within cfg.Timeout; past that, remaining connections are closed and
+// workers abandoned. Workers still busy cfg.RequeueTimeout before then are told to
+// abandon their work with abandonBackground, so they requeue or persist what's left
+// instead of losing it. A second signal exits immediately. It returns the error of
+// the component that failed, if one did.
+//
+// Progress is logged with the logger carried by ctx. Connections to MySQL and Redis
+// are closed by main once this returns.
+func ShutdownOnSignal(ctx context.Context, cfg config.Shutdown, components *lifecycle.Group, servers []*http.Server, stopBackground, abandonBackground context.CancelFunc) error {
+	signals := make(chan os.Signal, 2)
+	signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
+	select {
+	case sig := <-signals:
+		logging.From(ctx).Info("Shutting down", "signal", sig.String(), "timeout", cfg.Timeout)
+	case <-components.Failed():
+		logging.From(ctx).Error("Shutting down after a component failed", "error", components.Err(), "timeout", cfg.Timeout)
+	}
+	go func() {
+		<-signals
+		logging.From(ctx).Error("Received a second signal, exiting immediately")
+		os.Exit(1)
+	}()
+
+	markedNotReady.Store(true)
+	time.Sleep(cfg.ReadinessDelay)
+
+	start := time.Now()
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), cfg.Timeout)
+	defer cancel()
+
+	var wg sync.WaitGroup
+	for _, server := range servers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			err := server.Shutdown(shutdownCtx)
+			if err != nil {
+				logging.From(ctx).Error("Failed to drain in-flight requests, closing connections", "addr", server.Addr, "error", err)
+				server.Close()
+			}
+		}()
+	}
+	wg.Wait()
+
+	stopBackground()
+	stopped := make(chan struct{})
+	go func() {
+		components.Wait()
+		close(stopped)
+	}()
+	abandonAt := time.NewTimer(cfg.Timeout - cfg.RequeueTimeout - time.Since(start))
+	defer abandonAt.Stop()
+	select {
+	case <-stopped:
+		return components.Err()
+	case <-abandonAt.C:
+		logging.From(ctx).Warn("Background workers still busy, abandoning their work", "requeue_timeout", cfg.RequeueTimeout)
+		abandonBackground()
+	}
+	select {
+	case <-stopped:
+	case <-shutdownCtx.Done():
+		logging.From(ctx).Error("Background workers did not stop in time")
+	}
+	return components.Err()
+}
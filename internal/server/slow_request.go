@@ -0,0 +1,34 @@
+package server
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+
+	"go-mysql/internal/logging"
+)
+
+var slowRequests = promauto.NewCounterVec(prometheus.CounterOpts{
+	Name: "http_slow_requests_total",
+	Help: "HTTP requests that took longer than SLOW_REQUEST_THRESHOLD, by route.",
+}, []string{"route"})
+
+// SlowRequests logs requests taking longer than threshold, counting them by their route
+// in mux.
+func SlowRequests(threshold time.Duration, mux *http.ServeMux) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			start := time.Now()
+			next.ServeHTTP(w, r)
+			elapsed := time.Since(start)
+			if elapsed < threshold {
+				return
+			}
+			_, route := mux.Handler(r)
+			slowRequests.WithLabelValues(route).Inc()
+			logging.From(r.Context()).Warn("Slow request", "path", r.URL.Path, "duration", elapsed)
+		})
+	}
+}
@@ -0,0 +1,91 @@
+package server
+
+import (
+	"errors"
+	"net"
+	"net/http"
+	"strings"
+
+	"go-mysql/internal/config"
+	"go-mysql/internal/logging"
+	"go-mysql/internal/models"
+	"go-mysql/internal/service"
+	"go-mysql/internal/tenant"
+)
+
+const apiKeyHeader = "X-API-Key"
+
+// Tenant puts the tenant each request belongs to in its context, see package tenant.
+// It's the tenant of the API key in X-API-Key if there is one, else the tenant named
+// by cfg.Header, else the subdomain of cfg.BaseDomain the request was sent to. A
+// request naming none belongs to the default tenant, unless cfg.Required.
+//
+// The header and subdomain are up to the client, so they only name the tenant: any
+// tenant but the default one takes an API key of one of its users, and a request
+// naming another tenant than its API key's is rejected.
+func Tenant(cfg config.Tenancy, tenants *service.Tenants) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			ctx := r.Context()
+			var t models.Tenant
+			var err error
+			slug := requestTenantSlug(cfg, r)
+			if key := r.Header.Get(apiKeyHeader); key != "" {
+				t, err = tenants.ByAPIKey(ctx, key)
+				if errors.Is(err, service.ErrTenantNotFound) {
+					http.Error(w, "Invalid API key", http.StatusUnauthorized)
+					return
+				}
+				if err == nil && slug != "" && !strings.EqualFold(slug, t.Slug) {
+					http.Error(w, "API key belongs to another tenant", http.StatusForbidden)
+					return
+				}
+			} else if slug != "" {
+				t, err = tenants.BySlug(ctx, slug)
+				if errors.Is(err, service.ErrTenantNotFound) {
+					http.Error(w, "Unknown tenant", http.StatusNotFound)
+					return
+				}
+				if err == nil && t.ID != tenant.DefaultID {
+					http.Error(w, "Missing API key", http.StatusUnauthorized)
+					return
+				}
+			} else if cfg.Required {
+				http.Error(w, "Missing tenant", http.StatusBadRequest)
+				return
+			} else {
+				next.ServeHTTP(w, r)
+				return
+			}
+			if err != nil {
+				logging.From(ctx).Error("Failed to resolve tenant", "error", err)
+				http.Error(w, "Failed to resolve tenant", http.StatusInternalServerError)
+				return
+			}
+
+			ctx = tenant.WithID(ctx, t.ID)
+			ctx = logging.WithLogger(ctx, logging.From(ctx).With("tenant", t.Slug))
+			next.ServeHTTP(w, r.WithContext(ctx))
+		})
+	}
+}
+
+// requestTenantSlug returns the slug of the tenant r names in its header or
+// subdomain, or "".
+func requestTenantSlug(cfg config.Tenancy, r *http.Request) string {
+	if slug := r.Header.Get(cfg.Header); slug != "" {
+		return slug
+	}
+	if cfg.BaseDomain == "" {
+		return ""
+	}
+	host, _, err := net.SplitHostPort(r.Host)
+	if err != nil {
+		host = r.Host
+	}
+	sub, ok := strings.CutSuffix(strings.ToLower(host), "."+cfg.BaseDomain)
+	if !ok || strings.Contains(sub, ".") {
+		return ""
+	}
+	return sub
+}
@@ -0,0 +1,43 @@
+package server
+
+import (
+	"net/http"
+	"time"
+
+	"go-mysql/internal/config"
+)
+
+// streamingRoutes are left without a timeout; their responses are open-ended, or as
+// long as the data they stream, which a timeout would have to buffer.
+var streamingRoutes = map[string]bool{"/redis/subscribe": true, "GET /users/export": true}
+
+// Timeout answers 503 once a request has run longer than the timeout cfg sets
+// for its route in mux. The request context is cancelled at the same time, so queries
+// and Redis calls made with it are abandoned rather than left running for nobody.
+func Timeout(cfg config.Timeout, mux *http.ServeMux) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			timeout := routeTimeout(cfg, mux, r)
+			if timeout <= 0 {
+				next.ServeHTTP(w, r)
+				return
+			}
+			http.TimeoutHandler(next, timeout, "Request timed out").ServeHTTP(w, r)
+		})
+	}
+}
+
+// routeTimeout returns how long r, routed by mux, may take under cfg, or 0 for no limit.
+func routeTimeout(cfg config.Timeout, mux *http.ServeMux, r *http.Request) time.Duration {
+	_, route := mux.Handler(r)
+	if timeout, ok := cfg.Routes[route]; ok {
+		return timeout
+	}
+	if streamingRoutes[route] {
+		return 0
+	}
+	if r.Method == http.MethodGet || r.Method == http.MethodHead {
+		return cfg.Read
+	}
+	return cfg.Write
+}
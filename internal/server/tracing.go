@@ -0,0 +1,57 @@
+package server
+
+import (
+	"context"
+	"net/http"
+
+	"go.opentelemetry.io/contrib/instrumentation/net/http/otelhttp"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+
+	"go-mysql/internal/config"
+)
+
+// SetupTracing installs a tracer provider exporting spans over OTLP/HTTP. The endpoint
+// and headers come from the standard OTEL_EXPORTER_OTLP_* variables and the service
+// name from OTEL_SERVICE_NAME. Until it runs, the instrumentation records nothing.
+// The returned function flushes buffered spans and must be called before exiting.
+func SetupTracing(ctx context.Context, cfg config.Tracing) (shutdown func(context.Context) error, err error) {
+	exporter, err := otlptracehttp.New(ctx)
+	if err != nil {
+		return nil, err
+	}
+	res, err := resource.Merge(resource.Default(), resource.Environment())
+	if err != nil {
+		return nil, err
+	}
+
+	provider := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithSpanProcessor(requestIDSpanProcessor{}),
+		sdktrace.WithResource(res),
+		sdktrace.WithSampler(sdktrace.ParentBased(sdktrace.TraceIDRatioBased(cfg.SampleRatio))),
+	)
+	otel.SetTracerProvider(provider)
+	// Accept and forward W3C traceparent/tracestate and baggage headers
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(propagation.TraceContext{}, propagation.Baggage{}))
+	return provider.Shutdown, nil
+}
+
+// Tracing starts a server span for every request, continuing the trace the
+// caller propagated if there is one. Spans are named after the route the request
+// matches in mux, e.g. "GET /users/{id}".
+func Tracing(mux *http.ServeMux) func(http.Handler) http.Handler {
+	spanName := func(_ string, r *http.Request) string {
+		_, route := mux.Handler(r)
+		if route == "" {
+			return r.Method
+		}
+		return r.Method + " " + route
+	}
+	return func(next http.Handler) http.Handler {
+		return otelhttp.NewHandler(next, "http.server", otelhttp.WithSpanNameFormatter(spanName))
+	}
+}
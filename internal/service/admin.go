@@ -0,0 +1,183 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"slices"
+	"time"
+
+	"go-mysql/internal/events"
+	"go-mysql/internal/logging"
+	"go-mysql/internal/models"
+	"go-mysql/internal/repository"
+)
+
+// AdminStore is the database behind Admin, implemented by repository.Repository.
+// Changes to missing users return sql.ErrNoRows.
+type AdminStore interface {
+	AdminUsers(ctx context.Context, filter repository.AdminUserFilter) ([]models.AdminUser, int, error)
+	EachAdminUser(ctx context.Context, filter repository.AdminUserFilter, fn func(models.AdminUser) error) error
+	SetUserRole(ctx context.Context, id int, role string) error
+	BanUser(ctx context.Context, id int, reason string) error
+	UnbanUser(ctx context.Context, id int) error
+	RequirePasswordReset(ctx context.Context, id int) (models.User, error)
+	PrincipalByAPIKey(ctx context.Context, keyHash string) (models.Principal, error)
+	UserByID(ctx context.Context, id int) (models.User, error)
+	TouchUser(ctx context.Context, id int, loginIdle time.Duration) error
+	MergeUsers(ctx context.Context, id, into int) (merge models.UserMerge, user models.User, related []int, err error)
+}
+
+// SeenThrottle limits how often users are recorded as seen, implemented by
+// cache.UserCache.
+type SeenThrottle interface {
+	ThrottleSeen(ctx context.Context, id int, interval time.Duration) bool
+}
+
+// PasswordResetMailer sends password reset emails, implemented by mailer.Mailer.
+type PasswordResetMailer interface {
+	SendPasswordReset(ctx context.Context, user models.User, link string) error
+}
+
+// ErrUnauthenticated is returned for an API key no user has.
+var ErrUnauthenticated = errors.New("Invalid API key")
+
+// maxBanReasonLength is the size of the ban_reason column.
+const maxBanReasonLength = 255
+
+// Admin manages users on behalf of administrators: their roles, bans, password resets
+// and duplicates. Every operation is scoped to the tenant in ctx and logged for the audit
+// trail; actor is the id of the administrator acting.
+type Admin struct {
+	store AdminStore
+	mail  PasswordResetMailer
+	bus   *events.Bus
+	// resetLink is the page users reset their password on.
+	resetLink string
+
+	// seen, if set, throttles Seen to once per seenInterval per user.
+	seen         SeenThrottle
+	seenInterval time.Duration
+	loginIdle    time.Duration
+}
+
+// NewAdmin returns the admin operations on top of store, emailing users forced to
+// reset their password a link to resetLink and publishing the bans and unbans, which
+// change users' status, on bus.
+func NewAdmin(store AdminStore, mail PasswordResetMailer, bus *events.Bus, resetLink string) *Admin {
+	return &Admin{store: store, mail: mail, bus: bus, resetLink: resetLink}
+}
+
+// TrackLastSeen turns on Seen, which records users as seen at most once per interval
+// per user, and as logged in again once they were idle for loginIdle.
+func (a *Admin) TrackLastSeen(throttle SeenThrottle, interval, loginIdle time.Duration) {
+	a.seen, a.seenInterval, a.loginIdle = throttle, interval, loginIdle
+}
+
+// Seen records that p just made an authenticated request, unless it was recorded less
+// than the interval given to TrackLastSeen ago. Failures are only logged, so the
+// request goes on.
+func (a *Admin) Seen(ctx context.Context, p models.Principal) {
+	if a.seen == nil || !a.seen.ThrottleSeen(ctx, p.UserID, a.seenInterval) {
+		return
+	}
+	err := a.store.TouchUser(ctx, p.UserID, a.loginIdle)
+	if err != nil {
+		logging.From(ctx).Warn("Failed to record user as seen", "user_id", p.UserID, "error", err)
+	}
+}
+
+// Authenticate returns who key was issued to, or ErrUnauthenticated.
+func (a *Admin) Authenticate(ctx context.Context, key string) (models.Principal, error) {
+	p, err := a.store.PrincipalByAPIKey(ctx, HashAPIKey(key))
+	if err == sql.ErrNoRows {
+		return models.Principal{}, ErrUnauthenticated
+	}
+	return p, err
+}
+
+// List returns a page of users matching filter, with their roles and flags, and how
+// many match in total.
+func (a *Admin) List(ctx context.Context, filter repository.AdminUserFilter) ([]models.AdminUser, int, error) {
+	if filter.Role != "" && !slices.Contains(models.Roles, filter.Role) {
+		return nil, 0, fmt.Errorf("%w: unknown role %q", ErrInvalid, filter.Role)
+	}
+	if filter.Status != "" && !slices.Contains(models.Statuses, filter.Status) {
+		return nil, 0, fmt.Errorf("%w: unknown status %q", ErrInvalid, filter.Status)
+	}
+	return a.store.AdminUsers(ctx, filter)
+}
+
+// SetRole gives the user with the given id role. Administrators can't change their own
+// role, so a tenant can't lose its last one by accident.
+func (a *Admin) SetRole(ctx context.Context, actor, id int, role string) error {
+	if !slices.Contains(models.Roles, role) {
+		return fmt.Errorf("%w: unknown role %q", ErrInvalid, role)
+	}
+	if actor == id {
+		return fmt.Errorf("%w: administrators can't change their own role", ErrInvalid)
+	}
+	err := translateNotFound(a.store.SetUserRole(ctx, id, role))
+	if err == nil {
+		logging.From(ctx).Info("User role changed", "audit", true, "actor_id", actor, "user_id", id, "role", role)
+	}
+	return err
+}
+
+// Ban bans the user with the given id, whose API keys stop working.
+func (a *Admin) Ban(ctx context.Context, actor, id int, reason string) error {
+	if actor == id {
+		return fmt.Errorf("%w: administrators can't ban themselves", ErrInvalid)
+	}
+	if len(reason) > maxBanReasonLength {
+		return fmt.Errorf("%w: reason is longer than %d characters", ErrInvalid, maxBanReasonLength)
+	}
+	err := translateNotFound(a.store.BanUser(ctx, id, reason))
+	if err != nil {
+		return err
+	}
+	logging.From(ctx).Info("User banned", "audit", true, "actor_id", actor, "user_id", id, "reason", reason)
+	return a.publishStatus(ctx, id)
+}
+
+// Unban lifts the ban of the user with the given id.
+func (a *Admin) Unban(ctx context.Context, actor, id int) error {
+	err := translateNotFound(a.store.UnbanUser(ctx, id))
+	if err != nil {
+		return err
+	}
+	logging.From(ctx).Info("User unbanned", "audit", true, "actor_id", actor, "user_id", id)
+	return a.publishStatus(ctx, id)
+}
+
+// publishStatus publishes the user with the given id after its status changed, whole,
+// as listings depend on the status.
+func (a *Admin) publishStatus(ctx context.Context, id int) error {
+	user, err := a.store.UserByID(ctx, id)
+	if err != nil {
+		return translateNotFound(err)
+	}
+	a.bus.Publish(ctx, events.UserUpdated{User: user})
+	return nil
+}
+
+// ForcePasswordReset flags the user with the given id as having to choose a new
+// password and emails them the link to do so. Passwords themselves are handled by
+// whatever serves the reset page; this service only keeps the flag.
+func (a *Admin) ForcePasswordReset(ctx context.Context, actor, id int) error {
+	user, err := a.store.RequirePasswordReset(ctx, id)
+	err = translateNotFound(err)
+	if err != nil {
+		return err
+	}
+	logging.From(ctx).Info("Password reset forced", "audit", true, "actor_id", actor, "user_id", id)
+	return a.mail.SendPasswordReset(ctx, user, a.resetLink)
+}
+
+func translateNotFound(err error) error {
+	if err == sql.ErrNoRows {
+		return ErrNotFound
+	}
+	return err
+}
@@ -0,0 +1,196 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"database/sql"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"slices"
+	"time"
+
+	"go-mysql/internal/events"
+	"go-mysql/internal/logging"
+	"go-mysql/internal/models"
+	"go-mysql/internal/tenant"
+	"go-mysql/pkg/imaging"
+)
+
+// AvatarStorage is the object storage avatars are uploaded to, implemented by
+// storage.S3.
+type AvatarStorage interface {
+	Put(ctx context.Context, key string, data []byte, contentType string) error
+	Delete(ctx context.Context, keys ...string) error
+	SignedURL(ctx context.Context, key string, ttl time.Duration) (string, error)
+}
+
+var (
+	// ErrAvatarsDisabled is returned for avatar uploads when no storage is configured.
+	ErrAvatarsDisabled = errors.New("Avatar uploads are not enabled")
+	// ErrAvatarTooLarge is returned for avatars over the upload size limit.
+	ErrAvatarTooLarge = errors.New("Avatar is too large")
+)
+
+// Each uploaded avatar is stored in these sizes, as square images under its key.
+const (
+	avatarSize          = 512
+	avatarThumbnailSize = 128
+)
+
+// AvatarOptions limits the avatars users upload.
+type AvatarOptions struct {
+	// MaxUploadSize is the largest file accepted, in bytes, and MaxDimension the widest
+	// or tallest image, in pixels.
+	MaxUploadSize int
+	MaxDimension  int
+	// URLTTL is how long the signed URLs in responses stay valid.
+	URLTTL time.Duration
+}
+
+// UseAvatarStorage enables avatar uploads to storage. Without it SetAvatar returns
+// ErrAvatarsDisabled.
+func (s *UserService) UseAvatarStorage(storage AvatarStorage, opts AvatarOptions) {
+	s.avatars = storage
+	s.avatarOpts = opts
+}
+
+// MaxAvatarSize returns the largest avatar SetAvatar accepts, in bytes.
+func (s *UserService) MaxAvatarSize() int {
+	return s.avatarOpts.MaxUploadSize
+}
+
+// avatarObject returns the key of the object holding the avatar under key in size.
+func avatarObject(key string, size int) string {
+	return fmt.Sprintf("%s/%d", key, size)
+}
+
+// SetAvatar makes the image in data the avatar of the user with the given id, in place
+// of any avatar URL or previous upload, and returns the user. The image is resized to
+// squares of 512 and 128 pixels, which are all that's stored.
+func (s *UserService) SetAvatar(ctx context.Context, id int, data []byte) (models.User, error) {
+	if s.avatars == nil {
+		return models.User{}, ErrAvatarsDisabled
+	}
+	if len(data) > s.avatarOpts.MaxUploadSize {
+		return models.User{}, ErrAvatarTooLarge
+	}
+	img, err := imaging.Decode(data, s.avatarOpts.MaxDimension)
+	if err != nil {
+		return models.User{}, fmt.Errorf("%w: %v", ErrInvalid, err)
+	}
+
+	// Every upload gets a key of its own, so URLs signed for the previous avatar never
+	// show the new one from a stale cache
+	suffix := make([]byte, 8)
+	_, err = rand.Read(suffix)
+	if err != nil {
+		return models.User{}, err
+	}
+	key := fmt.Sprintf("avatars/%d/%d/%s", tenant.ID(ctx), id, hex.EncodeToString(suffix))
+	var objects []string
+	for _, size := range []int{avatarSize, avatarThumbnailSize} {
+		encoded, contentType, err := imaging.Encode(imaging.Thumbnail(img, size))
+		if err != nil {
+			return models.User{}, err
+		}
+		object := avatarObject(key, size)
+		err = s.avatars.Put(ctx, object, encoded, contentType)
+		if err != nil {
+			s.deleteAvatarObjects(ctx, objects...)
+			return models.User{}, err
+		}
+		objects = append(objects, object)
+	}
+
+	err = s.setAvatarKey(ctx, id, key)
+	if err != nil {
+		s.deleteAvatarObjects(ctx, objects...)
+		return models.User{}, err
+	}
+	return s.Get(ctx, id)
+}
+
+// DeleteAvatar removes the uploaded avatar of the user with the given id, if any.
+func (s *UserService) DeleteAvatar(ctx context.Context, id int) error {
+	if s.avatars == nil {
+		return ErrAvatarsDisabled
+	}
+	return s.setAvatarKey(ctx, id, "")
+}
+
+// setAvatarKey stores key as the user's avatar and deletes the objects of the previous
+// one.
+func (s *UserService) setAvatarKey(ctx context.Context, id int, key string) error {
+	username, previous, err := s.store.SetUserAvatar(ctx, id, key)
+	if err == sql.ErrNoRows {
+		return ErrNotFound
+	}
+	if err != nil {
+		return err
+	}
+	if previous == key {
+		return nil
+	}
+	s.bus.Publish(ctx, events.UserUpdated{User: models.User{ID: id, Username: username, AvatarKey: key}, Fields: []string{"avatar_key"}})
+	if previous != "" {
+		s.deleteAvatarObjects(ctx, avatarObject(previous, avatarSize), avatarObject(previous, avatarThumbnailSize))
+	}
+	return nil
+}
+
+// deleteAvatarObjects deletes objects no user refers to anymore. A failure only leaves
+// them behind, so it's logged rather than returned.
+func (s *UserService) deleteAvatarObjects(ctx context.Context, objects ...string) {
+	if len(objects) == 0 {
+		return
+	}
+	err := s.avatars.Delete(ctx, objects...)
+	if err != nil {
+		logging.From(ctx).Warn("Failed to delete avatar objects", "objects", objects, "error", err)
+	}
+}
+
+// withAvatarURLs replaces the avatar key of users with signed URLs to the avatar, for
+// responses. Without storage to sign them the key is just dropped.
+func (s *UserService) withAvatarURLs(ctx context.Context, users ...*models.User) {
+	for _, user := range users {
+		if user.AvatarKey == "" {
+			continue
+		}
+		if s.avatars != nil {
+			user.AvatarURL = s.signAvatar(ctx, user.AvatarKey, avatarSize)
+			user.AvatarThumbnailURL = s.signAvatar(ctx, user.AvatarKey, avatarThumbnailSize)
+		}
+		user.AvatarKey = ""
+	}
+}
+
+// withAvatarFields does what withAvatarURLs does for fields selected from a user,
+// which include avatar_key if the user asked for avatar_url.
+func (s *UserService) withAvatarFields(ctx context.Context, fields map[string]string) {
+	key, ok := fields["avatar_key"]
+	delete(fields, "avatar_key")
+	if ok && key != "" && s.avatars != nil {
+		fields["avatar_url"] = s.signAvatar(ctx, key, avatarSize)
+	}
+}
+
+// avatarFields returns the fields to read for a client asking for fields.
+func avatarFields(fields []string) []string {
+	if slices.Contains(fields, "avatar_url") {
+		return append(slices.Clone(fields), "avatar_key")
+	}
+	return fields
+}
+
+// signAvatar returns a signed URL to the avatar under key in size, or "" if signing
+// fails, which leaves the user without an avatar rather than failing the request.
+func (s *UserService) signAvatar(ctx context.Context, key string, size int) string {
+	url, err := s.avatars.SignedURL(ctx, avatarObject(key, size), s.avatarOpts.URLTTL)
+	if err != nil {
+		logging.From(ctx).Warn("Failed to sign avatar URL", "key", key, "error", err)
+		return ""
+	}
+	return url
+}
@@ -0,0 +1,112 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+
+	"go-mysql/internal/models"
+)
+
+// e164Pattern matches phone numbers in E.164 form: a +, then up to 15 digits.
+var e164Pattern = regexp.MustCompile(`^\+[1-9][0-9]{6,14}$`)
+
+// countryPattern matches ISO 3166-1 alpha-2 codes.
+var countryPattern = regexp.MustCompile(`^[A-Z]{2}$`)
+
+// normalizeUser returns user with its email and contact details the way they're
+// stored, see normalizeContact.
+func normalizeUser(user models.User) models.User {
+	user.Email = models.NormalizeEmail(user.Email)
+	user.Country = models.NormalizeCountry(user.Country)
+	user.Region = models.NormalizeRegion(user.Region, user.Country)
+	user.PostalCode = models.NormalizePostalCode(user.PostalCode)
+	user.Phone = models.NormalizePhone(user.Phone, user.Country)
+	return user
+}
+
+// normalizeContact normalizes the phone and address among profile fields keyed by
+// their JSON names. A phone without a calling code is taken for a national number of
+// the country among fields, if any.
+func normalizeContact(fields map[string]string) {
+	if country, ok := fields["country"]; ok {
+		fields["country"] = models.NormalizeCountry(country)
+	}
+	if region, ok := fields["region"]; ok {
+		fields["region"] = models.NormalizeRegion(region, fields["country"])
+	}
+	if code, ok := fields["postal_code"]; ok {
+		fields["postal_code"] = models.NormalizePostalCode(code)
+	}
+	if phone, ok := fields["phone"]; ok {
+		fields["phone"] = models.NormalizePhone(phone, fields["country"])
+	}
+}
+
+// validateContact checks the normalized phone and address among profile fields.
+// Either may be empty, but an address needs its first line, city and country, and in
+// countries of models.Countries, a known region and postal code if they have those.
+// Phone numbers of the address's country must have as many digits as its numbers do.
+func validateContact(fields map[string]string) error {
+	country := fields["country"]
+	if phone := fields["phone"]; phone != "" {
+		err := validatePhone(phone)
+		if err != nil {
+			return err
+		}
+		c, ok := models.Countries[country]
+		if national, found := strings.CutPrefix(phone, "+"+c.CallingCode); ok && found && (len(national) < c.MinDigits || len(national) > c.MaxDigits) {
+			return fmt.Errorf("%w: phone %q is not a valid number for %s", ErrInvalid, phone, country)
+		}
+	}
+
+	address := slices.ContainsFunc(models.AddressFieldNames, func(name string) bool { return fields[name] != "" })
+	if !address {
+		return nil
+	}
+	for _, name := range []string{"address_line1", "city", "country"} {
+		if fields[name] == "" {
+			return fmt.Errorf("%w: address is missing %s", ErrInvalid, name)
+		}
+	}
+	if !countryPattern.MatchString(country) {
+		return fmt.Errorf("%w: country %q is not an ISO 3166-1 alpha-2 code", ErrInvalid, country)
+	}
+	c, ok := models.Countries[country]
+	if !ok {
+		return nil
+	}
+	if c.Regions != nil && !slices.Contains(c.Regions, fields["region"]) {
+		return fmt.Errorf("%w: region %q is not a region code of %s", ErrInvalid, fields["region"], country)
+	}
+	if c.PostalCode != nil && !c.PostalCode.MatchString(fields["postal_code"]) {
+		return fmt.Errorf("%w: postal_code %q is not a postal code of %s", ErrInvalid, fields["postal_code"], country)
+	}
+	return nil
+}
+
+// validatePhone checks a normalized phone is in E.164 form.
+func validatePhone(phone string) error {
+	if !e164Pattern.MatchString(phone) {
+		return fmt.Errorf("%w: phone %q is not an international number such as +14155550100, nor a national one of the country", ErrInvalid, phone)
+	}
+	return nil
+}
+
+// FindByPhone returns up to limit listed users whose phone is phone, in id order. A
+// national number is taken to be one of country, see models.NormalizePhone.
+func (s *UserService) FindByPhone(ctx context.Context, phone, country string, limit int) ([]models.User, error) {
+	phone = models.NormalizePhone(phone, country)
+	err := validatePhone(phone)
+	if err != nil {
+		return nil, err
+	}
+	users, err := s.store.UsersByPhone(ctx, phone, limit)
+	if err != nil {
+		return nil, err
+	}
+	s.withAvatarURLs(ctx, pointers(users)...)
+	return users, nil
+}
@@ -0,0 +1,189 @@
+package service
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"math"
+	"slices"
+	"strings"
+
+	"go-mysql/internal/models"
+	"go-mysql/internal/repository"
+)
+
+// DefaultDuplicateSimilarity is the similarity of usernames or emails above which
+// Duplicates takes users for duplicates unless told otherwise.
+const DefaultDuplicateSimilarity = 0.85
+
+// minDuplicateKeyLength is the length below which normalized usernames and emails are
+// too short to tell people apart by, and aren't compared.
+const minDuplicateKeyLength = 4
+
+// Duplicates returns up to limit groups of users whose usernames or emails are alike,
+// most alike first, for an administrator to review. Values are compared normalized:
+// lowercased and stripped of anything but letters and digits, with emails reduced to
+// their local part without a +tag or Gmail dots. Two values are alike when their
+// similarity, one minus their Levenshtein distance over the longer length, is at least
+// minSimilarity.
+//
+// Only pairs sharing a trigram are compared, so scanning a tenant doesn't take a
+// comparison of every pair; values alike enough to be duplicates practically always
+// share one.
+func (a *Admin) Duplicates(ctx context.Context, minSimilarity float64, limit int) ([]models.DuplicateGroup, error) {
+	if minSimilarity <= 0 || minSimilarity > 1 {
+		return nil, fmt.Errorf("%w: similarity must be above 0 and at most 1", ErrInvalid)
+	}
+
+	var users []models.User
+	err := a.store.EachAdminUser(ctx, repository.AdminUserFilter{}, func(u models.AdminUser) error {
+		users = append(users, models.User{ID: u.ID, Username: u.Username, Email: u.Email, Status: u.Status})
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	var matches []models.DuplicateMatch
+	for _, field := range []string{models.DuplicateUsername, models.DuplicateEmail} {
+		keys := make([]string, len(users))
+		for i, user := range users {
+			keys[i] = duplicateKey(user, field)
+		}
+		for _, pair := range trigramPairs(keys) {
+			similarity := stringSimilarity(keys[pair[0]], keys[pair[1]])
+			if similarity >= minSimilarity {
+				matches = append(matches, models.DuplicateMatch{
+					UserIDs:    [2]int{users[pair[0]].ID, users[pair[1]].ID},
+					Field:      field,
+					Similarity: math.Round(similarity*100) / 100,
+				})
+			}
+		}
+	}
+	return groupDuplicates(users, matches, limit), nil
+}
+
+// duplicateKey returns the normalized field of user, or "" if it's too short to
+// compare.
+func duplicateKey(user models.User, field string) string {
+	value := user.Username
+	if field == models.DuplicateEmail {
+		value, _, _ = strings.Cut(models.CanonicalEmail(user.Email, true), "@")
+		value, _, _ = strings.Cut(value, "+")
+	}
+	key := foldAlphanumeric(value)
+	if len([]rune(key)) < minDuplicateKeyLength {
+		return ""
+	}
+	return key
+}
+
+// trigramPairs returns the pairs of indexes of keys, lowest first, of the non-empty
+// keys sharing at least one trigram.
+func trigramPairs(keys []string) [][2]int {
+	index := make(map[string][]int)
+	for i, key := range keys {
+		if key == "" {
+			continue
+		}
+		runes := []rune(key)
+		seen := make(map[string]bool)
+		for j := 0; j+3 <= len(runes); j++ {
+			trigram := string(runes[j : j+3])
+			if !seen[trigram] {
+				seen[trigram] = true
+				index[trigram] = append(index[trigram], i)
+			}
+		}
+	}
+
+	seen := make(map[[2]int]bool)
+	var pairs [][2]int
+	for _, ids := range index {
+		for x := range ids {
+			for _, j := range ids[x+1:] {
+				pair := [2]int{ids[x], j}
+				if !seen[pair] {
+					seen[pair] = true
+					pairs = append(pairs, pair)
+				}
+			}
+		}
+	}
+	return pairs
+}
+
+// stringSimilarity returns one minus the Levenshtein distance of a and b over the
+// length of the longer one, in runes.
+func stringSimilarity(a, b string) float64 {
+	ra, rb := []rune(a), []rune(b)
+	longest := max(len(ra), len(rb))
+	if longest == 0 {
+		return 1
+	}
+	prev := make([]int, len(rb)+1)
+	cur := make([]int, len(rb)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(ra); i++ {
+		cur[0] = i
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			cur[j] = min(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+		}
+		prev, cur = cur, prev
+	}
+	return 1 - float64(prev[len(rb)])/float64(longest)
+}
+
+// groupDuplicates joins the users linked by matches, directly or through others, into
+// groups, and returns up to limit of them, those with the most alike match first.
+func groupDuplicates(users []models.User, matches []models.DuplicateMatch, limit int) []models.DuplicateGroup {
+	// A union-find over user ids
+	parent := make(map[int]int)
+	var root func(id int) int
+	root = func(id int) int {
+		p, ok := parent[id]
+		if !ok || p == id {
+			return id
+		}
+		parent[id] = root(p)
+		return parent[id]
+	}
+	for _, m := range matches {
+		parent[root(m.UserIDs[1])] = root(m.UserIDs[0])
+	}
+
+	byRoot := make(map[int]*models.DuplicateGroup)
+	for _, m := range matches {
+		g, ok := byRoot[root(m.UserIDs[0])]
+		if !ok {
+			g = &models.DuplicateGroup{}
+			byRoot[root(m.UserIDs[0])] = g
+		}
+		g.Matches = append(g.Matches, m)
+	}
+	for _, user := range users {
+		if g, ok := byRoot[root(user.ID)]; ok {
+			g.Users = append(g.Users, user)
+		}
+	}
+
+	groups := make([]models.DuplicateGroup, 0, len(byRoot))
+	for _, g := range byRoot {
+		slices.SortFunc(g.Matches, func(a, b models.DuplicateMatch) int {
+			return cmp.Or(cmp.Compare(b.Similarity, a.Similarity), cmp.Compare(a.UserIDs[0], b.UserIDs[0]),
+				cmp.Compare(a.UserIDs[1], b.UserIDs[1]), strings.Compare(a.Field, b.Field))
+		})
+		groups = append(groups, *g)
+	}
+	slices.SortFunc(groups, func(a, b models.DuplicateGroup) int {
+		return cmp.Or(cmp.Compare(b.Matches[0].Similarity, a.Matches[0].Similarity), cmp.Compare(a.Users[0].ID, b.Users[0].ID))
+	})
+	return groups[:min(limit, len(groups))]
+}
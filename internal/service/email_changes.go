@@ -0,0 +1,123 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"database/sql"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/url"
+	"time"
+
+	"go-mysql/internal/events"
+	"go-mysql/internal/models"
+	"go-mysql/internal/repository"
+)
+
+// EmailChangeMailer sends the emails of an email change, implemented by
+// mailer.Mailer.
+type EmailChangeMailer interface {
+	SendEmailChangeRequested(ctx context.Context, user models.User, email string) error
+	SendEmailChangeConfirmation(ctx context.Context, user models.User, email, link string) error
+}
+
+var (
+	// ErrEmailChangesDisabled is returned for email changes when no mailer is set to
+	// confirm them.
+	ErrEmailChangesDisabled = errors.New("Email changes are not enabled")
+	// ErrInvalidEmailChangeToken is returned for a token no pending email change has,
+	// or whose change expired.
+	ErrInvalidEmailChangeToken = errors.New("Invalid or expired email change token")
+)
+
+// ConfirmEmailChanges enables ChangeEmail, sending the confirmations through mail with
+// a link to confirmLink carrying the token as ?token=, which stays valid for ttl.
+// Without it ChangeEmail returns ErrEmailChangesDisabled.
+func (s *UserService) ConfirmEmailChanges(mail EmailChangeMailer, confirmLink string, ttl time.Duration) {
+	s.emailChanges = mail
+	s.emailChangeLink = confirmLink
+	s.emailChangeTTL = ttl
+}
+
+// ChangeEmail asks for the email of the user called username to become email. Nothing
+// changes until ConfirmEmailChange is given the token sent to the new address, and the
+// current address is told about the change, so an API key alone can't take an account
+// over by moving it to another address. Asking again replaces the change pending, and
+// asking for the email the user has drops it, in which case pending is false. It
+// returns ErrEmailTaken if another user has the email.
+func (s *UserService) ChangeEmail(ctx context.Context, username, email string) (change models.EmailChange, pending bool, err error) {
+	if s.emailChanges == nil {
+		return models.EmailChange{}, false, ErrEmailChangesDisabled
+	}
+	if username == "" {
+		return models.EmailChange{}, false, fmt.Errorf("%w: missing username", ErrInvalid)
+	}
+	email = models.NormalizeEmail(email)
+	err = validateEmail(email)
+	if err != nil {
+		return models.EmailChange{}, false, err
+	}
+
+	buf := make([]byte, 32)
+	_, err = rand.Read(buf)
+	if err != nil {
+		return models.EmailChange{}, false, err
+	}
+	token := hex.EncodeToString(buf)
+	change = models.EmailChange{Email: email, ExpiresAt: time.Now().Add(s.emailChangeTTL).UTC().Truncate(time.Second)}
+
+	var user models.User
+	id, found, err := s.cache.ExecByUsername(ctx, username, func(id int) (bool, error) {
+		var err error
+		user, err = s.store.CreateEmailChange(ctx, id, username, email, HashAPIKey(token), change.ExpiresAt)
+		if err == sql.ErrNoRows {
+			return false, nil
+		}
+		return err == nil, err
+	})
+	if err == repository.ErrDuplicateEmail {
+		return models.EmailChange{}, false, ErrEmailTaken
+	}
+	if err != nil {
+		return models.EmailChange{}, false, err
+	}
+	if !found {
+		return models.EmailChange{}, false, ErrNotFound
+	}
+	change.UserID = id
+	if user.Email == email {
+		return change, false, nil
+	}
+
+	err = s.emailChanges.SendEmailChangeRequested(ctx, user, email)
+	if err != nil {
+		return models.EmailChange{}, false, err
+	}
+	err = s.emailChanges.SendEmailChangeConfirmation(ctx, user, email, s.emailChangeLink+"?token="+url.QueryEscape(token))
+	if err != nil {
+		return models.EmailChange{}, false, err
+	}
+	return change, true, nil
+}
+
+// ConfirmEmailChange applies the pending email change token was sent for and returns
+// the user with its new email. It returns ErrInvalidEmailChangeToken if there's no
+// such change or it expired, and ErrEmailTaken if another user took the email since.
+func (s *UserService) ConfirmEmailChange(ctx context.Context, token string) (models.User, error) {
+	if token == "" {
+		return models.User{}, ErrInvalidEmailChangeToken
+	}
+	user, err := s.store.ConfirmEmailChange(ctx, HashAPIKey(token), time.Now())
+	if err == sql.ErrNoRows {
+		return models.User{}, ErrInvalidEmailChangeToken
+	}
+	if err == repository.ErrDuplicateEmail {
+		return models.User{}, ErrEmailTaken
+	}
+	if err != nil {
+		return models.User{}, err
+	}
+	s.bus.Publish(ctx, events.UserUpdated{User: user, Fields: []string{"email"}})
+	return user, nil
+}
@@ -0,0 +1,46 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"go-mysql/internal/logging"
+	"go-mysql/internal/models"
+	"go-mysql/internal/repository"
+)
+
+// ErrForbidden is returned for what the role of the user acting doesn't allow.
+var ErrForbidden = errors.New("Forbidden")
+
+// ExportFields returns the fields of models.AdminUserFieldNames a user with role may
+// export: all of them for administrators, those of models.UserFieldNames for the
+// others.
+func ExportFields(role string) []string {
+	if role == models.RoleAdmin {
+		return models.AdminUserFieldNames
+	}
+	return models.UserFieldNames
+}
+
+// Export calls fn with each user matching filter that p may export, in id order, as
+// they're read from the database. Administrators export any user; the others only the
+// users listings show, and may not filter them. The Offset and Limit of filter are
+// ignored.
+func (a *Admin) Export(ctx context.Context, p models.Principal, filter repository.AdminUserFilter, fn func(models.AdminUser) error) error {
+	filter.Offset, filter.Limit = 0, 0
+	if p.Role != models.RoleAdmin {
+		if filter.Role != "" || filter.Banned != nil || !filter.InactiveSince.IsZero() || (filter.Status != "" && filter.Status != models.StatusActive) {
+			return fmt.Errorf("%w: only administrators can filter the users exported", ErrForbidden)
+		}
+		filter.Status = models.StatusActive
+	}
+
+	exported := 0
+	err := a.store.EachAdminUser(ctx, filter, func(user models.AdminUser) error {
+		exported++
+		return fn(user)
+	})
+	logging.From(ctx).Info("Users exported", "audit", true, "actor_id", p.UserID, "count", exported, "error", err)
+	return err
+}
@@ -0,0 +1,115 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"go-mysql/internal/events"
+	"go-mysql/internal/models"
+)
+
+// FollowStore is where follows are kept, implemented by repository.Repository.
+type FollowStore interface {
+	Follow(ctx context.Context, followerID, followeeID int) (bool, error)
+	Unfollow(ctx context.Context, followerID, followeeID int) (bool, error)
+	Followers(ctx context.Context, id, offset, limit int) ([]models.Follow, error)
+	Following(ctx context.Context, id, offset, limit int) ([]models.Follow, error)
+	FollowCounts(ctx context.Context, id int) (models.FollowCounts, error)
+}
+
+// FollowCounter caches follow counts, implemented by cache.UserCache.
+type FollowCounter interface {
+	FollowCounts(ctx context.Context, id int) (counts models.FollowCounts, ok bool)
+	SetFollowCounts(ctx context.Context, id int, counts models.FollowCounts)
+	CountFollow(ctx context.Context, followerID, followeeID int, followed bool)
+}
+
+// ErrNotFollowing is returned when unfollowing a user that isn't followed.
+var ErrNotFollowing = errors.New("Not following this user")
+
+// Follows manages users following each other. The number of followers of each user, and
+// of users it follows, is cached and kept up to date as follows come and go.
+type Follows struct {
+	store  FollowStore
+	counts FollowCounter
+	bus    *events.Bus
+}
+
+// NewFollows returns the follows kept in store, with their counts cached in counts,
+// publishing new follows on bus.
+func NewFollows(store FollowStore, counts FollowCounter, bus *events.Bus) *Follows {
+	return &Follows{store: store, counts: counts, bus: bus}
+}
+
+// Follow makes the user followerID follow the user followeeID. Following a user
+// already followed does nothing.
+func (f *Follows) Follow(ctx context.Context, followerID, followeeID int) error {
+	if followerID == followeeID {
+		return fmt.Errorf("%w: users can't follow themselves", ErrInvalid)
+	}
+	followed, err := f.store.Follow(ctx, followerID, followeeID)
+	if err == sql.ErrNoRows {
+		return ErrNotFound
+	}
+	if err != nil {
+		return err
+	}
+	if followed {
+		f.counts.CountFollow(ctx, followerID, followeeID, true)
+		f.bus.Publish(ctx, events.UserFollowed{FollowerID: followerID, FolloweeID: followeeID})
+	}
+	return nil
+}
+
+// Unfollow makes the user followerID stop following the user followeeID.
+func (f *Follows) Unfollow(ctx context.Context, followerID, followeeID int) error {
+	unfollowed, err := f.store.Unfollow(ctx, followerID, followeeID)
+	if err != nil {
+		return err
+	}
+	if !unfollowed {
+		return ErrNotFollowing
+	}
+	f.counts.CountFollow(ctx, followerID, followeeID, false)
+	return nil
+}
+
+// Followers returns a page of the users following the user with the given id, latest
+// first, along with the follow counts of the user.
+func (f *Follows) Followers(ctx context.Context, id, offset, limit int) ([]models.Follow, models.FollowCounts, error) {
+	return f.page(ctx, id, func() ([]models.Follow, error) { return f.store.Followers(ctx, id, offset, limit) })
+}
+
+// Following returns a page of the users the user with the given id follows, latest
+// first, along with the follow counts of the user.
+func (f *Follows) Following(ctx context.Context, id, offset, limit int) ([]models.Follow, models.FollowCounts, error) {
+	return f.page(ctx, id, func() ([]models.Follow, error) { return f.store.Following(ctx, id, offset, limit) })
+}
+
+func (f *Follows) page(ctx context.Context, id int, list func() ([]models.Follow, error)) ([]models.Follow, models.FollowCounts, error) {
+	counts, err := f.Counts(ctx, id)
+	if err != nil {
+		return nil, models.FollowCounts{}, err
+	}
+	follows, err := list()
+	return follows, counts, err
+}
+
+// Counts returns the follow counts of the user with the given id, from the cache if it
+// has them.
+func (f *Follows) Counts(ctx context.Context, id int) (models.FollowCounts, error) {
+	if counts, ok := f.counts.FollowCounts(ctx, id); ok {
+		return counts, nil
+	}
+	counts, err := f.store.FollowCounts(ctx, id)
+	if err == sql.ErrNoRows {
+		return models.FollowCounts{}, ErrNotFound
+	}
+	if err != nil {
+		return models.FollowCounts{}, err
+	}
+	f.counts.SetFollowCounts(ctx, id, counts)
+	return counts, nil
+}
@@ -0,0 +1,170 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand/v2"
+	"strings"
+
+	"go-mysql/internal/events"
+	"go-mysql/internal/logging"
+	"go-mysql/internal/models"
+	"go-mysql/internal/repository"
+)
+
+// generateAttempts is how many times a batch of generated users is made up again when
+// one of its usernames or emails turns out to be taken.
+const generateAttempts = 3
+
+var (
+	fakeFirstNames = []string{
+		"Aarav", "Ada", "Aiko", "Alejandro", "Amara", "Amelia", "Ana", "Andrea", "Arjun", "Astrid",
+		"Beatriz", "Ben", "Camille", "Carlos", "Chen", "Chloe", "Daniel", "David", "Elena", "Emily",
+		"Emma", "Ethan", "Fatima", "Felix", "Freya", "Gabriel", "Hannah", "Hiroshi", "Ines", "Isabella",
+		"Jack", "James", "Javier", "Jonas", "Julia", "Kai", "Laura", "Leon", "Liam", "Lucas",
+		"Lucia", "Maja", "Marco", "Maria", "Mateo", "Mia", "Noah", "Olivia", "Omar", "Priya",
+		"Rafael", "Ravi", "Sakura", "Samuel", "Sara", "Sofia", "Thomas", "Yuki", "Zoe", "Zara",
+	}
+	fakeLastNames = []string{
+		"Andersen", "Bauer", "Becker", "Brown", "Costa", "Davis", "Dubois", "Fernandes", "Fischer", "Garcia",
+		"Gonzalez", "Hansen", "Ito", "Jensen", "Johnson", "Kim", "Kowalski", "Kumar", "Lambert", "Lee",
+		"Lopez", "Martin", "Martinez", "Meyer", "Moreau", "Muller", "Nguyen", "Nielsen", "Novak", "Okafor",
+		"Patel", "Perez", "Rossi", "Santos", "Schmidt", "Schneider", "Sharma", "Silva", "Smith", "Suzuki",
+		"Tanaka", "Taylor", "Thompson", "Wagner", "Walker", "Weber", "Williams", "Wilson", "Wright", "Zhang",
+	}
+	fakeInterests = []string{
+		"hiking", "photography", "jazz", "board games", "cycling", "baking", "open source", "climbing",
+		"gardening", "chess", "travel", "running", "sci-fi novels", "coffee", "film", "yoga",
+	}
+	fakeJobs = []string{
+		"Software engineer", "Designer", "Product manager", "Data analyst", "Teacher", "Nurse",
+		"Photographer", "Student", "Architect", "Writer", "Chef", "Researcher",
+	}
+	fakeStreets = []string{
+		"Main Street", "Oak Avenue", "Park Road", "Station Road", "High Street", "Maple Drive",
+		"Cedar Lane", "Lake View", "Hill Street", "River Road", "Church Street", "Elm Street",
+	}
+	// fakeCities are cities with a postal code and region models.Countries accepts,
+	// the postal code's last digits being made up.
+	fakeCities = []struct {
+		city, region, country, postalCode string
+	}{
+		{"Austin", "TX", "US", "787##"},
+		{"Seattle", "WA", "US", "981##"},
+		{"Brooklyn", "NY", "US", "112##"},
+		{"Denver", "CO", "US", "802##"},
+		{"Toronto", "ON", "CA", "M5V 2T6"},
+		{"Vancouver", "BC", "CA", "V6B 1A1"},
+		{"London", "", "GB", "SW1A 1AA"},
+		{"Manchester", "", "GB", "M1 1AE"},
+		{"Berlin", "", "DE", "101##"},
+		{"Munich", "", "DE", "803##"},
+		{"Paris", "", "FR", "750##"},
+		{"Lyon", "", "FR", "690##"},
+		{"Madrid", "", "ES", "280##"},
+		{"Lisbon", "", "PT", "1100-###"},
+		{"Amsterdam", "", "NL", "1012 AB"},
+		{"Stockholm", "", "SE", "111 ##"},
+		{"Sydney", "NSW", "AU", "2000"},
+		{"Melbourne", "VIC", "AU", "3000"},
+		{"Tokyo", "", "JP", "100-0001"},
+		{"Bengaluru", "", "IN", "5600##"},
+	}
+)
+
+// GenerateUsers creates count users with made-up but realistic profiles, batch at a
+// time, for demos, then warms the cache, and returns how many it created. Generated
+// users have example.com emails and are published like any created user; a failure
+// leaves the batches created before it.
+func (s *UserService) GenerateUsers(ctx context.Context, count, batch int) (int, error) {
+	if count < 1 {
+		return 0, fmt.Errorf("%w: count must be positive", ErrInvalid)
+	}
+	created := 0
+	for created < count {
+		users, err := s.generateBatch(ctx, min(batch, count-created))
+		if err != nil {
+			return created, err
+		}
+		for _, user := range users {
+			s.bus.Publish(ctx, events.UserCreated{User: user})
+		}
+		created += len(users)
+	}
+	s.cache.Warm(ctx)
+	logging.From(ctx).Info("Generated users", "count", created)
+	return created, nil
+}
+
+// generateBatch creates n made-up users, making them up again if one's username or
+// email is taken.
+func (s *UserService) generateBatch(ctx context.Context, n int) ([]models.User, error) {
+	var err error
+	for range generateAttempts {
+		users := make([]models.User, n)
+		for i := range users {
+			users[i] = fakeUser()
+			err := Validate(users[i])
+			if err != nil {
+				return nil, err
+			}
+		}
+		users, err = s.store.CreateUsers(ctx, users)
+		if err == nil {
+			return users, nil
+		}
+		if !errors.Is(err, repository.ErrDuplicate) && !errors.Is(err, repository.ErrDuplicateEmail) {
+			return nil, err
+		}
+	}
+	return nil, err
+}
+
+// fakeUser makes up an active user, with a random number in its username so that
+// users with the same name can be told apart.
+func fakeUser() models.User {
+	first := fakeFirstNames[rand.IntN(len(fakeFirstNames))]
+	last := fakeLastNames[rand.IntN(len(fakeLastNames))]
+	username := fmt.Sprintf("%s.%s%d", strings.ToLower(first), strings.ToLower(last), rand.IntN(100000))
+	city := fakeCities[rand.IntN(len(fakeCities))]
+	// Two different interests
+	interest := rand.IntN(len(fakeInterests))
+	other := (interest + 1 + rand.IntN(len(fakeInterests)-1)) % len(fakeInterests)
+	return normalizeUser(models.User{
+		Username:     username,
+		Email:        username + "@example.com",
+		Status:       models.StatusActive,
+		FirstName:    first,
+		LastName:     last,
+		DisplayName:  first + " " + last,
+		Bio:          fmt.Sprintf("%s. Into %s and %s.", fakeJobs[rand.IntN(len(fakeJobs))], fakeInterests[interest], fakeInterests[other]),
+		Phone:        fakePhone(city.country),
+		AddressLine1: fmt.Sprintf("%d %s", 1+rand.IntN(250), fakeStreets[rand.IntN(len(fakeStreets))]),
+		City:         city.city,
+		Region:       city.region,
+		PostalCode:   fakeDigits(city.postalCode),
+		Country:      city.country,
+	})
+}
+
+// fakePhone makes up a phone number of country, a code of models.Countries, with as
+// few digits as its numbers have. North American numbers are of the 555-01xx range
+// set aside for fiction.
+func fakePhone(country string) string {
+	c := models.Countries[country]
+	if c.CallingCode == "1" {
+		return fakeDigits(fmt.Sprintf("+1%d##55501##", 2+rand.IntN(8)))
+	}
+	return fakeDigits(fmt.Sprintf("+%s%d%s", c.CallingCode, 1+rand.IntN(9), strings.Repeat("#", c.MinDigits-1)))
+}
+
+// fakeDigits replaces every # in pattern with a random digit.
+func fakeDigits(pattern string) string {
+	return strings.Map(func(r rune) rune {
+		if r == '#' {
+			return rune('0' + rand.IntN(10))
+		}
+		return r
+	}, pattern)
+}
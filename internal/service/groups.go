@@ -0,0 +1,218 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"unicode/utf8"
+
+	"go-mysql/internal/events"
+	"go-mysql/internal/logging"
+	"go-mysql/internal/models"
+	"go-mysql/internal/repository"
+)
+
+// GroupStore is where groups and their members are kept, implemented by
+// repository.Repository. Lookups and changes return sql.ErrNoRows for a missing group
+// or user.
+type GroupStore interface {
+	CreateGroup(ctx context.Context, g models.Group, ownerID int) (int, error)
+	Groups(ctx context.Context, offset, limit int) ([]models.Group, int, error)
+	GroupByID(ctx context.Context, id int) (models.Group, error)
+	DeleteGroup(ctx context.Context, id int) (bool, error)
+	GroupMembers(ctx context.Context, groupID int) ([]models.Membership, error)
+	UserGroups(ctx context.Context, userID int) ([]models.Membership, error)
+	SetGroupMember(ctx context.Context, groupID, userID int, role string) (bool, error)
+	RemoveGroupMember(ctx context.Context, groupID, userID int) (bool, error)
+}
+
+var (
+	// ErrGroupNotFound is returned for a group that doesn't exist.
+	ErrGroupNotFound = errors.New("Group not found")
+	// ErrGroupNameTaken is returned when creating a group with a name in use.
+	ErrGroupNameTaken = errors.New("Group name already taken")
+	// ErrLastOwner is returned when removing or demoting the only owner of a group.
+	ErrLastOwner = errors.New("A group needs an owner")
+	// ErrNotMember is returned when removing a user from a group it isn't in.
+	ErrNotMember = errors.New("User is not a member of the group")
+	// ErrInvalidGroup wraps the reason a group was rejected.
+	ErrInvalidGroup = errors.New("Invalid group")
+	// ErrNotGroupOwner is returned when changing a group on behalf of a user who isn't
+	// one of its owners.
+	ErrNotGroupOwner = errors.New("Only the owners of a group can change it")
+)
+
+// Maximum lengths of group fields, the sizes of their columns.
+const (
+	maxGroupNameLength        = 100
+	maxGroupDescriptionLength = 500
+)
+
+// Groups manages groups of users and their members. A group always has an owner: its
+// creator at first, and when its owners are deleted, its oldest member. A group whose
+// last member is deleted is deleted with it.
+type Groups struct {
+	store GroupStore
+	bus   *events.Bus
+}
+
+// NewGroups returns the groups kept in store, publishing the users joining them on bus.
+func NewGroups(store GroupStore, bus *events.Bus) *Groups {
+	return &Groups{store: store, bus: bus}
+}
+
+// Create stores a new group owned by the user ownerID and returns it with its id.
+func (g *Groups) Create(ctx context.Context, group models.Group, ownerID int) (models.Group, error) {
+	group.Name = strings.TrimSpace(group.Name)
+	switch {
+	case group.Name == "":
+		return models.Group{}, fmt.Errorf("%w: name is required", ErrInvalidGroup)
+	case utf8.RuneCountInString(group.Name) > maxGroupNameLength:
+		return models.Group{}, fmt.Errorf("%w: name is longer than %d characters", ErrInvalidGroup, maxGroupNameLength)
+	case utf8.RuneCountInString(group.Description) > maxGroupDescriptionLength:
+		return models.Group{}, fmt.Errorf("%w: description is longer than %d characters", ErrInvalidGroup, maxGroupDescriptionLength)
+	}
+
+	id, err := g.store.CreateGroup(ctx, group, ownerID)
+	switch {
+	case err == repository.ErrDuplicate:
+		return models.Group{}, ErrGroupNameTaken
+	case err == sql.ErrNoRows:
+		return models.Group{}, ErrNotFound
+	case err != nil:
+		return models.Group{}, err
+	}
+	logging.From(ctx).Info("Group created", "group_id", id, "owner_id", ownerID)
+	group, err = g.Get(ctx, id)
+	if err != nil {
+		return models.Group{}, err
+	}
+	g.bus.Publish(ctx, events.GroupJoined{Group: group, UserID: ownerID, Role: models.GroupRoleOwner})
+	return group, nil
+}
+
+// List returns a page of the groups ordered by name, and how many there are in total.
+func (g *Groups) List(ctx context.Context, offset, limit int) ([]models.Group, int, error) {
+	return g.store.Groups(ctx, offset, limit)
+}
+
+// Get returns the group with the given id, or ErrGroupNotFound.
+func (g *Groups) Get(ctx context.Context, id int) (models.Group, error) {
+	group, err := g.store.GroupByID(ctx, id)
+	if err == sql.ErrNoRows {
+		return models.Group{}, ErrGroupNotFound
+	}
+	return group, err
+}
+
+// Delete deletes the group with the given id and its memberships on behalf of actor,
+// who must be one of its owners or an administrator.
+func (g *Groups) Delete(ctx context.Context, actor models.Principal, id int) error {
+	err := g.authorize(ctx, actor, id)
+	if err != nil {
+		return err
+	}
+	found, err := g.store.DeleteGroup(ctx, id)
+	if err != nil {
+		return err
+	}
+	if !found {
+		return ErrGroupNotFound
+	}
+	logging.From(ctx).Info("Group deleted", "group_id", id, "actor_id", actor.UserID)
+	return nil
+}
+
+// Members returns the members of the group with the given id, owners first.
+func (g *Groups) Members(ctx context.Context, id int) ([]models.Membership, error) {
+	_, err := g.Get(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	return g.store.GroupMembers(ctx, id)
+}
+
+// UserGroups returns the groups the user with the given id belongs to.
+func (g *Groups) UserGroups(ctx context.Context, userID int) ([]models.Membership, error) {
+	return g.store.UserGroups(ctx, userID)
+}
+
+// SetMember adds the user with the given id to a group with role, a member if empty,
+// or changes its role if it's a member already, on behalf of actor, who must be one of
+// the group's owners or an administrator.
+func (g *Groups) SetMember(ctx context.Context, actor models.Principal, groupID, userID int, role string) error {
+	if role == "" {
+		role = models.GroupRoleMember
+	}
+	if !slices.Contains(models.GroupRoles, role) {
+		return fmt.Errorf("%w: unknown role %q", ErrInvalidGroup, role)
+	}
+	// The group is looked up first to tell a missing group from a missing user
+	group, err := g.Get(ctx, groupID)
+	if err != nil {
+		return err
+	}
+	err = g.authorize(ctx, actor, groupID)
+	if err != nil {
+		return err
+	}
+	added, err := g.store.SetGroupMember(ctx, groupID, userID, role)
+	switch {
+	case err == sql.ErrNoRows:
+		return ErrNotFound
+	case err == repository.ErrLastOwner:
+		return ErrLastOwner
+	case err != nil:
+		return err
+	}
+	if added {
+		g.bus.Publish(ctx, events.GroupJoined{Group: group, UserID: userID, Role: role})
+	}
+	return nil
+}
+
+// RemoveMember removes the user with the given id from a group on behalf of actor,
+// who must be that user, leaving, one of the group's owners or an administrator. The
+// group's only owner can't be removed.
+func (g *Groups) RemoveMember(ctx context.Context, actor models.Principal, groupID, userID int) error {
+	if actor.UserID != userID {
+		err := g.authorize(ctx, actor, groupID)
+		if err != nil {
+			return err
+		}
+	}
+	removed, err := g.store.RemoveGroupMember(ctx, groupID, userID)
+	switch {
+	case err == sql.ErrNoRows:
+		return ErrGroupNotFound
+	case err == repository.ErrLastOwner:
+		return ErrLastOwner
+	case err != nil:
+		return err
+	case !removed:
+		return ErrNotMember
+	}
+	return nil
+}
+
+// authorize returns ErrNotGroupOwner unless actor is an owner of the group with the
+// given id or an administrator, and ErrGroupNotFound if there's no such group.
+func (g *Groups) authorize(ctx context.Context, actor models.Principal, id int) error {
+	if actor.Role == models.RoleAdmin {
+		return nil
+	}
+	members, err := g.Members(ctx, id)
+	if err != nil {
+		return err
+	}
+	isOwner := slices.ContainsFunc(members, func(m models.Membership) bool {
+		return m.UserID == actor.UserID && m.Role == models.GroupRoleOwner
+	})
+	if !isOwner {
+		return ErrNotGroupOwner
+	}
+	return nil
+}
@@ -0,0 +1,106 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+
+	"go-mysql/internal/models"
+	"go-mysql/internal/repository"
+)
+
+// MaxImportRows is how many users one import takes at most.
+const MaxImportRows = 10000
+
+// Import checks every row, then creates the users of the valid ones unless dryRun,
+// and reports on both. Rows are rejected for failing Validate, for a username or email
+// an earlier row has, or for one another user has or that is reserved, emails being
+// compared by repository.CanonicalEmail and usernames ignoring case. Users are created
+// one at a time: those created before a failure stay, and a row losing a race for its
+// username or email is reported like one that was taken all along.
+func (s *UserService) Import(ctx context.Context, rows []models.ImportRow, dryRun bool) (models.ImportReport, error) {
+	report := models.ImportReport{DryRun: dryRun, Rows: len(rows), Errors: []models.ImportError{}}
+	if len(rows) > MaxImportRows {
+		return models.ImportReport{}, fmt.Errorf("%w: imports take at most %d rows", ErrInvalid, MaxImportRows)
+	}
+	reject := func(row models.ImportRow, code, field, message string) {
+		report.Errors = append(report.Errors, models.ImportError{
+			Line: row.Line, Username: row.User.Username, Field: field, Code: code, Message: message,
+		})
+	}
+
+	usernameLines := make(map[string]int, len(rows))
+	emailLines := make(map[string]int, len(rows))
+	var valid []models.ImportRow
+	for _, row := range rows {
+		row.User = normalizeUser(row.User)
+		err := Validate(row.User)
+		if err != nil {
+			reject(row, models.ImportInvalid, "", err.Error())
+			continue
+		}
+		username, email := strings.ToLower(row.User.Username), repository.CanonicalEmail(row.User.Email)
+		if line, ok := usernameLines[username]; ok {
+			reject(row, models.ImportDuplicate, "username", fmt.Sprintf("Username already on line %d", line))
+			continue
+		}
+		if line, ok := emailLines[email]; ok {
+			reject(row, models.ImportDuplicate, "email", fmt.Sprintf("Email already on line %d", line))
+			continue
+		}
+		err = s.checkReserved(ctx, row.User.Username)
+		if errors.Is(err, ErrUsernameReserved) {
+			reject(row, models.ImportTaken, "username", err.Error())
+			continue
+		}
+		if err != nil {
+			return models.ImportReport{}, err
+		}
+		usernameLines[username], emailLines[email] = row.Line, row.Line
+		valid = append(valid, row)
+	}
+
+	usernames := make([]string, len(valid))
+	emails := make([]string, len(valid))
+	for i, row := range valid {
+		usernames[i], emails[i] = row.User.Username, row.User.Email
+	}
+	takenUsernames, takenEmails, err := s.store.TakenUsernamesAndEmails(ctx, usernames, emails)
+	if err != nil {
+		return models.ImportReport{}, err
+	}
+	checked := valid[:0]
+	for _, row := range valid {
+		switch {
+		case takenUsernames[strings.ToLower(row.User.Username)]:
+			reject(row, models.ImportTaken, "username", ErrUsernameTaken.Error())
+		case takenEmails[repository.CanonicalEmail(row.User.Email)]:
+			reject(row, models.ImportTaken, "email", ErrEmailTaken.Error())
+		default:
+			checked = append(checked, row)
+		}
+	}
+
+	if !dryRun {
+		for _, row := range checked {
+			_, err := s.Create(ctx, row.User)
+			switch {
+			case errors.Is(err, ErrUsernameTaken), errors.Is(err, ErrUsernameReserved):
+				reject(row, models.ImportTaken, "username", err.Error())
+			case errors.Is(err, ErrEmailTaken):
+				reject(row, models.ImportTaken, "email", err.Error())
+			case err != nil:
+				return report, fmt.Errorf("line %d: %w", row.Line, err)
+			default:
+				report.Created++
+			}
+		}
+	}
+
+	slices.SortStableFunc(report.Errors, func(a, b models.ImportError) int { return a.Line - b.Line })
+	report.Invalid = len(report.Errors)
+	report.Valid = report.Rows - report.Invalid
+	return report, nil
+}
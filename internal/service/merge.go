@@ -0,0 +1,41 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"go-mysql/internal/events"
+	"go-mysql/internal/logging"
+	"go-mysql/internal/models"
+	"go-mysql/internal/repository"
+)
+
+// ErrAlreadyMerged is returned when merging a user that was merged already, or into
+// one.
+var ErrAlreadyMerged = errors.New("User already merged")
+
+// Merge merges the user with the given id, a duplicate found with Duplicates, into the
+// user into, which takes over its groups, follows, tags and username history; the
+// duplicate is kept, merged, for the record. See repository.Repository.MergeUsers.
+func (a *Admin) Merge(ctx context.Context, actor, id, into int) (models.UserMerge, error) {
+	if id == into {
+		return models.UserMerge{}, fmt.Errorf("%w: users can't be merged into themselves", ErrInvalid)
+	}
+	if actor == id {
+		return models.UserMerge{}, fmt.Errorf("%w: administrators can't merge themselves into another user", ErrInvalid)
+	}
+	merge, user, related, err := a.store.MergeUsers(ctx, id, into)
+	if errors.Is(err, repository.ErrMerged) {
+		return models.UserMerge{}, ErrAlreadyMerged
+	}
+	err = translateNotFound(err)
+	if err != nil {
+		return models.UserMerge{}, err
+	}
+	logging.From(ctx).Info("User merged", "audit", true, "actor_id", actor, "user_id", id, "into_id", into,
+		"groups", merge.Groups, "follows", merge.Follows, "tags", merge.Tags)
+	a.bus.Publish(ctx, events.UserUpdated{User: user})
+	a.bus.Publish(ctx, events.UserMerged{Merge: merge, Related: related})
+	return merge, nil
+}
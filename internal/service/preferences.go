@@ -0,0 +1,132 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"slices"
+	"time"
+)
+
+// Preferences are a user's settings, keyed by namespaced names like "ui.theme".
+// Values are any JSON value; the keys in preferenceSchema must have a valid one.
+type Preferences map[string]any
+
+// preferenceKeyPattern is what preference names look like: a namespace, then one or more
+// dot-separated names.
+var preferenceKeyPattern = regexp.MustCompile(`^[a-z][a-z0-9_]*(\.[a-z][a-z0-9_]*)+$`)
+
+// Limits on what a user can store, so preferences stay settings rather than storage.
+const (
+	maxPreferences         = 100
+	maxPreferenceKeyLength = 100
+	maxPreferencesSize     = 16 << 10
+)
+
+// preference describes a known preference: its default and the values it accepts.
+type preference struct {
+	def   any
+	valid func(v any) bool
+}
+
+// oneOf accepts the given strings.
+func oneOf(values ...string) func(any) bool {
+	return func(v any) bool {
+		s, ok := v.(string)
+		return ok && slices.Contains(values, s)
+	}
+}
+
+func isBool(v any) bool {
+	_, ok := v.(bool)
+	return ok
+}
+
+var languagePattern = regexp.MustCompile(`^[a-z]{2}(-[A-Z]{2})?$`)
+
+// preferenceSchema lists the preferences the service knows, with their defaults, which
+// are merged into every user's preferences.
+var preferenceSchema = map[string]preference{
+	"ui.theme": {def: "system", valid: oneOf("light", "dark", "system")},
+	"ui.language": {def: "en", valid: func(v any) bool {
+		s, ok := v.(string)
+		return ok && languagePattern.MatchString(s)
+	}},
+	"ui.timezone": {def: "UTC", valid: func(v any) bool {
+		s, ok := v.(string)
+		if !ok || s == "" || s == "Local" {
+			return false
+		}
+		_, err := time.LoadLocation(s)
+		return err == nil
+	}},
+	"notifications.email":   {def: true, valid: isBool},
+	"notifications.digest":  {def: "weekly", valid: oneOf("never", "daily", "weekly")},
+	"privacy.show_activity": {def: true, valid: isBool},
+}
+
+// Preferences returns the preferences of the user with the given id, with the
+// defaults of the known preferences it didn't set.
+func (s *UserService) Preferences(ctx context.Context, id int) (Preferences, error) {
+	data, err := s.store.UserPreferences(ctx, id)
+	if err == sql.ErrNoRows {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	prefs := make(Preferences, len(preferenceSchema))
+	if data != nil {
+		err = json.Unmarshal(data, &prefs)
+		if err != nil {
+			return nil, fmt.Errorf("decoding preferences of user %d: %w", id, err)
+		}
+	}
+	for key, p := range preferenceSchema {
+		if _, ok := prefs[key]; !ok {
+			prefs[key] = p.def
+		}
+	}
+	return prefs, nil
+}
+
+// SetPreferences replaces the preferences of the user with the given id and returns
+// them, with defaults merged in. A null value, or leaving a key out, resets it to its
+// default if it has one.
+func (s *UserService) SetPreferences(ctx context.Context, id int, prefs Preferences) (Preferences, error) {
+	if len(prefs) > maxPreferences {
+		return nil, fmt.Errorf("%w: more than %d preferences", ErrInvalid, maxPreferences)
+	}
+	stored := make(Preferences, len(prefs))
+	for key, value := range prefs {
+		if len(key) > maxPreferenceKeyLength || !preferenceKeyPattern.MatchString(key) {
+			return nil, fmt.Errorf("%w: preference %q must be namespaced, like ui.theme", ErrInvalid, key)
+		}
+		if value == nil {
+			continue
+		}
+		if p, known := preferenceSchema[key]; known && !p.valid(value) {
+			return nil, fmt.Errorf("%w: invalid value for preference %s", ErrInvalid, key)
+		}
+		stored[key] = value
+	}
+	data, err := json.Marshal(stored)
+	if err != nil {
+		return nil, err
+	}
+	if len(data) > maxPreferencesSize {
+		return nil, fmt.Errorf("%w: preferences are larger than %d bytes", ErrInvalid, maxPreferencesSize)
+	}
+
+	err = s.store.SetUserPreferences(ctx, id, data)
+	if err == sql.ErrNoRows {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return s.Preferences(ctx, id)
+}
@@ -0,0 +1,100 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+
+	"go-mysql/internal/logging"
+	"go-mysql/internal/models"
+	"go-mysql/pkg/saga"
+)
+
+// APIKeyStore keeps the hashes of issued API keys, implemented by
+// repository.Repository.
+type APIKeyStore interface {
+	CreateAPIKey(ctx context.Context, userID int, prefix, keyHash string) (int64, error)
+	DeleteAPIKey(ctx context.Context, id int64) (bool, error)
+}
+
+// Mailer sends the emails of the registration flow, implemented by mailer.Mailer.
+type Mailer interface {
+	SendAPIKeyCreated(ctx context.Context, user models.User, keyPrefix string) error
+}
+
+// apiKeyPrefixLength is how much of a key is kept in the clear to identify it.
+const apiKeyPrefixLength = 11
+
+// NewAPIKey returns a random API key, its prefix and the hash to store.
+func NewAPIKey() (key, prefix, hash string, err error) {
+	buf := make([]byte, 32)
+	_, err = rand.Read(buf)
+	if err != nil {
+		return "", "", "", err
+	}
+	key = "uk_" + hex.EncodeToString(buf)
+	return key, key[:apiKeyPrefixLength], HashAPIKey(key), nil
+}
+
+// HashAPIKey returns the hash an API key is stored and looked up by.
+func HashAPIKey(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:])
+}
+
+// Registration signs up new users: it creates the user, issues them an API key and
+// emails them about it. A failed step undoes the ones before it, so a registration
+// that fails leaves no user behind to retry against.
+type Registration struct {
+	users *UserService
+	keys  APIKeyStore
+	mail  Mailer
+}
+
+func NewRegistration(users *UserService, keys APIKeyStore, mail Mailer) *Registration {
+	return &Registration{users: users, keys: keys, mail: mail}
+}
+
+// Register creates user and returns it with its API key, which isn't stored and can't
+// be shown again. Errors from the user service, such as ErrInvalid, can be told apart
+// with errors.Is.
+func (r *Registration) Register(ctx context.Context, user models.User) (models.User, string, error) {
+	var key, prefix string
+	var keyID int64
+
+	err := saga.New("register user", logging.From(ctx)).
+		Step("create user", func(ctx context.Context) error {
+			var err error
+			user, err = r.users.Create(ctx, user)
+			return err
+		}, func(ctx context.Context) error {
+			err := r.users.Delete(ctx, user.Username)
+			if errors.Is(err, ErrNotFound) {
+				return nil
+			}
+			return err
+		}).
+		Step("issue API key", func(ctx context.Context) error {
+			var hash string
+			var err error
+			key, prefix, hash, err = NewAPIKey()
+			if err != nil {
+				return err
+			}
+			keyID, err = r.keys.CreateAPIKey(ctx, user.ID, prefix, hash)
+			return err
+		}, func(ctx context.Context) error {
+			_, err := r.keys.DeleteAPIKey(ctx, keyID)
+			return err
+		}).
+		Step("send API key email", func(ctx context.Context) error {
+			return r.mail.SendAPIKeyCreated(ctx, user, prefix)
+		}, nil).
+		Run(ctx)
+	if err != nil {
+		return models.User{}, "", err
+	}
+	return user, key, nil
+}
@@ -0,0 +1,217 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+	"unicode"
+
+	"go-mysql/internal/logging"
+	"go-mysql/internal/models"
+)
+
+// ReservedStore keeps the usernames administrators reserve for their tenant,
+// implemented by repository.Repository.
+type ReservedStore interface {
+	ReservedUsernames(ctx context.Context) ([]models.ReservedUsername, error)
+	AddReservedUsername(ctx context.Context, name models.ReservedUsername) (bool, error)
+	DeleteReservedUsername(ctx context.Context, name models.ReservedUsername) (bool, error)
+}
+
+// ReservedCache keeps the reserved usernames of each tenant between reads, implemented
+// by cache.UserCache.
+type ReservedCache interface {
+	ReservedUsernames(ctx context.Context) (names []models.ReservedUsername, ok bool)
+	SetReservedUsernames(ctx context.Context, names []models.ReservedUsername, ttl time.Duration)
+	ForgetReservedUsernames(ctx context.Context)
+}
+
+var (
+	// ErrUsernameReserved is returned for a new username that is reserved.
+	ErrUsernameReserved = errors.New("Username is reserved")
+	// ErrNotReserved is returned for releasing a username that isn't reserved.
+	ErrNotReserved = errors.New("Username not reserved")
+)
+
+// ReservedUsernames are the usernames no new or renamed user may have: those reserved
+// by configuration, for every tenant, and those administrators reserve for theirs,
+// which are read through a cache as every signup checks them.
+type ReservedUsernames struct {
+	store   ReservedStore
+	cache   ReservedCache
+	builtin []models.ReservedUsername
+	ttl     time.Duration
+}
+
+// NewReservedUsernames returns the reserved usernames kept in store and cached in
+// cache for ttl, on top of names, reserved exactly, and patterns, which usernames may
+// not contain.
+func NewReservedUsernames(store ReservedStore, cache ReservedCache, names, patterns []string, ttl time.Duration) *ReservedUsernames {
+	r := &ReservedUsernames{store: store, cache: cache, ttl: ttl}
+	for _, name := range names {
+		r.builtin = append(r.builtin, normalizeReserved(models.ReservedUsername{Name: name, Match: models.ReservedExact}))
+	}
+	for _, pattern := range patterns {
+		r.builtin = append(r.builtin, normalizeReserved(models.ReservedUsername{Name: pattern, Match: models.ReservedContains}))
+	}
+	r.builtin = slices.DeleteFunc(r.builtin, func(name models.ReservedUsername) bool { return name.Name == "" })
+	for i := range r.builtin {
+		r.builtin[i].Builtin = true
+	}
+	return r
+}
+
+// List returns the usernames reserved by configuration, then those of the tenant in
+// ctx, ordered by name.
+func (r *ReservedUsernames) List(ctx context.Context) ([]models.ReservedUsername, error) {
+	names, err := r.tenantNames(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return append(slices.Clip(r.builtin), names...), nil
+}
+
+// Add reserves name for the tenant in ctx, an exact match unless name.Match says
+// otherwise, and returns it as stored, reporting whether it wasn't reserved already.
+func (r *ReservedUsernames) Add(ctx context.Context, actor int, name models.ReservedUsername) (models.ReservedUsername, bool, error) {
+	name = normalizeReserved(name)
+	err := validateReserved(name)
+	if err != nil {
+		return models.ReservedUsername{}, false, err
+	}
+	if slices.Contains(r.builtin, withBuiltin(name)) {
+		return withBuiltin(name), false, nil
+	}
+	added, err := r.store.AddReservedUsername(ctx, name)
+	if err != nil {
+		return models.ReservedUsername{}, false, err
+	}
+	if added {
+		r.cache.ForgetReservedUsernames(ctx)
+		logging.From(ctx).Info("Username reserved", "audit", true, "actor_id", actor, "name", name.Name, "match", name.Match)
+	}
+	return name, added, nil
+}
+
+// Remove releases name for the tenant in ctx. It returns ErrNotReserved if the tenant
+// hasn't reserved it, and ErrInvalid for the usernames reserved by configuration.
+func (r *ReservedUsernames) Remove(ctx context.Context, actor int, name models.ReservedUsername) error {
+	name = normalizeReserved(name)
+	err := validateReserved(name)
+	if err != nil {
+		return err
+	}
+	if slices.Contains(r.builtin, withBuiltin(name)) {
+		return fmt.Errorf("%w: %q is reserved by configuration", ErrInvalid, name.Name)
+	}
+	deleted, err := r.store.DeleteReservedUsername(ctx, name)
+	if err != nil {
+		return err
+	}
+	if !deleted {
+		return ErrNotReserved
+	}
+	r.cache.ForgetReservedUsernames(ctx)
+	logging.From(ctx).Info("Username released", "audit", true, "actor_id", actor, "name", name.Name, "match", name.Match)
+	return nil
+}
+
+// Check returns ErrUsernameReserved if username is reserved for the tenant in ctx.
+func (r *ReservedUsernames) Check(ctx context.Context, username string) error {
+	if slices.ContainsFunc(r.builtin, func(name models.ReservedUsername) bool { return reservedMatch(name, username) }) {
+		return ErrUsernameReserved
+	}
+	names, err := r.tenantNames(ctx)
+	if err != nil {
+		return err
+	}
+	if slices.ContainsFunc(names, func(name models.ReservedUsername) bool { return reservedMatch(name, username) }) {
+		return ErrUsernameReserved
+	}
+	return nil
+}
+
+// tenantNames returns the usernames the tenant in ctx reserved, from the cache if it
+// has them.
+func (r *ReservedUsernames) tenantNames(ctx context.Context) ([]models.ReservedUsername, error) {
+	if names, ok := r.cache.ReservedUsernames(ctx); ok {
+		return names, nil
+	}
+	names, err := r.store.ReservedUsernames(ctx)
+	if err != nil {
+		return nil, err
+	}
+	r.cache.SetReservedUsernames(ctx, names, r.ttl)
+	return names, nil
+}
+
+// reservedMatch reports whether name reserves username.
+func reservedMatch(name models.ReservedUsername, username string) bool {
+	if name.Match == models.ReservedContains {
+		return strings.Contains(foldAlphanumeric(username), name.Name)
+	}
+	return strings.EqualFold(name.Name, username)
+}
+
+// normalizeReserved returns name the way it's stored: exact names trimmed and
+// lowercased, and those usernames may not contain folded by foldAlphanumeric. Names
+// match exactly unless told otherwise.
+func normalizeReserved(name models.ReservedUsername) models.ReservedUsername {
+	name.Builtin = false
+	if name.Match == "" {
+		name.Match = models.ReservedExact
+	}
+	if name.Match == models.ReservedContains {
+		name.Name = foldAlphanumeric(name.Name)
+	} else {
+		name.Name = strings.ToLower(strings.TrimSpace(name.Name))
+	}
+	return name
+}
+
+func validateReserved(name models.ReservedUsername) error {
+	if !slices.Contains(models.ReservedMatches, name.Match) {
+		return fmt.Errorf("%w: match must be one of %s", ErrInvalid, strings.Join(models.ReservedMatches, ", "))
+	}
+	if name.Name == "" {
+		return fmt.Errorf("%w: missing name", ErrInvalid)
+	}
+	if len(name.Name) > maxFieldLength {
+		return fmt.Errorf("%w: name is longer than %d characters", ErrInvalid, maxFieldLength)
+	}
+	return nil
+}
+
+func withBuiltin(name models.ReservedUsername) models.ReservedUsername {
+	name.Builtin = true
+	return name
+}
+
+// foldAlphanumeric returns s lowercased, without anything but letters and digits, so
+// that "J.Doe" and "j_doe" come out the same.
+func foldAlphanumeric(s string) string {
+	return strings.Map(func(r rune) rune {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			return unicode.ToLower(r)
+		}
+		return -1
+	}, s)
+}
+
+// ReserveUsernames makes Create, Upsert, Import and Rename refuse the usernames
+// reserved, with ErrUsernameReserved. Existing users keep theirs. It's off until
+// called.
+func (s *UserService) ReserveUsernames(reserved *ReservedUsernames) {
+	s.reserved = reserved
+}
+
+// checkReserved returns ErrUsernameReserved if username is reserved.
+func (s *UserService) checkReserved(ctx context.Context, username string) error {
+	if s.reserved == nil {
+		return nil
+	}
+	return s.reserved.Check(ctx, username)
+}
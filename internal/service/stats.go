@@ -0,0 +1,149 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"go-mysql/internal/events"
+	"go-mysql/internal/models"
+	"go-mysql/internal/tenant"
+)
+
+// StatsStore counts users, implemented by repository.Repository.
+type StatsStore interface {
+	Tenants(ctx context.Context) ([]models.Tenant, error)
+	CountUsers(ctx context.Context, activeSince time.Time) (total, active int, err error)
+	SignupsPerDay(ctx context.Context, since time.Time) (map[string]int, error)
+	MaxUserID(ctx context.Context) (int, error)
+}
+
+// StatsCache keeps the statistics between refreshes, and the daily signup counters,
+// implemented by cache.UserCache.
+type StatsCache interface {
+	UserStats(ctx context.Context) (stats models.UserStats, ok bool)
+	SetUserStats(ctx context.Context, stats models.UserStats, ttl time.Duration)
+	CountSignup(ctx context.Context, at time.Time, retention time.Duration)
+	Signups(ctx context.Context, days []string) (counts map[string]int, ok bool)
+}
+
+// ErrSignupsUnavailable is returned for the signup counters while Redis can't be
+// reached.
+var ErrSignupsUnavailable = errors.New("Signup counters are unavailable")
+
+// statsDays is how many days back signups are counted, and users count as active.
+const statsDays = 30
+
+// Stats sums up the users of each tenant. Counting them takes a scan of the tenant's
+// users, so the results are cached and refreshed in the background by Refresh. Signups
+// are also counted as they happen, per day, see Subscribe.
+type Stats struct {
+	store StatsStore
+	cache StatsCache
+	ttl   time.Duration
+	// signupsRetention is how long the daily signup counters are kept.
+	signupsRetention time.Duration
+}
+
+// NewStats returns the statistics counted in store, cached in cache for ttl, with the
+// daily signup counters kept for signupsRetention.
+func NewStats(store StatsStore, cache StatsCache, ttl, signupsRetention time.Duration) *Stats {
+	return &Stats{store: store, cache: cache, ttl: ttl, signupsRetention: signupsRetention}
+}
+
+// Subscribe counts the users created on bus in the daily signup counters. Signups from
+// before the counters were kept aren't counted; Users counts those from MySQL.
+func (s *Stats) Subscribe(bus *events.Bus) {
+	events.Subscribe(bus, func(ctx context.Context, e events.UserCreated) {
+		s.cache.CountSignup(ctx, time.Now(), s.signupsRetention)
+	})
+}
+
+// MaxUserID returns the highest id of a user of any tenant.
+func (s *Stats) MaxUserID(ctx context.Context) (int, error) {
+	return s.store.MaxUserID(ctx)
+}
+
+// MaxSignupDays is how many days back Signups can count, given the counters' retention.
+func (s *Stats) MaxSignupDays() int {
+	return int(s.signupsRetention / (24 * time.Hour))
+}
+
+// Signups returns the signups of the tenant in ctx on each of the last days days,
+// today included, read from the daily counters alone. It returns
+// ErrSignupsUnavailable if they can't be read.
+func (s *Stats) Signups(ctx context.Context, days int) (models.SignupStats, error) {
+	if days < 1 || days > s.MaxSignupDays() {
+		return models.SignupStats{}, fmt.Errorf("%w: days must be between 1 and %d", ErrInvalid, s.MaxSignupDays())
+	}
+	first := time.Now().UTC().AddDate(0, 0, 1-days)
+	dates := make([]string, days)
+	for i := range dates {
+		dates[i] = first.AddDate(0, 0, i).Format(time.DateOnly)
+	}
+	counts, ok := s.cache.Signups(ctx, dates)
+	if !ok {
+		return models.SignupStats{}, ErrSignupsUnavailable
+	}
+
+	stats := models.SignupStats{Days: days, Signups: make([]models.DailyCount, days)}
+	for i, date := range dates {
+		stats.Signups[i] = models.DailyCount{Date: date, Count: counts[date]}
+		stats.Total += counts[date]
+	}
+	return stats, nil
+}
+
+// Users returns the user statistics of the tenant in ctx, counting them if they aren't
+// cached.
+func (s *Stats) Users(ctx context.Context) (models.UserStats, error) {
+	if stats, ok := s.cache.UserStats(ctx); ok {
+		return stats, nil
+	}
+	return s.refresh(ctx)
+}
+
+// Refresh counts the user statistics of every tenant again and caches them, going on
+// past failures, whose errors it returns joined.
+func (s *Stats) Refresh(ctx context.Context) error {
+	tenants, err := s.store.Tenants(ctx)
+	if err != nil {
+		return err
+	}
+	var errs []error
+	for _, t := range tenants {
+		_, err := s.refresh(tenant.WithID(ctx, t.ID))
+		if err != nil {
+			errs = append(errs, fmt.Errorf("tenant %s: %w", t.Slug, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func (s *Stats) refresh(ctx context.Context) (models.UserStats, error) {
+	now := time.Now().UTC()
+	first := now.Truncate(24*time.Hour).AddDate(0, 0, 1-statsDays)
+	total, active, err := s.store.CountUsers(ctx, now.AddDate(0, 0, -statsDays))
+	if err != nil {
+		return models.UserStats{}, err
+	}
+	signups, err := s.store.SignupsPerDay(ctx, first)
+	if err != nil {
+		return models.UserStats{}, err
+	}
+
+	stats := models.UserStats{
+		Total:       total,
+		Active:      active,
+		ActiveDays:  statsDays,
+		Signups:     make([]models.DailyCount, statsDays),
+		GeneratedAt: now,
+	}
+	for i := range stats.Signups {
+		day := first.AddDate(0, 0, i).Format(time.DateOnly)
+		stats.Signups[i] = models.DailyCount{Date: day, Count: signups[day]}
+	}
+	s.cache.SetUserStats(ctx, stats, s.ttl)
+	return stats, nil
+}
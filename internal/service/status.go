@@ -0,0 +1,48 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+
+	"go-mysql/internal/events"
+	"go-mysql/internal/models"
+)
+
+// ErrBanned is returned when deactivating or reactivating a banned user, which only
+// administrators can unban.
+var ErrBanned = errors.New("User is banned")
+
+// Deactivate deactivates the user with the given id and returns it. Its data is kept,
+// but it can't authenticate and is left out of listings until reactivated.
+// Deactivating a deactivated user does nothing.
+func (s *UserService) Deactivate(ctx context.Context, id int) (models.User, error) {
+	return s.setStatus(ctx, id, models.StatusActive, models.StatusDeactivated)
+}
+
+// Reactivate makes the deactivated user with the given id active again and returns it.
+// Reactivating an active user does nothing.
+func (s *UserService) Reactivate(ctx context.Context, id int) (models.User, error) {
+	return s.setStatus(ctx, id, models.StatusDeactivated, models.StatusActive)
+}
+
+func (s *UserService) setStatus(ctx context.Context, id int, from, status string) (models.User, error) {
+	user, err := s.store.SetUserStatus(ctx, id, from, status)
+	if err == sql.ErrNoRows {
+		return models.User{}, ErrNotFound
+	}
+	if err != nil {
+		return models.User{}, err
+	}
+	switch user.Status {
+	case status:
+	case models.StatusBanned:
+		return models.User{}, ErrBanned
+	default:
+		return models.User{}, ErrInvalid
+	}
+	// The whole user is published, as listings depend on the status
+	s.bus.Publish(ctx, events.UserUpdated{User: user})
+	s.withAvatarURLs(ctx, &user)
+	return user, nil
+}
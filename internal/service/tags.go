@@ -0,0 +1,82 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"go-mysql/internal/models"
+)
+
+// tagPattern is what tag names look like once lowercased, such as "beta" or
+// "early-adopter".
+var tagPattern = regexp.MustCompile(`^[a-z0-9][a-z0-9_-]{0,31}$`)
+
+// ErrNotTagged is returned when taking a tag from a user that doesn't have it.
+var ErrNotTagged = errors.New("User doesn't have this tag")
+
+// normalizeTag returns the stored form of the tag called name, or ErrInvalid.
+func normalizeTag(name string) (string, error) {
+	name = strings.ToLower(strings.TrimSpace(name))
+	if !tagPattern.MatchString(name) {
+		return "", fmt.Errorf("%w: tags are 1 to 32 letters, digits, dashes or underscores", ErrInvalid)
+	}
+	return name, nil
+}
+
+// Tag gives the user with the given id the tag called name, ignoring case. Tagging a
+// user twice does nothing.
+func (s *UserService) Tag(ctx context.Context, id int, name string) error {
+	name, err := normalizeTag(name)
+	if err != nil {
+		return err
+	}
+	_, err = s.store.TagUser(ctx, id, name)
+	return translateNotFound(err)
+}
+
+// Untag takes the tag called name from the user with the given id, or returns
+// ErrNotTagged if it doesn't have it.
+func (s *UserService) Untag(ctx context.Context, id int, name string) error {
+	name, err := normalizeTag(name)
+	if err != nil {
+		return err
+	}
+	removed, err := s.store.UntagUser(ctx, id, name)
+	if err != nil {
+		return translateNotFound(err)
+	}
+	if !removed {
+		return ErrNotTagged
+	}
+	return nil
+}
+
+// Tags returns the tags of the user with the given id, in name order.
+func (s *UserService) Tags(ctx context.Context, id int) ([]string, error) {
+	tags, err := s.store.UserTags(ctx, id)
+	return tags, translateNotFound(err)
+}
+
+// TagCounts returns the tags listed users have, with how many have each, most used
+// first.
+func (s *UserService) TagCounts(ctx context.Context) ([]models.TagCount, error) {
+	return s.store.TagCounts(ctx)
+}
+
+// ListByTag returns a page of the listed users with the tag called name, in id order,
+// and how many there are in total. Unlike other listings it's read from the database.
+func (s *UserService) ListByTag(ctx context.Context, name string, offset, limit int) ([]models.User, int, error) {
+	name, err := normalizeTag(name)
+	if err != nil {
+		return nil, 0, err
+	}
+	users, total, err := s.store.UsersByTag(ctx, name, offset, limit)
+	if err != nil {
+		return nil, 0, err
+	}
+	s.withAvatarURLs(ctx, pointers(users)...)
+	return users, total, nil
+}
@@ -0,0 +1,157 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"regexp"
+	"sync"
+	"time"
+
+	"go-mysql/internal/events"
+	"go-mysql/internal/models"
+	"go-mysql/internal/repository"
+	"go-mysql/internal/tenant"
+)
+
+// TenantStore is where tenants are kept, implemented by repository.Repository.
+// Lookups return sql.ErrNoRows for missing tenants.
+type TenantStore interface {
+	Tenants(ctx context.Context) ([]models.Tenant, error)
+	TenantBySlug(ctx context.Context, slug string) (models.Tenant, error)
+	TenantByAPIKey(ctx context.Context, keyHash string) (models.Tenant, error)
+	CreateTenant(ctx context.Context, t models.Tenant) (int, error)
+	DeleteTenant(ctx context.Context, id int) (bool, error)
+}
+
+var (
+	// ErrTenantNotFound is returned for a tenant, or an API key, that doesn't exist.
+	ErrTenantNotFound = errors.New("Tenant not found")
+	// ErrSlugTaken is returned when creating a tenant with a slug in use.
+	ErrSlugTaken = errors.New("Tenant slug already taken")
+	// ErrTenantInUse is returned when deleting a tenant that still has users.
+	ErrTenantInUse = errors.New("Tenant still has users")
+	// ErrInvalidTenant wraps the reason a tenant was rejected.
+	ErrInvalidTenant = errors.New("Invalid tenant")
+)
+
+// slugPattern is what a slug must look like to work as a subdomain.
+var slugPattern = regexp.MustCompile(`^[a-z0-9]([a-z0-9-]{0,48}[a-z0-9])?$`)
+
+// Tenants resolves requests to tenants and manages them. Resolved tenants are
+// remembered for a while, so most requests don't query MySQL to find theirs; a
+// deleted tenant can keep resolving on other instances until then.
+type Tenants struct {
+	store TenantStore
+	bus   *events.Bus
+	ttl   time.Duration
+
+	mu       sync.Mutex
+	resolved map[string]resolvedTenant
+}
+
+type resolvedTenant struct {
+	tenant    models.Tenant
+	expiresAt time.Time
+}
+
+// NewTenants returns tenants kept in store, remembering each resolved one for ttl and
+// publishing their creation and deletion on bus.
+func NewTenants(store TenantStore, bus *events.Bus, ttl time.Duration) *Tenants {
+	return &Tenants{store: store, bus: bus, ttl: ttl, resolved: make(map[string]resolvedTenant)}
+}
+
+// BySlug returns the tenant called slug.
+func (t *Tenants) BySlug(ctx context.Context, slug string) (models.Tenant, error) {
+	return t.resolve(ctx, "slug:"+slug, func() (models.Tenant, error) {
+		return t.store.TenantBySlug(ctx, slug)
+	})
+}
+
+// ByAPIKey returns the tenant of the user an API key was issued to.
+func (t *Tenants) ByAPIKey(ctx context.Context, key string) (models.Tenant, error) {
+	hash := HashAPIKey(key)
+	return t.resolve(ctx, "key:"+hash, func() (models.Tenant, error) {
+		return t.store.TenantByAPIKey(ctx, hash)
+	})
+}
+
+func (t *Tenants) resolve(ctx context.Context, cacheKey string, load func() (models.Tenant, error)) (models.Tenant, error) {
+	t.mu.Lock()
+	r, ok := t.resolved[cacheKey]
+	t.mu.Unlock()
+	if ok && time.Now().Before(r.expiresAt) {
+		return r.tenant, nil
+	}
+
+	found, err := load()
+	if err == sql.ErrNoRows {
+		return models.Tenant{}, ErrTenantNotFound
+	}
+	if err != nil {
+		return models.Tenant{}, err
+	}
+	t.mu.Lock()
+	t.resolved[cacheKey] = resolvedTenant{tenant: found, expiresAt: time.Now().Add(t.ttl)}
+	t.mu.Unlock()
+	return found, nil
+}
+
+// forget drops every remembered tenant, after one was deleted.
+func (t *Tenants) forget() {
+	t.mu.Lock()
+	t.resolved = make(map[string]resolvedTenant)
+	t.mu.Unlock()
+}
+
+// List returns every tenant.
+func (t *Tenants) List(ctx context.Context) ([]models.Tenant, error) {
+	return t.store.Tenants(ctx)
+}
+
+// Create stores a new tenant and returns it with its id.
+func (t *Tenants) Create(ctx context.Context, tn models.Tenant) (models.Tenant, error) {
+	if !slugPattern.MatchString(tn.Slug) {
+		return models.Tenant{}, fmt.Errorf("%w: slug must be lowercase letters, digits and dashes", ErrInvalidTenant)
+	}
+	if tn.Name == "" {
+		tn.Name = tn.Slug
+	}
+	if len(tn.Name) > 100 {
+		return models.Tenant{}, fmt.Errorf("%w: name is longer than 100 characters", ErrInvalidTenant)
+	}
+
+	var err error
+	tn.ID, err = t.store.CreateTenant(ctx, tn)
+	if err == repository.ErrDuplicate {
+		return models.Tenant{}, ErrSlugTaken
+	}
+	if err != nil {
+		return models.Tenant{}, err
+	}
+	tn.CreatedAt = time.Now().UTC()
+	t.bus.Publish(ctx, events.TenantCreated{Tenant: tn})
+	return tn, nil
+}
+
+// Delete deletes a tenant and its webhooks. Its users must be deleted first; the
+// default tenant can't be deleted.
+func (t *Tenants) Delete(ctx context.Context, id int) error {
+	if id == tenant.DefaultID {
+		return fmt.Errorf("%w: the default tenant can't be deleted", ErrInvalidTenant)
+	}
+	found, err := t.store.DeleteTenant(ctx, id)
+	if err == repository.ErrReferenced {
+		return ErrTenantInUse
+	}
+	if err != nil {
+		return err
+	}
+	if !found {
+		return ErrTenantNotFound
+	}
+	t.forget()
+	t.bus.Publish(ctx, events.TenantDeleted{ID: id})
+	return nil
+}
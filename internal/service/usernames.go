@@ -0,0 +1,83 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"go-mysql/internal/events"
+	"go-mysql/internal/models"
+	"go-mysql/internal/repository"
+)
+
+// RedirectFormerUsernames makes ByUsername find users by the usernames they had until
+// grace ago, unless another user has taken them since. It's off until called.
+func (s *UserService) RedirectFormerUsernames(grace time.Duration) {
+	s.renameGrace = grace
+}
+
+// Rename changes the username of the user with the given id, keeping the old one in
+// its username history, and returns the user. It returns ErrUsernameTaken if another
+// user has the username, or ErrUsernameReserved if it's reserved.
+func (s *UserService) Rename(ctx context.Context, id int, username string) (models.User, error) {
+	err := validateUsername(username)
+	if err != nil {
+		return models.User{}, err
+	}
+	err = s.checkReserved(ctx, username)
+	if err != nil {
+		return models.User{}, err
+	}
+
+	previous, err := s.store.RenameUser(ctx, id, username)
+	if err == sql.ErrNoRows {
+		return models.User{}, ErrNotFound
+	}
+	if err == repository.ErrDuplicate {
+		return models.User{}, ErrUsernameTaken
+	}
+	if err != nil {
+		return models.User{}, err
+	}
+	if previous != username {
+		s.bus.Publish(ctx, events.UserUpdated{
+			User:             models.User{ID: id, Username: username},
+			Fields:           []string{"username"},
+			PreviousUsername: previous,
+		})
+	}
+	return s.Get(ctx, id)
+}
+
+// UsernameHistory returns the username changes of the user with the given id, latest
+// first.
+func (s *UserService) UsernameHistory(ctx context.Context, id int) ([]models.UsernameChange, error) {
+	_, err := s.get(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	changes, err := s.store.UsernameHistory(ctx, id)
+	if changes == nil && err == nil {
+		changes = []models.UsernameChange{}
+	}
+	return changes, err
+}
+
+// ByUsername returns the user called username. If no user is, but one was until
+// recently, see RedirectFormerUsernames, it returns that user with renamed true, so
+// the caller can point the client to its current username.
+func (s *UserService) ByUsername(ctx context.Context, username string) (user models.User, renamed bool, err error) {
+	id, err := s.store.UserIDByUsername(ctx, username)
+	if err == sql.ErrNoRows && s.renameGrace > 0 {
+		renamed = true
+		id, err = s.store.UserIDByFormerUsername(ctx, username, time.Now().Add(-s.renameGrace))
+	}
+	if err == sql.ErrNoRows {
+		return models.User{}, false, ErrNotFound
+	}
+	if err != nil {
+		return models.User{}, false, err
+	}
+	user, err = s.Get(ctx, id)
+	return user, renamed, err
+}
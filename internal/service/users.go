@@ -0,0 +1,594 @@
+// Package service holds the business rules of the user service: validation,
+// uniqueness, reads from the read model or the cache and the events every change
+// publishes. The HTTP handlers, the NATS API and the provisioning worker all go
+// through it, so they only translate their own protocol.
+package service
+
+import (
+	"cmp"
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/mail"
+	"net/url"
+	"slices"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+
+	"go-mysql/internal/events"
+	"go-mysql/internal/logging"
+	"go-mysql/internal/models"
+	"go-mysql/internal/readmodel"
+	"go-mysql/internal/repository"
+)
+
+//go:generate mockgen -destination=mocks/mocks.go -package=mocks go-mysql/internal/service Store,Cache,ReadModel,APIKeyStore,Mailer,TenantStore,AvatarStorage,AdminStore,PasswordResetMailer,GroupStore,FollowStore,FollowCounter,SeenThrottle,EmailChangeMailer,StatsStore,StatsCache,ReservedStore,ReservedCache
+
+// Store is the database behind the user service, implemented by
+// repository.Repository. Lookups return sql.ErrNoRows for missing users.
+type Store interface {
+	UserByID(ctx context.Context, id int) (models.User, error)
+	UserIDByUsername(ctx context.Context, username string) (int, error)
+	UsersByPhone(ctx context.Context, phone string, limit int) ([]models.User, error)
+	TakenUsernamesAndEmails(ctx context.Context, usernames, emails []string) (takenUsernames, takenEmails map[string]bool, err error)
+	CreateUser(ctx context.Context, user models.User) (int, error)
+	CreateUsers(ctx context.Context, users []models.User) ([]models.User, error)
+	UpsertUser(ctx context.Context, user models.User) (id int, created bool, err error)
+	UpdateUserProfile(ctx context.Context, id int, username string, fields map[string]string) (bool, error)
+	CreateEmailChange(ctx context.Context, id int, username, email, tokenHash string, expiresAt time.Time) (models.User, error)
+	ConfirmEmailChange(ctx context.Context, tokenHash string, now time.Time) (models.User, error)
+	SetUserAvatar(ctx context.Context, id int, key string) (username, previous string, err error)
+	RenameUser(ctx context.Context, id int, username string) (previous string, err error)
+	UsernameHistory(ctx context.Context, userID int) ([]models.UsernameChange, error)
+	UserIDByFormerUsername(ctx context.Context, username string, since time.Time) (int, error)
+	UserPreferences(ctx context.Context, id int) ([]byte, error)
+	SetUserPreferences(ctx context.Context, id int, preferences []byte) error
+	SetUserStatus(ctx context.Context, id int, from, status string) (models.User, error)
+	TagUser(ctx context.Context, id int, name string) (bool, error)
+	UntagUser(ctx context.Context, id int, name string) (bool, error)
+	UserTags(ctx context.Context, id int) ([]string, error)
+	TagCounts(ctx context.Context) ([]models.TagCount, error)
+	UsersByTag(ctx context.Context, name string, offset, limit int) ([]models.User, int, error)
+	DeleteUser(ctx context.Context, id int, username string) (bool, error)
+}
+
+// Cache is the user cache in front of the Store, implemented by cache.UserCache. Reads
+// report a miss rather than an error, and SetUser logs its failures instead of
+// returning them, so an operation never fails because of the cache. Changes reach the
+// cache through the user events published on the bus, see cache.UserCache.Subscribe.
+type Cache interface {
+	Users(ctx context.Context) (users []models.User, ok bool)
+	LoadUsers(ctx context.Context) ([]models.User, error)
+	User(ctx context.Context, id int) (models.User, bool)
+	UserFields(ctx context.Context, id int, fields []string) (map[string]string, bool)
+	SetUser(ctx context.Context, user models.User)
+	ExecByUsername(ctx context.Context, username string, exec func(id int) (bool, error)) (id int, found bool, err error)
+	Warm(ctx context.Context)
+}
+
+// ReadModel is the copy of the users built for reads, implemented by readmodel.Model.
+// Until it's Ready, reads go through the Cache instead.
+type ReadModel interface {
+	Ready(ctx context.Context) bool
+	User(ctx context.Context, id int) (user models.User, ok bool, err error)
+	List(ctx context.Context, opts readmodel.ListOptions) (users []models.User, total int, err error)
+	Search(ctx context.Context, prefix string, limit int) ([]models.User, error)
+	Autocomplete(ctx context.Context, prefix string, limit int) ([]models.User, error)
+}
+
+var (
+	// ErrNotFound is returned for operations on a user that doesn't exist.
+	ErrNotFound = errors.New("User not found")
+	// ErrUsernameTaken is returned when creating a user with a username in use.
+	ErrUsernameTaken = errors.New("Username already taken")
+	// ErrEmailTaken is returned when giving a user an email another user has, in any
+	// letter case, see repository.CanonicalEmail.
+	ErrEmailTaken = errors.New("Email already registered")
+	// ErrInvalid wraps the reason a user was rejected.
+	ErrInvalid = errors.New("Invalid user")
+)
+
+// maxFieldLength is the size of the username and email columns.
+const maxFieldLength = 50
+
+// Validate checks the fields every stored user must have. The email, phone and address
+// are checked as normalizeUser normalizes them before they're stored.
+func Validate(user models.User) error {
+	user = normalizeUser(user)
+	err := validateUsername(user.Username)
+	if err != nil {
+		return err
+	}
+	err = validateEmail(user.Email)
+	if err != nil {
+		return err
+	}
+	fields := models.SelectUserFields(user, models.ProfileFieldNames)
+	err = validateProfile(fields)
+	if err != nil {
+		return err
+	}
+	return validateContact(fields)
+}
+
+func validateUsername(username string) error {
+	if username == "" {
+		return fmt.Errorf("%w: missing username", ErrInvalid)
+	}
+	if len(username) > maxFieldLength {
+		return fmt.Errorf("%w: username is longer than %d characters", ErrInvalid, maxFieldLength)
+	}
+	return nil
+}
+
+func validateEmail(email string) error {
+	email = models.NormalizeEmail(email)
+	if email == "" {
+		return fmt.Errorf("%w: missing email", ErrInvalid)
+	}
+	if len(email) > maxFieldLength {
+		return fmt.Errorf("%w: email is longer than %d characters", ErrInvalid, maxFieldLength)
+	}
+	addr, err := mail.ParseAddress(email)
+	if err != nil || addr.Address != email {
+		return fmt.Errorf("%w: %q is not an email address", ErrInvalid, email)
+	}
+	return nil
+}
+
+// maxProfileLengths are the sizes of the profile columns, in characters.
+var maxProfileLengths = map[string]int{
+	"first_name":    50,
+	"last_name":     50,
+	"display_name":  100,
+	"bio":           500,
+	"avatar_url":    2048,
+	"phone":         16,
+	"address_line1": 100,
+	"address_line2": 100,
+	"city":          50,
+	"region":        50,
+	"postal_code":   16,
+	"country":       2,
+}
+
+// validateProfile checks profile fields keyed by their JSON names. Every field may be
+// empty; names are a single line, and the avatar must be an http or https URL.
+func validateProfile(fields map[string]string) error {
+	for _, name := range models.ProfileFieldNames {
+		value, ok := fields[name]
+		if !ok || value == "" {
+			continue
+		}
+		if !utf8.ValidString(value) {
+			return fmt.Errorf("%w: %s is not valid UTF-8", ErrInvalid, name)
+		}
+		if utf8.RuneCountInString(value) > maxProfileLengths[name] {
+			return fmt.Errorf("%w: %s is longer than %d characters", ErrInvalid, name, maxProfileLengths[name])
+		}
+		if strings.TrimSpace(value) != value {
+			return fmt.Errorf("%w: %s has leading or trailing spaces", ErrInvalid, name)
+		}
+		// The bio may span lines, nothing else may hold control characters
+		if strings.ContainsFunc(value, func(r rune) bool { return unicode.IsControl(r) && (name != "bio" || r != '\n') }) {
+			return fmt.Errorf("%w: %s contains control characters", ErrInvalid, name)
+		}
+	}
+	if avatar := fields["avatar_url"]; avatar != "" {
+		u, err := url.Parse(avatar)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return fmt.Errorf("%w: avatar_url is not an http or https URL", ErrInvalid)
+		}
+	}
+	return nil
+}
+
+// UserService reads and changes users.
+type UserService struct {
+	store Store
+	cache Cache
+	reads ReadModel
+	bus   *events.Bus
+
+	// avatars holds uploaded avatars, if enabled with UseAvatarStorage.
+	avatars    AvatarStorage
+	avatarOpts AvatarOptions
+	// renameGrace is how long former usernames still find their user, see
+	// RedirectFormerUsernames.
+	renameGrace time.Duration
+	// emailChanges sends the confirmations of email changes, if enabled with
+	// ConfirmEmailChanges.
+	emailChanges    EmailChangeMailer
+	emailChangeLink string
+	emailChangeTTL  time.Duration
+	// reserved are the usernames new and renamed users can't have, if enabled with
+	// ReserveUsernames.
+	reserved *ReservedUsernames
+}
+
+// NewUserService returns a service on top of store, reading from reads, or through
+// cache while reads isn't ready or is nil, and publishing every change on bus.
+func NewUserService(store Store, cache Cache, reads ReadModel, bus *events.Bus) *UserService {
+	return &UserService{store: store, cache: cache, reads: reads, bus: bus}
+}
+
+// readModel returns the read model if it can serve reads.
+func (s *UserService) readModel(ctx context.Context) (ReadModel, bool) {
+	if s.reads == nil || !s.reads.Ready(ctx) {
+		return nil, false
+	}
+	return s.reads, true
+}
+
+// logReadModelError notes a failed read before falling back to the cache.
+func logReadModelError(ctx context.Context, err error) {
+	logging.From(ctx).Warn("Failed to read from the read model, falling back", "error", err)
+}
+
+// List returns every user, ordered by creation.
+func (s *UserService) List(ctx context.Context) ([]models.User, error) {
+	users, _, err := s.ListPage(ctx, readmodel.ListOptions{})
+	return users, err
+}
+
+// ListPage returns a page of users and how many there are in total.
+func (s *UserService) ListPage(ctx context.Context, opts readmodel.ListOptions) ([]models.User, int, error) {
+	users, total, err := s.listPage(ctx, opts)
+	if err != nil {
+		return nil, 0, err
+	}
+	s.withAvatarURLs(ctx, pointers(users)...)
+	return users, total, nil
+}
+
+// pointers returns pointers to the elements of users.
+func pointers(users []models.User) []*models.User {
+	ptrs := make([]*models.User, len(users))
+	for i := range users {
+		ptrs[i] = &users[i]
+	}
+	return ptrs
+}
+
+// listPage is ListPage without the avatar URLs. Users read from the cache are copied,
+// so they can be changed.
+func (s *UserService) listPage(ctx context.Context, opts readmodel.ListOptions) ([]models.User, int, error) {
+	if reads, ok := s.readModel(ctx); ok {
+		users, total, err := reads.List(ctx, opts)
+		if err == nil {
+			return users, total, nil
+		}
+		logReadModelError(ctx, err)
+	}
+
+	users, err := s.cachedUsers(ctx)
+	if err != nil {
+		return nil, 0, err
+	}
+	// Ids are assigned in creation order, so sorting by id stands in for created_at
+	users = slices.Clone(users)
+	if opts.Sort == readmodel.SortUsername {
+		slices.SortFunc(users, func(a, b models.User) int { return strings.Compare(a.Username, b.Username) })
+	} else {
+		slices.SortFunc(users, func(a, b models.User) int { return a.ID - b.ID })
+	}
+	if opts.Desc {
+		slices.Reverse(users)
+	}
+	users = slices.DeleteFunc(users, func(user models.User) bool { return !user.Listed() })
+	total := len(users)
+	start := min(opts.Offset, total)
+	if opts.After != 0 {
+		i := slices.IndexFunc(users, func(user models.User) bool { return user.ID == opts.After })
+		if i < 0 {
+			return nil, 0, readmodel.ErrCursorNotFound
+		}
+		start = i + 1
+	}
+	users = users[start:]
+	if opts.Limit > 0 {
+		users = users[:min(opts.Limit, len(users))]
+	}
+	return users, total, nil
+}
+
+// cachedUsers returns every user, from the cache when it holds the listing.
+func (s *UserService) cachedUsers(ctx context.Context) ([]models.User, error) {
+	users, ok := s.cache.Users(ctx)
+	if ok {
+		return users, nil
+	}
+	return s.cache.LoadUsers(ctx)
+}
+
+// Search returns up to limit users whose username starts with prefix, in username
+// order.
+func (s *UserService) Search(ctx context.Context, prefix string, limit int) ([]models.User, error) {
+	if reads, ok := s.readModel(ctx); ok {
+		users, err := reads.Search(ctx, prefix, limit)
+		if err == nil {
+			s.withAvatarURLs(ctx, pointers(users)...)
+			return users, nil
+		}
+		logReadModelError(ctx, err)
+	}
+
+	users, _, err := s.listPage(ctx, readmodel.ListOptions{Sort: readmodel.SortUsername})
+	if err != nil {
+		return nil, err
+	}
+	var matches []models.User
+	for _, user := range users {
+		if len(matches) == limit {
+			break
+		}
+		if strings.HasPrefix(user.Username, prefix) {
+			matches = append(matches, user)
+		}
+	}
+	s.withAvatarURLs(ctx, pointers(matches)...)
+	return matches, nil
+}
+
+// Autocomplete returns up to limit users whose username starts with prefix in any
+// letter case, ordered by their lowercased usernames, with only their ID and Username
+// set. It's served from a single index of the read model, so it stays quick enough to
+// call on every keystroke.
+func (s *UserService) Autocomplete(ctx context.Context, prefix string, limit int) ([]models.User, error) {
+	if reads, ok := s.readModel(ctx); ok {
+		users, err := reads.Autocomplete(ctx, prefix, limit)
+		if err == nil {
+			return users, nil
+		}
+		logReadModelError(ctx, err)
+	}
+
+	users, _, err := s.listPage(ctx, readmodel.ListOptions{})
+	if err != nil {
+		return nil, err
+	}
+	prefix = strings.ToLower(prefix)
+	var matches []models.User
+	for _, user := range users {
+		if strings.HasPrefix(strings.ToLower(user.Username), prefix) {
+			matches = append(matches, models.User{ID: user.ID, Username: user.Username})
+		}
+	}
+	slices.SortFunc(matches, func(a, b models.User) int {
+		return cmp.Or(strings.Compare(strings.ToLower(a.Username), strings.ToLower(b.Username)), strings.Compare(a.Username, b.Username))
+	})
+	if len(matches) > limit {
+		matches = matches[:limit]
+	}
+	return matches, nil
+}
+
+// Get returns the user with the given id, caching it on a miss.
+func (s *UserService) Get(ctx context.Context, id int) (models.User, error) {
+	user, err := s.get(ctx, id)
+	if err != nil {
+		return models.User{}, err
+	}
+	s.withAvatarURLs(ctx, &user)
+	return user, nil
+}
+
+func (s *UserService) get(ctx context.Context, id int) (models.User, error) {
+	if user, ok := s.readModelUser(ctx, id); ok {
+		return user, nil
+	}
+	user, ok := s.cache.User(ctx, id)
+	if ok {
+		return user, nil
+	}
+	return s.load(ctx, id)
+}
+
+// readModelUser looks a user up in the read model. A miss falls back to the cache and
+// MySQL, in case the read model failed to follow a change.
+func (s *UserService) readModelUser(ctx context.Context, id int) (models.User, bool) {
+	reads, ok := s.readModel(ctx)
+	if !ok {
+		return models.User{}, false
+	}
+	user, ok, err := reads.User(ctx, id)
+	if err != nil {
+		logReadModelError(ctx, err)
+	}
+	return user, ok
+}
+
+// GetFields returns only the given fields of a user, see models.ParseUserFields.
+func (s *UserService) GetFields(ctx context.Context, id int, fields []string) (map[string]string, error) {
+	selected, err := s.getFields(ctx, id, avatarFields(fields))
+	if err != nil {
+		return nil, err
+	}
+	s.withAvatarFields(ctx, selected)
+	return selected, nil
+}
+
+func (s *UserService) getFields(ctx context.Context, id int, fields []string) (map[string]string, error) {
+	if user, ok := s.readModelUser(ctx, id); ok {
+		return models.SelectUserFields(user, fields), nil
+	}
+	selected, ok := s.cache.UserFields(ctx, id, fields)
+	if ok {
+		return selected, nil
+	}
+	user, err := s.load(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	return models.SelectUserFields(user, fields), nil
+}
+
+// load reads a user from the store into the cache.
+func (s *UserService) load(ctx context.Context, id int) (models.User, error) {
+	user, err := s.store.UserByID(ctx, id)
+	if err == sql.ErrNoRows {
+		return models.User{}, ErrNotFound
+	}
+	if err != nil {
+		return models.User{}, err
+	}
+	s.cache.SetUser(ctx, user)
+	return user, nil
+}
+
+// Create stores a new user and returns it with its id. It returns ErrUsernameTaken
+// if the username is in use, ErrUsernameReserved if it's reserved, or ErrEmailTaken if
+// the email is in use.
+func (s *UserService) Create(ctx context.Context, user models.User) (models.User, error) {
+	user = normalizeUser(user)
+	err := Validate(user)
+	if err != nil {
+		return models.User{}, err
+	}
+	err = s.checkReserved(ctx, user.Username)
+	if err != nil {
+		return models.User{}, err
+	}
+	_, err = s.store.UserIDByUsername(ctx, user.Username)
+	if err == nil {
+		return models.User{}, ErrUsernameTaken
+	}
+	if err != sql.ErrNoRows {
+		return models.User{}, err
+	}
+
+	// New users are active, whatever the caller says
+	user.Status = models.StatusActive
+	// The unique index settles a race with another create of the same username
+	user.ID, err = s.store.CreateUser(ctx, user)
+	if err == repository.ErrDuplicate {
+		return models.User{}, ErrUsernameTaken
+	}
+	if err == repository.ErrDuplicateEmail {
+		return models.User{}, ErrEmailTaken
+	}
+	if err != nil {
+		return models.User{}, err
+	}
+	s.bus.Publish(ctx, events.UserCreated{User: user})
+	return user, nil
+}
+
+// UpdateProfile applies update to the profile of the user called username and returns
+// the user as it is afterwards.
+func (s *UserService) UpdateProfile(ctx context.Context, username string, update models.ProfileUpdate) (models.User, error) {
+	if username == "" {
+		return models.User{}, fmt.Errorf("%w: missing username", ErrInvalid)
+	}
+	fields := update.Fields()
+	if len(fields) == 0 {
+		return models.User{}, fmt.Errorf("%w: no profile fields to update", ErrInvalid)
+	}
+	normalizeContact(fields)
+	err := validateProfile(fields)
+	if err != nil {
+		return models.User{}, err
+	}
+	err = validateContact(fields)
+	if err != nil {
+		return models.User{}, err
+	}
+
+	id, found, err := s.cache.ExecByUsername(ctx, username, func(id int) (bool, error) {
+		return s.store.UpdateUserProfile(ctx, id, username, fields)
+	})
+	if err != nil {
+		return models.User{}, err
+	}
+	if !found {
+		return models.User{}, ErrNotFound
+	}
+	values := models.UserFieldMap(models.User{ID: id, Username: username})
+	var changed []string
+	for _, name := range models.ProfileFieldNames {
+		if value, ok := fields[name]; ok {
+			values[name] = value
+			changed = append(changed, name)
+		}
+	}
+	user, err := models.UserFromFieldMap(values)
+	if err != nil {
+		return models.User{}, err
+	}
+	s.bus.Publish(ctx, events.UserUpdated{User: user, Fields: changed})
+	return s.Get(ctx, id)
+}
+
+// Delete deletes the user called username.
+func (s *UserService) Delete(ctx context.Context, username string) error {
+	if username == "" {
+		return fmt.Errorf("%w: missing username", ErrInvalid)
+	}
+
+	id, found, err := s.cache.ExecByUsername(ctx, username, func(id int) (bool, error) {
+		return s.store.DeleteUser(ctx, id, username)
+	})
+	if err != nil {
+		return err
+	}
+	if !found {
+		return ErrNotFound
+	}
+	s.bus.Publish(ctx, events.UserDeleted{ID: id, Username: username})
+	return nil
+}
+
+// Upsert creates the user called user.Username, or updates its profile if it exists,
+// reporting which happened. An existing user's email can't be changed this way, only
+// through ChangeEmail by the user themselves, so a different one is refused with
+// ErrInvalid. Applying the same user twice leaves it as it is, which makes Upsert safe
+// for redelivered requests. Only new users are refused reserved usernames.
+func (s *UserService) Upsert(ctx context.Context, user models.User) (models.User, bool, error) {
+	user = normalizeUser(user)
+	err := Validate(user)
+	if err != nil {
+		return models.User{}, false, err
+	}
+	id, err := s.store.UserIDByUsername(ctx, user.Username)
+	switch {
+	case err == sql.ErrNoRows:
+		if s.reserved != nil {
+			err = s.checkReserved(ctx, user.Username)
+		} else {
+			err = nil
+		}
+	case err == nil:
+		var existing models.User
+		existing, err = s.store.UserByID(ctx, id)
+		if err == nil && existing.Email != user.Email {
+			err = fmt.Errorf("%w: the email can only be changed through /user/update", ErrInvalid)
+		}
+	}
+	if err != nil {
+		return models.User{}, false, err
+	}
+
+	id, created, err := s.store.UpsertUser(ctx, user)
+	if err == repository.ErrDuplicateEmail {
+		return models.User{}, false, ErrEmailTaken
+	}
+	if err != nil {
+		return models.User{}, false, err
+	}
+	user.ID = id
+	if created {
+		user.Status = models.StatusActive
+		s.bus.Publish(ctx, events.UserCreated{User: user})
+		return user, created, nil
+	}
+	// The stored user has fields the request doesn't set, such as its status
+	user, err = s.store.UserByID(ctx, id)
+	if err != nil {
+		return models.User{}, false, err
+	}
+	s.bus.Publish(ctx, events.UserUpdated{User: user})
+	return user, created, nil
+}
@@ -0,0 +1,152 @@
+package service_test
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"testing"
+	"time"
+
+	"go.uber.org/mock/gomock"
+
+	"go-mysql/internal/events"
+	"go-mysql/internal/models"
+	"go-mysql/internal/service"
+	"go-mysql/internal/service/mocks"
+)
+
+func TestGet(t *testing.T) {
+	user := models.User{ID: 1, Username: "alice", Email: "alice@example.com"}
+	errDB := errors.New("connection refused")
+
+	tests := []struct {
+		name    string
+		expect  func(store *mocks.MockStore, cache *mocks.MockCache)
+		want    models.User
+		wantErr error
+	}{
+		{
+			name: "cache hit",
+			expect: func(store *mocks.MockStore, cache *mocks.MockCache) {
+				cache.EXPECT().User(gomock.Any(), 1).Return(user, true)
+			},
+			want: user,
+		},
+		{
+			name: "cache miss",
+			expect: func(store *mocks.MockStore, cache *mocks.MockCache) {
+				cache.EXPECT().User(gomock.Any(), 1).Return(models.User{}, false)
+				store.EXPECT().UserByID(gomock.Any(), 1).Return(user, nil)
+				cache.EXPECT().SetUser(gomock.Any(), user)
+			},
+			want: user,
+		},
+		{
+			name: "not found",
+			expect: func(store *mocks.MockStore, cache *mocks.MockCache) {
+				cache.EXPECT().User(gomock.Any(), 1).Return(models.User{}, false)
+				store.EXPECT().UserByID(gomock.Any(), 1).Return(models.User{}, sql.ErrNoRows)
+			},
+			wantErr: service.ErrNotFound,
+		},
+		{
+			name: "database error",
+			expect: func(store *mocks.MockStore, cache *mocks.MockCache) {
+				cache.EXPECT().User(gomock.Any(), 1).Return(models.User{}, false)
+				store.EXPECT().UserByID(gomock.Any(), 1).Return(models.User{}, errDB)
+			},
+			wantErr: errDB,
+		},
+		{
+			name: "slow database",
+			expect: func(store *mocks.MockStore, cache *mocks.MockCache) {
+				cache.EXPECT().User(gomock.Any(), 1).Return(models.User{}, false)
+				store.EXPECT().UserByID(gomock.Any(), 1).DoAndReturn(func(ctx context.Context, id int) (models.User, error) {
+					<-ctx.Done()
+					return models.User{}, ctx.Err()
+				})
+			},
+			wantErr: context.DeadlineExceeded,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			store := mocks.NewMockStore(ctrl)
+			cache := mocks.NewMockCache(ctrl)
+			tt.expect(store, cache)
+			users := service.NewUserService(store, cache, nil, events.NewBus())
+
+			ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+			defer cancel()
+			got, err := users.Get(ctx, 1)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Get() error = %v, want %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("Get() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestUpsert(t *testing.T) {
+	stored := models.User{ID: 1, Username: "alice", Email: "alice@example.com", FirstName: "Alice", LastName: "Smith", Status: models.StatusActive}
+	user := models.User{Username: "alice", Email: "alice@example.com", FirstName: "Alice", LastName: "Jones"}
+
+	tests := []struct {
+		name        string
+		user        models.User
+		expect      func(store *mocks.MockStore)
+		wantCreated bool
+		wantErr     error
+	}{
+		{
+			name: "new user",
+			user: user,
+			expect: func(store *mocks.MockStore) {
+				store.EXPECT().UserIDByUsername(gomock.Any(), "alice").Return(0, sql.ErrNoRows)
+				store.EXPECT().UpsertUser(gomock.Any(), gomock.Any()).Return(1, true, nil)
+			},
+			wantCreated: true,
+		},
+		{
+			name: "same email",
+			user: user,
+			expect: func(store *mocks.MockStore) {
+				store.EXPECT().UserIDByUsername(gomock.Any(), "alice").Return(1, nil)
+				store.EXPECT().UserByID(gomock.Any(), 1).Return(stored, nil)
+				store.EXPECT().UpsertUser(gomock.Any(), gomock.Any()).Return(1, false, nil)
+				store.EXPECT().UserByID(gomock.Any(), 1).Return(stored, nil)
+			},
+		},
+		{
+			// Neither the profile nor a pending email change is stored, so no
+			// confirmation goes out to the new address
+			name: "new email",
+			user: models.User{Username: "alice", Email: "mallory@example.com", FirstName: "Alice", LastName: "Smith"},
+			expect: func(store *mocks.MockStore) {
+				store.EXPECT().UserIDByUsername(gomock.Any(), "alice").Return(1, nil)
+				store.EXPECT().UserByID(gomock.Any(), 1).Return(stored, nil)
+			},
+			wantErr: service.ErrInvalid,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			store := mocks.NewMockStore(ctrl)
+			cache := mocks.NewMockCache(ctrl)
+			tt.expect(store)
+			users := service.NewUserService(store, cache, nil, events.NewBus())
+
+			_, created, err := users.Upsert(context.Background(), tt.user)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Upsert() error = %v, want %v", err, tt.wantErr)
+			}
+			if created != tt.wantCreated {
+				t.Errorf("Upsert() created = %v, want %v", created, tt.wantCreated)
+			}
+		})
+	}
+}
@@ -0,0 +1,29 @@
+// Package storage keeps files, such as uploaded avatars, in object storage and hands
+// out signed URLs to them, so they're served by the storage rather than by the API.
+package storage
+
+import (
+	"context"
+	"time"
+
+	"go-mysql/internal/config"
+)
+
+// Storage stores objects by key.
+type Storage interface {
+	// Put stores data under key, replacing any object there.
+	Put(ctx context.Context, key string, data []byte, contentType string) error
+	// Delete deletes the objects under keys; missing objects aren't an error.
+	Delete(ctx context.Context, keys ...string) error
+	// SignedURL returns a URL anyone can get the object under key from for ttl.
+	SignedURL(ctx context.Context, key string, ttl time.Duration) (string, error)
+}
+
+// New returns the storage cfg configures, or nil if the backend is "none".
+func New(ctx context.Context, cfg config.Storage) (Storage, error) {
+	switch cfg.Backend {
+	case "s3":
+		return NewS3(ctx, cfg)
+	}
+	return nil, nil
+}
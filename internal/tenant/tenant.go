@@ -0,0 +1,45 @@
+// Package tenant carries the tenant a piece of work belongs to in its context. Every
+// tenant's users are kept apart: the repository scopes its queries by the tenant in
+// ctx, and Redis keys holding a tenant's data are built with Key.
+//
+// Work without a tenant, such as a request to a deployment that doesn't resolve them,
+// belongs to the default tenant.
+package tenant
+
+import (
+	"context"
+	"strconv"
+)
+
+// DefaultID is the tenant created with the schema, owning every user from before
+// tenants existed.
+const DefaultID = 1
+
+type contextKey struct{}
+
+// WithID returns a copy of ctx belonging to the tenant with the given id.
+func WithID(ctx context.Context, id int) context.Context {
+	return context.WithValue(ctx, contextKey{}, id)
+}
+
+// ID returns the tenant ctx belongs to, DefaultID if none was set.
+func ID(ctx context.Context) int {
+	if id, ok := ctx.Value(contextKey{}).(int); ok {
+		return id
+	}
+	return DefaultID
+}
+
+// Key returns the Redis key called name for the tenant ctx belongs to.
+func Key(ctx context.Context, name string) string {
+	return KeyFor(ID(ctx), name)
+}
+
+// KeyFor returns the Redis key called name for the tenant with the given id.
+func KeyFor(id int, name string) string {
+	return KeyPrefix + strconv.Itoa(id) + ":" + name
+}
+
+// KeyPrefix starts every key built by Key, so the keys of every tenant can be
+// dropped together.
+const KeyPrefix = "tenant:"
@@ -0,0 +1,51 @@
+package webhooks
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/netip"
+	"syscall"
+	"time"
+)
+
+// errPrivateAddress is returned for deliveries to addresses that aren't on the public
+// internet.
+var errPrivateAddress = errors.New("destination is not a public address")
+
+// cgnat is the shared address space carriers use, RFC 6598, which netip doesn't count
+// as private.
+var cgnat = netip.MustParsePrefix("100.64.0.0/10")
+
+// publicTransport returns a transport that only connects to public addresses, so
+// webhooks can't be pointed at the loopback interface, the private network or cloud
+// metadata endpoints. Addresses are checked once resolved, which covers hostnames
+// resolving to private addresses and redirects too. Deliveries don't go through
+// proxies, which would hide their destination.
+func publicTransport() *http.Transport {
+	dialer := &net.Dialer{
+		Timeout:   deliveryTimeout,
+		KeepAlive: 30 * time.Second,
+		Control: func(network, address string, _ syscall.RawConn) error {
+			addrPort, err := netip.ParseAddrPort(address)
+			if err != nil {
+				return err
+			}
+			if !isPublic(addrPort.Addr()) {
+				return fmt.Errorf("%w: %s", errPrivateAddress, addrPort.Addr())
+			}
+			return nil
+		},
+	}
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.Proxy = nil
+	transport.DialContext = dialer.DialContext
+	return transport
+}
+
+// isPublic reports whether addr is a unicast address on the public internet.
+func isPublic(addr netip.Addr) bool {
+	addr = addr.Unmap()
+	return addr.IsGlobalUnicast() && !addr.IsPrivate() && !cgnat.Contains(addr)
+}
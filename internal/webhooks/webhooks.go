@@ -0,0 +1,355 @@
+// Package webhooks delivers user events to URLs registered by API clients. Every
+// delivery is logged in the database and sent by a job on the job queue, which retries
+// failed attempts with exponential backoff.
+//
+// Each request is a POST of a JSON envelope:
+//
+//	{"id": 42, "event": "user.created", "occurred_at": "...", "data": {...}}
+//
+// signed with the webhook's secret in the X-Webhook-Signature header as
+// "t=<unix time>,v1=<hex HMAC-SHA256 of "<unix time>.<body>">". Receivers should check
+// the signature and reject old timestamps.
+package webhooks
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/netip"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"go.opentelemetry.io/contrib/instrumentation/net/http/otelhttp"
+
+	"go-mysql/internal/events"
+	"go-mysql/internal/logging"
+	"go-mysql/internal/models"
+	"go-mysql/internal/repository"
+	"go-mysql/internal/requestid"
+	"go-mysql/internal/tenant"
+	"go-mysql/pkg/jobqueue"
+	"go-mysql/pkg/retry"
+	"go-mysql/pkg/workerpool"
+)
+
+// Job types.
+const (
+	// TypeDelivery sends one delivery.
+	TypeDelivery = "webhook_delivery"
+	// TypeDispatch logs and queues the deliveries of an event, when it couldn't be
+	// done on the worker pool.
+	TypeDispatch = "webhook_dispatch"
+)
+
+// deliveryTimeout bounds a single attempt, including reading the response.
+const deliveryTimeout = 10 * time.Second
+
+// EventTypes are the events a webhook can subscribe to.
+var EventTypes = []string{
+	events.UserCreated{}.EventName(),
+	events.UserUpdated{}.EventName(),
+	events.UserDeleted{}.EventName(),
+}
+
+// ErrInvalid is returned by Register for a webhook that can't be registered.
+var ErrInvalid = errors.New("invalid webhook")
+
+// delivery is the payload of a TypeDelivery job.
+type delivery struct {
+	DeliveryID int64 `json:"delivery_id"`
+	// TenantID owns the webhook; deliveries enqueued before tenants existed belong to
+	// the default tenant.
+	TenantID int `json:"tenant_id"`
+}
+
+// dispatch is an event to deliver to the webhooks subscribed to it, and the payload
+// of a TypeDispatch job.
+type dispatch struct {
+	Event      string          `json:"event"`
+	OccurredAt time.Time       `json:"occurred_at"`
+	Data       json.RawMessage `json:"data"`
+	TenantID   int             `json:"tenant_id"`
+}
+
+// envelope is the body of every delivery.
+type envelope struct {
+	ID         int64     `json:"id"`
+	Event      string    `json:"event"`
+	OccurredAt time.Time `json:"occurred_at"`
+	Data       any       `json:"data"`
+}
+
+// Service manages webhooks and sends their deliveries.
+type Service struct {
+	repo   *repository.Repository
+	jobs   *jobqueue.Queue
+	pool   *workerpool.Pool
+	client *http.Client
+}
+
+// New returns a Service keeping webhooks in repo, fanning events out on pool and
+// sending the deliveries through jobs.
+func New(repo *repository.Repository, jobs *jobqueue.Queue, pool *workerpool.Pool) *Service {
+	return &Service{
+		repo:   repo,
+		jobs:   jobs,
+		pool:   pool,
+		client: &http.Client{Timeout: deliveryTimeout, Transport: otelhttp.NewTransport(publicTransport())},
+	}
+}
+
+// Register validates and stores a webhook. An empty secret is replaced by a random
+// one; the returned webhook is the only place it's shown.
+func (s *Service) Register(ctx context.Context, hook models.Webhook) (models.Webhook, error) {
+	u, err := url.Parse(hook.URL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return models.Webhook{}, fmt.Errorf("%w: url must be an absolute http or https URL", ErrInvalid)
+	}
+	// Hostnames are checked as deliveries connect, see publicTransport
+	if addr, err := netip.ParseAddr(u.Hostname()); (err == nil && !isPublic(addr)) || strings.EqualFold(u.Hostname(), "localhost") {
+		return models.Webhook{}, fmt.Errorf("%w: url must point to a public address", ErrInvalid)
+	}
+	if len(hook.Events) == 0 {
+		hook.Events = EventTypes
+	}
+	for _, event := range hook.Events {
+		if !slices.Contains(EventTypes, event) {
+			return models.Webhook{}, fmt.Errorf("%w: unknown event %q", ErrInvalid, event)
+		}
+	}
+	if hook.Secret == "" {
+		buf := make([]byte, 32)
+		_, err := rand.Read(buf)
+		if err != nil {
+			return models.Webhook{}, err
+		}
+		hook.Secret = hex.EncodeToString(buf)
+	}
+
+	hook.ID, err = s.repo.CreateWebhook(ctx, hook)
+	if err != nil {
+		return models.Webhook{}, err
+	}
+	hook.CreatedAt = time.Now().UTC()
+	return hook, nil
+}
+
+// List returns every webhook, without secrets.
+func (s *Service) List(ctx context.Context) ([]models.Webhook, error) {
+	hooks, err := s.repo.Webhooks(ctx)
+	for i := range hooks {
+		hooks[i].Secret = ""
+	}
+	return hooks, err
+}
+
+// Delete removes a webhook and its delivery log, reporting whether it existed.
+// Deliveries still queued are dropped when they come up.
+func (s *Service) Delete(ctx context.Context, id int) (bool, error) {
+	return s.repo.DeleteWebhook(ctx, id)
+}
+
+// Deliveries returns the latest limit deliveries to a webhook, newest first.
+func (s *Service) Deliveries(ctx context.Context, webhookID, limit int) ([]models.WebhookDelivery, error) {
+	return s.repo.WebhookDeliveries(ctx, webhookID, limit)
+}
+
+// Redeliver sends a logged delivery again, whatever its status, reporting whether it
+// exists.
+func (s *Service) Redeliver(ctx context.Context, id int64) (bool, error) {
+	found, err := s.repo.ResetWebhookDelivery(ctx, id)
+	if err != nil || !found {
+		return found, err
+	}
+	_, err = s.jobs.Enqueue(ctx, TypeDelivery, delivery{DeliveryID: id, TenantID: tenant.ID(ctx)})
+	return true, err
+}
+
+// Subscribe delivers the user events published on bus to the webhooks subscribed to
+// them. Looking the webhooks up and logging the deliveries happens on the worker pool,
+// so it doesn't hold up the request that published the event.
+func (s *Service) Subscribe(bus *events.Bus) {
+	events.Subscribe(bus, func(ctx context.Context, e events.UserCreated) {
+		s.dispatch(ctx, e, e.User)
+	})
+	events.Subscribe(bus, func(ctx context.Context, e events.UserUpdated) {
+		s.dispatch(ctx, e, struct {
+			User             models.User `json:"user"`
+			Fields           []string    `json:"fields,omitempty"`
+			PreviousUsername string      `json:"previous_username,omitempty"`
+		}{e.User, e.Fields, e.PreviousUsername})
+	})
+	events.Subscribe(bus, func(ctx context.Context, e events.UserDeleted) {
+		s.dispatch(ctx, e, struct {
+			ID       int    `json:"id"`
+			Username string `json:"username"`
+		}{e.ID, e.Username})
+	})
+}
+
+// dispatch logs a delivery of data to every webhook of the tenant ctx belongs to
+// subscribed to e and queues them. That's done on the worker pool, or by a
+// TypeDispatch job if the pool is full or shuts down first.
+func (s *Service) dispatch(ctx context.Context, e events.Event, data any) {
+	body, err := json.Marshal(data)
+	if err != nil {
+		logging.From(ctx).Error("Failed to dispatch webhooks", "event", e.EventName(), "error", err)
+		return
+	}
+	d := dispatch{Event: e.EventName(), OccurredAt: time.Now().UTC(), Data: body, TenantID: tenant.ID(ctx)}
+	err = s.pool.SubmitWithFallback(ctx, "dispatch webhooks", func(ctx context.Context) error {
+		return s.fanOut(ctx, d)
+	}, func(ctx context.Context) error {
+		_, err := s.jobs.Enqueue(ctx, TypeDispatch, d)
+		return err
+	})
+	if err != nil {
+		logging.From(ctx).Error("Failed to dispatch webhooks", "event", e.EventName(), "error", err)
+	}
+}
+
+// fanOut logs and queues a delivery of d to every webhook subscribed to it.
+func (s *Service) fanOut(ctx context.Context, d dispatch) error {
+	hooks, err := s.repo.Webhooks(ctx)
+	if err != nil {
+		return err
+	}
+	for _, hook := range hooks {
+		if !slices.Contains(hook.Events, d.Event) {
+			continue
+		}
+		payload, err := json.Marshal(envelope{Event: d.Event, OccurredAt: d.OccurredAt, Data: d.Data})
+		if err != nil {
+			return err
+		}
+		id, err := s.repo.CreateWebhookDelivery(ctx, hook.ID, d.Event, payload)
+		if err != nil {
+			return err
+		}
+		_, err = s.jobs.Enqueue(ctx, TypeDelivery, delivery{DeliveryID: id, TenantID: d.TenantID})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// RegisterJobs installs the delivery and dispatch handlers on q.
+func (s *Service) RegisterJobs(q *jobqueue.Queue) {
+	q.Handle(TypeDelivery, s.deliver)
+	q.Handle(TypeDispatch, func(ctx context.Context, job jobqueue.Job) error {
+		var d dispatch
+		err := job.Decode(&d)
+		if err != nil {
+			return err
+		}
+		return s.fanOut(tenant.WithID(ctx, d.TenantID), d)
+	})
+}
+
+// deliver makes one attempt at a delivery and logs its outcome. An error makes the
+// queue retry it, unless it's permanent.
+func (s *Service) deliver(ctx context.Context, job jobqueue.Job) error {
+	p := delivery{TenantID: tenant.DefaultID}
+	err := job.Decode(&p)
+	if err != nil {
+		return err
+	}
+	ctx = tenant.WithID(ctx, p.TenantID)
+	d, err := s.repo.WebhookDelivery(ctx, p.DeliveryID)
+	if err == sql.ErrNoRows {
+		return nil // the webhook was deleted
+	}
+	if err != nil {
+		return err
+	}
+	hook, err := s.repo.WebhookByID(ctx, d.WebhookID)
+	if err == sql.ErrNoRows {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	code, sendErr := s.send(ctx, hook, d)
+	errMsg := ""
+	if sendErr != nil {
+		errMsg = sendErr.Error()
+	}
+	err = s.repo.RecordWebhookAttempt(ctx, d.ID, code, errMsg)
+	if err != nil {
+		logging.From(ctx).Error("Failed to record webhook attempt", "delivery_id", d.ID, "error", err)
+	}
+	if sendErr != nil {
+		return fmt.Errorf("delivering to webhook %d: %w", hook.ID, sendErr)
+	}
+	logging.From(ctx).Info("Delivered webhook", "webhook_id", hook.ID, "delivery_id", d.ID, "event", d.EventType, "status", code)
+	return nil
+}
+
+// send POSTs a delivery to its webhook and returns the response status. Anything but
+// a 2xx is an error, a permanent one for a 4xx other than 408 and 429: the receiver
+// rejected the delivery and won't accept it later either.
+func (s *Service) send(ctx context.Context, hook models.Webhook, d models.WebhookDelivery) (int, error) {
+	var env envelope
+	err := json.Unmarshal(d.Payload, &env)
+	if err != nil {
+		return 0, err
+	}
+	env.ID = d.ID
+	body, err := json.Marshal(env)
+	if err != nil {
+		return 0, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, hook.URL, bytes.NewReader(body))
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "go-mysql-webhooks")
+	req.Header.Set("X-Webhook-Event", d.EventType)
+	req.Header.Set("X-Webhook-Delivery", strconv.FormatInt(d.ID, 10))
+	req.Header.Set("X-Webhook-Signature", Sign(hook.Secret, time.Now(), body))
+	if id := requestid.From(ctx); id != "" {
+		req.Header.Set(requestid.Header, id)
+	}
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		err = fmt.Errorf("webhook answered %s", resp.Status)
+		if resp.StatusCode >= 400 && resp.StatusCode < 500 &&
+			resp.StatusCode != http.StatusRequestTimeout && resp.StatusCode != http.StatusTooManyRequests {
+			err = retry.Permanent(err)
+		}
+		return resp.StatusCode, err
+	}
+	return resp.StatusCode, nil
+}
+
+// Sign returns the X-Webhook-Signature header for body sent at t.
+func Sign(secret string, t time.Time, body []byte) string {
+	ts := strconv.FormatInt(t.Unix(), 10)
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(ts))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return "t=" + ts + ",v1=" + hex.EncodeToString(mac.Sum(nil))
+}
@@ -0,0 +1,53 @@
+// Package worker implements the worker run mode: instead of serving HTTP, the process
+// consumes bulk user provisioning requests from RabbitMQ.
+package worker
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"go-mysql/internal/models"
+	"go-mysql/internal/service"
+)
+
+// errInvalid marks requests that will never succeed, so they aren't requeued.
+var errInvalid = errors.New("invalid provisioning request")
+
+// ProvisionRequest is the body of a provisioning message: the users to create, or to
+// update if a user with the same username exists.
+type ProvisionRequest struct {
+	Users []models.User `json:"users"`
+}
+
+// Provisioner applies provisioning requests through the user service, the way
+// PUT /users/{username} does.
+type Provisioner struct {
+	users *service.UserService
+}
+
+func NewProvisioner(users *service.UserService) *Provisioner {
+	return &Provisioner{users: users}
+}
+
+// Provision upserts every user in req. Upserting makes a redelivered request
+// harmless: users applied the first time are simply left as they are.
+func (p *Provisioner) Provision(ctx context.Context, req ProvisionRequest) error {
+	if len(req.Users) == 0 {
+		return fmt.Errorf("%w: no users", errInvalid)
+	}
+	for i, user := range req.Users {
+		err := service.Validate(user)
+		if err != nil {
+			return fmt.Errorf("%w: user %d: %w", errInvalid, i, err)
+		}
+	}
+
+	for _, user := range req.Users {
+		_, _, err := p.users.Upsert(ctx, user)
+		if err != nil {
+			return fmt.Errorf("upserting user %q: %w", user.Username, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,153 @@
+package worker
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"time"
+
+	amqp "github.com/rabbitmq/amqp091-go"
+
+	"go-mysql/internal/config"
+	"go-mysql/internal/logging"
+	"go-mysql/internal/requestid"
+	"go-mysql/pkg/retry"
+)
+
+// Consumer feeds the messages of a RabbitMQ queue to a Provisioner, acknowledging
+// each one once it's processed.
+type Consumer struct {
+	cfg         config.RabbitMQ
+	provisioner *Provisioner
+}
+
+func NewConsumer(cfg config.RabbitMQ, provisioner *Provisioner) *Consumer {
+	return &Consumer{cfg: cfg, provisioner: provisioner}
+}
+
+// Run consumes until ctx is done, reconnecting with backoff whenever the connection
+// to RabbitMQ is lost. The message being processed when ctx is done is finished and
+// acknowledged; prefetched ones go back to the queue when the channel closes.
+func (c *Consumer) Run(ctx context.Context) {
+	backoff := retry.Policy{InitialDelay: time.Second, MaxDelay: 30 * time.Second, Jitter: 0.2}.Backoff()
+	for {
+		connected, err := c.consume(ctx)
+		if ctx.Err() != nil {
+			return
+		}
+		if connected {
+			backoff.Reset()
+		}
+		wait := backoff.Next()
+		logging.From(ctx).Error("RabbitMQ consumer stopped, reconnecting", "queue", c.cfg.Queue, "retry_in", wait, "error", err)
+		if retry.Sleep(ctx, wait) != nil {
+			return
+		}
+	}
+}
+
+// consume runs one connection until it fails or ctx is done. connected reports whether
+// it got as far as consuming.
+func (c *Consumer) consume(ctx context.Context) (connected bool, err error) {
+	conn, err := amqp.DialConfig(c.cfg.URL, amqp.Config{
+		Heartbeat: 10 * time.Second,
+		Locale:    "en_US",
+		// Dialing gives up once ctx is done rather than after the default timeout
+		Dial: func(network, addr string) (net.Conn, error) {
+			var dialer net.Dialer
+			return dialer.DialContext(ctx, network, addr)
+		},
+	})
+	if err != nil {
+		return false, err
+	}
+	defer conn.Close()
+	ch, err := conn.Channel()
+	if err != nil {
+		return false, err
+	}
+	defer ch.Close()
+
+	err = ch.Qos(c.cfg.Prefetch, 0, false)
+	if err != nil {
+		return false, err
+	}
+	args := amqp.Table{}
+	if c.cfg.DeadLetterExchange != "" {
+		args["x-dead-letter-exchange"] = c.cfg.DeadLetterExchange
+	}
+	_, err = ch.QueueDeclare(c.cfg.Queue, true, false, false, false, args)
+	if err != nil {
+		return false, fmt.Errorf("declaring queue: %w", err)
+	}
+	deliveries, err := ch.Consume(c.cfg.Queue, "", false, false, false, false, nil)
+	if err != nil {
+		return false, err
+	}
+	logging.From(ctx).Info("Consuming RabbitMQ queue", "queue", c.cfg.Queue, "prefetch", c.cfg.Prefetch)
+
+	for {
+		select {
+		case <-ctx.Done():
+			return true, nil
+		case d, ok := <-deliveries:
+			if !ok {
+				return true, errors.New("delivery channel closed")
+			}
+			c.handle(context.WithoutCancel(ctx), d)
+		}
+	}
+}
+
+// handle processes one message and acks it, or nacks it following the requeue policy.
+// It runs as a request with the id in the message's X-Request-ID header, if it has one.
+func (c *Consumer) handle(ctx context.Context, d amqp.Delivery) {
+	id, _ := d.Headers[requestid.Header].(string)
+	if !requestid.Valid(id) {
+		id = requestid.New()
+	}
+	log := logging.From(ctx).With("request_id", id, "message_id", d.MessageId, "delivery_tag", d.DeliveryTag, "redelivered", d.Redelivered)
+	ctx = logging.WithLogger(requestid.With(ctx, id), log)
+
+	var req ProvisionRequest
+	err := json.Unmarshal(d.Body, &req)
+	if err != nil {
+		err = fmt.Errorf("%w: %w", errInvalid, err)
+	} else {
+		err = c.provisioner.Provision(ctx, req)
+	}
+
+	if err == nil {
+		ackErr := d.Ack(false)
+		if ackErr != nil {
+			log.Error("Failed to ack message", "error", ackErr)
+			return
+		}
+		log.Info("Provisioned users", "count", len(req.Users))
+		return
+	}
+
+	requeue := c.requeue(d) && !errors.Is(err, errInvalid)
+	nackErr := d.Nack(false, requeue)
+	if nackErr != nil {
+		log.Error("Failed to nack message", "error", nackErr)
+	}
+	if requeue {
+		log.Warn("Failed to process message, requeued", "error", err)
+		return
+	}
+	log.Error("Failed to process message, rejected", "error", err)
+}
+
+// requeue applies the configured policy to a failed delivery.
+func (c *Consumer) requeue(d amqp.Delivery) bool {
+	switch c.cfg.Requeue {
+	case "always":
+		return true
+	case "once":
+		return !d.Redelivered
+	}
+	return false
+}
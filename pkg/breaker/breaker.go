@@ -0,0 +1,220 @@
+// Package breaker implements circuit breakers, which stop calling a dependency that
+// keeps failing so callers fail fast instead of each waiting for it to time out.
+//
+// A breaker starts closed, letting every call through. After Failures consecutive
+// failures it opens and rejects calls for OpenTimeout, then turns half-open and lets
+// HalfOpenRequests probe calls through: if they all succeed it closes again, if any
+// fails it opens for another OpenTimeout.
+package breaker
+
+import (
+	"errors"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+var (
+	// ErrOpen is returned instead of calling a dependency whose breaker is open.
+	ErrOpen = errors.New("circuit breaker is open")
+	// ErrTooManyRequests is returned while the breaker is half-open and its probe
+	// calls are already in flight.
+	ErrTooManyRequests = errors.New("circuit breaker is half-open, too many requests")
+)
+
+// State is the state of a breaker.
+type State int
+
+const (
+	StateClosed State = iota
+	StateHalfOpen
+	StateOpen
+)
+
+func (s State) String() string {
+	switch s {
+	case StateClosed:
+		return "closed"
+	case StateHalfOpen:
+		return "half-open"
+	case StateOpen:
+		return "open"
+	}
+	return "unknown"
+}
+
+// Settings configures a Breaker. Zero values get the defaults documented on each field.
+type Settings struct {
+	// Name identifies the dependency in logs and metrics.
+	Name string
+	// Failures is how many consecutive failures open the breaker, 5 by default.
+	Failures int
+	// OpenTimeout is how long the breaker stays open before probing, 10 seconds by
+	// default.
+	OpenTimeout time.Duration
+	// HalfOpenRequests is how many probe calls may be in flight while half-open, and
+	// how many must succeed in a row to close the breaker; 1 by default.
+	HalfOpenRequests int
+	// IsFailure reports whether an error counts against the dependency. Nil counts
+	// every error; errors such as a missing row or a rejected write usually shouldn't.
+	IsFailure func(err error) bool
+	// IsExcluded reports whether an error says nothing about the dependency either
+	// way; such calls count as neither successes nor failures.
+	IsExcluded func(err error) bool
+	// OnStateChange, if set, is called whenever the breaker changes state. The
+	// breaker is locked meanwhile, so it mustn't be used from there.
+	OnStateChange func(name string, from, to State)
+}
+
+// Breaker is a circuit breaker. It's safe for concurrent use.
+type Breaker struct {
+	settings Settings
+
+	mu          sync.Mutex
+	state       State
+	generation  uint64
+	consecutive int
+	openedAt    time.Time
+	probes      int
+	succeeded   int
+
+	rejected atomic.Int64
+	opened   atomic.Int64
+}
+
+// New returns a closed breaker.
+func New(settings Settings) *Breaker {
+	if settings.Failures <= 0 {
+		settings.Failures = 5
+	}
+	if settings.OpenTimeout <= 0 {
+		settings.OpenTimeout = 10 * time.Second
+	}
+	if settings.HalfOpenRequests <= 0 {
+		settings.HalfOpenRequests = 1
+	}
+	return &Breaker{settings: settings}
+}
+
+// Name returns Settings.Name.
+func (b *Breaker) Name() string {
+	return b.settings.Name
+}
+
+// Do calls fn if the breaker lets it through and records its outcome, or returns
+// ErrOpen or ErrTooManyRequests without calling it.
+func (b *Breaker) Do(fn func() error) error {
+	done, err := b.Allow()
+	if err != nil {
+		return err
+	}
+	err = fn()
+	done(err)
+	return err
+}
+
+// Allow is Do in two steps, for calls that don't fit in a function: it returns
+// ErrOpen or ErrTooManyRequests if the call mustn't be made, else a function the
+// caller must call with the call's outcome.
+func (b *Breaker) Allow() (done func(err error), err error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.refresh()
+	switch b.state {
+	case StateOpen:
+		b.rejected.Add(1)
+		return nil, ErrOpen
+	case StateHalfOpen:
+		if b.probes >= b.settings.HalfOpenRequests {
+			b.rejected.Add(1)
+			return nil, ErrTooManyRequests
+		}
+		b.probes++
+	}
+	generation := b.generation
+	return func(err error) { b.record(generation, err) }, nil
+}
+
+// record applies the outcome of a call let through in generation. Calls let through
+// before the last state change don't count.
+func (b *Breaker) record(generation uint64, err error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.refresh()
+	if generation != b.generation {
+		return
+	}
+	if b.state == StateHalfOpen {
+		b.probes--
+	}
+	if err != nil && b.settings.IsExcluded != nil && b.settings.IsExcluded(err) {
+		return
+	}
+	failed := err != nil && (b.settings.IsFailure == nil || b.settings.IsFailure(err))
+	switch b.state {
+	case StateClosed:
+		if !failed {
+			b.consecutive = 0
+			return
+		}
+		b.consecutive++
+		if b.consecutive >= b.settings.Failures {
+			b.setState(StateOpen)
+		}
+	case StateHalfOpen:
+		if failed {
+			b.setState(StateOpen)
+			return
+		}
+		b.succeeded++
+		if b.succeeded >= b.settings.HalfOpenRequests {
+			b.setState(StateClosed)
+		}
+	}
+}
+
+// refresh turns an open breaker half-open once OpenTimeout has passed.
+func (b *Breaker) refresh() {
+	if b.state == StateOpen && time.Since(b.openedAt) >= b.settings.OpenTimeout {
+		b.setState(StateHalfOpen)
+	}
+}
+
+func (b *Breaker) setState(state State) {
+	from := b.state
+	b.state = state
+	b.generation++
+	b.consecutive = 0
+	b.probes = 0
+	b.succeeded = 0
+	if state == StateOpen {
+		b.openedAt = time.Now()
+		b.opened.Add(1)
+	}
+	if b.settings.OnStateChange != nil {
+		b.settings.OnStateChange(b.settings.Name, from, state)
+	}
+}
+
+// State returns the current state.
+func (b *Breaker) State() State {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.refresh()
+	return b.state
+}
+
+// Stats is a snapshot of a breaker's state and counters.
+type Stats struct {
+	State State
+	// Rejected counts the calls refused while open or half-open, Opened how many
+	// times the breaker opened.
+	Rejected int64
+	Opened   int64
+}
+
+func (b *Breaker) Stats() Stats {
+	return Stats{State: b.State(), Rejected: b.rejected.Load(), Opened: b.opened.Load()}
+}
@@ -0,0 +1,106 @@
+// Package errreport sends errors to an error tracker. Backends share the Reporter
+// interface, so the service only decides at startup whether reports go to Sentry,
+// to the log, or nowhere.
+package errreport
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/getsentry/sentry-go"
+)
+
+// Event is one error worth reporting.
+type Event struct {
+	Err error
+	// Stack is the stack trace of the goroutine that failed, if known.
+	Stack []byte
+	// Request is the request being served when the error happened, if any.
+	Request   *http.Request
+	RequestID string
+	// Status is the HTTP status code sent for the request, 0 if none was sent.
+	Status int
+}
+
+// Reporter delivers events to an error tracker. Report must not block for long;
+// backends that send events over the network queue them instead.
+type Reporter interface {
+	Report(ctx context.Context, e Event)
+	// Flush waits up to timeout for queued events to be delivered and reports
+	// whether they all were.
+	Flush(timeout time.Duration) bool
+}
+
+// Noop discards every event.
+type Noop struct{}
+
+func (Noop) Report(context.Context, Event) {}
+func (Noop) Flush(time.Duration) bool      { return true }
+
+// Log writes events to a structured logger at error level, stack trace included.
+type Log struct {
+	Logger  *slog.Logger
+	Release string
+}
+
+func (l Log) Report(ctx context.Context, e Event) {
+	attrs := []any{"error", e.Err, "release", l.Release}
+	if e.RequestID != "" {
+		attrs = append(attrs, "request_id", e.RequestID)
+	}
+	if e.Request != nil {
+		attrs = append(attrs, "method", e.Request.Method, "path", e.Request.URL.Path)
+	}
+	if e.Status != 0 {
+		attrs = append(attrs, "status", e.Status)
+	}
+	if e.Stack != nil {
+		attrs = append(attrs, "stack", string(e.Stack))
+	}
+	l.Logger.ErrorContext(ctx, "Error reported", attrs...)
+}
+
+func (Log) Flush(time.Duration) bool { return true }
+
+// Sentry sends events to Sentry, or any service speaking its protocol such as GlitchTip.
+type Sentry struct {
+	client *sentry.Client
+}
+
+// NewSentry returns a reporter sending events to the project identified by dsn, tagged
+// with release and environment.
+func NewSentry(dsn, release, environment string) (*Sentry, error) {
+	client, err := sentry.NewClient(sentry.ClientOptions{
+		Dsn:              dsn,
+		Release:          release,
+		Environment:      environment,
+		AttachStacktrace: true,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &Sentry{client: client}, nil
+}
+
+// Report captures the stack of the calling goroutine, so for panics it must be called
+// from the deferred function that recovered.
+func (s *Sentry) Report(ctx context.Context, e Event) {
+	scope := sentry.NewScope()
+	if e.Request != nil {
+		scope.SetRequest(e.Request)
+	}
+	if e.RequestID != "" {
+		scope.SetTag("request_id", e.RequestID)
+	}
+	if e.Status != 0 {
+		scope.SetTag("status", strconv.Itoa(e.Status))
+	}
+	sentry.NewHub(s.client, scope).CaptureException(e.Err)
+}
+
+func (s *Sentry) Flush(timeout time.Duration) bool {
+	return s.client.Flush(timeout)
+}
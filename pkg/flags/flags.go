@@ -0,0 +1,241 @@
+// Package flags implements feature flags: defaults from configuration, overridden at
+// runtime through a Redis hash shared by every instance. A flag is on for a percentage
+// of subjects, such as requests or users, so a change can be rolled out gradually.
+//
+// Handlers don't reach for the Flags directly: Middleware puts the request's subject in
+// its context, and Enabled answers for it.
+package flags
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"log/slog"
+	"math/rand"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+)
+
+// Flag is the state of one flag.
+type Flag struct {
+	Name string `json:"name"`
+	// Percentage of subjects the flag is on for: 0 is off, 100 on for everyone.
+	Percentage int `json:"percentage"`
+	// Overridden reports whether Percentage comes from a runtime override rather than
+	// the configured default.
+	Overridden bool `json:"overridden"`
+}
+
+// ParseDefaults parses flag defaults written as "name=on,other=off,rollout=25%".
+func ParseDefaults(s string) (map[string]int, error) {
+	defaults := make(map[string]int)
+	for _, entry := range strings.Split(s, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		name, value, ok := strings.Cut(entry, "=")
+		if !ok || name == "" {
+			return nil, fmt.Errorf("invalid flag %q: want name=on|off|N%%", entry)
+		}
+		pct, err := ParsePercentage(value)
+		if err != nil {
+			return nil, fmt.Errorf("invalid flag %q: %w", entry, err)
+		}
+		defaults[name] = pct
+	}
+	return defaults, nil
+}
+
+// ParsePercentage parses "on", "off" or a percentage such as "25%".
+func ParsePercentage(s string) (int, error) {
+	switch s {
+	case "on", "true":
+		return 100, nil
+	case "off", "false":
+		return 0, nil
+	}
+	pct, err := strconv.Atoi(strings.TrimSuffix(s, "%"))
+	if err != nil || pct < 0 || pct > 100 {
+		return 0, errors.New("percentage must be on, off or between 0% and 100%")
+	}
+	return pct, nil
+}
+
+// Flags holds the defaults and a local copy of the overrides, refreshed by Run, so
+// checking a flag never waits on Redis.
+type Flags struct {
+	client   redis.UniversalClient
+	key      string
+	defaults map[string]int
+	logger   *slog.Logger
+
+	mu        sync.RWMutex
+	overrides map[string]int
+}
+
+// New returns flags with the given defaults, overridden by the hash at key in client.
+func New(client redis.UniversalClient, key string, defaults map[string]int, logger *slog.Logger) *Flags {
+	return &Flags{
+		client:    client,
+		key:       key,
+		defaults:  defaults,
+		logger:    logger,
+		overrides: make(map[string]int),
+	}
+}
+
+// Run refreshes the overrides every interval until ctx is done, picking up changes
+// made through other instances.
+func (f *Flags) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		err := f.Refresh(ctx)
+		if err != nil && ctx.Err() == nil {
+			f.logger.Warn("Failed to refresh feature flag overrides", "error", err)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Refresh reloads the overrides from Redis. Invalid entries are skipped.
+func (f *Flags) Refresh(ctx context.Context) error {
+	values, err := f.client.HGetAll(ctx, f.key).Result()
+	if err != nil {
+		return err
+	}
+	overrides := make(map[string]int, len(values))
+	for name, value := range values {
+		pct, err := strconv.Atoi(value)
+		if err != nil || pct < 0 || pct > 100 {
+			f.logger.Warn("Ignoring invalid feature flag override", "flag", name, "value", value)
+			continue
+		}
+		overrides[name] = pct
+	}
+	f.mu.Lock()
+	f.overrides = overrides
+	f.mu.Unlock()
+	return nil
+}
+
+// Set overrides a flag's percentage for every instance. Others see it on their next
+// refresh.
+func (f *Flags) Set(ctx context.Context, name string, pct int) error {
+	if pct < 0 || pct > 100 {
+		return errors.New("percentage must be between 0 and 100")
+	}
+	err := f.client.HSet(ctx, f.key, name, pct).Err()
+	if err != nil {
+		return err
+	}
+	f.mu.Lock()
+	f.overrides[name] = pct
+	f.mu.Unlock()
+	return nil
+}
+
+// Clear drops a flag's override, returning it to its default.
+func (f *Flags) Clear(ctx context.Context, name string) error {
+	err := f.client.HDel(ctx, f.key, name).Err()
+	if err != nil {
+		return err
+	}
+	f.mu.Lock()
+	delete(f.overrides, name)
+	f.mu.Unlock()
+	return nil
+}
+
+// Lookup returns the state of a flag. Unknown flags are off.
+func (f *Flags) Lookup(name string) Flag {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+	if pct, ok := f.overrides[name]; ok {
+		return Flag{Name: name, Percentage: pct, Overridden: true}
+	}
+	return Flag{Name: name, Percentage: f.defaults[name]}
+}
+
+// All returns every flag with a default or an override, ordered by name.
+func (f *Flags) All() []Flag {
+	f.mu.RLock()
+	names := make([]string, 0, len(f.defaults)+len(f.overrides))
+	for name := range f.defaults {
+		names = append(names, name)
+	}
+	for name := range f.overrides {
+		if _, ok := f.defaults[name]; !ok {
+			names = append(names, name)
+		}
+	}
+	f.mu.RUnlock()
+
+	slices.Sort(names)
+	all := make([]Flag, len(names))
+	for i, name := range names {
+		all[i] = f.Lookup(name)
+	}
+	return all
+}
+
+// EnabledFor reports whether a flag is on for subject. A subject always gets the same
+// answer for a given percentage, and raising the percentage only adds subjects. An
+// empty subject is decided at random.
+func (f *Flags) EnabledFor(name, subject string) bool {
+	pct := f.Lookup(name).Percentage
+	switch {
+	case pct <= 0:
+		return false
+	case pct >= 100:
+		return true
+	case subject == "":
+		return rand.Intn(100) < pct
+	}
+	h := fnv.New32a()
+	h.Write([]byte(name))
+	h.Write([]byte{0})
+	h.Write([]byte(subject))
+	return int(h.Sum32()%100) < pct
+}
+
+type contextKey struct{}
+
+type evaluator struct {
+	flags   *Flags
+	subject string
+}
+
+// Middleware makes the flags available to Enabled in the context of every request,
+// evaluated for the subject returned by subject, such as the request ID for a
+// per-request rollout or a user ID for a per-user one.
+func (f *Flags) Middleware(subject func(r *http.Request) string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			ctx := context.WithValue(r.Context(), contextKey{}, evaluator{flags: f, subject: subject(r)})
+			next.ServeHTTP(w, r.WithContext(ctx))
+		})
+	}
+}
+
+// Enabled reports whether a flag is on for the request ctx belongs to. Without
+// Middleware every flag is off.
+func Enabled(ctx context.Context, name string) bool {
+	e, ok := ctx.Value(contextKey{}).(evaluator)
+	if !ok {
+		return false
+	}
+	return e.flags.EnabledFor(name, e.subject)
+}
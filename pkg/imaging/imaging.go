@@ -0,0 +1,88 @@
+// Package imaging decodes uploaded images, refusing anything that isn't a JPEG, PNG,
+// GIF or WebP image of reasonable dimensions, and makes square thumbnails of them.
+package imaging
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"image"
+	"image/gif"
+	"image/jpeg"
+	"image/png"
+	"io"
+	"net/http"
+
+	"golang.org/x/image/draw"
+	"golang.org/x/image/webp"
+)
+
+var (
+	// ErrUnsupported is returned for data that isn't an image in a supported format.
+	ErrUnsupported = errors.New("unsupported image type, use JPEG, PNG, GIF or WebP")
+	// ErrTooLarge is returned for images wider or taller than allowed.
+	ErrTooLarge = errors.New("image dimensions too large")
+)
+
+// Decode decodes data, judging its format by its content rather than by anything the
+// uploader claims. Images wider or taller than maxDimension are refused before they're
+// decoded, so a small file can't claim a huge image and exhaust memory.
+func Decode(data []byte, maxDimension int) (image.Image, error) {
+	// The decoders are called directly rather than registered with package image, so
+	// no other format can slip through
+	var decode func(r io.Reader) (image.Image, error)
+	var decodeConfig func(r io.Reader) (image.Config, error)
+	switch http.DetectContentType(data) {
+	case "image/jpeg":
+		decode, decodeConfig = jpeg.Decode, jpeg.DecodeConfig
+	case "image/png":
+		decode, decodeConfig = png.Decode, png.DecodeConfig
+	case "image/gif":
+		decode, decodeConfig = gif.Decode, gif.DecodeConfig
+	case "image/webp":
+		decode, decodeConfig = webp.Decode, webp.DecodeConfig
+	default:
+		return nil, ErrUnsupported
+	}
+
+	cfg, err := decodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrUnsupported, err)
+	}
+	if cfg.Width <= 0 || cfg.Height <= 0 {
+		return nil, ErrUnsupported
+	}
+	if cfg.Width > maxDimension || cfg.Height > maxDimension {
+		return nil, fmt.Errorf("%w: %dx%d, at most %d pixels wide and tall", ErrTooLarge, cfg.Width, cfg.Height, maxDimension)
+	}
+	img, err := decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrUnsupported, err)
+	}
+	return img, nil
+}
+
+// Thumbnail returns a size by size copy of the largest centered square of img. Images
+// smaller than size are scaled up.
+func Thumbnail(img image.Image, size int) image.Image {
+	b := img.Bounds()
+	side := min(b.Dx(), b.Dy())
+	x, y := b.Min.X+(b.Dx()-side)/2, b.Min.Y+(b.Dy()-side)/2
+	src := image.Rect(x, y, x+side, y+side)
+
+	dst := image.NewRGBA(image.Rect(0, 0, size, size))
+	draw.CatmullRom.Scale(dst, dst.Bounds(), img, src, draw.Src, nil)
+	return dst
+}
+
+// Encode encodes img as a JPEG, or as a PNG if it has transparent pixels, and returns
+// the data with its content type.
+func Encode(img image.Image) ([]byte, string, error) {
+	var buf bytes.Buffer
+	if o, ok := img.(interface{ Opaque() bool }); ok && !o.Opaque() {
+		err := png.Encode(&buf, img)
+		return buf.Bytes(), "image/png", err
+	}
+	err := jpeg.Encode(&buf, img, &jpeg.Options{Quality: 85})
+	return buf.Bytes(), "image/jpeg", err
+}
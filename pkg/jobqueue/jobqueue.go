@@ -0,0 +1,505 @@
+// Package jobqueue is a durable background job queue kept in Redis. Jobs survive
+// restarts, can be scheduled for later, are retried with exponential backoff when
+// they fail, and end up in a dead-letter list once their retries are exhausted.
+//
+// Delivery is at least once: a job whose worker dies, or that runs longer than
+// Options.Timeout, is handed to another worker, so handlers must be idempotent.
+package jobqueue
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+
+	"go-mysql/pkg/retry"
+)
+
+// Job is a unit of work and its delivery state.
+type Job struct {
+	ID      string          `json:"id"`
+	Type    string          `json:"type"`
+	Payload json.RawMessage `json:"payload,omitempty"`
+	// Retried is how many times the job has failed and been rescheduled.
+	Retried    int       `json:"retried"`
+	MaxRetries int       `json:"max_retries"`
+	EnqueuedAt time.Time `json:"enqueued_at"`
+	// LastError and FailedAt describe the most recent failure.
+	LastError string     `json:"last_error,omitempty"`
+	FailedAt  *time.Time `json:"failed_at,omitempty"`
+	// Headers carry values of the context the job was enqueued with, see
+	// Options.Inject.
+	Headers map[string]string `json:"headers,omitempty"`
+}
+
+// Decode unmarshals the job's payload into v.
+func (j Job) Decode(v any) error {
+	return json.Unmarshal(j.Payload, v)
+}
+
+// HandlerFunc processes one job. A returned error, or a panic, fails the attempt; an
+// error marked with retry.Permanent dead-letters the job without further retries.
+type HandlerFunc func(ctx context.Context, job Job) error
+
+// Options configures a Queue. Zero values get the defaults documented on each field.
+type Options struct {
+	// Name identifies the queue; queues with the same name share their jobs. It
+	// defaults to "default".
+	Name string
+	// KeyPrefix namespaces the Redis keys, "jobs:" by default.
+	KeyPrefix string
+	// Workers is how many jobs Run processes at once, 1 by default.
+	Workers int
+	// MaxRetries is how many times a failed job is retried before it's dead-lettered,
+	// 5 by default; a negative value disables retries.
+	MaxRetries int
+	// RetryBackoff is the delay before the first retry, 10 seconds by default. It
+	// doubles with every further retry, up to MaxBackoff (an hour by default).
+	RetryBackoff time.Duration
+	MaxBackoff   time.Duration
+	// Timeout bounds a single attempt, 5 minutes by default. A job still running after
+	// Timeout is assumed lost and delivered again.
+	Timeout time.Duration
+	// PollInterval is how often idle workers check for jobs, a second by default.
+	PollInterval time.Duration
+	// MaxDead caps the dead-letter list, dropping the oldest entries; 1000 by default.
+	MaxDead int
+	// Inject, if set, records values of the context a job is enqueued with, such as
+	// a request id or trace context, in its headers. Extract puts them back into the
+	// context the job is processed with.
+	Inject  func(ctx context.Context, headers map[string]string)
+	Extract func(ctx context.Context, headers map[string]string) context.Context
+}
+
+// Queue enqueues jobs and, once Run is called, processes them.
+type Queue struct {
+	client redis.UniversalClient
+	opts   Options
+	logger *slog.Logger
+
+	// Keys; they share a hash tag so the scripts work on Redis Cluster.
+	ready     string // list of jobs waiting for a worker
+	scheduled string // sorted set of jobs by when they're due
+	active    string // sorted set of running jobs by when their attempt times out
+	dead      string // list of jobs that exhausted their retries, newest first
+
+	mu       sync.RWMutex
+	handlers map[string]HandlerFunc
+}
+
+// NewQueue returns a Queue keeping its jobs in client.
+func NewQueue(client redis.UniversalClient, opts Options, logger *slog.Logger) *Queue {
+	if opts.Name == "" {
+		opts.Name = "default"
+	}
+	if opts.KeyPrefix == "" {
+		opts.KeyPrefix = "jobs:"
+	}
+	if opts.Workers <= 0 {
+		opts.Workers = 1
+	}
+	if opts.MaxRetries < 0 {
+		opts.MaxRetries = 0
+	} else if opts.MaxRetries == 0 {
+		opts.MaxRetries = 5
+	}
+	if opts.RetryBackoff <= 0 {
+		opts.RetryBackoff = 10 * time.Second
+	}
+	if opts.MaxBackoff <= 0 {
+		opts.MaxBackoff = time.Hour
+	}
+	if opts.Timeout <= 0 {
+		opts.Timeout = 5 * time.Minute
+	}
+	if opts.PollInterval <= 0 {
+		opts.PollInterval = time.Second
+	}
+	if opts.MaxDead <= 0 {
+		opts.MaxDead = 1000
+	}
+
+	prefix := opts.KeyPrefix + "{" + opts.Name + "}:"
+	return &Queue{
+		client:    client,
+		opts:      opts,
+		logger:    logger,
+		ready:     prefix + "ready",
+		scheduled: prefix + "scheduled",
+		active:    prefix + "active",
+		dead:      prefix + "dead",
+		handlers:  make(map[string]HandlerFunc),
+	}
+}
+
+// Handle registers h for jobs of type jobType, replacing any earlier handler.
+func (q *Queue) Handle(jobType string, h HandlerFunc) {
+	q.mu.Lock()
+	q.handlers[jobType] = h
+	q.mu.Unlock()
+}
+
+// Enqueue adds a job of type jobType with payload marshalled as JSON, to run as soon
+// as a worker is free.
+func (q *Queue) Enqueue(ctx context.Context, jobType string, payload any) (Job, error) {
+	return q.EnqueueAt(ctx, time.Time{}, jobType, payload)
+}
+
+// EnqueueIn is Enqueue for a job that must not run before delay has passed.
+func (q *Queue) EnqueueIn(ctx context.Context, delay time.Duration, jobType string, payload any) (Job, error) {
+	return q.EnqueueAt(ctx, time.Now().Add(delay), jobType, payload)
+}
+
+// EnqueueAt is Enqueue for a job that must not run before at. A zero at runs it now.
+func (q *Queue) EnqueueAt(ctx context.Context, at time.Time, jobType string, payload any) (Job, error) {
+	job := Job{
+		ID:         newID(),
+		Type:       jobType,
+		MaxRetries: q.opts.MaxRetries,
+		EnqueuedAt: time.Now().UTC(),
+	}
+	if payload != nil {
+		data, err := json.Marshal(payload)
+		if err != nil {
+			return Job{}, fmt.Errorf("jobqueue: marshalling %s payload: %w", jobType, err)
+		}
+		job.Payload = data
+	}
+	if q.opts.Inject != nil {
+		job.Headers = make(map[string]string)
+		q.opts.Inject(ctx, job.Headers)
+		if len(job.Headers) == 0 {
+			job.Headers = nil
+		}
+	}
+	data, err := json.Marshal(job)
+	if err != nil {
+		return Job{}, err
+	}
+
+	if at.IsZero() || !at.After(time.Now()) {
+		err = q.client.LPush(ctx, q.ready, data).Err()
+	} else {
+		err = q.client.ZAdd(ctx, q.scheduled, &redis.Z{Score: score(at), Member: data}).Err()
+	}
+	if err != nil {
+		return Job{}, err
+	}
+	return job, nil
+}
+
+// Run processes jobs with Options.Workers workers until ctx is done, then waits for
+// the attempts in progress to finish. Attempts aren't cancelled by ctx; they're
+// bounded by Options.Timeout, and by drain: once drain is done they're cancelled and
+// their jobs put back on the ready list, without counting as a retry, for another
+// worker to pick up rather than waiting for Timeout.
+func (q *Queue) Run(ctx, drain context.Context) {
+	q.logger.Info("Processing jobs", "queue", q.opts.Name, "workers", q.opts.Workers)
+
+	var wg sync.WaitGroup
+	wg.Add(q.opts.Workers + 1)
+	go func() {
+		defer wg.Done()
+		q.forward(ctx)
+	}()
+	for i := 0; i < q.opts.Workers; i++ {
+		go func() {
+			defer wg.Done()
+			q.work(ctx, drain)
+		}()
+	}
+	wg.Wait()
+}
+
+// forward moves due scheduled jobs, and running jobs whose attempt timed out, back to
+// the ready list.
+func (q *Queue) forward(ctx context.Context) {
+	ticker := time.NewTicker(q.opts.PollInterval)
+	defer ticker.Stop()
+	for {
+		now := strconv.FormatFloat(score(time.Now()), 'f', -1, 64)
+		for _, from := range []string{q.scheduled, q.active} {
+			err := forwardScript.Run(ctx, q.client, []string{from, q.ready}, now).Err()
+			if err != nil && ctx.Err() == nil {
+				q.logger.Error("Failed to forward due jobs", "queue", q.opts.Name, "error", err)
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (q *Queue) work(ctx, drain context.Context) {
+	for ctx.Err() == nil {
+		deadline := time.Now().Add(q.opts.Timeout)
+		data, err := dequeueScript.Run(ctx, q.client, []string{q.ready, q.active}, score(deadline)).Text()
+		if err != nil {
+			if err != redis.Nil && ctx.Err() == nil {
+				q.logger.Error("Failed to dequeue job", "queue", q.opts.Name, "error", err)
+			}
+			select {
+			case <-ctx.Done():
+			case <-time.After(q.opts.PollInterval):
+			}
+			continue
+		}
+		q.process(context.WithoutCancel(ctx), drain, data, deadline)
+	}
+}
+
+// process runs the job encoded in data and records the outcome. The attempt is
+// cancelled when drain is done.
+func (q *Queue) process(ctx, drain context.Context, data string, deadline time.Time) {
+	var job Job
+	err := json.Unmarshal([]byte(data), &job)
+	if err != nil {
+		q.logger.Error("Dropping malformed job", "queue", q.opts.Name, "job", data, "error", err)
+		q.client.ZRem(ctx, q.active, data)
+		return
+	}
+
+	q.mu.RLock()
+	h, ok := q.handlers[job.Type]
+	q.mu.RUnlock()
+	if !ok {
+		err = fmt.Errorf("no handler for job type %q", job.Type)
+		job.Retried = job.MaxRetries
+	} else {
+		attemptCtx, cancel := context.WithDeadline(ctx, deadline)
+		if q.opts.Extract != nil {
+			attemptCtx = q.opts.Extract(attemptCtx, job.Headers)
+		}
+		stop := context.AfterFunc(drain, cancel)
+		err = q.call(attemptCtx, h, job)
+		stop()
+		cancel()
+	}
+	if err != nil && drain.Err() != nil {
+		q.requeueInterrupted(ctx, data, job)
+		return
+	}
+
+	if err == nil {
+		err = q.client.ZRem(ctx, q.active, data).Err()
+		if err != nil {
+			q.logger.Error("Failed to complete job", "queue", q.opts.Name, "id", job.ID, "type", job.Type, "error", err)
+		}
+		return
+	}
+	q.fail(ctx, data, job, err)
+}
+
+func (q *Queue) call(ctx context.Context, h HandlerFunc, job Job) (err error) {
+	defer func() {
+		if v := recover(); v != nil {
+			err = fmt.Errorf("panic: %v", v)
+		}
+	}()
+	return h(ctx, job)
+}
+
+// fail reschedules job with backoff, or dead-letters it once it's out of retries or
+// jobErr is permanent.
+func (q *Queue) fail(ctx context.Context, data string, job Job, jobErr error) {
+	job.LastError = jobErr.Error()
+	now := time.Now().UTC()
+	job.FailedAt = &now
+	dead := job.Retried >= job.MaxRetries || retry.IsPermanent(jobErr)
+	if !dead {
+		job.Retried++
+	}
+	updated, err := json.Marshal(job)
+	if err != nil {
+		q.logger.Error("Failed to record job failure", "queue", q.opts.Name, "id", job.ID, "type", job.Type, "error", err)
+		return
+	}
+
+	pipe := q.client.TxPipeline()
+	pipe.ZRem(ctx, q.active, data)
+	if dead {
+		pipe.LPush(ctx, q.dead, updated)
+		pipe.LTrim(ctx, q.dead, 0, int64(q.opts.MaxDead-1))
+	} else {
+		retryAt := time.Now().Add(q.backoff(job.Retried))
+		pipe.ZAdd(ctx, q.scheduled, &redis.Z{Score: score(retryAt), Member: updated})
+	}
+	_, err = pipe.Exec(ctx)
+	if err != nil {
+		q.logger.Error("Failed to record job failure", "queue", q.opts.Name, "id", job.ID, "type", job.Type, "error", err)
+		return
+	}
+
+	if dead {
+		q.logger.Error("Job failed permanently, moved to dead letters", "queue", q.opts.Name, "id", job.ID, "type", job.Type, "retried", job.Retried, "error", jobErr)
+		return
+	}
+	q.logger.Warn("Job failed, will retry", "queue", q.opts.Name, "id", job.ID, "type", job.Type, "retry", job.Retried, "error", jobErr)
+}
+
+// requeueInterrupted puts a job whose attempt was cancelled by shutdown back at the
+// front of the ready list, as it was.
+func (q *Queue) requeueInterrupted(ctx context.Context, data string, job Job) {
+	pipe := q.client.TxPipeline()
+	pipe.ZRem(ctx, q.active, data)
+	pipe.RPush(ctx, q.ready, data)
+	_, err := pipe.Exec(ctx)
+	if err != nil {
+		// It's still in the active set, so it's delivered again once its attempt times out
+		q.logger.Error("Failed to requeue job interrupted by shutdown", "queue", q.opts.Name, "id", job.ID, "type", job.Type, "error", err)
+		return
+	}
+	q.logger.Info("Requeued job interrupted by shutdown", "queue", q.opts.Name, "id", job.ID, "type", job.Type)
+}
+
+// backoff returns the delay before the given retry: RetryBackoff doubled for every
+// earlier retry, capped at MaxBackoff, with up to 20% jitter so jobs that failed
+// together don't all retry together.
+func (q *Queue) backoff(n int) time.Duration {
+	return retry.Policy{InitialDelay: q.opts.RetryBackoff, MaxDelay: q.opts.MaxBackoff, Jitter: 0.2}.Delay(n)
+}
+
+// Stats counts the jobs in each state.
+type Stats struct {
+	Ready     int64 `json:"ready"`
+	Scheduled int64 `json:"scheduled"`
+	Active    int64 `json:"active"`
+	Dead      int64 `json:"dead"`
+}
+
+func (q *Queue) Stats(ctx context.Context) (Stats, error) {
+	pipe := q.client.Pipeline()
+	ready := pipe.LLen(ctx, q.ready)
+	scheduled := pipe.ZCard(ctx, q.scheduled)
+	active := pipe.ZCard(ctx, q.active)
+	dead := pipe.LLen(ctx, q.dead)
+	_, err := pipe.Exec(ctx)
+	if err != nil {
+		return Stats{}, err
+	}
+	return Stats{Ready: ready.Val(), Scheduled: scheduled.Val(), Active: active.Val(), Dead: dead.Val()}, nil
+}
+
+// Dead returns up to limit dead-lettered jobs, most recent first.
+func (q *Queue) Dead(ctx context.Context, limit int) ([]Job, error) {
+	entries, err := q.client.LRange(ctx, q.dead, 0, int64(limit-1)).Result()
+	if err != nil {
+		return nil, err
+	}
+	jobs := make([]Job, 0, len(entries))
+	for _, data := range entries {
+		var job Job
+		if json.Unmarshal([]byte(data), &job) == nil {
+			jobs = append(jobs, job)
+		}
+	}
+	return jobs, nil
+}
+
+// Requeue moves the dead-lettered job with the given ID back to the ready list with
+// its retries reset. It reports false if there's no such job.
+func (q *Queue) Requeue(ctx context.Context, id string) (bool, error) {
+	entries, err := q.client.LRange(ctx, q.dead, 0, -1).Result()
+	if err != nil {
+		return false, err
+	}
+	for _, data := range entries {
+		var job Job
+		if json.Unmarshal([]byte(data), &job) != nil || job.ID != id {
+			continue
+		}
+		return q.requeue(ctx, data, job)
+	}
+	return false, nil
+}
+
+// RequeueAll moves every dead-lettered job back to the ready list and returns how
+// many were moved.
+func (q *Queue) RequeueAll(ctx context.Context) (int, error) {
+	entries, err := q.client.LRange(ctx, q.dead, 0, -1).Result()
+	if err != nil {
+		return 0, err
+	}
+	moved := 0
+	for _, data := range entries {
+		var job Job
+		if json.Unmarshal([]byte(data), &job) != nil {
+			continue
+		}
+		ok, err := q.requeue(ctx, data, job)
+		if err != nil {
+			return moved, err
+		}
+		if ok {
+			moved++
+		}
+	}
+	return moved, nil
+}
+
+func (q *Queue) requeue(ctx context.Context, data string, job Job) (bool, error) {
+	job.Retried = 0
+	updated, err := json.Marshal(job)
+	if err != nil {
+		return false, err
+	}
+	moved, err := requeueScript.Run(ctx, q.client, []string{q.dead, q.ready}, data, updated).Int()
+	if err != nil {
+		return false, err
+	}
+	if moved == 1 {
+		q.logger.Info("Requeued dead job", "queue", q.opts.Name, "id", job.ID, "type", job.Type)
+	}
+	return moved == 1, nil
+}
+
+// dequeueScript pops the oldest ready job and records it as running until ARGV[1].
+var dequeueScript = redis.NewScript(`
+local job = redis.call('RPOP', KEYS[1])
+if job then
+	redis.call('ZADD', KEYS[2], ARGV[1], job)
+end
+return job
+`)
+
+// forwardScript moves up to 100 members of the sorted set KEYS[1] scored at or below
+// ARGV[1] to the ready list KEYS[2].
+var forwardScript = redis.NewScript(`
+local due = redis.call('ZRANGEBYSCORE', KEYS[1], '-inf', ARGV[1], 'LIMIT', 0, 100)
+for _, job in ipairs(due) do
+	redis.call('ZREM', KEYS[1], job)
+	redis.call('LPUSH', KEYS[2], job)
+end
+return #due
+`)
+
+// requeueScript replaces ARGV[1] in the dead list KEYS[1] with ARGV[2] in the ready
+// list KEYS[2], unless another caller got there first.
+var requeueScript = redis.NewScript(`
+if redis.call('LREM', KEYS[1], 1, ARGV[1]) == 1 then
+	redis.call('LPUSH', KEYS[2], ARGV[2])
+	return 1
+end
+return 0
+`)
+
+// score converts t to a sorted set score in milliseconds.
+func score(t time.Time) float64 {
+	return float64(t.UnixMilli())
+}
+
+func newID() string {
+	b := make([]byte, 12)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
@@ -0,0 +1,76 @@
+// Package lifecycle runs the long-lived components of a service, such as servers and
+// background workers, as a group: the first one to fail marks the group failed, so the
+// service can shut the others down in order rather than carry on without it.
+package lifecycle
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"runtime/debug"
+	"sync"
+
+	"golang.org/x/sync/errgroup"
+)
+
+// Group runs components and records the first one that fails.
+type Group struct {
+	logger *slog.Logger
+	group  *errgroup.Group
+	failed context.Context
+
+	mu  sync.Mutex
+	err error
+}
+
+// New returns an empty group.
+func New(logger *slog.Logger) *Group {
+	g, failed := errgroup.WithContext(context.Background())
+	return &Group{logger: logger, group: g, failed: failed}
+}
+
+// Go starts the component called name, which runs until run returns. run must return
+// once the context the caller gave it is done; the group doesn't cancel it. A
+// component returning an error, or panicking, fails the group.
+func (g *Group) Go(name string, run func() error) {
+	g.group.Go(func() (err error) {
+		defer func() {
+			if v := recover(); v != nil {
+				err = fmt.Errorf("panic: %v", v)
+				g.logger.Error("Component panicked", "component", name, "panic", v, "stack", string(debug.Stack()))
+			}
+			if err != nil {
+				err = fmt.Errorf("%s: %w", name, err)
+				g.fail(err)
+			}
+		}()
+		return run()
+	})
+}
+
+func (g *Group) fail(err error) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if g.err == nil {
+		g.err = err
+		g.logger.Error("Component failed", "error", err)
+	}
+}
+
+// Failed returns a channel closed once a component failed, or Wait returned.
+func (g *Group) Failed() <-chan struct{} {
+	return g.failed.Done()
+}
+
+// Err returns the error of the first component that failed, or nil.
+func (g *Group) Err() error {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	return g.err
+}
+
+// Wait waits for every component to return and returns Err.
+func (g *Group) Wait() error {
+	g.group.Wait()
+	return g.Err()
+}
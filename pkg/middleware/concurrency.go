@@ -0,0 +1,43 @@
+package middleware
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// ConcurrencyLimit caps the requests in flight through the middleware at limit. A request
+// arriving when all slots are taken waits up to wait for one to free up, then gets
+// 503 Service Unavailable with a Retry-After header, so a traffic spike is shed at the
+// door instead of piling up on the database. onShed, if not nil, is called for every
+// rejected request.
+func ConcurrencyLimit(limit int, wait time.Duration, onShed func(*http.Request)) Middleware {
+	slots := make(chan struct{}, limit)
+	retryAfter := strconv.Itoa(max(1, int(wait.Round(time.Second).Seconds())))
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			select {
+			case slots <- struct{}{}:
+			default:
+				timer := time.NewTimer(wait)
+				select {
+				case slots <- struct{}{}:
+					timer.Stop()
+				case <-timer.C:
+					if onShed != nil {
+						onShed(r)
+					}
+					w.Header().Set("Retry-After", retryAfter)
+					http.Error(w, "Server is overloaded", http.StatusServiceUnavailable)
+					return
+				case <-r.Context().Done():
+					timer.Stop()
+					return
+				}
+			}
+			defer func() { <-slots }()
+			next.ServeHTTP(w, r)
+		})
+	}
+}
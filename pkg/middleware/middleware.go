@@ -0,0 +1,67 @@
+// Package middleware composes HTTP middleware into ordered chains and applies them to
+// groups of routes, so cross-cutting concerns are declared once per group instead of
+// being wired around each handler.
+package middleware
+
+import "net/http"
+
+// Middleware wraps a handler with extra behaviour.
+type Middleware func(http.Handler) http.Handler
+
+// Chain is an ordered list of middleware. The first one sees requests first.
+type Chain []Middleware
+
+// New returns a chain of mws, skipping nil entries so optional middleware can be
+// listed unconditionally.
+func New(mws ...Middleware) Chain {
+	return Chain(nil).Append(mws...)
+}
+
+// Append returns a new chain with mws added after c's middleware. c is not modified.
+func (c Chain) Append(mws ...Middleware) Chain {
+	chain := make(Chain, 0, len(c)+len(mws))
+	chain = append(chain, c...)
+	for _, mw := range mws {
+		if mw != nil {
+			chain = append(chain, mw)
+		}
+	}
+	return chain
+}
+
+// Then wraps h in every middleware of the chain.
+func (c Chain) Then(h http.Handler) http.Handler {
+	for i := len(c) - 1; i >= 0; i-- {
+		h = c[i](h)
+	}
+	return h
+}
+
+// ThenFunc is Then for a handler function.
+func (c Chain) ThenFunc(f http.HandlerFunc) http.Handler {
+	return c.Then(f)
+}
+
+// Group registers routes on a mux, each wrapped in the group's chain.
+type Group struct {
+	mux   *http.ServeMux
+	chain Chain
+}
+
+// NewGroup returns a group registering routes on mux behind chain.
+func NewGroup(mux *http.ServeMux, chain Chain) *Group {
+	return &Group{mux: mux, chain: chain}
+}
+
+// With returns a group on the same mux whose chain adds mws to g's.
+func (g *Group) With(mws ...Middleware) *Group {
+	return &Group{mux: g.mux, chain: g.chain.Append(mws...)}
+}
+
+func (g *Group) Handle(pattern string, h http.Handler) {
+	g.mux.Handle(pattern, g.chain.Then(h))
+}
+
+func (g *Group) HandleFunc(pattern string, f http.HandlerFunc) {
+	g.mux.Handle(pattern, g.chain.ThenFunc(f))
+}
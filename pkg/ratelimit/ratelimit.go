@@ -0,0 +1,105 @@
+// Package ratelimit implements Redis-backed rate limiters sharing one Limiter interface.
+// Every algorithm runs as a single Lua script, so concurrent requests from any number of
+// app instances are counted atomically, and uses Redis' clock rather than the callers'.
+package ratelimit
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+)
+
+// Result is the outcome of one Allow call.
+type Result struct {
+	Allowed bool
+	// Remaining is how many more requests would be allowed right now.
+	Remaining int
+	// RetryAfter is how long to wait before the next request can succeed; zero if Allowed.
+	RetryAfter time.Duration
+}
+
+// Limiter decides whether the caller identified by key may make another request.
+type Limiter interface {
+	Allow(ctx context.Context, key string) (Result, error)
+}
+
+// TokenBucket refills at rate tokens per second up to burst; each request takes a token.
+// It allows short bursts while enforcing a steady long-term rate.
+type TokenBucket struct {
+	client redis.UniversalClient
+	prefix string
+	rate   float64
+	burst  int
+}
+
+// NewTokenBucket returns a token bucket limiter storing its state under prefix+key.
+func NewTokenBucket(client redis.UniversalClient, prefix string, rate float64, burst int) *TokenBucket {
+	return &TokenBucket{client: client, prefix: prefix, rate: rate, burst: burst}
+}
+
+func (l *TokenBucket) Allow(ctx context.Context, key string) (Result, error) {
+	return run(ctx, l.client, tokenBucketScript, l.prefix+key, l.rate, l.burst)
+}
+
+// FixedWindow allows limit requests per window, counted from the first request of each
+// window. It is the cheapest algorithm but lets up to twice the limit through around a
+// window boundary.
+type FixedWindow struct {
+	client redis.UniversalClient
+	prefix string
+	limit  int
+	window time.Duration
+}
+
+// NewFixedWindow returns a fixed window limiter storing its counter under prefix+key.
+func NewFixedWindow(client redis.UniversalClient, prefix string, limit int, window time.Duration) *FixedWindow {
+	return &FixedWindow{client: client, prefix: prefix, limit: limit, window: window}
+}
+
+func (l *FixedWindow) Allow(ctx context.Context, key string) (Result, error) {
+	return run(ctx, l.client, fixedWindowScript, l.prefix+key, l.limit, l.window.Milliseconds())
+}
+
+// SlidingWindowLog allows limit requests in any window-long interval by logging each
+// request's timestamp in a sorted set. It is exact, at the cost of memory per request.
+type SlidingWindowLog struct {
+	client redis.UniversalClient
+	prefix string
+	limit  int
+	window time.Duration
+}
+
+// NewSlidingWindowLog returns a sliding window log limiter storing its log under prefix+key.
+func NewSlidingWindowLog(client redis.UniversalClient, prefix string, limit int, window time.Duration) *SlidingWindowLog {
+	return &SlidingWindowLog{client: client, prefix: prefix, limit: limit, window: window}
+}
+
+func (l *SlidingWindowLog) Allow(ctx context.Context, key string) (Result, error) {
+	// Each request needs a distinct member even if two arrive in the same millisecond
+	buf := make([]byte, 8)
+	_, err := rand.Read(buf)
+	if err != nil {
+		return Result{}, err
+	}
+	return run(ctx, l.client, slidingWindowLogScript, l.prefix+key, l.limit, l.window.Milliseconds(), hex.EncodeToString(buf))
+}
+
+// run executes a limiter script, which must return {allowed, remaining, retry_after_ms}.
+func run(ctx context.Context, client redis.UniversalClient, script *redis.Script, key string, args ...any) (Result, error) {
+	vals, err := script.Run(ctx, client, []string{key}, args...).Int64Slice()
+	if err != nil {
+		return Result{}, err
+	}
+	if len(vals) != 3 {
+		return Result{}, fmt.Errorf("ratelimit: unexpected script result %v", vals)
+	}
+	return Result{
+		Allowed:    vals[0] == 1,
+		Remaining:  int(vals[1]),
+		RetryAfter: time.Duration(vals[2]) * time.Millisecond,
+	}, nil
+}
@@ -0,0 +1,37 @@
+package ratelimit
+
+import (
+	"embed"
+
+	"github.com/go-redis/redis/v8"
+)
+
+// The limiters' Lua scripts live in scripts/*.lua and are embedded in the binary. They
+// are run with EVALSHA, falling back to EVAL when Redis answers NOSCRIPT; Scripts lets
+// them be loaded into Redis' script cache up front.
+//
+//go:embed scripts/*.lua
+var scriptFiles embed.FS
+
+var (
+	tokenBucketScript      = loadScript("token_bucket")
+	fixedWindowScript      = loadScript("fixed_window")
+	slidingWindowLogScript = loadScript("sliding_window_log")
+)
+
+func loadScript(name string) *redis.Script {
+	src, err := scriptFiles.ReadFile("scripts/" + name + ".lua")
+	if err != nil {
+		panic(err)
+	}
+	return redis.NewScript(string(src))
+}
+
+// Scripts returns the limiters' scripts by name.
+func Scripts() map[string]*redis.Script {
+	return map[string]*redis.Script{
+		"token_bucket":       tokenBucketScript,
+		"fixed_window":       fixedWindowScript,
+		"sliding_window_log": slidingWindowLogScript,
+	}
+}
@@ -0,0 +1,12 @@
+-- Counts a request in the window in KEYS[1], allowing ARGV[1] per window of ARGV[2]
+-- milliseconds. Returns {allowed, remaining, retry_after_ms}.
+local limit = tonumber(ARGV[1])
+local count = redis.call("INCR", KEYS[1])
+if count == 1 then
+	redis.call("PEXPIRE", KEYS[1], ARGV[2])
+end
+local ttl = redis.call("PTTL", KEYS[1])
+if count > limit then
+	return {0, 0, ttl}
+end
+return {1, limit - count, 0}
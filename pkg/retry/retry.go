@@ -0,0 +1,164 @@
+// Package retry runs operations that can fail transiently again with exponential
+// backoff and jitter, until they succeed, fail in a way retrying can't fix, run out of
+// attempts, or their context is done.
+//
+// Long-running loops that back off between iterations rather than retry a single
+// operation, such as reconnecting consumers, use a Backoff instead.
+package retry
+
+import (
+	"context"
+	"errors"
+	"math/rand/v2"
+	"time"
+)
+
+// Policy says how often and how fast an operation is retried. The zero value makes
+// three attempts 100ms then 200ms apart, retrying every error but permanent ones.
+type Policy struct {
+	// MaxAttempts is how many times the operation runs at most, first attempt
+	// included; 3 if zero, unlimited if negative.
+	MaxAttempts int
+	// InitialDelay is the delay before the first retry, 100ms if zero. It's
+	// multiplied by Multiplier (2 if zero) for every further retry, up to MaxDelay
+	// (a minute if zero).
+	InitialDelay time.Duration
+	MaxDelay     time.Duration
+	Multiplier   float64
+	// Jitter is the fraction of each delay that's random, so operations that failed
+	// together don't all retry together: 0.2 makes delays vary between 80% and 100%
+	// of their nominal value. Zero means no jitter.
+	Jitter float64
+	// Retryable reports whether an error is worth retrying. Nil means every error
+	// but those wrapped with Permanent.
+	Retryable func(err error) bool
+	// OnRetry, if set, is called before waiting for each retry, with the attempt
+	// that failed (starting at 1), its error and the delay; callers log with it.
+	OnRetry func(attempt int, err error, delay time.Duration)
+}
+
+// Do runs fn until it succeeds or p gives up, and returns its last error, unwrapped
+// from Permanent. If ctx is done while waiting for a retry, the last error is
+// returned as well; fn is expected to honor ctx itself.
+func Do(ctx context.Context, p Policy, fn func(ctx context.Context) error) error {
+	maxAttempts := p.MaxAttempts
+	if maxAttempts == 0 {
+		maxAttempts = 3
+	}
+	for attempt := 1; ; attempt++ {
+		err := fn(ctx)
+		if err == nil {
+			return nil
+		}
+		if !p.retryable(err) {
+			return unwrapPermanent(err)
+		}
+		if maxAttempts > 0 && attempt >= maxAttempts {
+			return err
+		}
+		delay := p.Delay(attempt)
+		if p.OnRetry != nil {
+			p.OnRetry(attempt, err, delay)
+		}
+		if Sleep(ctx, delay) != nil {
+			return err
+		}
+	}
+}
+
+// Delay returns the delay before the given retry, starting at 1.
+func (p Policy) Delay(retry int) time.Duration {
+	initial, maxDelay, multiplier := p.InitialDelay, p.MaxDelay, p.Multiplier
+	if initial <= 0 {
+		initial = 100 * time.Millisecond
+	}
+	if maxDelay <= 0 {
+		maxDelay = time.Minute
+	}
+	if multiplier < 1 {
+		multiplier = 2
+	}
+
+	d := float64(initial)
+	for i := 1; i < retry && d < float64(maxDelay); i++ {
+		d *= multiplier
+	}
+	d = min(d, float64(maxDelay))
+	if p.Jitter > 0 {
+		d -= d * min(p.Jitter, 1) * rand.Float64()
+	}
+	return time.Duration(d)
+}
+
+func (p Policy) retryable(err error) bool {
+	if IsPermanent(err) {
+		return false
+	}
+	return p.Retryable == nil || p.Retryable(err)
+}
+
+// Backoff hands out the growing delays of a Policy to a loop that waits between
+// failed iterations, and starts over when one succeeds. It's not safe for concurrent
+// use.
+type Backoff struct {
+	policy Policy
+	retry  int
+}
+
+// Backoff returns a Backoff following p's delays. p.MaxAttempts isn't used.
+func (p Policy) Backoff() *Backoff {
+	return &Backoff{policy: p}
+}
+
+// Next returns the delay to wait after another failure.
+func (b *Backoff) Next() time.Duration {
+	b.retry++
+	return b.policy.Delay(b.retry)
+}
+
+// Reset starts the delays over, after a success.
+func (b *Backoff) Reset() {
+	b.retry = 0
+}
+
+// Sleep waits for d, or until ctx is done and then returns its error.
+func Sleep(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+// permanentError marks an error retrying can't fix.
+type permanentError struct {
+	err error
+}
+
+func (e permanentError) Error() string { return e.err.Error() }
+func (e permanentError) Unwrap() error { return e.err }
+
+// Permanent wraps err so it isn't retried, whatever the policy; for instance a
+// request the other side rejected as invalid. It returns nil if err is nil.
+func Permanent(err error) error {
+	if err == nil {
+		return nil
+	}
+	return permanentError{err: err}
+}
+
+// IsPermanent reports whether err, or an error it wraps, was marked with Permanent.
+func IsPermanent(err error) bool {
+	var p permanentError
+	return errors.As(err, &p)
+}
+
+func unwrapPermanent(err error) error {
+	if p, ok := err.(permanentError); ok {
+		return p.err
+	}
+	return err
+}
@@ -0,0 +1,105 @@
+// Package saga runs operations made of several steps that can't share a transaction,
+// such as a database write followed by calls to other systems. Each step can register
+// a compensation; when a step fails, the compensations of the steps that completed run
+// in reverse order, undoing as much of the operation as can be undone.
+package saga
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"runtime/debug"
+)
+
+// Func is the action or the compensation of a step.
+type Func func(ctx context.Context) error
+
+type step struct {
+	name       string
+	action     Func
+	compensate Func
+}
+
+// Saga is a sequence of steps. Build it with New and Step, then Run it once.
+type Saga struct {
+	name   string
+	steps  []step
+	logger *slog.Logger
+}
+
+// New returns an empty saga. name identifies it in logs and errors.
+func New(name string, logger *slog.Logger) *Saga {
+	return &Saga{name: name, logger: logger}
+}
+
+// Step appends a step running action. compensate undoes it if a later step fails; nil
+// means there's nothing to undo, such as for the last step or one that can't be
+// taken back, like sending an email. Steps usually share state through variables the
+// functions close over.
+func (s *Saga) Step(name string, action, compensate Func) *Saga {
+	s.steps = append(s.steps, step{name: name, action: action, compensate: compensate})
+	return s
+}
+
+// Error is returned by Run when a step fails.
+type Error struct {
+	Saga string
+	// Step names the step that failed, and Err is its error.
+	Step string
+	Err  error
+	// CompensationErrs holds the errors of compensations that failed too, which may
+	// have left the operation half done.
+	CompensationErrs []error
+}
+
+func (e *Error) Error() string {
+	msg := fmt.Sprintf("%s: step %s: %v", e.Saga, e.Step, e.Err)
+	if len(e.CompensationErrs) > 0 {
+		msg += fmt.Sprintf(" (%d compensations failed: %v)", len(e.CompensationErrs), errors.Join(e.CompensationErrs...))
+	}
+	return msg
+}
+
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// Run runs the steps in order. If one fails or panics, the compensations of the steps
+// before it run in reverse order and Run returns an *Error. Compensations run even if
+// ctx is cancelled, since that's often why the step failed.
+func (s *Saga) Run(ctx context.Context) error {
+	for i, st := range s.steps {
+		err := call(ctx, st.action)
+		if err == nil {
+			continue
+		}
+
+		sagaErr := &Error{Saga: s.name, Step: st.name, Err: err}
+		s.logger.Warn("Saga step failed, compensating", "saga", s.name, "step", st.name, "error", err)
+		compensateCtx := context.WithoutCancel(ctx)
+		for j := i - 1; j >= 0; j-- {
+			done := s.steps[j]
+			if done.compensate == nil {
+				continue
+			}
+			err := call(compensateCtx, done.compensate)
+			if err != nil {
+				s.logger.Error("Saga compensation failed", "saga", s.name, "step", done.name, "error", err)
+				sagaErr.CompensationErrs = append(sagaErr.CompensationErrs, fmt.Errorf("compensating %s: %w", done.name, err))
+			}
+		}
+		return sagaErr
+	}
+	return nil
+}
+
+// call runs fn, turning a panic into an error.
+func call(ctx context.Context, fn Func) (err error) {
+	defer func() {
+		if v := recover(); v != nil {
+			err = fmt.Errorf("panic: %v\n%s", v, debug.Stack())
+		}
+	}()
+	return fn(ctx)
+}
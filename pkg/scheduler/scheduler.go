@@ -0,0 +1,193 @@
+// Package scheduler runs recurring jobs in-process on cron-style schedules. A job
+// never overlaps with itself: an activation that comes while the previous run is still
+// going is skipped. Each job's last run is kept for status reporting.
+package scheduler
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"runtime/debug"
+	"sort"
+	"sync"
+	"time"
+)
+
+// Func is the work a job does. ctx is cancelled when the scheduler stops waiting for
+// runs in progress, see Run.
+type Func func(ctx context.Context) error
+
+// Status describes a job and its most recent run.
+type Status struct {
+	Name     string `json:"name"`
+	Schedule string `json:"schedule"`
+	Running  bool   `json:"running"`
+	// NextRun is zero while the scheduler isn't running.
+	NextRun      time.Time     `json:"next_run"`
+	LastStart    time.Time     `json:"last_start"`
+	LastDuration time.Duration `json:"last_duration"`
+	LastError    string        `json:"last_error,omitempty"`
+	Runs         int64         `json:"runs"`
+	Failures     int64         `json:"failures"`
+	// Skipped counts activations dropped because the previous run hadn't finished.
+	Skipped int64 `json:"skipped"`
+}
+
+type job struct {
+	spec     string
+	schedule Schedule
+	fn       Func
+
+	// Guarded by Scheduler.mu
+	status Status
+}
+
+// Scheduler runs the jobs added to it once Run is called.
+type Scheduler struct {
+	logger *slog.Logger
+
+	mu   sync.Mutex
+	jobs map[string]*job
+}
+
+func New(logger *slog.Logger) *Scheduler {
+	return &Scheduler{logger: logger, jobs: make(map[string]*job)}
+}
+
+// Add registers fn to run on the schedule described by spec, see Parse. Jobs must be
+// added before Run is called.
+func (s *Scheduler) Add(name, spec string, fn Func) error {
+	schedule, err := Parse(spec)
+	if err != nil {
+		return err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.jobs[name]; ok {
+		return fmt.Errorf("scheduler: job %q already added", name)
+	}
+	s.jobs[name] = &job{
+		spec:     spec,
+		schedule: schedule,
+		fn:       fn,
+		status:   Status{Name: name, Schedule: spec},
+	}
+	return nil
+}
+
+// Run starts every job's schedule and blocks until ctx is done and the runs in
+// progress have returned. Runs aren't cancelled by ctx, so they can finish, but by
+// drain; a cancelled run is logged and left for the next activation to redo.
+func (s *Scheduler) Run(ctx, drain context.Context) {
+	s.mu.Lock()
+	jobs := make(map[string]*job, len(s.jobs))
+	for name, j := range s.jobs {
+		jobs[name] = j
+	}
+	s.mu.Unlock()
+
+	var wg sync.WaitGroup
+	for name, j := range jobs {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			s.loop(ctx, drain, name, j)
+		}()
+		s.logger.Info("Scheduled job", "job", name, "schedule", j.spec)
+	}
+	wg.Wait()
+}
+
+// loop waits for each activation of j and starts a run unless one is in progress.
+func (s *Scheduler) loop(ctx, drain context.Context, name string, j *job) {
+	var runs sync.WaitGroup
+	defer runs.Wait()
+	for {
+		next := j.schedule.Next(time.Now())
+		if next.IsZero() {
+			s.logger.Warn("Scheduled job will never run again", "job", name, "schedule", j.spec)
+			return
+		}
+		s.mu.Lock()
+		j.status.NextRun = next
+		s.mu.Unlock()
+
+		timer := time.NewTimer(time.Until(next))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return
+		case <-timer.C:
+		}
+
+		s.mu.Lock()
+		if j.status.Running {
+			j.status.Skipped++
+			s.mu.Unlock()
+			s.logger.Warn("Skipped scheduled job, previous run still in progress", "job", name)
+			continue
+		}
+		j.status.Running = true
+		j.status.LastStart = time.Now()
+		s.mu.Unlock()
+
+		runs.Add(1)
+		go func() {
+			defer runs.Done()
+			s.run(ctx, drain, name, j)
+		}()
+	}
+}
+
+func (s *Scheduler) run(ctx, drain context.Context, name string, j *job) {
+	runCtx, cancel := context.WithCancel(context.WithoutCancel(ctx))
+	defer cancel()
+	stop := context.AfterFunc(drain, cancel)
+	defer stop()
+
+	start := time.Now()
+	err := call(runCtx, j.fn)
+	duration := time.Since(start)
+
+	s.mu.Lock()
+	j.status.Running = false
+	j.status.LastDuration = duration
+	j.status.Runs++
+	j.status.LastError = ""
+	if err != nil {
+		j.status.Failures++
+		j.status.LastError = err.Error()
+	}
+	s.mu.Unlock()
+
+	if err != nil && drain.Err() != nil {
+		s.logger.Warn("Scheduled job interrupted by shutdown", "job", name, "duration", duration, "error", err)
+		return
+	}
+	if err != nil {
+		s.logger.Error("Scheduled job failed", "job", name, "duration", duration, "error", err)
+		return
+	}
+	s.logger.Debug("Scheduled job finished", "job", name, "duration", duration)
+}
+
+func call(ctx context.Context, fn Func) (err error) {
+	defer func() {
+		if v := recover(); v != nil {
+			err = fmt.Errorf("panic: %v\n%s", v, debug.Stack())
+		}
+	}()
+	return fn(ctx)
+}
+
+// Status returns every job's status, ordered by name.
+func (s *Scheduler) Status() []Status {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	statuses := make([]Status, 0, len(s.jobs))
+	for _, j := range s.jobs {
+		statuses = append(statuses, j.status)
+	}
+	sort.Slice(statuses, func(i, k int) bool { return statuses[i].Name < statuses[k].Name })
+	return statuses
+}
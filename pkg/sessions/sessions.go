@@ -0,0 +1,247 @@
+// Package sessions stores HTTP sessions in Redis. The browser only holds a random
+// session ID in a cookie; values and one-shot flash messages live server side under
+// a key that expires MaxAge after the session was last saved or touched.
+package sessions
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+)
+
+// ErrNotFound is returned by Load when the request has no session cookie or the
+// session has expired or been destroyed.
+var ErrNotFound = errors.New("sessions: session not found")
+
+// Options configures the session cookie and storage. Zero values get the defaults
+// documented on each field.
+type Options struct {
+	// CookieName defaults to "session_id".
+	CookieName string
+	// Path defaults to "/".
+	Path   string
+	Domain string
+	// MaxAge is the idle lifetime of a session, 24 hours by default.
+	MaxAge   time.Duration
+	Secure   bool
+	HttpOnly bool
+	// SameSite defaults to http.SameSiteLaxMode.
+	SameSite http.SameSite
+	// KeyPrefix namespaces the Redis keys, "session:" by default.
+	KeyPrefix string
+}
+
+// Store creates, loads and saves sessions.
+type Store struct {
+	client redis.UniversalClient
+	opts   Options
+}
+
+// NewStore returns a Store keeping sessions in client.
+func NewStore(client redis.UniversalClient, opts Options) *Store {
+	if opts.CookieName == "" {
+		opts.CookieName = "session_id"
+	}
+	if opts.Path == "" {
+		opts.Path = "/"
+	}
+	if opts.MaxAge <= 0 {
+		opts.MaxAge = 24 * time.Hour
+	}
+	if opts.SameSite == 0 {
+		opts.SameSite = http.SameSiteLaxMode
+	}
+	if opts.KeyPrefix == "" {
+		opts.KeyPrefix = "session:"
+	}
+	return &Store{client: client, opts: opts}
+}
+
+// Session is the server-side state of one browser session.
+type Session struct {
+	ID      string            `json:"-"`
+	Values  map[string]string `json:"values"`
+	Flashes []string          `json:"flashes,omitempty"`
+}
+
+// AddFlash queues a message to be shown once, e.g. after a redirect.
+func (s *Session) AddFlash(message string) {
+	s.Flashes = append(s.Flashes, message)
+}
+
+// PopFlashes returns the queued flash messages and clears them.
+// Save the session afterwards so they aren't shown again.
+func (s *Session) PopFlashes() []string {
+	flashes := s.Flashes
+	s.Flashes = nil
+	return flashes
+}
+
+// New creates an empty session, stores it, and sets its cookie on w.
+func (st *Store) New(ctx context.Context, w http.ResponseWriter) (*Session, error) {
+	id, err := newID()
+	if err != nil {
+		return nil, err
+	}
+	sess := &Session{ID: id, Values: map[string]string{}}
+	return sess, st.Save(ctx, w, sess)
+}
+
+// Load returns the session named by r's cookie, or ErrNotFound.
+func (st *Store) Load(ctx context.Context, r *http.Request) (*Session, error) {
+	cookie, err := r.Cookie(st.opts.CookieName)
+	if err != nil || cookie.Value == "" {
+		return nil, ErrNotFound
+	}
+
+	data, err := st.client.Get(ctx, st.key(cookie.Value)).Bytes()
+	if err == redis.Nil {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	sess := &Session{ID: cookie.Value}
+	err = json.Unmarshal(data, sess)
+	if err != nil {
+		return nil, err
+	}
+	if sess.Values == nil {
+		sess.Values = map[string]string{}
+	}
+	return sess, nil
+}
+
+// LoadOrNew loads the request's session, creating one if there is none.
+func (st *Store) LoadOrNew(ctx context.Context, w http.ResponseWriter, r *http.Request) (*Session, error) {
+	sess, err := st.Load(ctx, r)
+	if err == ErrNotFound {
+		return st.New(ctx, w)
+	}
+	return sess, err
+}
+
+// Save stores sess and restarts its idle lifetime.
+func (st *Store) Save(ctx context.Context, w http.ResponseWriter, sess *Session) error {
+	data, err := json.Marshal(sess)
+	if err != nil {
+		return err
+	}
+	err = st.client.Set(ctx, st.key(sess.ID), data, st.opts.MaxAge).Err()
+	if err != nil {
+		return err
+	}
+	st.setCookie(w, sess.ID, st.opts.MaxAge)
+	return nil
+}
+
+// Touch restarts the session's idle lifetime without rewriting its values.
+func (st *Store) Touch(ctx context.Context, w http.ResponseWriter, sess *Session) error {
+	ok, err := st.client.Expire(ctx, st.key(sess.ID), st.opts.MaxAge).Result()
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return ErrNotFound
+	}
+	st.setCookie(w, sess.ID, st.opts.MaxAge)
+	return nil
+}
+
+// Destroy deletes the session and tells the browser to drop its cookie.
+func (st *Store) Destroy(ctx context.Context, w http.ResponseWriter, sess *Session) error {
+	err := st.client.Del(ctx, st.key(sess.ID)).Err()
+	if err != nil {
+		return err
+	}
+	st.setCookie(w, "", -1)
+	return nil
+}
+
+// Cleanup deletes sessions that would otherwise never expire, such as ones whose TTL
+// was removed by hand, and returns how many it deleted. Sessions saved by a Store
+// always carry a TTL, so this is housekeeping rather than part of normal expiry.
+func (st *Store) Cleanup(ctx context.Context) (int, error) {
+	match := escapeGlob(st.opts.KeyPrefix) + "*"
+	if cluster, ok := st.client.(*redis.ClusterClient); ok {
+		var mu sync.Mutex
+		total := 0
+		err := cluster.ForEachMaster(ctx, func(ctx context.Context, node *redis.Client) error {
+			n, err := deletePersistent(ctx, node, match)
+			mu.Lock()
+			total += n
+			mu.Unlock()
+			return err
+		})
+		return total, err
+	}
+	return deletePersistent(ctx, st.client, match)
+}
+
+// deletePersistent SCANs for keys matching match and deletes those without a TTL.
+func deletePersistent(ctx context.Context, client redis.UniversalClient, match string) (int, error) {
+	deleted := 0
+	iter := client.Scan(ctx, 0, match, 100).Iterator()
+	for iter.Next(ctx) {
+		ttl, err := client.TTL(ctx, iter.Val()).Result()
+		if err != nil {
+			return deleted, err
+		}
+		// -1 means the key exists without an expiry; -2 that it's already gone
+		if ttl != -1 {
+			continue
+		}
+		err = client.Del(ctx, iter.Val()).Err()
+		if err != nil {
+			return deleted, err
+		}
+		deleted++
+	}
+	return deleted, iter.Err()
+}
+
+// escapeGlob escapes the characters MATCH patterns treat specially.
+func escapeGlob(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `*`, `\*`, `?`, `\?`, `[`, `\[`, `]`, `\]`).Replace(s)
+}
+
+func (st *Store) key(id string) string {
+	return st.opts.KeyPrefix + id
+}
+
+// setCookie sets the session cookie; a negative maxAge deletes it.
+func (st *Store) setCookie(w http.ResponseWriter, value string, maxAge time.Duration) {
+	cookie := &http.Cookie{
+		Name:     st.opts.CookieName,
+		Value:    value,
+		Path:     st.opts.Path,
+		Domain:   st.opts.Domain,
+		Secure:   st.opts.Secure,
+		HttpOnly: st.opts.HttpOnly,
+		SameSite: st.opts.SameSite,
+	}
+	if maxAge < 0 {
+		cookie.MaxAge = -1
+	} else {
+		cookie.MaxAge = int(maxAge.Seconds())
+	}
+	http.SetCookie(w, cookie)
+}
+
+func newID() (string, error) {
+	buf := make([]byte, 32)
+	_, err := rand.Read(buf)
+	if err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(buf), nil
+}
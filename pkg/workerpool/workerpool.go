@@ -0,0 +1,233 @@
+// Package workerpool runs background tasks on a fixed number of goroutines fed by a
+// bounded queue, so bursts of asynchronous work can't spawn unbounded goroutines or
+// pile up without limit.
+package workerpool
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"runtime/debug"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+var (
+	// ErrQueueFull is returned by Submit when every worker is busy and the queue is full.
+	ErrQueueFull = errors.New("worker pool queue is full")
+	// ErrClosed is returned by Submit once Close has been called.
+	ErrClosed = errors.New("worker pool is closed")
+)
+
+// Task is a unit of work. Its context carries the values of the context it was
+// submitted with, but isn't cancelled when that one is; it's cancelled when Shutdown
+// gives up waiting.
+type Task func(ctx context.Context) error
+
+type job struct {
+	ctx      context.Context
+	name     string
+	task     Task
+	fallback Task
+}
+
+// fallbackTimeout bounds a fallback run in place of a task abandoned at shutdown.
+const fallbackTimeout = 5 * time.Second
+
+// Pool runs submitted tasks in the background. Failed and panicking tasks are logged
+// and counted; nothing is retried.
+type Pool struct {
+	logger  *slog.Logger
+	queue   chan job
+	workers int
+	wg      sync.WaitGroup
+
+	// abandoned is cancelled when Shutdown gives up; running tasks are cancelled and
+	// queued ones don't start.
+	abandoned context.Context
+	abandon   context.CancelFunc
+
+	mu     sync.RWMutex
+	closed bool
+
+	busy      atomic.Int64
+	completed atomic.Int64
+	failed    atomic.Int64
+	rejected  atomic.Int64
+	fellBack  atomic.Int64
+	dropped   atomic.Int64
+}
+
+// New starts workers goroutines taking tasks from a queue holding up to queueSize
+// tasks that haven't started yet.
+func New(workers, queueSize int, logger *slog.Logger) *Pool {
+	p := &Pool{
+		logger:  logger,
+		queue:   make(chan job, queueSize),
+		workers: workers,
+	}
+	p.abandoned, p.abandon = context.WithCancel(context.Background())
+	p.wg.Add(workers)
+	for i := 0; i < workers; i++ {
+		go p.work()
+	}
+	return p
+}
+
+// Submit queues task without waiting. name identifies the task in logs. It returns
+// ErrQueueFull rather than blocking when the pool can't keep up, leaving the caller to
+// decide whether the work can be dropped.
+func (p *Pool) Submit(ctx context.Context, name string, task Task) error {
+	return p.submit(job{ctx: context.WithoutCancel(ctx), name: name, task: task})
+}
+
+// SubmitWithFallback is Submit for a task that mustn't be lost. If it can't be queued,
+// fallback runs right away instead; if the pool is shut down before it starts,
+// fallback runs then. fallback should persist the work somewhere durable, such as a
+// job queue, and return quickly. The error is fallback's.
+func (p *Pool) SubmitWithFallback(ctx context.Context, name string, task, fallback Task) error {
+	j := job{ctx: context.WithoutCancel(ctx), name: name, task: task, fallback: fallback}
+	err := p.submit(j)
+	if err == nil {
+		return nil
+	}
+	p.fellBack.Add(1)
+	return fallback(ctx)
+}
+
+func (p *Pool) submit(j job) error {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	if p.closed {
+		return ErrClosed
+	}
+	select {
+	case p.queue <- j:
+		return nil
+	default:
+		p.rejected.Add(1)
+		return ErrQueueFull
+	}
+}
+
+// Close stops accepting tasks and waits for the queued ones to finish. Callers that
+// can't wait indefinitely should use Shutdown.
+func (p *Pool) Close() {
+	p.Shutdown(context.Background())
+}
+
+// Shutdown stops accepting tasks and waits for the queued ones to finish until ctx is
+// done. Then it gives up: running tasks are cancelled, and the queued ones that
+// haven't started are handed to their fallback, or dropped and logged if they have
+// none. It returns ctx's error if it gave up.
+func (p *Pool) Shutdown(ctx context.Context) error {
+	p.mu.Lock()
+	if !p.closed {
+		p.closed = true
+		close(p.queue)
+	}
+	p.mu.Unlock()
+
+	done := make(chan struct{})
+	go func() {
+		p.wg.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+	}
+
+	p.logger.Warn("Abandoning background tasks", "busy", p.busy.Load(), "queued", len(p.queue))
+	p.abandon()
+	<-done
+	return ctx.Err()
+}
+
+func (p *Pool) work() {
+	defer p.wg.Done()
+	for j := range p.queue {
+		if p.abandoned.Err() != nil {
+			p.runFallback(j)
+			continue
+		}
+		p.run(j)
+	}
+}
+
+func (p *Pool) run(j job) {
+	p.busy.Add(1)
+	defer p.busy.Add(-1)
+	defer func() {
+		if v := recover(); v != nil {
+			p.failed.Add(1)
+			p.logger.Error("Background task panicked", "task", j.name, "panic", v, "stack", string(debug.Stack()))
+		}
+	}()
+
+	ctx, cancel := context.WithCancel(j.ctx)
+	defer cancel()
+	stop := context.AfterFunc(p.abandoned, cancel)
+	defer stop()
+
+	err := j.task(ctx)
+	if err != nil {
+		p.failed.Add(1)
+		p.logger.Warn("Background task failed", "task", j.name, "error", err)
+		return
+	}
+	p.completed.Add(1)
+}
+
+// runFallback runs the fallback of a task abandoned before it started.
+func (p *Pool) runFallback(j job) {
+	if j.fallback == nil {
+		p.dropped.Add(1)
+		p.logger.Warn("Dropped background task at shutdown", "task", j.name)
+		return
+	}
+	defer func() {
+		if v := recover(); v != nil {
+			p.logger.Error("Background task fallback panicked", "task", j.name, "panic", v, "stack", string(debug.Stack()))
+		}
+	}()
+
+	ctx, cancel := context.WithTimeout(j.ctx, fallbackTimeout)
+	defer cancel()
+	p.fellBack.Add(1)
+	err := j.fallback(ctx)
+	if err != nil {
+		p.logger.Error("Background task fallback failed, task lost", "task", j.name, "error", err)
+	}
+}
+
+// Stats is a snapshot of a pool's state and counters since it started.
+type Stats struct {
+	Workers   int
+	Busy      int
+	Queued    int
+	QueueSize int
+	Completed int64
+	Failed    int64
+	Rejected  int64
+	// FellBack counts tasks that ran their fallback instead, and Dropped tasks
+	// abandoned at shutdown without one.
+	FellBack int64
+	Dropped  int64
+}
+
+func (p *Pool) Stats() Stats {
+	return Stats{
+		Workers:   p.workers,
+		Busy:      int(p.busy.Load()),
+		Queued:    len(p.queue),
+		QueueSize: cap(p.queue),
+		Completed: p.completed.Load(),
+		Failed:    p.failed.Load(),
+		Rejected:  p.rejected.Load(),
+		FellBack:  p.fellBack.Load(),
+		Dropped:   p.dropped.Load(),
+	}
+}
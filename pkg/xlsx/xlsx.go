@@ -0,0 +1,144 @@
+// Package xlsx writes Office Open XML spreadsheets of a single sheet of text cells, a
+// row at a time, so a sheet of any size streams out without being held in memory.
+//
+// The workbook has no styles, formulas or shared strings: every cell is an inline
+// string, which every spreadsheet application opens.
+package xlsx
+
+import (
+	"archive/zip"
+	"bufio"
+	"encoding/xml"
+	"errors"
+	"io"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// ContentType is the media type of the files Writer writes.
+const ContentType = "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet"
+
+// maxSheetNameLength is the longest sheet name spreadsheet applications accept.
+const maxSheetNameLength = 31
+
+// The parts around the sheet, which never change but for the sheet's name.
+const (
+	contentTypesXML = xml.Header + `<Types xmlns="http://schemas.openxmlformats.org/package/2006/content-types">` +
+		`<Default Extension="rels" ContentType="application/vnd.openxmlformats-package.relationships+xml"/>` +
+		`<Default Extension="xml" ContentType="application/xml"/>` +
+		`<Override PartName="/xl/workbook.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.sheet.main+xml"/>` +
+		`<Override PartName="/xl/worksheets/sheet1.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.worksheet+xml"/>` +
+		`</Types>`
+	rootRelsXML = xml.Header + `<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">` +
+		`<Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/officeDocument" Target="xl/workbook.xml"/>` +
+		`</Relationships>`
+	workbookRelsXML = xml.Header + `<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">` +
+		`<Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/worksheet" Target="worksheets/sheet1.xml"/>` +
+		`</Relationships>`
+	workbookXMLStart = xml.Header + `<workbook xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main" ` +
+		`xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships"><sheets><sheet name="`
+	workbookXMLEnd = `" sheetId="1" r:id="rId1"/></sheets></workbook>`
+	sheetXMLStart  = xml.Header + `<worksheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main"><sheetData>`
+	sheetXMLEnd    = `</sheetData></worksheet>`
+)
+
+// ErrClosed is returned for rows written after Close.
+var ErrClosed = errors.New("xlsx: writer is closed")
+
+// Writer writes a workbook whose only sheet holds the rows given to Write.
+type Writer struct {
+	zw    *zip.Writer
+	sheet *bufio.Writer
+	rows  int
+	err   error
+}
+
+// NewWriter starts a workbook on w whose sheet is called sheetName, shortened to what
+// spreadsheet applications accept. Nothing is complete until Close.
+func NewWriter(w io.Writer, sheetName string) (*Writer, error) {
+	zw := zip.NewWriter(w)
+	var name strings.Builder
+	xml.EscapeText(&name, []byte(truncate(sheetName, maxSheetNameLength)))
+	parts := []struct{ name, data string }{
+		{"[Content_Types].xml", contentTypesXML},
+		{"_rels/.rels", rootRelsXML},
+		{"xl/workbook.xml", workbookXMLStart + name.String() + workbookXMLEnd},
+		{"xl/_rels/workbook.xml.rels", workbookRelsXML},
+	}
+	for _, part := range parts {
+		f, err := zw.Create(part.name)
+		if err != nil {
+			return nil, err
+		}
+		_, err = io.WriteString(f, part.data)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	// The sheet comes last, so its rows can stream straight into the archive
+	f, err := zw.Create("xl/worksheets/sheet1.xml")
+	if err != nil {
+		return nil, err
+	}
+	sheet := bufio.NewWriter(f)
+	_, err = sheet.WriteString(sheetXMLStart)
+	if err != nil {
+		return nil, err
+	}
+	return &Writer{zw: zw, sheet: sheet}, nil
+}
+
+// Write adds record as the next row, one text cell per value. Like for csv.Writer,
+// rows are buffered, and an error writing one is returned by every later call too.
+func (w *Writer) Write(record []string) error {
+	if w.err != nil {
+		return w.err
+	}
+	w.rows++
+	row := strconv.Itoa(w.rows)
+	w.sheet.WriteString(`<row r="` + row + `">`)
+	for i, value := range record {
+		w.sheet.WriteString(`<c r="` + ColumnName(i) + row + `" t="inlineStr"><is><t xml:space="preserve">`)
+		xml.EscapeText(w.sheet, []byte(value))
+		w.sheet.WriteString(`</t></is></c>`)
+	}
+	_, w.err = w.sheet.WriteString(`</row>`)
+	return w.err
+}
+
+// Close ends the sheet and the archive; it doesn't close the underlying writer.
+func (w *Writer) Close() error {
+	if w.err != nil {
+		return w.err
+	}
+	w.err = ErrClosed
+	_, err := w.sheet.WriteString(sheetXMLEnd)
+	if err != nil {
+		return err
+	}
+	err = w.sheet.Flush()
+	if err != nil {
+		return err
+	}
+	return w.zw.Close()
+}
+
+// ColumnName returns the letters naming the column at index i, counting from 0: A to
+// Z, then AA and so on.
+func ColumnName(i int) string {
+	name := ""
+	for i++; i > 0; i = (i - 1) / 26 {
+		name = string(rune('A'+(i-1)%26)) + name
+	}
+	return name
+}
+
+// truncate returns s cut to at most n runes.
+func truncate(s string, n int) string {
+	if utf8.RuneCountInString(s) <= n {
+		return s
+	}
+	return string([]rune(s)[:n])
+}